@@ -0,0 +1,160 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+)
+
+type (
+	// ISender dispatches commands and queries through object methods instead of generic functions.
+	ISender interface {
+		Send(ctx context.Context, request any) (any, error)
+	}
+
+	// IPublisher publishes events through object methods instead of generic functions.
+	IPublisher interface {
+		Publish(ctx context.Context, event any) error
+	}
+
+	// Sender is an ISender backed by the registered handlers.
+	// Dispatches go through the same middlewares and behaviors as SendCommand and SendQuery.
+	Sender struct{}
+
+	// Publisher is an IPublisher backed by the registered event handlers.
+	// Events are delivered exactly as with PublishEvent.
+	Publisher struct{}
+
+	// ConversionError is returned by As for a value that cannot be converted to the requested type.
+	ConversionError struct {
+		From reflect.Type // Type of the value.
+		To   reflect.Type // Requested type.
+	}
+
+	// ConversionOption enables additional conversions in As.
+	ConversionOption func(*conversionConfig)
+
+	// conversionConfig holds the conversions enabled by the ConversionOptions.
+	conversionConfig struct {
+		numeric bool
+	}
+)
+
+// Send dispatches request and returns the handler's response as any.
+// Use As to convert the response to its concrete type.
+func (Sender) Send(ctx context.Context, request any) (any, error) {
+	return send[any](ctx, request)
+}
+
+// Publish publishes event to its registered handlers.
+func (Publisher) Publish(ctx context.Context, event any) error {
+	return PublishEvent(ctx, event)
+}
+
+// Error names both types.
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("cannot convert %v to %v", e.From, e.To)
+}
+
+// AllowNumericConversion lets As convert between numeric types of different kinds, such as an int to a float64
+// or an int64, as long as the value is represented exactly; values that would overflow or lose their fraction
+// still fail.
+func AllowNumericConversion() ConversionOption {
+	return func(config *conversionConfig) {
+		config.numeric = true
+	}
+}
+
+// As converts a response returned as any, e.g. by Sender, SendByName or a spy, to TResponse.
+// Besides values of TResponse or assignable to it, it converts:
+//   - nil, including nil pointers, maps, slices and other nilable values, to the zero value of TResponse;
+//   - a pointer to a struct to the struct, and a struct to a pointer to a copy of it;
+//   - a named type to another type of the same kind it is convertible to, such as a named string to a string;
+//   - numbers of another kind, with AllowNumericConversion.
+//
+// Any other value returns a *ConversionError naming both types.
+func As[TResponse T](v any, opts ...ConversionOption) (TResponse, error) {
+	var converted TResponse
+	if typed, ok := v.(TResponse); ok || v == nil {
+		return typed, nil
+	}
+
+	var config conversionConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	target := reflect.ValueOf(&converted).Elem()
+	value, ok := convertValue(reflect.ValueOf(v), target.Type(), config)
+	if !ok {
+		return converted, &ConversionError{From: reflect.TypeOf(v), To: target.Type()}
+	}
+	target.Set(value)
+	return converted, nil
+}
+
+// MustAs is like As but panics if v cannot be converted, e.g. in tests.
+func MustAs[TResponse T](v any, opts ...ConversionOption) TResponse {
+	converted, err := As[TResponse](v, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return converted
+}
+
+// convertValue converts value to target as described by As. It returns false if it cannot.
+func convertValue(value reflect.Value, target reflect.Type, config conversionConfig) (reflect.Value, bool) {
+	switch {
+	case isNilValue(value):
+		return reflect.Zero(target), true
+	case value.Type().AssignableTo(target):
+		return value, true
+	case value.Kind() == reflect.Pointer && value.Type().Elem() == target && target.Kind() == reflect.Struct:
+		return value.Elem(), true
+	case target.Kind() == reflect.Pointer && target.Elem() == value.Type() && value.Kind() == reflect.Struct:
+		pointer := reflect.New(value.Type())
+		pointer.Elem().Set(value)
+		return pointer, true
+	case value.Kind() == target.Kind() && value.Type().ConvertibleTo(target):
+		// Only conversions between types sharing their kind, which excludes lossy numeric or int to string conversions.
+		return value.Convert(target), true
+	case config.numeric && isNumeric(value.Kind()) && isNumeric(target.Kind()):
+		converted := value.Convert(target)
+		if !sameNumber(value, converted) {
+			return reflect.Value{}, false
+		}
+		return converted, true
+	}
+	return reflect.Value{}, false
+}
+
+// sameNumber reports whether two numeric values are mathematically equal. Comparing them exactly, rather than
+// converting back, rejects the values a conversion wraps around, such as -1 converted to an unsigned integer.
+func sameNumber(a, b reflect.Value) bool {
+	x, ok := exactNumber(a)
+	if !ok {
+		return false
+	}
+	y, ok := exactNumber(b)
+	return ok && x.Cmp(y) == 0
+}
+
+// exactNumber returns the exact value of a number. It returns false for NaN, which equals no number.
+func exactNumber(v reflect.Value) (*big.Float, bool) {
+	switch {
+	case v.CanInt():
+		return new(big.Float).SetInt64(v.Int()), true
+	case v.CanUint():
+		return new(big.Float).SetUint64(v.Uint()), true
+	case math.IsNaN(v.Float()):
+		return nil, false
+	}
+	return new(big.Float).SetFloat64(v.Float()), true
+}
+
+// isNumeric reports whether kind is an integer or floating-point kind.
+func isNumeric(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
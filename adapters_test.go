@@ -0,0 +1,158 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type parityCommand struct {
+	Amount int
+}
+
+type parityCommandHandler struct{}
+
+func (h *parityCommandHandler) Handle(ctx context.Context, command parityCommand) (int, error) {
+	if command.Amount < 0 {
+		return 0, errors.New("negative amount")
+	}
+	return command.Amount * 10, nil
+}
+
+type parityEvent struct{}
+
+type parityEventHandler struct {
+	calls int
+}
+
+func (h *parityEventHandler) Handle(ctx context.Context, event parityEvent) error {
+	h.calls++
+	return errors.New("projection failed")
+}
+
+// TestSender_Parity tests that the adapter and the generic functions behave identically.
+func TestSender_Parity(t *testing.T) {
+	var preCalls, postCalls, behaviorCalls int
+	AddCommandHandler[parityCommand, int](&parityCommandHandler{}).
+		PreMiddleware(func(ctx context.Context, request any) (context.Context, any, bool) {
+			preCalls++
+			return ctx, request, true
+		}).
+		PostMiddleware(func(ctx context.Context, request any) (context.Context, any, bool) {
+			postCalls++
+			return ctx, request, true
+		}).
+		Behavior(func(ctx context.Context, request any, next NextFunc) (any, error) {
+			behaviorCalls++
+			return next(ctx, request)
+		})
+
+	var sender ISender = Sender{}
+	scenarios := []parityCommand{{Amount: 4}, {Amount: -1}}
+	for _, command := range scenarios {
+		generic, genericErr := SendCommand[int](context.Background(), command)
+		adapted, adaptedErr := sender.Send(context.Background(), command)
+
+		typed, err := As[int](adapted)
+		assert.NoError(t, err)
+		assert.Equal(t, generic, typed)
+		assert.Equal(t, genericErr, adaptedErr)
+	}
+	assert.Equal(t, 4, preCalls)
+	assert.Equal(t, 4, postCalls)
+	assert.Equal(t, 4, behaviorCalls)
+
+	assert.Panics(t, func() { _, _ = sender.Send(context.Background(), struct{ Unregistered bool }{}) })
+}
+
+// TestPublisher_Parity tests that the adapter and PublishEvent behave identically.
+func TestPublisher_Parity(t *testing.T) {
+	handler := &parityEventHandler{}
+	assert.NoError(t, AddEventHandlers[parityEvent](handler))
+
+	var publisher IPublisher = Publisher{}
+	assert.Equal(t, PublishEvent(context.Background(), parityEvent{}), publisher.Publish(context.Background(), parityEvent{}))
+	assert.Equal(t, 2, handler.calls)
+}
+
+// TestAs tests converting responses returned as any.
+func TestAs(t *testing.T) {
+	value, err := As[int](42)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, value)
+
+	value, err = As[int](nil)
+	assert.NoError(t, err)
+	assert.Zero(t, value)
+
+	_, err = As[int]("42")
+	assert.EqualError(t, err, "cannot convert string to int")
+
+	var e error
+	e, err = As[error](errors.New("boom"))
+	assert.NoError(t, err)
+	assert.EqualError(t, e, "boom")
+}
+
+type (
+	asReceipt struct{ ID int }
+	asLabel   string
+)
+
+// TestAs_Conversions tests the conversions As performs and the ones it rejects.
+func TestAs_Conversions(t *testing.T) {
+	receipt := asReceipt{ID: 7}
+
+	tests := []struct {
+		name     string
+		convert  func() (any, error)
+		expected any
+		err      string
+	}{
+		{"struct", func() (any, error) { return As[asReceipt](receipt) }, receipt, ""},
+		{"pointer to struct", func() (any, error) { return As[asReceipt](&receipt) }, receipt, ""},
+		{"struct to pointer", func() (any, error) { return As[*asReceipt](receipt) }, &receipt, ""},
+		{"nil pointer to struct", func() (any, error) { return As[asReceipt]((*asReceipt)(nil)) }, asReceipt{}, ""},
+		{"nil to pointer", func() (any, error) { return As[*asReceipt](nil) }, (*asReceipt)(nil), ""},
+		{"interface", func() (any, error) { return As[fmt.Stringer](time.Second) }, fmt.Stringer(time.Second), ""},
+		{"missing interface", func() (any, error) { return As[fmt.Stringer](receipt) }, nil, "cannot convert gocqrs.asReceipt to fmt.Stringer"},
+		{"named type", func() (any, error) { return As[string](asLabel("paid")) }, "paid", ""},
+		{"primitive", func() (any, error) { return As[bool](true) }, true, ""},
+		{"unrelated struct", func() (any, error) { return As[asReceipt](struct{ ID int }{ID: 7}) }, receipt, ""},
+		{"int to string", func() (any, error) { return As[string](65) }, nil, "cannot convert int to string"},
+		{"numeric without opt-in", func() (any, error) { return As[float64](3) }, nil, "cannot convert int to float64"},
+		{"numeric", func() (any, error) { return As[float64](3, AllowNumericConversion()) }, 3.0, ""},
+		{"widening", func() (any, error) { return As[int64](int32(3), AllowNumericConversion()) }, int64(3), ""},
+		{"fraction", func() (any, error) { return As[int](2.5, AllowNumericConversion()) }, nil, "cannot convert float64 to int"},
+		{"overflow", func() (any, error) { return As[int8](300, AllowNumericConversion()) }, nil, "cannot convert int to int8"},
+		{"negative to unsigned", func() (any, error) { return As[uint](-1, AllowNumericConversion()) }, nil, "cannot convert int to uint"},
+		{"unsigned overflow", func() (any, error) { return As[int64](uint64(math.MaxUint64), AllowNumericConversion()) }, nil, "cannot convert uint64 to int64"},
+		{"float overflow", func() (any, error) { return As[int64](1e20, AllowNumericConversion()) }, nil, "cannot convert float64 to int64"},
+		{"float precision", func() (any, error) { return As[float64](int64(math.MaxInt64), AllowNumericConversion()) }, nil, "cannot convert int64 to float64"},
+		{"unsigned", func() (any, error) { return As[uint8](255, AllowNumericConversion()) }, uint8(255), ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			converted, err := test.convert()
+			if test.err != "" {
+				var conversionErr *ConversionError
+				assert.ErrorAs(t, err, &conversionErr)
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, converted)
+		})
+	}
+}
+
+// TestMustAs tests that MustAs panics on values it cannot convert.
+func TestMustAs(t *testing.T) {
+	assert.Equal(t, 42, MustAs[int](42))
+	assert.PanicsWithError(t, "cannot convert string to int", func() { MustAs[int]("42") })
+}
@@ -0,0 +1,228 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// LimitMode is what an adaptive concurrency limiter does with a dispatch once the limit is reached.
+type LimitMode int
+
+const (
+	// LimitBlock makes the dispatch wait for a slot until its context is done.
+	LimitBlock LimitMode = iota
+	// LimitReject makes the dispatch fail with a *LimitExceededError wrapping ErrLimitExceeded.
+	LimitReject
+)
+
+type (
+	// AdaptiveLimitConfig configures the adaptive concurrency limit of a handler.
+	// The limit follows an AIMD control loop updated on every completion: it grows by one when the handler
+	// completed within LatencyThreshold without error, and is multiplied by Backoff otherwise.
+	AdaptiveLimitConfig struct {
+		InitialLimit     int           // Limit before any completion, MinLimit if lower.
+		MinLimit         int           // Lowest limit, at least 1.
+		MaxLimit         int           // Highest limit, unbounded if 0.
+		LatencyThreshold time.Duration // Latency above which the handler is considered overloaded.
+		Backoff          float64       // Factor applied to the limit on overload, 0.9 if not in (0, 1).
+		Mode             LimitMode     // Behavior of dispatches beyond the limit.
+	}
+
+	// AdaptiveLimitStat describes the current state of an adaptive concurrency limiter.
+	AdaptiveLimitStat struct {
+		Limit    int // Number of dispatches currently allowed to run at the same time.
+		InFlight int // Number of dispatches running.
+		Waiting  int // Number of dispatches waiting for a slot.
+	}
+
+	// LimitExceededError is returned when an adaptive concurrency limit in LimitReject mode rejects a dispatch.
+	// It wraps ErrLimitExceeded.
+	LimitExceededError struct {
+		Handler    string        // Name of the handler whose limit was reached.
+		Limit      int           // Number of concurrent dispatches allowed when the dispatch was rejected.
+		retryAfter time.Duration // Expected time until a slot frees up.
+	}
+
+	// adaptiveLimiter bounds the concurrency of a handler with a limit adjusted from its latency and errors.
+	adaptiveLimiter struct {
+		config   AdaptiveLimitConfig
+		mutex    sync.Mutex
+		limit    float64
+		inFlight int
+		waiters  []chan struct{} // Dispatches waiting for a slot, oldest first.
+		latency  time.Duration   // Moving average of the latency of the completed dispatches.
+	}
+)
+
+// latencySmoothing is the inverse weight of a completion in the moving average of the latency of a handler.
+const latencySmoothing = 5
+
+var (
+	adaptiveLimitMutex sync.RWMutex
+	adaptiveLimiters   = make(map[string]*adaptiveLimiter) // Handler name to its limiter.
+)
+
+// WithAdaptiveLimit bounds the number of concurrent dispatches of the current handler with a limit
+// that adapts to its observed latency and errors, as configured by config.
+func (middlewareBuilder *AddMiddlewareBuilder) WithAdaptiveLimit(config AdaptiveLimitConfig) *AddMiddlewareBuilder {
+	handlerName := middlewareBuilder.currentHandler()
+
+	adaptiveLimitMutex.Lock()
+	defer adaptiveLimitMutex.Unlock()
+	adaptiveLimiters[handlerName] = newAdaptiveLimiter(config)
+	return middlewareBuilder
+}
+
+// AdaptiveLimits returns the state of the adaptive concurrency limiters, keyed by handler name.
+func AdaptiveLimits() map[string]AdaptiveLimitStat {
+	adaptiveLimitMutex.RLock()
+	defer adaptiveLimitMutex.RUnlock()
+
+	snapshot := make(map[string]AdaptiveLimitStat, len(adaptiveLimiters))
+	for handlerName, limiter := range adaptiveLimiters {
+		snapshot[handlerName] = limiter.stat()
+	}
+	return snapshot
+}
+
+// Error describes the limit that was reached.
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%v: %v allows %d concurrent dispatches", ErrLimitExceeded, e.Handler, e.Limit)
+}
+
+// Unwrap returns ErrLimitExceeded.
+func (e *LimitExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// RetryAfter returns how long to wait before retrying the dispatch: the average latency of the handler,
+// or its LatencyThreshold until a dispatch completed.
+func (e *LimitExceededError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// newAdaptiveLimiter creates a limiter, normalizing config.
+func newAdaptiveLimiter(config AdaptiveLimitConfig) *adaptiveLimiter {
+	if config.MinLimit < 1 {
+		config.MinLimit = 1
+	}
+	if config.MaxLimit > 0 && config.MaxLimit < config.MinLimit {
+		config.MaxLimit = config.MinLimit
+	}
+	if config.Backoff <= 0 || config.Backoff >= 1 {
+		config.Backoff = 0.9
+	}
+	limiter := &adaptiveLimiter{config: config}
+	limiter.limit = limiter.clamp(float64(config.InitialLimit))
+	return limiter
+}
+
+// acquireAdaptiveLimit waits for a slot in the adaptive limiter of a handler and returns the function
+// releasing it, which must be given the outcome of the dispatch.
+func acquireAdaptiveLimit(ctx context.Context, handlerName string) (func(err error), error) {
+	adaptiveLimitMutex.RLock()
+	limiter, ok := adaptiveLimiters[handlerName]
+	adaptiveLimitMutex.RUnlock()
+
+	if !ok {
+		return func(error) {}, nil
+	}
+	if err := limiter.acquire(ctx, handlerName); err != nil {
+		return nil, err
+	}
+	start := now()
+	return func(err error) {
+		limiter.release(now().Sub(start), err)
+	}, nil
+}
+
+// acquire takes a slot, waiting for one in LimitBlock mode.
+func (limiter *adaptiveLimiter) acquire(ctx context.Context, handlerName string) error {
+	limiter.mutex.Lock()
+	if limiter.inFlight < int(limiter.limit) {
+		limiter.inFlight++
+		limiter.mutex.Unlock()
+		return nil
+	}
+	if limiter.config.Mode == LimitReject {
+		err := &LimitExceededError{Handler: handlerName, Limit: int(limiter.limit), retryAfter: limiter.latency}
+		if err.retryAfter <= 0 {
+			err.retryAfter = limiter.config.LatencyThreshold
+		}
+		limiter.mutex.Unlock()
+		return err
+	}
+	granted := make(chan struct{})
+	limiter.waiters = append(limiter.waiters, granted)
+	limiter.mutex.Unlock()
+
+	select {
+	case <-granted:
+		return nil
+	case <-ctx.Done():
+	}
+
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	for i, waiter := range limiter.waiters {
+		if waiter == granted {
+			limiter.waiters = append(limiter.waiters[:i:i], limiter.waiters[i+1:]...)
+			return ctx.Err()
+		}
+	}
+	// Granted concurrently with the cancellation, hand the slot over.
+	limiter.inFlight--
+	limiter.grantLocked()
+	return ctx.Err()
+}
+
+// release frees a slot and adjusts the limit from the latency and error of the completed dispatch.
+func (limiter *adaptiveLimiter) release(latency time.Duration, err error) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	limiter.inFlight--
+	if limiter.latency == 0 {
+		limiter.latency = latency
+	} else {
+		limiter.latency += (latency - limiter.latency) / latencySmoothing
+	}
+	if err != nil || latency > limiter.config.LatencyThreshold {
+		limiter.limit = limiter.clamp(math.Floor(limiter.limit * limiter.config.Backoff))
+	} else {
+		limiter.limit = limiter.clamp(limiter.limit + 1)
+	}
+	limiter.grantLocked()
+}
+
+// grantLocked hands the free slots to the waiting dispatches, oldest first.
+func (limiter *adaptiveLimiter) grantLocked() {
+	for len(limiter.waiters) > 0 && limiter.inFlight < int(limiter.limit) {
+		limiter.inFlight++
+		close(limiter.waiters[0])
+		limiter.waiters = limiter.waiters[1:]
+	}
+}
+
+// clamp bounds a limit to the configured range.
+func (limiter *adaptiveLimiter) clamp(limit float64) float64 {
+	limit = math.Max(limit, float64(limiter.config.MinLimit))
+	if limiter.config.MaxLimit > 0 {
+		limit = math.Min(limit, float64(limiter.config.MaxLimit))
+	}
+	return limit
+}
+
+// stat returns the current state of the limiter.
+func (limiter *adaptiveLimiter) stat() AdaptiveLimitStat {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	return AdaptiveLimitStat{
+		Limit:    int(limiter.limit),
+		InFlight: limiter.inFlight,
+		Waiting:  len(limiter.waiters),
+	}
+}
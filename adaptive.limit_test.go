@@ -0,0 +1,136 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	congestedQuery struct{}
+	exclusiveQuery struct{}
+)
+
+// congestedQueryHandler gets slower the more dispatches run at the same time, while slow is set.
+type congestedQueryHandler struct {
+	inFlight atomic.Int64
+	slow     atomic.Bool
+}
+
+func (h *congestedQueryHandler) Handle(ctx context.Context, query congestedQuery) (string, error) {
+	n := h.inFlight.Add(1)
+	defer h.inFlight.Add(-1)
+	if h.slow.Load() {
+		time.Sleep(time.Duration(n) * 2 * time.Millisecond)
+	}
+	return "ok", nil
+}
+
+type exclusiveQueryHandler struct {
+	release chan struct{}
+}
+
+func (h *exclusiveQueryHandler) Handle(ctx context.Context, query exclusiveQuery) (string, error) {
+	<-h.release
+	return "ok", nil
+}
+
+// TestWithAdaptiveLimit tests that the limit converges downward under congestion and grows back once latency improves.
+func TestWithAdaptiveLimit(t *testing.T) {
+	handler := &congestedQueryHandler{}
+	handler.slow.Store(true)
+	AddQueryHandler[congestedQuery, string](handler).
+		WithAdaptiveLimit(AdaptiveLimitConfig{
+			InitialLimit:     16,
+			MaxLimit:         16,
+			LatencyThreshold: 6 * time.Millisecond,
+		})
+	limit := func() int {
+		return AdaptiveLimits()["*gocqrs.congestedQueryHandler"].Limit
+	}
+	assert.Equal(t, 16, limit())
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				_, err := SendQuery[string](context.Background(), congestedQuery{})
+				assert.NoError(t, err)
+			}
+		}()
+	}
+
+	assert.Eventually(t, func() bool { return limit() <= 4 }, 5*time.Second, time.Millisecond,
+		"the limit should settle where latency stays around the threshold")
+
+	handler.slow.Store(false)
+	assert.Eventually(t, func() bool { return limit() == 16 }, 5*time.Second, time.Millisecond,
+		"the limit should grow back once latency improves")
+
+	close(stop)
+	wg.Wait()
+}
+
+// TestWithAdaptiveLimit_Reject tests that dispatches beyond the limit fail in LimitReject mode.
+func TestWithAdaptiveLimit_Reject(t *testing.T) {
+	handler := &exclusiveQueryHandler{release: make(chan struct{})}
+	AddQueryHandler[exclusiveQuery, string](handler).
+		WithAdaptiveLimit(AdaptiveLimitConfig{InitialLimit: 1, MaxLimit: 1, LatencyThreshold: time.Second, Mode: LimitReject})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := SendQuery[string](context.Background(), exclusiveQuery{})
+		assert.NoError(t, err)
+	}()
+	assert.Eventually(t, func() bool {
+		return AdaptiveLimits()["*gocqrs.exclusiveQueryHandler"].InFlight == 1
+	}, time.Second, time.Millisecond)
+
+	_, err := SendQuery[string](context.Background(), exclusiveQuery{})
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	assert.EqualError(t, err, "concurrency limit exceeded: *gocqrs.exclusiveQueryHandler allows 1 concurrent dispatches")
+	assert.Equal(t, CategoryResourceExhausted, Categorize(err))
+
+	// Until a dispatch completed, the hint is the latency threshold.
+	retryAfter, ok := RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+
+	close(handler.release)
+	<-done
+	assert.Equal(t, AdaptiveLimitStat{Limit: 1}, AdaptiveLimits()["*gocqrs.exclusiveQueryHandler"])
+}
+
+// TestWithAdaptiveLimit_RetryAfter tests that rejected dispatches hint the average latency of the handler.
+func TestWithAdaptiveLimit_RetryAfter(t *testing.T) {
+	limiter := newAdaptiveLimiter(AdaptiveLimitConfig{InitialLimit: 1, MaxLimit: 1, LatencyThreshold: time.Second, Mode: LimitReject})
+	for _, latency := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond} {
+		assert.NoError(t, limiter.acquire(context.Background(), "handler"))
+		limiter.release(latency, nil)
+	}
+
+	assert.NoError(t, limiter.acquire(context.Background(), "handler"))
+	err := limiter.acquire(context.Background(), "handler")
+	var exceeded *LimitExceededError
+	if assert.ErrorAs(t, err, &exceeded) {
+		assert.Equal(t, "handler", exceeded.Handler)
+		assert.Equal(t, 1, exceeded.Limit)
+		assert.Equal(t, 120*time.Millisecond, exceeded.RetryAfter())
+	}
+	_, ok := RetryAfter(errors.New("plain"))
+	assert.False(t, ok)
+}
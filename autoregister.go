@@ -0,0 +1,116 @@
+package gocqrs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	bindingMutex sync.RWMutex
+	bindings     = make(map[reflect.Type]reflect.Value) // Bound type to its value.
+)
+
+// Bind provides value to the constructors registered with AutoRegister for their parameters of type TDependency,
+// or of an interface TDependency implements. Binding a type again replaces its value.
+func Bind[TDependency T](value TDependency) {
+	bindingMutex.Lock()
+	defer bindingMutex.Unlock()
+	bindings[reflect.TypeOf(new(TDependency)).Elem()] = reflect.ValueOf(&value).Elem()
+}
+
+// AutoRegister builds handlers by calling constructors such as
+// func(repo OrderRepo, clock Clock) *CreateOrderHandler, with their parameters resolved from the values given to Bind,
+// and registers each handler for the request type of its Handle(ctx, request) (response, error) method.
+// A constructor may also return an error as its second result.
+//
+// A parameter is resolved by the binding of its exact type or, failing that, by the only binding assignable to it.
+// The errors of all the constructors are returned joined; the constructors without errors are registered.
+func AutoRegister(constructors ...any) error {
+	var errs []error
+	for _, constructor := range constructors {
+		if err := autoRegister(constructor); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// autoRegister builds and registers the handler of a single constructor.
+func autoRegister(constructor any) error {
+	value := reflect.ValueOf(constructor)
+	if value.Kind() != reflect.Func {
+		return fmt.Errorf("constructor %T is not a function", constructor)
+	}
+	constructorType := value.Type()
+	name := funcName(constructor)
+	if constructorType.NumOut() == 0 || constructorType.NumOut() > 2 || (constructorType.NumOut() == 2 && constructorType.Out(1) != errorType) {
+		return fmt.Errorf("constructor %v must return a handler and optionally an error", name)
+	}
+	args := make([]reflect.Value, constructorType.NumIn())
+	var errs []error
+	for i := range args {
+		arg, err := resolveBinding(constructorType.In(i))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("constructor %v parameter %d: %w", name, i, err))
+			continue
+		}
+		args[i] = arg
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	results := value.Call(args)
+	if len(results) == 2 && !results[1].IsNil() {
+		return fmt.Errorf("constructor %v: %w", name, results[1].Interface().(error))
+	}
+	handler := results[0]
+	if isNilValue(handler) {
+		return fmt.Errorf("constructor %v returned a nil handler", name)
+	}
+	if handler.Kind() == reflect.Interface {
+		handler = handler.Elem()
+	}
+
+	// Register the Handle method like the methods of RegisterService, under the name of the handler type.
+	method := handler.MethodByName("Handle")
+	if !method.IsValid() || !isHandlerShaped(method.Type()) {
+		return fmt.Errorf("constructor %v returns %v, which has no Handle(context.Context, request) (response, error) method", name, handler.Type())
+	}
+	typedHandlerName := handler.Type().String()
+	registerHandler(method.Type().In(1), newHandlerWrapper[any, any](&serviceMethodHandler{method: method, handler: handler.Interface()}, typedHandlerName), typedHandlerName, nil)
+	return nil
+}
+
+// resolveBinding returns the bound value of a constructor parameter.
+func resolveBinding(paramType reflect.Type) (reflect.Value, error) {
+	bindingMutex.RLock()
+	defer bindingMutex.RUnlock()
+
+	if value, ok := bindings[paramType]; ok {
+		return value, nil
+	}
+
+	var matches []reflect.Type
+	for boundType := range bindings {
+		if boundType.AssignableTo(paramType) {
+			matches = append(matches, boundType)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return reflect.Value{}, fmt.Errorf("%w: no binding for %v", ErrUnresolvedDependency, paramType)
+	case 1:
+		return bindings[matches[0]], nil
+	}
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, match.String())
+	}
+	sort.Strings(names)
+	return reflect.Value{}, fmt.Errorf("%w: ambiguous bindings for %v: %v", ErrUnresolvedDependency, paramType, strings.Join(names, ", "))
+}
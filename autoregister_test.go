@@ -0,0 +1,168 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	autoOrderRepo interface {
+		Owner(id int) string
+	}
+	autoClock interface {
+		Year() int
+	}
+	autoMailer interface {
+		Sender() string
+	}
+
+	fixedOrderRepo struct{}
+	fixedClock     struct{}
+
+	autoOrderOwnerQuery  struct{ ID int }
+	autoOrderYearQuery   struct{}
+	autoOrderNotifyQuery struct{}
+
+	autoOrderOwnerHandler struct {
+		repo  autoOrderRepo
+		clock autoClock
+	}
+	autoOrderYearHandler struct {
+		clock autoClock
+	}
+	autoOrderNotifyHandler struct{}
+)
+
+func (fixedOrderRepo) Owner(id int) string { return "alice" }
+func (fixedClock) Year() int               { return 2024 }
+
+func (h *autoOrderOwnerHandler) Handle(ctx context.Context, query autoOrderOwnerQuery) (string, error) {
+	return h.repo.Owner(query.ID), nil
+}
+
+func (h *autoOrderYearHandler) Handle(ctx context.Context, query autoOrderYearQuery) (int, error) {
+	return h.clock.Year(), nil
+}
+
+func (h *autoOrderNotifyHandler) Handle(ctx context.Context, query autoOrderNotifyQuery) (string, error) {
+	return "", nil
+}
+
+// TestAutoRegister tests that handlers built from constructors with shared and distinct dependencies are dispatched to.
+func TestAutoRegister(t *testing.T) {
+	Bind[autoOrderRepo](fixedOrderRepo{})
+	Bind[autoClock](fixedClock{})
+
+	err := AutoRegister(
+		func(repo autoOrderRepo, clock autoClock) *autoOrderOwnerHandler {
+			return &autoOrderOwnerHandler{repo: repo, clock: clock}
+		},
+		func(clock autoClock) (*autoOrderYearHandler, error) {
+			return &autoOrderYearHandler{clock: clock}, nil
+		},
+	)
+	assert.NoError(t, err)
+
+	owner, err := SendQuery[string](context.Background(), autoOrderOwnerQuery{ID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", owner)
+
+	year, err := SendQuery[int](context.Background(), autoOrderYearQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2024, year)
+}
+
+// TestAutoRegisterUnresolved tests that the unresolvable parameters of every constructor are reported together.
+func TestAutoRegisterUnresolved(t *testing.T) {
+	Bind[autoClock](fixedClock{})
+
+	err := AutoRegister(
+		func(clock autoClock, mailer autoMailer) *autoOrderNotifyHandler {
+			return &autoOrderNotifyHandler{}
+		},
+		func(mailer autoMailer, retries int) *autoOrderNotifyHandler {
+			return &autoOrderNotifyHandler{}
+		},
+		"not a constructor",
+	)
+	assert.ErrorIs(t, err, ErrUnresolvedDependency)
+	assert.Contains(t, err.Error(), "TestAutoRegisterUnresolved.func1 parameter 1: unresolved dependency: no binding for gocqrs.autoMailer")
+	assert.Contains(t, err.Error(), "TestAutoRegisterUnresolved.func2 parameter 0: unresolved dependency: no binding for gocqrs.autoMailer")
+	assert.Contains(t, err.Error(), "TestAutoRegisterUnresolved.func2 parameter 1: unresolved dependency: no binding for int")
+	assert.Contains(t, err.Error(), "constructor string is not a function")
+
+	_, ok := getMapValue(handlers, "gocqrs.autoOrderNotifyQuery", &handlerMutex)
+	assert.False(t, ok)
+}
+
+// TestAutoRegisterAmbiguous tests that a parameter matched by several bindings is not resolved.
+func TestAutoRegisterAmbiguous(t *testing.T) {
+	Bind(fixedClock{})
+	Bind(&fixedClock{})
+	defer func() {
+		bindingMutex.Lock()
+		delete(bindings, reflect.TypeOf(fixedClock{}))
+		delete(bindings, reflect.TypeOf(&fixedClock{}))
+		bindingMutex.Unlock()
+	}()
+
+	err := AutoRegister(func(clock interface{ Year() int }) *autoOrderNotifyHandler {
+		return &autoOrderNotifyHandler{}
+	})
+	assert.True(t, errors.Is(err, ErrUnresolvedDependency))
+	assert.Contains(t, err.Error(), "ambiguous bindings")
+}
+
+type (
+	autoTenantKey      struct{}
+	autoLifecycleQuery struct{}
+
+	// autoLifecycleHandler requires a tenant in the context and is startable and stoppable.
+	autoLifecycleHandler struct {
+		log *lifecycleLog
+	}
+)
+
+func (h *autoLifecycleHandler) Handle(ctx context.Context, query autoLifecycleQuery) (string, error) {
+	return "handled", nil
+}
+
+func (h *autoLifecycleHandler) RequiredContextKeys() []any {
+	return []any{autoTenantKey{}}
+}
+
+func (h *autoLifecycleHandler) Start(ctx context.Context) error {
+	h.log.record("start")
+	return nil
+}
+
+func (h *autoLifecycleHandler) Stop(ctx context.Context) error {
+	h.log.record("stop")
+	return nil
+}
+
+// TestAutoRegister_OptionalInterfaces tests that the optional interfaces of a handler built by AutoRegister are honored.
+func TestAutoRegister_OptionalInterfaces(t *testing.T) {
+	log := &lifecycleLog{}
+	assert.NoError(t, AutoRegister(func() *autoLifecycleHandler { return &autoLifecycleHandler{log: log} }))
+
+	t.Run("required context keys", func(t *testing.T) {
+		_, err := SendQuery[string](context.Background(), autoLifecycleQuery{})
+		assert.ErrorIs(t, err, ErrMissingContextValue)
+
+		response, err := SendQuery[string](context.WithValue(context.Background(), autoTenantKey{}, "acme"), autoLifecycleQuery{})
+		assert.NoError(t, err)
+		assert.Equal(t, "handled", response)
+	})
+
+	t.Run("start and stop", func(t *testing.T) {
+		assert.NoError(t, StartAll(context.Background()))
+		assert.Equal(t, []string{"start"}, log.events)
+		assert.NoError(t, Shutdown(context.Background()))
+		assert.Equal(t, []string{"start", "stop"}, log.events)
+	})
+}
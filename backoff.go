@@ -0,0 +1,86 @@
+package gocqrs
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+type (
+	// Backoff computes the delay to wait before a retry.
+	// Next receives the number of the retry, starting at 1; lower values are treated as 1.
+	// Implementations must be safe for concurrent use.
+	Backoff interface {
+		Next(attempt int) time.Duration
+	}
+
+	// exponentialBackoff multiplies the base delay by factor on every attempt, up to max.
+	exponentialBackoff struct {
+		base   time.Duration
+		factor float64
+		max    time.Duration
+	}
+
+	// fixedBackoff always waits the same delay.
+	fixedBackoff struct {
+		delay time.Duration
+	}
+
+	// decorrelatedJitter waits a random delay between base and an upper bound
+	// that grows threefold on every attempt, up to max.
+	decorrelatedJitter struct {
+		base time.Duration
+		max  time.Duration
+	}
+)
+
+// ExponentialBackoff returns a Backoff waiting base * factor^(attempt-1), capped at max.
+// A factor lower than 1 is treated as 1.
+func ExponentialBackoff(base time.Duration, factor float64, max time.Duration) Backoff {
+	if factor < 1 {
+		factor = 1
+	}
+	return exponentialBackoff{base: base, factor: factor, max: max}
+}
+
+// FixedBackoff returns a Backoff always waiting delay.
+func FixedBackoff(delay time.Duration) Backoff {
+	return fixedBackoff{delay: delay}
+}
+
+// DecorrelatedJitter returns a Backoff waiting a random delay in [base, min(max, base * 3^(attempt-1))].
+// Spreading retries randomly avoids many callers retrying in lockstep.
+func DecorrelatedJitter(base, max time.Duration) Backoff {
+	return decorrelatedJitter{base: base, max: max}
+}
+
+// Next implements Backoff.
+func (backoff exponentialBackoff) Next(attempt int) time.Duration {
+	return growDelay(backoff.base, backoff.factor, attempt, backoff.max)
+}
+
+// Next implements Backoff.
+func (backoff fixedBackoff) Next(int) time.Duration {
+	return backoff.delay
+}
+
+// Next implements Backoff.
+func (backoff decorrelatedJitter) Next(attempt int) time.Duration {
+	upper := growDelay(backoff.base, 3, attempt, backoff.max)
+	if upper <= backoff.base {
+		return upper
+	}
+	return backoff.base + time.Duration(rand.Int63n(int64(upper-backoff.base)+1))
+}
+
+// growDelay computes base * factor^(attempt-1) capped at max, guarding against overflow.
+func growDelay(base time.Duration, factor float64, attempt int, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := float64(base) * math.Pow(factor, float64(attempt-1))
+	if delay >= float64(max) || math.IsInf(delay, 0) {
+		return max
+	}
+	return time.Duration(delay)
+}
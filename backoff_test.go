@@ -0,0 +1,52 @@
+package gocqrs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExponentialBackoff tests that delays grow monotonically and are capped.
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, 2, time.Second)
+
+	assert.Equal(t, 10*time.Millisecond, backoff.Next(1))
+	assert.Equal(t, 20*time.Millisecond, backoff.Next(2))
+	assert.Equal(t, 40*time.Millisecond, backoff.Next(3))
+	assert.Equal(t, 10*time.Millisecond, backoff.Next(0), "Attempts lower than 1 should be treated as 1")
+
+	previous := time.Duration(0)
+	for attempt := 1; attempt <= 200; attempt++ {
+		delay := backoff.Next(attempt)
+		assert.GreaterOrEqual(t, delay, previous, "Delay should never decrease")
+		assert.LessOrEqual(t, delay, time.Second, "Delay should be capped at max")
+		previous = delay
+	}
+	assert.Equal(t, time.Second, backoff.Next(1000), "Huge attempts should not overflow")
+}
+
+// TestFixedBackoff tests that the delay never changes.
+func TestFixedBackoff(t *testing.T) {
+	backoff := FixedBackoff(50 * time.Millisecond)
+	for attempt := 1; attempt <= 10; attempt++ {
+		assert.Equal(t, 50*time.Millisecond, backoff.Next(attempt))
+	}
+}
+
+// TestDecorrelatedJitter tests that delays stay within their bounds.
+func TestDecorrelatedJitter(t *testing.T) {
+	base, max := 10*time.Millisecond, 500*time.Millisecond
+	backoff := DecorrelatedJitter(base, max)
+
+	assert.Equal(t, base, backoff.Next(1), "First attempt has no room for jitter")
+	for attempt := 1; attempt <= 50; attempt++ {
+		upper := growDelay(base, 3, attempt, max)
+		for i := 0; i < 100; i++ {
+			delay := backoff.Next(attempt)
+			assert.GreaterOrEqual(t, delay, base, "Delay should not be lower than base")
+			assert.LessOrEqual(t, delay, upper, "Delay should not exceed the attempt upper bound")
+			assert.LessOrEqual(t, delay, max, "Delay should be capped at max")
+		}
+	}
+}
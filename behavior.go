@@ -0,0 +1,77 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+type (
+	// NextFunc runs the rest of the pipeline: the following behaviors and finally the handler.
+	NextFunc func(ctx context.Context, request any) (any, error)
+
+	// PipelineBehavior wraps the handler invocation of a command or query.
+	// A behavior usually calls next and may inspect or replace its result, but it can also return its
+	// own response and error without calling next, in which case the handler is not invoked.
+	// Behaviors run between the pre- and post-middlewares, in registration order: the first registered
+	// behavior is the outermost one. When several behaviors return without calling next, the outermost
+	// one wins because the inner ones are never reached.
+	PipelineBehavior func(ctx context.Context, request any, next NextFunc) (any, error)
+)
+
+// Behavior adds a pipeline behavior to the current handler.
+func (middlewareBuilder *AddMiddlewareBuilder) Behavior(behavior PipelineBehavior) *AddMiddlewareBuilder {
+	return middlewareBuilder.stagedBehavior(behavior, PipelineStep{Stage: StageBehaviors, Name: funcName(behavior)})
+}
+
+// Behaviors adds a list of pipeline behaviors to the current handler, in order.
+func (middlewareBuilder *AddMiddlewareBuilder) Behaviors(behaviors ...PipelineBehavior) *AddMiddlewareBuilder {
+	for _, behavior := range behaviors {
+		middlewareBuilder.Behavior(behavior)
+	}
+	return middlewareBuilder
+}
+
+// behaviorsFor returns a snapshot of the pipeline behaviors registered for a handler.
+func (middlewareBuilder *AddMiddlewareBuilder) behaviorsFor(handlerName string) []PipelineBehavior {
+	behaviors, ok := middlewareBuilder.behaviors.Load(handlerName)
+	if !ok {
+		return nil
+	}
+	return behaviors.([]PipelineBehavior)
+}
+
+// invokePipeline runs the pipeline behaviors of a handler around its Handle method.
+func invokePipeline[Response T](ctx context.Context, handleMethod reflect.Value, handlerName string, in any) (Response, error) {
+	behaviors := middlewareBuilder.behaviorsFor(handlerName)
+	if tagged := tagBehaviorsFor(handlerName); len(tagged) > 0 {
+		behaviors = append(tagged, behaviors...)
+	}
+	if len(behaviors) == 0 {
+		return invokeHandler[Response](ctx, handleMethod, handlerName, in)
+	}
+
+	handler := func(ctx context.Context, request any) (any, error) {
+		return invokeHandler[Response](ctx, handleMethod, handlerName, request)
+	}
+
+	out, err := chainBehaviors(behaviors, handler)(ctx, in)
+	response, convErr := As[Response](out)
+	if convErr != nil {
+		// A behavior replaced the response with a value of another type.
+		return response, fmt.Errorf("incorrect response type: %w", convErr)
+	}
+	return response, err
+}
+
+// chainBehaviors composes behaviors around handler, the first behavior being the outermost one.
+func chainBehaviors(behaviors []PipelineBehavior, handler NextFunc) NextFunc {
+	next := handler
+	for i := len(behaviors) - 1; i >= 0; i-- {
+		behavior, inner := behaviors[i], next
+		next = func(ctx context.Context, request any) (any, error) {
+			return behavior(ctx, request, inner)
+		}
+	}
+	return next
+}
@@ -0,0 +1,97 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type canaryQuery struct {
+	UserID int
+}
+
+type canaryQueryHandler struct {
+	calls int
+}
+
+func (h *canaryQueryHandler) Handle(ctx context.Context, query canaryQuery) (string, error) {
+	h.calls++
+	return "stable", nil
+}
+
+// TestBehavior_Override tests a behavior returning without calling next: the handler is not invoked.
+func TestBehavior_Override(t *testing.T) {
+	handler := &canaryQueryHandler{}
+	AddQueryHandler[canaryQuery, string](handler).Behavior(func(ctx context.Context, request any, next NextFunc) (any, error) {
+		if request.(canaryQuery).UserID%2 == 0 {
+			return "canary", nil
+		}
+		return next(ctx, request)
+	})
+
+	response, err := SendQuery[string](context.Background(), canaryQuery{UserID: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "canary", response)
+	assert.Equal(t, 0, handler.calls, "The real handler should not be invoked")
+
+	response, err = SendQuery[string](context.Background(), canaryQuery{UserID: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, "stable", response)
+	assert.Equal(t, 1, handler.calls)
+}
+
+type orderedBehaviorCommand struct{}
+
+type orderedBehaviorCommandHandler struct{}
+
+func (h *orderedBehaviorCommandHandler) Handle(ctx context.Context, command orderedBehaviorCommand) (string, error) {
+	return "handler", nil
+}
+
+// TestBehavior_Order tests that behaviors are nested in registration order and the outermost override wins.
+func TestBehavior_Order(t *testing.T) {
+	var trace []string
+	record := func(name string) PipelineBehavior {
+		return func(ctx context.Context, request any, next NextFunc) (any, error) {
+			trace = append(trace, name+":before")
+			out, err := next(ctx, request)
+			trace = append(trace, name+":after")
+			return out, err
+		}
+	}
+	override := func(response string) PipelineBehavior {
+		return func(ctx context.Context, request any, next NextFunc) (any, error) {
+			trace = append(trace, response)
+			return response, nil
+		}
+	}
+
+	AddCommandHandler[orderedBehaviorCommand, string](&orderedBehaviorCommandHandler{}).
+		Behaviors(record("outer"), override("first override"), override("second override"))
+
+	response, err := SendCommand[string](context.Background(), orderedBehaviorCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, "first override", response)
+	assert.Equal(t, []string{"outer:before", "first override", "outer:after"}, trace)
+}
+
+type mistypedBehaviorQuery struct{}
+
+type mistypedBehaviorQueryHandler struct{}
+
+func (h *mistypedBehaviorQueryHandler) Handle(ctx context.Context, query mistypedBehaviorQuery) (string, error) {
+	return "handler", nil
+}
+
+// TestBehavior_IncorrectResponseType tests a behavior replacing the response with a value of another type.
+func TestBehavior_IncorrectResponseType(t *testing.T) {
+	errStub := errors.New("stub")
+	AddQueryHandler[mistypedBehaviorQuery, string](&mistypedBehaviorQueryHandler{}).Behavior(func(ctx context.Context, request any, next NextFunc) (any, error) {
+		return 42, errStub
+	})
+
+	_, err := SendQuery[string](context.Background(), mistypedBehaviorQuery{})
+	assert.EqualError(t, err, "incorrect response type: cannot convert int to string")
+}
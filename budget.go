@@ -0,0 +1,73 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// childBudgetsKey is the context key of the child budgets declared by the handler of the current dispatch.
+type childBudgetsKey struct{}
+
+var (
+	childBudgetMutex sync.RWMutex
+	childBudgets     = make(map[string]map[string]float64) // Handler name to child request type name to fraction.
+)
+
+// WithChildBudgets declares the share of its remaining deadline the current handler grants to the commands and
+// queries it dispatches. When the handler dispatches a request of a listed type, the child's deadline is the
+// declared fraction of the parent's remaining budget, never exceeding it. Unlisted children inherit the deadline.
+func (middlewareBuilder *AddMiddlewareBuilder) WithChildBudgets(budgets map[reflect.Type]float64) *AddMiddlewareBuilder {
+	handlerName := middlewareBuilder.currentHandler()
+
+	fractions := make(map[string]float64, len(budgets))
+	for requestType, fraction := range budgets {
+		fractions[typeKey(requestType)] = fraction
+	}
+
+	childBudgetMutex.Lock()
+	defer childBudgetMutex.Unlock()
+	childBudgets[handlerName] = fractions
+	return middlewareBuilder
+}
+
+// withChildBudget derives the deadline of a dispatch of typedIn from the budgets declared by its parent dispatch,
+// then replaces the declared budgets with those of handlerName for the dispatches nested in it.
+func withChildBudget(ctx context.Context, typedIn, handlerName string) (context.Context, context.CancelFunc) {
+	cancel := context.CancelFunc(func() {})
+
+	if fractions, ok := ctx.Value(childBudgetsKey{}).(map[string]float64); ok {
+		if fraction, listed := fractions[typedIn]; listed {
+			if deadline, hasDeadline := ctx.Deadline(); hasDeadline {
+				ctx, cancel = context.WithDeadlineCause(ctx, childDeadline(deadline, fraction), causeBudgetExceeded)
+			}
+		}
+	}
+
+	childBudgetMutex.RLock()
+	fractions, ok := childBudgets[handlerName]
+	childBudgetMutex.RUnlock()
+
+	if ok || ctx.Value(childBudgetsKey{}) != nil {
+		// Budgets declared by the parent do not apply to its grandchildren.
+		ctx = context.WithValue(ctx, childBudgetsKey{}, fractions)
+	}
+	return ctx, cancel
+}
+
+// childDeadline returns the deadline granting fraction of the budget remaining until deadline.
+func childDeadline(deadline time.Time, fraction float64) time.Time {
+	if fraction < 0 {
+		fraction = 0
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	current := now()
+	remaining := deadline.Sub(current)
+	if remaining <= 0 {
+		return deadline
+	}
+	return current.Add(time.Duration(float64(remaining) * fraction))
+}
@@ -0,0 +1,89 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type checkoutCommand struct{}
+
+type reserveStockCommand struct{}
+
+type chargeCardCommand struct{}
+
+type sendReceiptCommand struct{}
+
+type checkoutCommandHandler struct{}
+
+func (h *checkoutCommandHandler) Handle(ctx context.Context, command checkoutCommand) (string, error) {
+	for _, child := range []any{reserveStockCommand{}, chargeCardCommand{}, sendReceiptCommand{}} {
+		if _, err := SendCommand[time.Time](ctx, child); err != nil {
+			return "", err
+		}
+	}
+	return "checked out", nil
+}
+
+// budgetChildHandler records the deadline it observed and simulates 100ms of work on the fake clock.
+type budgetChildHandler[TCommand T] struct {
+	clock     *fakeClock
+	deadlines *[]time.Time
+}
+
+func (h *budgetChildHandler[TCommand]) Handle(ctx context.Context, command TCommand) (time.Time, error) {
+	deadline, _ := ctx.Deadline()
+	*h.deadlines = append(*h.deadlines, deadline)
+	h.clock.Advance(100 * time.Millisecond)
+	return deadline, nil
+}
+
+// TestWithChildBudgets tests that children of a listed type get their declared share of the remaining budget.
+func TestWithChildBudgets(t *testing.T) {
+	clock := &fakeClock{now: time.Now().Add(time.Hour)}
+	SetClock(clock)
+	defer SetClock(nil)
+
+	var deadlines []time.Time
+	AddCommandHandler[checkoutCommand, string](&checkoutCommandHandler{}).
+		WithChildBudgets(map[reflect.Type]float64{
+			reflect.TypeOf(reserveStockCommand{}): 0.5,
+			reflect.TypeOf(chargeCardCommand{}):   0.5,
+		})
+	AddCommandHandler[reserveStockCommand, time.Time](&budgetChildHandler[reserveStockCommand]{clock: clock, deadlines: &deadlines})
+	AddCommandHandler[chargeCardCommand, time.Time](&budgetChildHandler[chargeCardCommand]{clock: clock, deadlines: &deadlines})
+	AddCommandHandler[sendReceiptCommand, time.Time](&budgetChildHandler[sendReceiptCommand]{clock: clock, deadlines: &deadlines})
+
+	start := clock.Now()
+	ctx, cancel := context.WithDeadline(context.Background(), start.Add(900*time.Millisecond))
+	defer cancel()
+
+	_, err := SendCommand[string](ctx, checkoutCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		start.Add(450 * time.Millisecond), // Half of 900ms.
+		start.Add(500 * time.Millisecond), // 100ms later, half of the remaining 800ms.
+		start.Add(900 * time.Millisecond), // Unlisted, inherits the parent deadline.
+	}, deadlines)
+}
+
+// TestWithChildBudgets_DirectDispatch tests that budgets only apply to children of the declaring handler.
+func TestWithChildBudgets_DirectDispatch(t *testing.T) {
+	clock := &fakeClock{now: time.Now().Add(time.Hour)}
+	SetClock(clock)
+	defer SetClock(nil)
+
+	var deadlines []time.Time
+	AddCommandHandler[reserveStockCommand, time.Time](&budgetChildHandler[reserveStockCommand]{clock: clock, deadlines: &deadlines})
+
+	deadline := clock.Now().Add(900 * time.Millisecond)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	_, err := SendCommand[time.Time](ctx, reserveStockCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{deadline}, deadlines)
+}
@@ -0,0 +1,60 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+var (
+	poolMutex    sync.RWMutex
+	handlerPools = make(map[string]string)        // Handler name to pool name.
+	pools        = make(map[string]chan struct{}) // Pool name to its concurrency slots.
+)
+
+// SetHandlerPool assigns a command or query handler to a named pool, so its dispatches share the pool's
+// concurrency budget with the other handlers of the pool and no other. handler is the registered handler
+// or a nil pointer of its type. Passing an empty pool name removes the handler from its pool.
+func SetHandlerPool(handler any, poolName string) {
+	handlerName := reflect.TypeOf(handler).String()
+
+	poolMutex.Lock()
+	defer poolMutex.Unlock()
+	if poolName == "" {
+		delete(handlerPools, handlerName)
+		return
+	}
+	handlerPools[handlerName] = poolName
+}
+
+// ConfigurePool sets how many handlers of a pool can run at the same time.
+// Dispatches beyond that wait for a slot until their context is done. Pools that are not configured,
+// or configured with a non-positive maxConcurrency, are unbounded. Dispatches already running when the
+// pool is reconfigured release their slot in the previous budget.
+func ConfigurePool(poolName string, maxConcurrency int) {
+	poolMutex.Lock()
+	defer poolMutex.Unlock()
+	if maxConcurrency <= 0 {
+		delete(pools, poolName)
+		return
+	}
+	pools[poolName] = make(chan struct{}, maxConcurrency)
+}
+
+// acquirePool waits for a slot in the pool of a handler and returns the function releasing it.
+func acquirePool(ctx context.Context, handlerName string) (func(), error) {
+	poolMutex.RLock()
+	slots, ok := pools[handlerPools[handlerName]]
+	poolMutex.RUnlock()
+
+	if !ok {
+		return func() {}, nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,69 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type reportCommand struct{}
+
+// reportCommandHandler blocks until release is closed.
+type reportCommandHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *reportCommandHandler) Handle(ctx context.Context, command reportCommand) (string, error) {
+	h.started <- struct{}{}
+	<-h.release
+	return "report", nil
+}
+
+type paymentCommand struct{}
+
+type paymentCommandHandler struct{}
+
+func (h *paymentCommandHandler) Handle(ctx context.Context, command paymentCommand) (string, error) {
+	return "paid", nil
+}
+
+// TestBulkhead tests that a saturated pool does not block the handlers of another pool.
+func TestBulkhead(t *testing.T) {
+	reports := &reportCommandHandler{started: make(chan struct{}, 2), release: make(chan struct{})}
+	AddCommandHandler[reportCommand, string](reports)
+	AddCommandHandler[paymentCommand, string](&paymentCommandHandler{})
+
+	ConfigurePool("reporting", 1)
+	ConfigurePool("payments", 1)
+	SetHandlerPool(reports, "reporting")
+	SetHandlerPool((*paymentCommandHandler)(nil), "payments")
+	defer func() {
+		SetHandlerPool(reports, "")
+		SetHandlerPool((*paymentCommandHandler)(nil), "")
+	}()
+
+	// Saturate the reporting pool.
+	done := make(chan error, 1)
+	go func() {
+		_, err := SendCommand[string](context.Background(), reportCommand{})
+		done <- err
+	}()
+	<-reports.started
+
+	// A second report waits for the slot until its context expires.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := SendCommand[string](ctx, reportCommand{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The payments pool is not affected.
+	response, err := SendCommand[string](context.Background(), paymentCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, "paid", response)
+
+	close(reports.release)
+	assert.NoError(t, <-done)
+}
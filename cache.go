@@ -0,0 +1,257 @@
+package gocqrs
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// defaultCacheCapacity bounds the number of results a cache keeps in memory, unless set with CacheMaxEntries.
+const defaultCacheCapacity = 10000
+
+type (
+	// CacheOption configures the result cache created by WithCache.
+	CacheOption func(*resultCache)
+
+	// resultCache remembers the results of a handler by request fingerprint.
+	resultCache struct {
+		ttl         time.Duration
+		negativeTTL time.Duration
+		cacheError  func(err error) bool
+		persistent  CacheStore // Store of the successful results, if set with CacheInStore.
+		version     int        // Schema version of the persisted results.
+		final       bool       // Set by CacheStoresFinalResponse.
+
+		mutex    sync.Mutex
+		capacity int
+		order    *list.List               // Most recently used results first.
+		entries  map[string]*list.Element // Request fingerprint to its result in order.
+	}
+
+	// cachedResult is a result kept until expiresAt.
+	cachedResult struct {
+		key       string
+		response  any
+		err       error
+		expiresAt time.Time
+	}
+)
+
+// CacheErrors also caches the errors for which cacheError returns true, such as a not found error,
+// for negativeTTL, so known-absent keys do not reach the backend again.
+func CacheErrors(cacheError func(err error) bool, negativeTTL time.Duration) CacheOption {
+	return func(cache *resultCache) {
+		cache.cacheError = cacheError
+		cache.negativeTTL = negativeTTL
+	}
+}
+
+// CacheMaxEntries bounds the number of results kept in memory to capacity, 10000 by default. Beyond it, the least
+// recently used results are evicted first. A capacity below 1 is ignored.
+func CacheMaxEntries(capacity int) CacheOption {
+	return func(cache *resultCache) {
+		if capacity > 0 {
+			cache.capacity = capacity
+		}
+	}
+}
+
+// CacheInStore keeps the successful results in store instead of memory, encoded as JSON, so a store such as
+// DiskCacheStore can keep them across restarts. Cached errors are still kept in memory. Results that cannot be
+// encoded or decoded are not cached; store failures are reported with the ErrorReporter.
+func CacheInStore(store CacheStore) CacheOption {
+	return func(cache *resultCache) {
+		cache.persistent = store
+	}
+}
+
+// CacheSchemaVersion tags the results persisted with CacheInStore with version. Results saved with another
+// version, e.g. by a deploy before the shape of the response changed, are discarded on lookup.
+func CacheSchemaVersion(version int) CacheOption {
+	return func(cache *resultCache) {
+		cache.version = version
+	}
+}
+
+// CacheStoresHandlerOutput caches the output of the handler and of the behaviors added after WithCache, so cache
+// hits still go through the response processor and the post-middlewares. It is the default.
+func CacheStoresHandlerOutput() CacheOption {
+	return func(cache *resultCache) {
+		cache.final = false
+	}
+}
+
+// CacheStoresFinalResponse caches the response returned by the response processor, e.g. an envelope or a
+// localized response, so cache hits skip the response processor. Post-middlewares still run on hits.
+func CacheStoresFinalResponse() CacheOption {
+	return func(cache *resultCache) {
+		cache.final = true
+	}
+}
+
+// WithCache serves the dispatches of the current handler from a cache for ttl after a successful result,
+// skipping the handler. Requests are keyed by RequestFingerprint; requests that cannot be fingerprinted are
+// not cached. Errors are not cached unless CacheErrors is given. Expired results are dropped on the next
+// lookup of their request, or evicted with the least recently used ones beyond CacheMaxEntries. Time is measured with the configured Clock.
+func (middlewareBuilder *AddMiddlewareBuilder) WithCache(ttl time.Duration, opts ...CacheOption) *AddMiddlewareBuilder {
+	cache := newResultCache(ttl, opts...)
+	if cache.final {
+		return middlewareBuilder.stagedBehavior(cache.behavior,
+			PipelineStep{Stage: StageBehaviors, Name: "cache lookup"},
+			PipelineStep{Stage: StageCacheStore, Name: "cache"})
+	}
+	return middlewareBuilder.stagedBehavior(cache.behavior, PipelineStep{Stage: StageBehaviors, Name: "cache"})
+}
+
+// newResultCache creates the cache of a handler.
+func newResultCache(ttl time.Duration, opts ...CacheOption) *resultCache {
+	cache := &resultCache{
+		ttl:      ttl,
+		capacity: defaultCacheCapacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+	for _, opt := range opts {
+		opt(cache)
+	}
+	return cache
+}
+
+// behavior is the pipeline behavior serving cached results.
+func (cache *resultCache) behavior(ctx context.Context, request any, next NextFunc) (any, error) {
+	key, err := RequestFingerprint(request)
+	if err != nil {
+		return next(ctx, request)
+	}
+	// Final responses are only known by the dispatch, after the response processor.
+	state, staged := pipelineStateFrom(ctx)
+	final := cache.final && staged
+
+	if result, ok := cache.lookup(key); ok {
+		if final {
+			state.finalResponse()
+		}
+		return result.response, result.err
+	}
+	if response, ok := cache.loadPersisted(ctx, key); ok {
+		if final {
+			state.finalResponse()
+		}
+		return response, nil
+	}
+
+	response, err := next(ctx, request)
+	if final {
+		state.onFinalResponse(func(response any, err error) {
+			cache.save(ctx, key, response, err)
+		})
+		return response, err
+	}
+	cache.save(ctx, key, response, err)
+	return response, err
+}
+
+// save caches the result of a request, in the store if it has one and the request succeeded.
+func (cache *resultCache) save(ctx context.Context, key string, response any, err error) {
+	if err == nil && cache.persistent != nil {
+		cache.persist(ctx, key, response)
+		return
+	}
+	cache.store(key, response, err)
+}
+
+// loadPersisted returns the unexpired result of a request saved in the store with the current schema version,
+// decoded into the response type of the dispatch.
+func (cache *resultCache) loadPersisted(ctx context.Context, key string) (any, bool) {
+	if cache.persistent == nil {
+		return nil, false
+	}
+	responseType, ok := ResponseTypeFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	entry, ok, err := cache.persistent.Load(key)
+	if err != nil {
+		reportError(ctx, fmt.Errorf("cannot load a cached result: %w", err))
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	response := reflect.New(responseType)
+	if entry.Version != cache.version || !now().Before(entry.ExpiresAt) || json.Unmarshal(entry.Value, response.Interface()) != nil {
+		if err := cache.persistent.Delete(key); err != nil {
+			reportError(ctx, fmt.Errorf("cannot delete a cached result: %w", err))
+		}
+		return nil, false
+	}
+	return response.Elem().Interface(), true
+}
+
+// persist saves the successful result of a request in the store.
+func (cache *resultCache) persist(ctx context.Context, key string, response any) {
+	if cache.ttl <= 0 {
+		return
+	}
+	value, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	entry := CacheEntry{Value: value, Version: cache.version, ExpiresAt: now().Add(cache.ttl)}
+	if err := cache.persistent.Store(key, entry); err != nil {
+		reportError(ctx, fmt.Errorf("cannot store a cached result: %w", err))
+	}
+}
+
+// lookup returns the unexpired result of a request.
+func (cache *resultCache) lookup(key string) (cachedResult, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, ok := cache.entries[key]
+	if !ok {
+		return cachedResult{}, false
+	}
+	result := element.Value.(*cachedResult)
+	if !now().Before(result.expiresAt) {
+		cache.order.Remove(element)
+		delete(cache.entries, key)
+		return cachedResult{}, false
+	}
+	cache.order.MoveToFront(element)
+	return *result, true
+}
+
+// store caches the result of a request, if it is cacheable, evicting the least recently used results beyond capacity.
+func (cache *resultCache) store(key string, response any, err error) {
+	ttl := cache.ttl
+	if err != nil {
+		if cache.cacheError == nil || !cache.cacheError(err) {
+			return
+		}
+		ttl = cache.negativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	result := &cachedResult{key: key, response: response, err: err, expiresAt: now().Add(ttl)}
+	if element, ok := cache.entries[key]; ok {
+		element.Value = result
+		cache.order.MoveToFront(element)
+	} else {
+		cache.entries[key] = cache.order.PushFront(result)
+	}
+	for cache.order.Len() > cache.capacity {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*cachedResult).key)
+	}
+}
@@ -0,0 +1,223 @@
+package gocqrs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// CacheStore persists the results cached by WithCache, e.g. on disk so they survive restarts.
+	// Implementations must be safe for concurrent use.
+	CacheStore interface {
+		// Load returns the entry of key. It returns false if there is none.
+		Load(key string) (CacheEntry, bool, error)
+		// Store saves the entry of key, replacing any previous one.
+		Store(key string, entry CacheEntry) error
+		// Delete removes the entry of key, if any.
+		Delete(key string) error
+	}
+
+	// CacheEntry is a result saved in a CacheStore.
+	CacheEntry struct {
+		Value     []byte    // JSON encoding of the response.
+		Version   int       // Schema version set with CacheSchemaVersion.
+		ExpiresAt time.Time // Time after which the entry is stale.
+	}
+
+	// DiskCacheStore is a CacheStore keeping every entry in its own file of a directory, along with an index file.
+	// When the entries exceed its size limit, the least recently used ones are evicted.
+	DiskCacheStore struct {
+		dir      string
+		maxBytes int64
+
+		mutex sync.Mutex
+		index diskCacheIndex
+	}
+
+	// diskCacheIndex is the content of the index file of a DiskCacheStore.
+	diskCacheIndex struct {
+		Entries map[string]diskCacheIndexEntry `json:"entries"` // Key to its entry.
+		Size    int64                          `json:"size"`    // Total size of the entry files.
+		Clock   uint64                         `json:"clock"`   // Last use sequence number.
+	}
+
+	// diskCacheIndexEntry describes the file of an entry.
+	diskCacheIndexEntry struct {
+		File      string    `json:"file"`
+		Size      int64     `json:"size"`
+		Version   int       `json:"version"`
+		ExpiresAt time.Time `json:"expiresAt"`
+		LastUsed  uint64    `json:"lastUsed"`
+	}
+)
+
+// diskCacheIndexFile is the name of the index file of a DiskCacheStore.
+const diskCacheIndexFile = "index.json"
+
+// NewDiskCacheStore returns a store keeping at most maxBytes of entries in dir, created if needed.
+// The entries saved by a previous store over the same directory are kept.
+func NewDiskCacheStore(dir string, maxBytes int64) (*DiskCacheStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create the cache directory: %w", err)
+	}
+	store := &DiskCacheStore{
+		dir:      dir,
+		maxBytes: maxBytes,
+		index:    diskCacheIndex{Entries: make(map[string]diskCacheIndexEntry)},
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, diskCacheIndexFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the cache index: %w", err)
+	}
+	if err := json.Unmarshal(data, &store.index); err != nil {
+		return nil, fmt.Errorf("cannot decode the cache index: %w", err)
+	}
+	if store.index.Entries == nil {
+		store.index.Entries = make(map[string]diskCacheIndexEntry)
+	}
+	return store, nil
+}
+
+// Load returns the entry of key and marks it as recently used.
+func (store *DiskCacheStore) Load(key string) (CacheEntry, bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	indexed, ok := store.index.Entries[key]
+	if !ok {
+		return CacheEntry{}, false, nil
+	}
+	value, err := os.ReadFile(filepath.Join(store.dir, indexed.File))
+	if errors.Is(err, fs.ErrNotExist) {
+		// The file was removed behind the store's back.
+		store.remove(key)
+		return CacheEntry{}, false, store.writeIndex()
+	}
+	if err != nil {
+		return CacheEntry{}, false, fmt.Errorf("cannot read the cache entry: %w", err)
+	}
+
+	// The new use is persisted with the next write of the index.
+	store.index.Clock++
+	indexed.LastUsed = store.index.Clock
+	store.index.Entries[key] = indexed
+	return CacheEntry{Value: value, Version: indexed.Version, ExpiresAt: indexed.ExpiresAt}, true, nil
+}
+
+// Store saves the entry of key, then evicts the least recently used entries beyond the size limit.
+// Entries larger than the limit are not saved.
+func (store *DiskCacheStore) Store(key string, entry CacheEntry) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.remove(key)
+	size := int64(len(entry.Value))
+	if size > store.maxBytes {
+		return store.writeIndex()
+	}
+
+	hash := sha256.Sum256([]byte(key))
+	file := hex.EncodeToString(hash[:])
+	if err := writeFileAtomic(filepath.Join(store.dir, file), entry.Value); err != nil {
+		return fmt.Errorf("cannot write the cache entry: %w", err)
+	}
+	store.index.Clock++
+	store.index.Entries[key] = diskCacheIndexEntry{
+		File:      file,
+		Size:      size,
+		Version:   entry.Version,
+		ExpiresAt: entry.ExpiresAt,
+		LastUsed:  store.index.Clock,
+	}
+	store.index.Size += size
+
+	if store.index.Size > store.maxBytes {
+		keys := make([]string, 0, len(store.index.Entries))
+		for k := range store.index.Entries {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return store.index.Entries[keys[i]].LastUsed < store.index.Entries[keys[j]].LastUsed
+		})
+		for _, k := range keys {
+			if store.index.Size <= store.maxBytes {
+				break
+			}
+			store.remove(k)
+		}
+	}
+	return store.writeIndex()
+}
+
+// Delete removes the entry of key.
+func (store *DiskCacheStore) Delete(key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, ok := store.index.Entries[key]; !ok {
+		return nil
+	}
+	store.remove(key)
+	return store.writeIndex()
+}
+
+// Size returns the total size of the entries.
+func (store *DiskCacheStore) Size() int64 {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.index.Size
+}
+
+// remove drops the entry of key from the index and deletes its file. The index is not written.
+func (store *DiskCacheStore) remove(key string) {
+	indexed, ok := store.index.Entries[key]
+	if !ok {
+		return
+	}
+	delete(store.index.Entries, key)
+	store.index.Size -= indexed.Size
+	_ = os.Remove(filepath.Join(store.dir, indexed.File))
+}
+
+// writeIndex saves the index file.
+func (store *DiskCacheStore) writeIndex() error {
+	data, err := json.Marshal(store.index)
+	if err != nil {
+		return fmt.Errorf("cannot encode the cache index: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(store.dir, diskCacheIndexFile), data); err != nil {
+		return fmt.Errorf("cannot write the cache index: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file renamed to path, so readers never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,96 @@
+package gocqrs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type salesReportQuery struct{ Year int }
+
+type salesReport struct {
+	Year  int
+	Total int
+}
+
+// TestCacheInStore tests that persisted results survive a restart and are discarded when the schema version changes.
+func TestCacheInStore(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	dir := t.TempDir()
+	ctx := withResponseType[salesReport](context.Background())
+	calls := 0
+	next := func(ctx context.Context, request any) (any, error) {
+		calls++
+		query := request.(salesReportQuery)
+		return salesReport{Year: query.Year, Total: 42}, nil
+	}
+
+	store, err := NewDiskCacheStore(dir, 1<<20)
+	assert.NoError(t, err)
+	cache := newResultCache(time.Hour, CacheInStore(store), CacheSchemaVersion(3))
+	report, err := cache.behavior(ctx, salesReportQuery{Year: 2020}, next)
+	assert.NoError(t, err)
+	assert.Equal(t, salesReport{Year: 2020, Total: 42}, report)
+
+	// A new store over the same directory serves the result saved before the restart.
+	restarted, err := NewDiskCacheStore(dir, 1<<20)
+	assert.NoError(t, err)
+	cache = newResultCache(time.Hour, CacheInStore(restarted), CacheSchemaVersion(3))
+	report, err = cache.behavior(ctx, salesReportQuery{Year: 2020}, next)
+	assert.NoError(t, err)
+	assert.Equal(t, salesReport{Year: 2020, Total: 42}, report)
+	assert.Equal(t, 1, calls)
+
+	// A deploy changing the schema version invalidates the result.
+	cache = newResultCache(time.Hour, CacheInStore(restarted), CacheSchemaVersion(4))
+	_, _ = cache.behavior(ctx, salesReportQuery{Year: 2020}, next)
+	_, _ = cache.behavior(ctx, salesReportQuery{Year: 2020}, next)
+	assert.Equal(t, 2, calls)
+
+	// Expired results are discarded as well.
+	clock.Advance(time.Hour)
+	_, _ = cache.behavior(ctx, salesReportQuery{Year: 2020}, next)
+	assert.Equal(t, 3, calls)
+}
+
+// TestDiskCacheStore_Eviction tests that the least recently used entries are evicted beyond the size limit.
+func TestDiskCacheStore_Eviction(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewDiskCacheStore(dir, 25)
+	assert.NoError(t, err)
+
+	entry := func(value string) CacheEntry {
+		return CacheEntry{Value: []byte(value), ExpiresAt: time.Now().Add(time.Hour)}
+	}
+	assert.NoError(t, store.Store("a", entry(strings.Repeat("a", 10))))
+	assert.NoError(t, store.Store("b", entry(strings.Repeat("b", 10))))
+	_, ok, err := store.Load("a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// "b" is the least recently used entry.
+	assert.NoError(t, store.Store("c", entry(strings.Repeat("c", 10))))
+	assert.Equal(t, int64(20), store.Size())
+
+	restarted, err := NewDiskCacheStore(dir, 25)
+	assert.NoError(t, err)
+	for key, expected := range map[string]bool{"a": true, "b": false, "c": true} {
+		_, ok, err := restarted.Load(key)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, ok, key)
+	}
+
+	// Entries larger than the limit are not saved.
+	assert.NoError(t, restarted.Store("d", entry(strings.Repeat("d", 30))))
+	_, ok, _ = restarted.Load("d")
+	assert.False(t, ok)
+
+	assert.NoError(t, restarted.Delete("a"))
+	assert.Equal(t, int64(10), restarted.Size())
+}
@@ -0,0 +1,105 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errProductNotFound = errors.New("product not found")
+
+type findProductQuery struct{ SKU string }
+
+type findProductQueryHandler struct {
+	calls map[string]int
+}
+
+func (h *findProductQueryHandler) Handle(ctx context.Context, query findProductQuery) (string, error) {
+	h.calls[query.SKU]++
+	if query.SKU == "missing" {
+		return "", errProductNotFound
+	}
+	if query.SKU == "flaky" {
+		return "", errors.New("backend unavailable")
+	}
+	return "product " + query.SKU, nil
+}
+
+// TestWithCache tests that results, and not found errors for a shorter time, are served without the handler.
+func TestWithCache(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	handler := &findProductQueryHandler{calls: make(map[string]int)}
+	AddQueryHandler[findProductQuery, string](handler).
+		WithCache(time.Minute, CacheErrors(func(err error) bool {
+			return errors.Is(err, errProductNotFound)
+		}, 10*time.Second))
+
+	for i := 0; i < 3; i++ {
+		product, err := SendQuery[string](context.Background(), findProductQuery{SKU: "a1"})
+		assert.NoError(t, err)
+		assert.Equal(t, "product a1", product)
+
+		_, err = SendQuery[string](context.Background(), findProductQuery{SKU: "missing"})
+		assert.ErrorIs(t, err, errProductNotFound)
+
+		_, err = SendQuery[string](context.Background(), findProductQuery{SKU: "flaky"})
+		assert.Error(t, err)
+	}
+	assert.Equal(t, map[string]int{"a1": 1, "missing": 1, "flaky": 3}, handler.calls)
+
+	// The negative result expires first.
+	clock.Advance(10 * time.Second)
+	_, _ = SendQuery[string](context.Background(), findProductQuery{SKU: "a1"})
+	_, _ = SendQuery[string](context.Background(), findProductQuery{SKU: "missing"})
+	assert.Equal(t, map[string]int{"a1": 1, "missing": 2, "flaky": 3}, handler.calls)
+
+	clock.Advance(time.Minute)
+	_, _ = SendQuery[string](context.Background(), findProductQuery{SKU: "a1"})
+	assert.Equal(t, 2, handler.calls["a1"])
+}
+
+type boundedCacheQuery struct{ SKU string }
+
+type boundedCacheQueryHandler struct {
+	calls map[string]int
+}
+
+func (h *boundedCacheQueryHandler) Handle(ctx context.Context, query boundedCacheQuery) (string, error) {
+	h.calls[query.SKU]++
+	return "product " + query.SKU, nil
+}
+
+// TestWithCache_MaxEntries tests that the least recently used results are evicted beyond the capacity.
+func TestWithCache_MaxEntries(t *testing.T) {
+	handler := &boundedCacheQueryHandler{calls: make(map[string]int)}
+	AddQueryHandler[boundedCacheQuery, string](handler).WithCache(time.Minute, CacheMaxEntries(2))
+
+	for _, sku := range []string{"a", "b", "a", "c", "a", "b"} {
+		_, err := SendQuery[string](context.Background(), boundedCacheQuery{SKU: sku})
+		assert.NoError(t, err)
+	}
+	// "c" evicted "b", the least recently used, and "b" then evicted "c" while "a" stayed cached.
+	assert.Equal(t, map[string]int{"a": 1, "b": 2, "c": 1}, handler.calls)
+}
+
+// TestResultCache_Bounded tests that the cache keeps at most its capacity of results.
+func TestResultCache_Bounded(t *testing.T) {
+	cache := newResultCache(time.Minute, CacheMaxEntries(3))
+	for i := 0; i < 10; i++ {
+		cache.store(string(rune('a'+i)), i, nil)
+	}
+	assert.Len(t, cache.entries, 3)
+	assert.Equal(t, 3, cache.order.Len())
+
+	_, ok := cache.lookup("a")
+	assert.False(t, ok)
+	result, ok := cache.lookup("j")
+	assert.True(t, ok)
+	assert.Equal(t, 9, result.response)
+}
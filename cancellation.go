@@ -0,0 +1,193 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Outcome labels of a dispatch, as returned by DispatchOutcome.
+const (
+	OutcomeOK              = "ok"
+	OutcomeError           = "error"
+	OutcomeCallerCanceled  = "caller_canceled"
+	OutcomeHandlerTimeout  = "handler_timeout"
+	OutcomePublishCanceled = "publish_canceled"
+)
+
+// CancellationReason tells why a dispatch was canceled.
+type CancellationReason string
+
+// Reasons of a canceled dispatch, as carried by CanceledError.
+const (
+	ReasonCallerCanceled CancellationReason = "caller_canceled" // The caller canceled its context.
+	ReasonDeadline       CancellationReason = "deadline"        // The caller's deadline expired.
+	ReasonHandlerTimeout CancellationReason = "handler_timeout" // The timeout set with WithTimeout elapsed.
+	ReasonBudgetExceeded CancellationReason = "budget_exceeded" // The share granted by WithChildBudgets elapsed.
+	ReasonManualCancel   CancellationReason = "manual_cancel"   // CancelDispatch was called.
+)
+
+type (
+	// CanceledError is returned by a dispatch failing with a context error, with the reason of the cancellation.
+	// It unwraps to the error returned by the dispatch, itself wrapping the context error.
+	CanceledError struct {
+		Reason CancellationReason
+		Err    error
+	}
+
+	// cancellationCause is the cause given to the contexts the package cancels, recording the reason.
+	cancellationCause struct {
+		reason CancellationReason
+	}
+)
+
+// Causes of the contexts canceled by the package.
+var (
+	causeHandlerTimeout = &cancellationCause{reason: ReasonHandlerTimeout}
+	causeBudgetExceeded = &cancellationCause{reason: ReasonBudgetExceeded}
+	causeManualCancel   = &cancellationCause{reason: ReasonManualCancel}
+)
+
+var (
+	handlerTimeoutMutex sync.RWMutex
+	handlerTimeouts     = make(map[string]time.Duration) // Handler name to its timeout.
+)
+
+// WithTimeout bounds every dispatch of the current handler to d, on top of the caller's deadline,
+// which d never extends.
+// A dispatch failing because d elapsed returns an error wrapping ErrHandlerTimeout and context.DeadlineExceeded.
+// A non-positive d removes the timeout.
+func (middlewareBuilder *AddMiddlewareBuilder) WithTimeout(d time.Duration) *AddMiddlewareBuilder {
+	handlerName := middlewareBuilder.currentHandler()
+
+	handlerTimeoutMutex.Lock()
+	defer handlerTimeoutMutex.Unlock()
+	if d <= 0 {
+		delete(handlerTimeouts, handlerName)
+	} else {
+		handlerTimeouts[handlerName] = d
+	}
+	return middlewareBuilder
+}
+
+// DispatchOutcome returns the outcome label of a dispatch or publish that returned err:
+// OutcomeOK, OutcomeCallerCanceled, OutcomeHandlerTimeout, OutcomePublishCanceled or OutcomeError.
+func DispatchOutcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.Is(err, ErrCallerCanceled):
+		return OutcomeCallerCanceled
+	case errors.Is(err, ErrHandlerTimeout):
+		return OutcomeHandlerTimeout
+	case errors.Is(err, ErrPublishCanceled):
+		return OutcomePublishCanceled
+	default:
+		return OutcomeError
+	}
+}
+
+// handlerTimeout returns the timeout of a handler set with WithTimeout.
+func handlerTimeout(handlerName string) (time.Duration, bool) {
+	handlerTimeoutMutex.RLock()
+	defer handlerTimeoutMutex.RUnlock()
+	d, ok := handlerTimeouts[handlerName]
+	return d, ok
+}
+
+// withHandlerTimeout applies the timeout of a handler, if it has one.
+// The timeout is clamped to the deadline inherited from the caller or the parent dispatch: when that deadline
+// comes first, the dispatch keeps it, so a nested dispatch never extends the budget of the top-level request
+// and its cancellation is attributed to the caller.
+func withHandlerTimeout(ctx context.Context, handlerName string) (context.Context, context.CancelFunc) {
+	d, ok := handlerTimeout(handlerName)
+	if !ok {
+		return ctx, func() {}
+	}
+	// Context deadlines are set and enforced by the context package against the wall clock, whatever the
+	// configured Clock, so the inherited deadline is compared with the wall clock too.
+	if deadline, inherited := ctx.Deadline(); inherited && !deadline.After(time.Now().Add(d)) {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, d, causeHandlerTimeout)
+}
+
+// classifyCancellation attaches the reason of the cancellation to the context errors of a dispatch,
+// read from the cause of the context that was canceled first. The ones caused by the caller's context
+// ending are also wrapped with ErrCallerCanceled, the ones caused by the handler's own timeout with ErrHandlerTimeout.
+// caller is the context given to the dispatch and dispatch the one derived for it.
+func classifyCancellation(caller, dispatch context.Context, err error) error {
+	if !isContextError(err) || dispatch.Err() == nil {
+		// Not a cancellation of this dispatch, e.g. one already explained by a nested dispatch.
+		return err
+	}
+
+	if caller.Err() != nil {
+		reason := cancellationReason(caller)
+		if hasCancellationReason(err, reason) {
+			return err
+		}
+		if !errors.Is(err, ErrCallerCanceled) {
+			err = fmt.Errorf("%w: %w", ErrCallerCanceled, err)
+		}
+		return &CanceledError{Reason: reason, Err: err}
+	}
+
+	reason := cancellationReason(dispatch)
+	if hasCancellationReason(err, reason) {
+		return err
+	}
+	if reason == ReasonHandlerTimeout && !errors.Is(err, ErrHandlerTimeout) {
+		err = fmt.Errorf("%w: %w", ErrHandlerTimeout, err)
+	}
+	return &CanceledError{Reason: reason, Err: err}
+}
+
+// hasCancellationReason reports whether err already carries a CanceledError with reason, set by a nested dispatch.
+func hasCancellationReason(err error, reason CancellationReason) bool {
+	var canceled *CanceledError
+	return errors.As(err, &canceled) && canceled.Reason == reason
+}
+
+// cancellationReason returns the reason ctx was canceled for.
+// Contexts canceled outside the package are reported as a caller cancellation or deadline.
+func cancellationReason(ctx context.Context) CancellationReason {
+	var cause *cancellationCause
+	if errors.As(context.Cause(ctx), &cause) {
+		return cause.reason
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ReasonDeadline
+	}
+	return ReasonCallerCanceled
+}
+
+// classifyPublishCancellation wraps with ErrPublishCanceled the context errors of a publish whose context ended.
+func classifyPublishCancellation(ctx context.Context, err error) error {
+	if ctx.Err() == nil || !isContextError(err) || errors.Is(err, ErrPublishCanceled) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrPublishCanceled, err)
+}
+
+// isContextError reports whether err comes from a canceled or expired context.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// Error describes the cancellation and its reason.
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("%v (%v)", e.Err, e.Reason)
+}
+
+// Unwrap returns the error returned by the dispatch.
+func (e *CanceledError) Unwrap() error {
+	return e.Err
+}
+
+// Error returns the reason.
+func (cause *cancellationCause) Error() string {
+	return string(cause.reason)
+}
@@ -0,0 +1,168 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	waitingQuery       struct{}
+	timedReportQuery   struct{}
+	canceledPubEvent   struct{}
+	manualCancelQuery  struct{}
+	nestingCancelQuery struct{}
+	budgetParentQuery  struct{}
+	budgetChildQuery   struct{}
+)
+
+// contextWaitingHandler blocks until its context is done and returns the context error.
+type contextWaitingHandler[TRequest T] struct {
+	started chan struct{}
+}
+
+func (h *contextWaitingHandler[TRequest]) Handle(ctx context.Context, request TRequest) (string, error) {
+	if h.started != nil {
+		close(h.started)
+	}
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+type canceledPubEventHandler struct{}
+
+func (h *canceledPubEventHandler) Handle(ctx context.Context, event canceledPubEvent) error {
+	return ctx.Err()
+}
+
+// TestCancellation_Caller tests that a dispatch ended by the caller's context wraps ErrCallerCanceled.
+func TestCancellation_Caller(t *testing.T) {
+	handler := &contextWaitingHandler[waitingQuery]{started: make(chan struct{})}
+	AddQueryHandler[waitingQuery, string](handler).WithTimeout(time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-handler.started
+		cancel()
+	}()
+	_, err := SendQuery[string](ctx, waitingQuery{})
+	assert.ErrorIs(t, err, ErrCallerCanceled)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, ErrHandlerTimeout)
+	assert.Equal(t, OutcomeCallerCanceled, DispatchOutcome(err))
+	var canceled *CanceledError
+	if assert.ErrorAs(t, err, &canceled) {
+		assert.Equal(t, ReasonCallerCanceled, canceled.Reason)
+	}
+
+	// The caller's own deadline is the caller's business too, and stays recognizable.
+	handler.started = nil
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = SendQuery[string](ctx, waitingQuery{})
+	assert.ErrorIs(t, err, ErrCallerCanceled)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	if assert.ErrorAs(t, err, &canceled) {
+		assert.Equal(t, ReasonDeadline, canceled.Reason)
+	}
+
+	assert.Equal(t, uint64(2), Stats()["gocqrs.waitingQuery"].Outcomes[OutcomeCallerCanceled])
+}
+
+// TestCancellation_HandlerTimeout tests that a dispatch ended by the handler's timeout wraps ErrHandlerTimeout.
+func TestCancellation_HandlerTimeout(t *testing.T) {
+	AddQueryHandler[timedReportQuery, string](&contextWaitingHandler[timedReportQuery]{}).
+		WithTimeout(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	_, err := SendQuery[string](ctx, timedReportQuery{})
+	assert.ErrorIs(t, err, ErrHandlerTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, ErrCallerCanceled)
+	assert.EqualError(t, err, "handler timed out: context deadline exceeded (handler_timeout)")
+	var canceled *CanceledError
+	if assert.ErrorAs(t, err, &canceled) {
+		assert.Equal(t, ReasonHandlerTimeout, canceled.Reason)
+	}
+	assert.Equal(t, OutcomeHandlerTimeout, DispatchOutcome(err))
+	assert.Equal(t, uint64(1), Stats()["gocqrs.timedReportQuery"].Outcomes[OutcomeHandlerTimeout])
+}
+
+// TestCancellation_Manual tests that a dispatch canceled with CancelDispatch reports ReasonManualCancel,
+// including when the cancellation reaches it through a nested dispatch.
+func TestCancellation_Manual(t *testing.T) {
+	handler := &contextWaitingHandler[manualCancelQuery]{started: make(chan struct{})}
+	AddQueryHandler[manualCancelQuery, string](handler)
+	AddQueryFunc[nestingCancelQuery, string](func(ctx context.Context, query nestingCancelQuery) (string, error) {
+		return SendQuery[string](ctx, manualCancelQuery{})
+	})
+
+	go func() {
+		<-handler.started
+		for _, dispatch := range InFlight() {
+			if dispatch.RequestType == "gocqrs.nestingCancelQuery" {
+				CancelDispatch(dispatch.ID)
+			}
+		}
+	}()
+	_, err := SendQuery[string](context.Background(), nestingCancelQuery{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, ErrHandlerTimeout)
+	var canceled *CanceledError
+	if assert.ErrorAs(t, err, &canceled) {
+		assert.Equal(t, ReasonManualCancel, canceled.Reason)
+	}
+	assert.EqualError(t, err, "caller canceled the dispatch: context canceled (manual_cancel)")
+}
+
+// TestCancellation_Publish tests that a publish ended by its context wraps ErrPublishCanceled.
+func TestCancellation_Publish(t *testing.T) {
+	assert.NoError(t, AddEventHandlers[canceledPubEvent](&canceledPubEventHandler{}))
+
+	assert.NoError(t, PublishEvent(context.Background(), canceledPubEvent{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := PublishEvent(ctx, canceledPubEvent{})
+	assert.ErrorIs(t, err, ErrPublishCanceled)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, OutcomePublishCanceled, DispatchOutcome(err))
+}
+
+// deadlineRecordingHandler records the deadline of its context, then waits for it like contextWaitingHandler.
+type deadlineRecordingHandler struct {
+	deadline time.Time
+}
+
+func (h *deadlineRecordingHandler) Handle(ctx context.Context, query budgetChildQuery) (string, error) {
+	h.deadline, _ = ctx.Deadline()
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+type budgetParentQueryHandler struct{}
+
+func (h *budgetParentQueryHandler) Handle(ctx context.Context, query budgetParentQuery) (string, error) {
+	return SendQuery[string](ctx, budgetChildQuery{})
+}
+
+// TestCancellation_TimeoutClampedToParentDeadline tests that the timeout of a nested dispatch longer than the
+// remaining deadline of its parent does not extend it.
+func TestCancellation_TimeoutClampedToParentDeadline(t *testing.T) {
+	child := &deadlineRecordingHandler{}
+	AddQueryHandler[budgetChildQuery, string](child).WithTimeout(time.Hour)
+	AddQueryHandler[budgetParentQuery, string](&budgetParentQueryHandler{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	_, err := SendQuery[string](ctx, budgetParentQuery{})
+	assert.Equal(t, deadline, child.deadline)
+	assert.ErrorIs(t, err, ErrCallerCanceled)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, ErrHandlerTimeout)
+}
@@ -0,0 +1,62 @@
+package gocqrs
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Clock is the source of time used by the package, injectable to control time in tests.
+	Clock interface {
+		// Now returns the current time.
+		Now() time.Time
+		// AfterFunc calls f in its own goroutine once d has elapsed.
+		AfterFunc(d time.Duration, f func()) Timer
+	}
+
+	// Timer is a pending call created by Clock.AfterFunc.
+	Timer interface {
+		// Stop prevents the call from running. It returns false if the call already ran or was stopped.
+		Stop() bool
+	}
+
+	// systemClock is the Clock backed by the time package.
+	systemClock struct{}
+)
+
+var (
+	clockMutex sync.RWMutex
+	clock      Clock = systemClock{}
+)
+
+// SetClock replaces the Clock used by the package. Passing nil restores the system clock.
+func SetClock(c Clock) {
+	clockMutex.Lock()
+	defer clockMutex.Unlock()
+	if c == nil {
+		c = systemClock{}
+	}
+	clock = c
+}
+
+// getClock returns the configured Clock.
+func getClock() Clock {
+	clockMutex.RLock()
+	defer clockMutex.RUnlock()
+	return clock
+}
+
+// now returns the current time of the configured Clock.
+func now() time.Time {
+	return getClock().Now()
+}
+
+// Now implements Clock.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// AfterFunc implements Clock.
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
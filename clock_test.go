@@ -0,0 +1,101 @@
+package gocqrs
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// fakeClock is a Clock whose time only moves when Advance is called.
+	fakeClock struct {
+		mutex  sync.Mutex
+		now    time.Time
+		timers []*fakeTimer
+	}
+
+	fakeTimer struct {
+		clock *fakeClock
+		at    time.Time
+		f     func()
+	}
+)
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	timer := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+	return timer
+}
+
+// Pending returns the number of timers waiting to fire.
+func (c *fakeClock) Pending() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.timers)
+}
+
+// Advance moves the time forward and synchronously runs the timers that became due, in order.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	c.now = c.now.Add(d)
+	due, pending := make([]*fakeTimer, 0), make([]*fakeTimer, 0)
+	for _, timer := range c.timers {
+		if !timer.at.After(c.now) {
+			due = append(due, timer)
+		} else {
+			pending = append(pending, timer)
+		}
+	}
+	c.timers = pending
+	c.mutex.Unlock()
+
+	sort.SliceStable(due, func(i, j int) bool { return due[i].at.Before(due[j].at) })
+	for _, timer := range due {
+		timer.f()
+	}
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	for i, timer := range t.clock.timers {
+		if timer == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// TestSetClock tests replacing and restoring the package clock.
+func TestSetClock(t *testing.T) {
+	fake := newFakeClock()
+	SetClock(fake)
+	assert.Equal(t, fake.Now(), now())
+
+	fired := false
+	timer := getClock().AfterFunc(time.Second, func() { fired = true })
+	fake.Advance(500 * time.Millisecond)
+	assert.False(t, fired)
+	fake.Advance(500 * time.Millisecond)
+	assert.True(t, fired)
+	assert.False(t, timer.Stop())
+
+	SetClock(nil)
+	assert.WithinDuration(t, time.Now(), now(), time.Second)
+}
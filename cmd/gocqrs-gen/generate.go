@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const gocqrsImportPath = "github.com/victoragudo/go-cqrs"
+
+type (
+	// handlerDecl describes a handler found while scanning the package.
+	handlerDecl struct {
+		typeName string // Name of the handler type.
+		pointer  bool   // Whether Handle has a pointer receiver.
+		request  string // Source of the request type expression.
+		response string // Source of the response type expression.
+		query    bool   // Whether the handler is registered as a query handler.
+	}
+
+	// importDecl is an import required by the request or response type of a handler.
+	importDecl struct {
+		name string
+		path string
+	}
+)
+
+// generate scans the package in dir and returns the formatted source of the registration file.
+func generate(dir, output string) ([]byte, error) {
+	fset := token.NewFileSet()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var packageName string
+	var handlers []handlerDecl
+	imports := make(map[string]importDecl)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || name == output {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		if packageName == "" {
+			packageName = file.Name.Name
+		} else if packageName != file.Name.Name {
+			return nil, fmt.Errorf("found packages %s and %s in %s", packageName, file.Name.Name, dir)
+		}
+
+		fileImports := importsOf(file)
+		for _, decl := range file.Decls {
+			handler, used, ok := handlerFromDecl(fset, decl, fileImports)
+			if !ok {
+				continue
+			}
+			handlers = append(handlers, handler)
+			for _, imp := range used {
+				imports[imp.path] = imp
+			}
+		}
+	}
+
+	if packageName == "" {
+		return nil, fmt.Errorf("no Go files found in %s", dir)
+	}
+
+	sort.Slice(handlers, func(i, j int) bool {
+		return handlers[i].typeName < handlers[j].typeName
+	})
+	return render(packageName, handlers, imports)
+}
+
+// importsOf maps the identifier each import is referenced by to the import itself.
+func importsOf(file *ast.File) map[string]importDecl {
+	imports := make(map[string]importDecl)
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = importDecl{name: name, path: importPath}
+	}
+	return imports
+}
+
+// handlerFromDecl reports whether decl is a Handle method matching IHandler and describes its handler.
+func handlerFromDecl(fset *token.FileSet, decl ast.Decl, fileImports map[string]importDecl) (handlerDecl, []importDecl, bool) {
+	fn, ok := decl.(*ast.FuncDecl)
+	if !ok || fn.Recv == nil || fn.Name.Name != "Handle" || len(fn.Recv.List) != 1 {
+		return handlerDecl{}, nil, false
+	}
+
+	// The receiver must be a plain named type; generic handlers cannot be instantiated by the generator.
+	handler := handlerDecl{}
+	receiver := fn.Recv.List[0].Type
+	if star, ok := receiver.(*ast.StarExpr); ok {
+		handler.pointer = true
+		receiver = star.X
+	}
+	ident, ok := receiver.(*ast.Ident)
+	if !ok {
+		return handlerDecl{}, nil, false
+	}
+	handler.typeName = ident.Name
+
+	params := flattenFields(fn.Type.Params)
+	results := flattenFields(fn.Type.Results)
+	if len(params) != 2 || len(results) != 2 {
+		return handlerDecl{}, nil, false
+	}
+	if !isSelector(params[0], fileImports, "context", "Context") || !isIdent(results[1], "error") {
+		return handlerDecl{}, nil, false
+	}
+
+	handler.request = exprString(fset, params[1])
+	handler.response = exprString(fset, results[0])
+	handler.query = strings.HasSuffix(typeBaseName(params[1]), "Query")
+
+	var used []importDecl
+	for _, expr := range []ast.Expr{params[1], results[0]} {
+		ast.Inspect(expr, func(node ast.Node) bool {
+			if selector, ok := node.(*ast.SelectorExpr); ok {
+				if x, ok := selector.X.(*ast.Ident); ok {
+					if imp, ok := fileImports[x.Name]; ok {
+						used = append(used, imp)
+					}
+				}
+				return false
+			}
+			return true
+		})
+	}
+	return handler, used, true
+}
+
+// flattenFields returns one type expression per parameter, expanding grouped names such as (a, b int).
+func flattenFields(fields *ast.FieldList) []ast.Expr {
+	if fields == nil {
+		return nil
+	}
+	var exprs []ast.Expr
+	for _, field := range fields.List {
+		count := len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+		for i := 0; i < count; i++ {
+			exprs = append(exprs, field.Type)
+		}
+	}
+	return exprs
+}
+
+// isSelector reports whether expr is pkg.name, where pkg refers to the import with path importPath.
+func isSelector(expr ast.Expr, fileImports map[string]importDecl, importPath, name string) bool {
+	selector, ok := expr.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != name {
+		return false
+	}
+	x, ok := selector.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	imp, ok := fileImports[x.Name]
+	return ok && imp.path == importPath
+}
+
+// isIdent reports whether expr is the identifier name.
+func isIdent(expr ast.Expr, name string) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == name
+}
+
+// typeBaseName returns the name of a type expression without pointers or package qualifiers.
+func typeBaseName(expr ast.Expr) string {
+	switch typed := expr.(type) {
+	case *ast.StarExpr:
+		return typeBaseName(typed.X)
+	case *ast.SelectorExpr:
+		return typed.Sel.Name
+	case *ast.Ident:
+		return typed.Name
+	}
+	return ""
+}
+
+// exprString prints a type expression as source.
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	_ = printer.Fprint(&buf, fset, expr)
+	return buf.String()
+}
+
+// render writes the registration file and formats it.
+func render(packageName string, handlers []handlerDecl, imports map[string]importDecl) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gocqrs-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", packageName)
+
+	paths := make([]string, 0, len(imports))
+	for importPath := range imports {
+		paths = append(paths, importPath)
+	}
+	sort.Strings(paths)
+
+	buf.WriteString("import (\n")
+	for _, importPath := range paths {
+		imp := imports[importPath]
+		if imp.name == path.Base(imp.path) {
+			fmt.Fprintf(&buf, "%q\n", imp.path)
+		} else {
+			fmt.Fprintf(&buf, "%s %q\n", imp.name, imp.path)
+		}
+	}
+	fmt.Fprintf(&buf, "\ngocqrs %q\n)\n\n", gocqrsImportPath)
+
+	buf.WriteString("// Handlers holds the handler instances registered by RegisterAll.\n")
+	buf.WriteString("type Handlers struct {\n")
+	for _, handler := range handlers {
+		fieldType := handler.typeName
+		if handler.pointer {
+			fieldType = "*" + fieldType
+		}
+		fmt.Fprintf(&buf, "%s %s\n", handler.typeName, fieldType)
+	}
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// RegisterAll registers every handler of h with explicit request and response types.\n")
+	buf.WriteString("func RegisterAll(h Handlers) {\n")
+	for _, handler := range handlers {
+		register := "AddCommandFunc"
+		if handler.query {
+			register = "AddQueryFunc"
+		}
+		fmt.Fprintf(&buf, "gocqrs.%s[%s, %s](h.%s.Handle)\n", register, handler.request, handler.response, handler.typeName)
+	}
+	buf.WriteString("}\n")
+
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// TestGenerate_Golden tests the generated registrations against the golden file.
+func TestGenerate_Golden(t *testing.T) {
+	src, err := generate(filepath.Join("testdata", "orders"), "gocqrs_registrations.gen.go")
+	assert.NoError(t, err)
+
+	golden := filepath.Join("testdata", "orders.golden")
+	if *update {
+		assert.NoError(t, os.WriteFile(golden, src, 0o644))
+	}
+
+	expected, err := os.ReadFile(golden)
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(src))
+}
+
+// TestGenerate_NoFiles tests that an empty directory is reported as an error.
+func TestGenerate_NoFiles(t *testing.T) {
+	_, err := generate(t.TempDir(), "gocqrs_registrations.gen.go")
+	assert.Error(t, err)
+}
@@ -0,0 +1,38 @@
+package orders
+
+import (
+	"context"
+	"time"
+)
+
+type CreateOrder struct {
+	Customer string
+}
+
+type CreateOrderHandler struct{}
+
+func (h *CreateOrderHandler) Handle(ctx context.Context, command CreateOrder) (string, error) {
+	return "order-1", nil
+}
+
+type GetOrderQuery struct {
+	ID string
+}
+
+type Order struct {
+	ID        string
+	CreatedAt time.Time
+}
+
+type GetOrderHandler struct{}
+
+func (h GetOrderHandler) Handle(ctx context.Context, query GetOrderQuery) (*Order, error) {
+	return &Order{ID: query.ID}, nil
+}
+
+type OrderCreatedHandler struct{}
+
+// Handle is an event handler and is not registered by the generator.
+func (h *OrderCreatedHandler) Handle(ctx context.Context, event CreateOrder) error {
+	return nil
+}
@@ -0,0 +1,24 @@
+package orders
+
+import (
+	stdctx "context"
+	"time"
+)
+
+type ScheduleDelivery struct {
+	At time.Time
+}
+
+type ScheduleDeliveryHandler struct{}
+
+func (h *ScheduleDeliveryHandler) Handle(ctx stdctx.Context, command ScheduleDelivery) (time.Time, error) {
+	return command.At, nil
+}
+
+type DeliveryWindowQuery struct{}
+
+type DeliveryWindowHandler struct{}
+
+func (h *DeliveryWindowHandler) Handle(ctx stdctx.Context, query *DeliveryWindowQuery) (time.Duration, error) {
+	return time.Hour, nil
+}
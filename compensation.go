@@ -0,0 +1,163 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+type (
+	// CompensableStep is a command dispatched by RunCompensable.
+	CompensableStep struct {
+		Command any
+	}
+
+	// CompensationError is returned by RunCompensable when a step fails.
+	// It unwraps to the error of the failed step; the failures of the compensations are kept apart.
+	CompensationError struct {
+		Step               int     // Index of the failed step.
+		Command            any     // Command of the failed step.
+		Err                error   // Error returned by the failed step.
+		CompensationErrors []error // Errors of the compensations dispatched for the preceding steps.
+	}
+
+	// compensation builds the compensating command of a command type.
+	compensation struct {
+		compensationType string
+		build            func(ctx context.Context, original any, result any) any
+	}
+)
+
+var (
+	compensationMutex  sync.RWMutex
+	compensations      = make(map[string]compensation) // Command type name to its compensation.
+	compensableHandler = make(map[string]bool)         // Command types registered with the Compensable option.
+)
+
+// Compensable declares that the command handled by the handler must have a compensation,
+// which Verify checks.
+func Compensable() HandlerOption {
+	return func(config *handlerConfig) {
+		config.compensable = true
+	}
+}
+
+// RegisterCompensation registers how to undo a TCommand: when a later step of RunCompensable fails,
+// build is called with the original command and its result, and the returned TCompensation is dispatched.
+// Registering another compensation for TCommand replaces it.
+func RegisterCompensation[TCommand T, TCompensation T](build func(ctx context.Context, original TCommand, result any) TCompensation) {
+	typed := typeKey(reflect.TypeOf(new(TCommand)).Elem())
+
+	compensationMutex.Lock()
+	defer compensationMutex.Unlock()
+	compensations[typed] = compensation{
+		compensationType: typeKey(reflect.TypeOf(new(TCompensation)).Elem()),
+		build: func(ctx context.Context, original any, result any) any {
+			return build(ctx, original.(TCommand), result)
+		},
+	}
+}
+
+// Compensations returns the registered compensations, as command type names mapped to the type names of
+// their compensating commands.
+func Compensations() map[string]string {
+	compensationMutex.RLock()
+	defer compensationMutex.RUnlock()
+	pairs := make(map[string]string, len(compensations))
+	for commandType, c := range compensations {
+		pairs[commandType] = c.compensationType
+	}
+	return pairs
+}
+
+// RunCompensable dispatches the commands of steps in order, like SendCommand.
+// When a step fails, the compensations of the steps that succeeded are dispatched in reverse order and
+// a *CompensationError is returned. Steps without a registered compensation are not undone.
+// Compensations run even if ctx is canceled, since the failure may be the cancellation itself.
+func RunCompensable(ctx context.Context, steps ...CompensableStep) error {
+	results := make([]any, 0, len(steps))
+	for i, step := range steps {
+		result, err := send[any](ctx, step.Command)
+		if err != nil {
+			return &CompensationError{
+				Step:               i,
+				Command:            step.Command,
+				Err:                err,
+				CompensationErrors: compensate(context.WithoutCancel(ctx), steps[:i], results),
+			}
+		}
+		results = append(results, result)
+	}
+	return nil
+}
+
+// compensate dispatches the compensations of the completed steps in reverse order and returns their errors.
+func compensate(ctx context.Context, completed []CompensableStep, results []any) []error {
+	var compensationErrors []error
+	for i := len(completed) - 1; i >= 0; i-- {
+		command := completed[i].Command
+		compensationMutex.RLock()
+		c, ok := compensations[typeKey(reflect.TypeOf(command))]
+		compensationMutex.RUnlock()
+		if !ok {
+			continue
+		}
+
+		if _, err := send[any](ctx, c.build(ctx, command, results[i])); err != nil {
+			compensationErrors = append(compensationErrors, fmt.Errorf("compensating step %d (%T): %w", i, command, err))
+		}
+	}
+	return compensationErrors
+}
+
+// Error describes the failed step and how many of its compensations failed.
+func (e *CompensationError) Error() string {
+	msg := fmt.Sprintf("step %d (%T) failed: %v", e.Step, e.Command, e.Err)
+	if len(e.CompensationErrors) > 0 {
+		msg += fmt.Sprintf("; %d compensation(s) failed: %v", len(e.CompensationErrors), errors.Join(e.CompensationErrors...))
+	}
+	return msg
+}
+
+// Unwrap returns the error of the failed step.
+func (e *CompensationError) Unwrap() error {
+	return e.Err
+}
+
+// markCompensable records whether the handler of a command type was registered with Compensable.
+func markCompensable(typed string, compensable bool) {
+	compensationMutex.Lock()
+	defer compensationMutex.Unlock()
+	if compensable {
+		compensableHandler[typed] = true
+	} else {
+		delete(compensableHandler, typed)
+	}
+}
+
+// verifyCompensations reports compensable commands without a compensation and compensations
+// whose compensating command has no handler.
+func verifyCompensations() []error {
+	compensationMutex.RLock()
+	defer compensationMutex.RUnlock()
+
+	var problems []error
+	for commandType := range compensableHandler {
+		if _, ok := compensations[commandType]; !ok {
+			problems = append(problems, fmt.Errorf("%w: %v", ErrMissingCompensation, commandType))
+		}
+	}
+	for commandType, c := range compensations {
+		if _, ok := getMapValue(handlers, c.compensationType, &handlerMutex); !ok {
+			problems = append(problems, fmt.Errorf("%w: %v, the compensation of %v", ErrUnknownRequestType, c.compensationType, commandType))
+		}
+	}
+	// Map iteration order is random, keep the report stable.
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Error() < problems[j].Error()
+	})
+	return problems
+}
@@ -0,0 +1,185 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	reserveInventory struct{ Sku string }
+	releaseInventory struct{ Reservation string }
+	chargePayment    struct{ Amount int }
+	refundPayment    struct{ Charge string }
+	shipOrder        struct{ Fail bool }
+	auditOrder       struct{}
+)
+
+// compensationLog records the commands handled by the compensation test handlers.
+type compensationLog struct {
+	mutex    sync.Mutex
+	commands []string
+}
+
+func (l *compensationLog) record(command any) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.commands = append(l.commands, fmt.Sprintf("%T", command))
+}
+
+func (l *compensationLog) reset() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.commands = nil
+}
+
+var compensated = &compensationLog{}
+
+type reserveInventoryHandler struct{}
+
+func (h *reserveInventoryHandler) Handle(ctx context.Context, command reserveInventory) (string, error) {
+	compensated.record(command)
+	return "reservation-" + command.Sku, nil
+}
+
+type releaseInventoryHandler struct{}
+
+func (h *releaseInventoryHandler) Handle(ctx context.Context, command releaseInventory) (string, error) {
+	compensated.record(command)
+	return command.Reservation, nil
+}
+
+type chargePaymentHandler struct{}
+
+func (h *chargePaymentHandler) Handle(ctx context.Context, command chargePayment) (string, error) {
+	compensated.record(command)
+	return fmt.Sprintf("charge-%d", command.Amount), nil
+}
+
+type refundPaymentHandler struct{}
+
+func (h *refundPaymentHandler) Handle(ctx context.Context, command refundPayment) (string, error) {
+	compensated.record(command)
+	if command.Charge == "charge-0" {
+		return "", errors.New("nothing to refund")
+	}
+	return command.Charge, nil
+}
+
+type shipOrderHandler struct{}
+
+func (h *shipOrderHandler) Handle(ctx context.Context, command shipOrder) (string, error) {
+	compensated.record(command)
+	if command.Fail {
+		return "", errors.New("carrier unavailable")
+	}
+	return "shipped", nil
+}
+
+func init() {
+	AddCommandHandler[reserveInventory, string](&reserveInventoryHandler{}, Compensable())
+	AddCommandHandler[releaseInventory, string](&releaseInventoryHandler{})
+	AddCommandHandler[chargePayment, string](&chargePaymentHandler{}, Compensable())
+	AddCommandHandler[refundPayment, string](&refundPaymentHandler{})
+	AddCommandHandler[shipOrder, string](&shipOrderHandler{})
+
+	RegisterCompensation[reserveInventory, releaseInventory](func(ctx context.Context, original reserveInventory, result any) releaseInventory {
+		return releaseInventory{Reservation: result.(string)}
+	})
+	RegisterCompensation[chargePayment, refundPayment](func(ctx context.Context, original chargePayment, result any) refundPayment {
+		return refundPayment{Charge: result.(string)}
+	})
+}
+
+// TestRunCompensable tests that the completed steps are compensated in reverse order when a step fails.
+func TestRunCompensable(t *testing.T) {
+	compensated.reset()
+	err := RunCompensable(context.Background(),
+		CompensableStep{Command: reserveInventory{Sku: "book"}},
+		CompensableStep{Command: chargePayment{Amount: 10}},
+		CompensableStep{Command: shipOrder{Fail: true}},
+	)
+
+	var compensationErr *CompensationError
+	assert.ErrorAs(t, err, &compensationErr)
+	assert.Equal(t, 2, compensationErr.Step)
+	assert.Equal(t, shipOrder{Fail: true}, compensationErr.Command)
+	assert.EqualError(t, compensationErr.Err, "carrier unavailable")
+	assert.Empty(t, compensationErr.CompensationErrors)
+	assert.EqualError(t, err, "step 2 (gocqrs.shipOrder) failed: carrier unavailable")
+	assert.Equal(t, []string{
+		"gocqrs.reserveInventory",
+		"gocqrs.chargePayment",
+		"gocqrs.shipOrder",
+		"gocqrs.refundPayment",
+		"gocqrs.releaseInventory",
+	}, compensated.commands)
+
+	compensated.reset()
+	assert.NoError(t, RunCompensable(context.Background(),
+		CompensableStep{Command: reserveInventory{Sku: "book"}},
+		CompensableStep{Command: shipOrder{}},
+	))
+	assert.Equal(t, []string{"gocqrs.reserveInventory", "gocqrs.shipOrder"}, compensated.commands)
+}
+
+// TestRunCompensable_CompensationFailure tests that failed compensations are reported apart from the step error.
+func TestRunCompensable_CompensationFailure(t *testing.T) {
+	compensated.reset()
+	err := RunCompensable(context.Background(),
+		CompensableStep{Command: reserveInventory{Sku: "book"}},
+		CompensableStep{Command: chargePayment{Amount: 0}},
+		CompensableStep{Command: shipOrder{Fail: true}},
+	)
+
+	var compensationErr *CompensationError
+	assert.ErrorAs(t, err, &compensationErr)
+	assert.EqualError(t, errors.Unwrap(err), "carrier unavailable")
+	if assert.Len(t, compensationErr.CompensationErrors, 1) {
+		assert.EqualError(t, compensationErr.CompensationErrors[0], "compensating step 1 (gocqrs.chargePayment): nothing to refund")
+	}
+	assert.EqualError(t, err, "step 2 (gocqrs.shipOrder) failed: carrier unavailable; "+
+		"1 compensation(s) failed: compensating step 1 (gocqrs.chargePayment): nothing to refund")
+
+	// The remaining compensations still run after a failed one.
+	assert.Equal(t, "gocqrs.releaseInventory", compensated.commands[len(compensated.commands)-1])
+}
+
+// TestVerify_Compensations tests that Verify reports compensable commands without a compensation.
+func TestVerify_Compensations(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		"gocqrs.reserveInventory": "gocqrs.releaseInventory",
+		"gocqrs.chargePayment":    "gocqrs.refundPayment",
+	}, filterCompensations(Compensations(), "gocqrs.reserveInventory", "gocqrs.chargePayment"))
+	assert.NoError(t, Verify())
+
+	AddCommandHandler[auditOrder, string](&auditOrderHandler{}, Compensable())
+	err := Verify()
+	assert.ErrorIs(t, err, ErrMissingCompensation)
+	assert.EqualError(t, err, "compensations: missing compensation: gocqrs.auditOrder")
+
+	// Registering the handler again without the option withdraws the declaration.
+	AddCommandHandler[auditOrder, string](&auditOrderHandler{})
+	assert.NoError(t, Verify())
+}
+
+type auditOrderHandler struct{}
+
+func (h *auditOrderHandler) Handle(ctx context.Context, command auditOrder) (string, error) {
+	return "audited", nil
+}
+
+// filterCompensations keeps the compensations of the given command types.
+func filterCompensations(pairs map[string]string, commandTypes ...string) map[string]string {
+	filtered := make(map[string]string)
+	for _, commandType := range commandTypes {
+		if compensationType, ok := pairs[commandType]; ok {
+			filtered[commandType] = compensationType
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,47 @@
+package gocqrs
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Compressible is implemented by responses telling transport adapters whether they are worth compressing.
+type Compressible interface {
+	Compressible() bool
+}
+
+var (
+	compressibleMutex sync.RWMutex
+	compressibleTypes = make(map[string]bool) // Response type name to its compressibility.
+)
+
+// SetCompressible records whether responses of type TResponse, or pointers to it, are worth compressing,
+// for response types that cannot implement Compressible, such as types of another package.
+func SetCompressible[TResponse T](compressible bool) {
+	compressibleMutex.Lock()
+	defer compressibleMutex.Unlock()
+	compressibleTypes[typeKey(reflect.TypeOf(new(TResponse)).Elem())] = compressible
+}
+
+// IsCompressible reports whether a transport adapter should compress response. Responses implementing
+// Compressible decide for themselves; otherwise the hint recorded with SetCompressible for their type is used.
+// Responses without any hint are not compressible.
+func IsCompressible(response any) bool {
+	if response == nil {
+		return false
+	}
+	if compressible, ok := response.(Compressible); ok {
+		return compressible.Compressible()
+	}
+
+	responseType := reflect.TypeOf(response)
+	compressibleMutex.RLock()
+	defer compressibleMutex.RUnlock()
+	if compressible, ok := compressibleTypes[typeKey(responseType)]; ok {
+		return compressible
+	}
+	if responseType.Kind() == reflect.Pointer {
+		return compressibleTypes[typeKey(responseType.Elem())]
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package gocqrs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type exportResponse struct {
+	Rows [][]string
+}
+
+func (r exportResponse) Compressible() bool {
+	return len(r.Rows) > 100
+}
+
+type thumbnailResponse struct {
+	PNG []byte
+}
+
+type auditTrailResponse struct {
+	Entries []string
+}
+
+// TestIsCompressible tests the hints of responses implementing Compressible and of types marked with SetCompressible.
+func TestIsCompressible(t *testing.T) {
+	SetCompressible[auditTrailResponse](true)
+	SetCompressible[thumbnailResponse](false)
+
+	assert.True(t, IsCompressible(exportResponse{Rows: make([][]string, 101)}))
+	assert.False(t, IsCompressible(exportResponse{}))
+	assert.True(t, IsCompressible(auditTrailResponse{}))
+	assert.True(t, IsCompressible(&auditTrailResponse{}))
+	assert.False(t, IsCompressible(thumbnailResponse{}))
+	assert.False(t, IsCompressible("untagged"))
+	assert.False(t, IsCompressible(nil))
+}
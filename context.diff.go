@@ -0,0 +1,106 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ContextDiff describes the context values that differ between the pre- and post-middleware
+// snapshots taken by ContextDiffMiddleware. It is reported with the ErrorReporter.
+type ContextDiff struct {
+	HandlerName string // Handler of the dispatch, if known.
+	Changed     []any  // Keys whose value changed.
+	Lost        []any  // Keys that had a value before the handler and none after it.
+}
+
+// Error describes the differences.
+func (diff *ContextDiff) Error() string {
+	return fmt.Sprintf("context diff for %v: changed %v, lost %v", diff.HandlerName, diff.Changed, diff.Lost)
+}
+
+// contextDiffer takes the snapshots of ContextDiffMiddleware.
+type contextDiffer struct {
+	keys      []any
+	mutex     sync.Mutex
+	snapshots map[uint64][]any // Dispatch ID to the values of keys before its handler.
+}
+
+// ContextDiffMiddleware returns a pre- and post-middleware pair that snapshot the values of keys before and after
+// the handler and report the keys that changed or were lost as a *ContextDiff through the ErrorReporter.
+// Register pre last among the pre-middlewares and post first among the post-middlewares, so the snapshots
+// surround the handler. It is a debugging aid for context propagation issues, such as values set by
+// pre-middlewares that never reach the handler.
+// The snapshot of a dispatch whose post-middlewares do not run is dropped when the dispatch ends.
+func ContextDiffMiddleware(keys ...any) (pre, post MiddlewareFunc) {
+	differ := newContextDiffer(keys)
+	return differ.pre, differ.post
+}
+
+// newContextDiffer creates a contextDiffer of keys without snapshots.
+func newContextDiffer(keys []any) *contextDiffer {
+	return &contextDiffer{keys: keys, snapshots: make(map[uint64][]any)}
+}
+
+// pre takes the snapshot of the dispatch before its handler.
+func (differ *contextDiffer) pre(ctx context.Context, request any) (context.Context, any, bool) {
+	id, ok := ctx.Value(dispatchIDKey{}).(uint64)
+	if !ok {
+		reportError(ctx, fmt.Errorf("context diff: the dispatch context was replaced before the snapshot of %T", request))
+		return ctx, request, true
+	}
+	differ.mutex.Lock()
+	differ.snapshots[id] = snapshotContext(ctx, differ.keys)
+	differ.mutex.Unlock()
+	OnDispatchEnd(ctx, func(ctx context.Context, err error) {
+		differ.take(id)
+	})
+	return ctx, request, true
+}
+
+// post compares the values of the dispatch after its handler with its snapshot.
+func (differ *contextDiffer) post(ctx context.Context, request any) (context.Context, any, bool) {
+	id, _ := ctx.Value(dispatchIDKey{}).(uint64)
+	before, ok := differ.take(id)
+	if !ok {
+		return ctx, request, true
+	}
+
+	diff := &ContextDiff{}
+	if dispatch, ok := dispatchInfo(id); ok {
+		diff.HandlerName = dispatch.HandlerName
+	}
+	after := snapshotContext(ctx, differ.keys)
+	for i, key := range differ.keys {
+		switch {
+		case before[i] == nil:
+		case after[i] == nil:
+			diff.Lost = append(diff.Lost, key)
+		case !reflect.DeepEqual(before[i], after[i]):
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+	if len(diff.Changed) > 0 || len(diff.Lost) > 0 {
+		reportError(ctx, diff)
+	}
+	return ctx, request, true
+}
+
+// take removes and returns the snapshot of a dispatch.
+func (differ *contextDiffer) take(id uint64) ([]any, bool) {
+	differ.mutex.Lock()
+	defer differ.mutex.Unlock()
+	before, ok := differ.snapshots[id]
+	delete(differ.snapshots, id)
+	return before, ok
+}
+
+// snapshotContext returns the values of keys in ctx.
+func snapshotContext(ctx context.Context, keys []any) []any {
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		values[i] = ctx.Value(key)
+	}
+	return values
+}
@@ -0,0 +1,76 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type principalKey struct{}
+
+type traceKey struct{}
+
+type contextDiffCommand struct{}
+
+type contextDiffCommandHandler struct{}
+
+func (h *contextDiffCommandHandler) Handle(ctx context.Context, command contextDiffCommand) (string, error) {
+	return "ok", nil
+}
+
+// TestContextDiffMiddleware tests that a context value set by a pre-middleware and dropped before the handler is reported.
+func TestContextDiffMiddleware(t *testing.T) {
+	var reported []error
+	SetErrorReporter(func(ctx context.Context, err error) {
+		reported = append(reported, err)
+	})
+	defer SetErrorReporter(nil)
+
+	pre, post := ContextDiffMiddleware(principalKey{}, traceKey{})
+	AddCommandHandler[contextDiffCommand, string](&contextDiffCommandHandler{}).
+		PreMiddlewareNamed("Authenticate", func(ctx context.Context, request any) (context.Context, any, bool) {
+			return context.WithValue(ctx, principalKey{}, "alice"), request, true
+		}).
+		PreMiddlewareNamed("ContextDiffPre", pre).
+		PostMiddlewareNamed("ContextDiffPost", post)
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "trace-1")
+	_, err := SendCommand[string](ctx, contextDiffCommand{})
+	assert.NoError(t, err)
+
+	assert.Len(t, reported, 1)
+	var diff *ContextDiff
+	assert.True(t, errors.As(reported[0], &diff))
+	assert.Equal(t, "*gocqrs.contextDiffCommandHandler", diff.HandlerName)
+	assert.Equal(t, []any{principalKey{}}, diff.Lost, "the principal set by a pre-middleware should be reported lost")
+	assert.Empty(t, diff.Changed)
+}
+
+type failingContextDiffCommand struct{}
+
+type failingContextDiffCommandHandler struct{}
+
+func (h *failingContextDiffCommandHandler) Handle(ctx context.Context, command failingContextDiffCommand) (string, error) {
+	return "", errors.New("rejected")
+}
+
+// TestContextDiffMiddleware_NoPost tests that the snapshot of a dispatch whose post-middlewares do not run is dropped.
+func TestContextDiffMiddleware_NoPost(t *testing.T) {
+	SetRunPostOnError(false)
+	defer SetRunPostOnError(true)
+
+	differ := newContextDiffer([]any{traceKey{}})
+	AddCommandHandler[failingContextDiffCommand, string](&failingContextDiffCommandHandler{}).
+		PreMiddlewareNamed("ContextDiffPre", differ.pre).
+		PostMiddlewareNamed("ContextDiffPost", differ.post)
+
+	for i := 0; i < 3; i++ {
+		_, err := SendCommand[string](context.Background(), failingContextDiffCommand{})
+		assert.EqualError(t, err, "rejected")
+	}
+	differ.mutex.Lock()
+	defer differ.mutex.Unlock()
+	assert.Empty(t, differ.snapshots)
+}
@@ -0,0 +1,27 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// IRequiredContextKeys is an optional interface for command and query handlers depending on context values.
+// SendCommand and SendQuery check every returned key is present in the context before running the dispatch.
+type IRequiredContextKeys interface {
+	RequiredContextKeys() []any
+}
+
+// checkRequiredContextKeys returns ErrMissingContextValue if ctx lacks a key required by the handler.
+func checkRequiredContextKeys(ctx context.Context, handlerField reflect.Value, handlerName string) error {
+	requirer, ok := handlerInstance(handlerField.Interface()).(IRequiredContextKeys)
+	if !ok {
+		return nil
+	}
+	for _, key := range requirer.RequiredContextKeys() {
+		if ctx.Value(key) == nil {
+			return fmt.Errorf("%w: %v required by %v", ErrMissingContextValue, key, handlerName)
+		}
+	}
+	return nil
+}
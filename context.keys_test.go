@@ -0,0 +1,35 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tenantKey struct{}
+
+type tenantCommand struct{}
+
+type tenantCommandHandler struct{}
+
+func (h *tenantCommandHandler) Handle(ctx context.Context, command tenantCommand) (string, error) {
+	return ctx.Value(tenantKey{}).(string), nil
+}
+
+func (h *tenantCommandHandler) RequiredContextKeys() []any {
+	return []any{tenantKey{}}
+}
+
+// TestRequiredContextKeys tests that a dispatch missing a required context value fails before the handler runs.
+func TestRequiredContextKeys(t *testing.T) {
+	AddCommandHandler[tenantCommand, string](&tenantCommandHandler{})
+
+	_, err := SendCommand[string](context.Background(), tenantCommand{})
+	assert.ErrorIs(t, err, ErrMissingContextValue)
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	response, err := SendCommand[string](ctx, tenantCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", response)
+}
@@ -0,0 +1,198 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type (
+	// DebounceOption configures the coalescing window created by WithDebounce.
+	DebounceOption func(*debouncer)
+
+	// DebounceStat describes the open coalescing windows of a handler.
+	DebounceStat struct {
+		Windows int // Number of open windows.
+		Waiting int // Number of dispatches waiting for the execution of an open window.
+	}
+
+	// debouncer coalesces dispatches sharing a key within a time window into a single execution.
+	debouncer struct {
+		window  time.Duration
+		keyFn   func(request any) string
+		leading bool
+
+		mutex   sync.Mutex
+		pending map[string]*debouncedCall // Open windows by key.
+	}
+
+	// debouncedCall is the single execution shared by every dispatch coalesced in a window.
+	debouncedCall struct {
+		done     chan struct{}
+		waiters  int // Number of dispatches waiting for the call.
+		out      any
+		err      error
+		warnings []string // Warnings raised by the execution, added to the collector of every waiter.
+	}
+
+	// sharedCallContext is the context of a debounced execution: it carries the values of the dispatch that
+	// opened the window but not its dispatch scope, which the execution may outlive.
+	sharedCallContext struct {
+		context.Context
+	}
+)
+
+var (
+	debouncerMutex sync.RWMutex
+	debouncers     = make(map[string][]*debouncer) // Handler name to its debouncers.
+)
+
+// DebounceLeading executes the first dispatch of a window immediately instead of when the window closes.
+// Identical dispatches arriving until the window closes are suppressed and receive its result.
+func DebounceLeading() DebounceOption {
+	return func(debouncer *debouncer) {
+		debouncer.leading = true
+	}
+}
+
+// WithDebounce coalesces dispatches of the current handler whose requests share the key returned by keyFn.
+// The first dispatch opens a window; it is executed once the window closes, and every identical dispatch
+// received in the meantime waits for that single execution and receives its result.
+// A caller whose context is canceled stops waiting without affecting the others; the shared execution
+// does not inherit the cancellation of any caller. Time is measured with the configured Clock.
+// The shared execution has its own dispatch state rather than the one of the dispatch that opened the window:
+// its OnDispatchEnd hooks run and its produced events are published once it ends, its warnings are added to
+// every waiter's collector, and the goroutines it spawns with Go are detached.
+func (middlewareBuilder *AddMiddlewareBuilder) WithDebounce(window time.Duration, keyFn func(request any) string, opts ...DebounceOption) *AddMiddlewareBuilder {
+	debouncer := &debouncer{
+		window:  window,
+		keyFn:   keyFn,
+		pending: make(map[string]*debouncedCall),
+	}
+	for _, opt := range opts {
+		opt(debouncer)
+	}
+
+	handlerName := middlewareBuilder.currentHandler()
+	debouncerMutex.Lock()
+	debouncers[handlerName] = append(debouncers[handlerName], debouncer)
+	debouncerMutex.Unlock()
+	return middlewareBuilder.stagedBehavior(debouncer.behavior, PipelineStep{Stage: StageBehaviors, Name: "debounce"})
+}
+
+// DebounceStats returns the open coalescing windows of every handler configured with WithDebounce,
+// keyed by handler type name.
+func DebounceStats() map[string]DebounceStat {
+	debouncerMutex.RLock()
+	defer debouncerMutex.RUnlock()
+
+	snapshot := make(map[string]DebounceStat, len(debouncers))
+	for handlerName, handlerDebouncers := range debouncers {
+		var stat DebounceStat
+		for _, debouncer := range handlerDebouncers {
+			debouncer.mutex.Lock()
+			stat.Windows += len(debouncer.pending)
+			for _, call := range debouncer.pending {
+				stat.Waiting += call.waiters
+			}
+			debouncer.mutex.Unlock()
+		}
+		snapshot[handlerName] = stat
+	}
+	return snapshot
+}
+
+// behavior is the pipeline behavior coalescing dispatches.
+func (debouncer *debouncer) behavior(ctx context.Context, request any, next NextFunc) (any, error) {
+	call := debouncer.join(ctx, request, next)
+	defer debouncer.leave(call)
+
+	select {
+	case <-call.done:
+		for _, warning := range call.warnings {
+			AddWarning(ctx, warning)
+		}
+		return call.out, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// join returns the call of the window open for the request's key, opening a new window if needed.
+func (debouncer *debouncer) join(ctx context.Context, request any, next NextFunc) *debouncedCall {
+	key := debouncer.keyFn(request)
+
+	debouncer.mutex.Lock()
+	defer debouncer.mutex.Unlock()
+
+	if call, ok := debouncer.pending[key]; ok {
+		call.waiters++
+		return call
+	}
+
+	call := &debouncedCall{done: make(chan struct{}), waiters: 1}
+	debouncer.pending[key] = call
+
+	// The execution is shared by every waiter, so it must not be canceled by the first one.
+	// It runs on its own goroutine, so a panic becomes the error of every waiter instead of crashing the process.
+	execute := func() {
+		shared := context.Context(sharedCallContext{context.WithoutCancel(ctx)})
+		shared = context.WithValue(shared, warningsKey{}, &warningCollector{})
+		shared, produced := withProducedEvents(shared)
+		shared, cleanups := withDispatchCleanups(shared)
+
+		defer close(call.done)
+		defer func() {
+			call.warnings = WarningsFromContext(shared)
+			cleanups.run(shared, call.err)
+		}()
+		defer func() {
+			if r := recover(); r != nil {
+				call.out, call.err = nil, fmt.Errorf("debounced handler panicked: %v", r)
+			}
+		}()
+		call.out, call.err = next(shared, request)
+		if call.err == nil {
+			call.err = produced.publish(shared)
+		}
+	}
+
+	if debouncer.leading {
+		go execute()
+		getClock().AfterFunc(debouncer.window, func() {
+			debouncer.close(key, call)
+		})
+		return call
+	}
+
+	getClock().AfterFunc(debouncer.window, func() {
+		debouncer.close(key, call)
+		execute()
+	})
+	return call
+}
+
+// leave stops counting a dispatch among the waiters of a call.
+func (debouncer *debouncer) leave(call *debouncedCall) {
+	debouncer.mutex.Lock()
+	defer debouncer.mutex.Unlock()
+	call.waiters--
+}
+
+// close ends the window of a call so later dispatches open a new one.
+func (debouncer *debouncer) close(key string, call *debouncedCall) {
+	debouncer.mutex.Lock()
+	defer debouncer.mutex.Unlock()
+	if debouncer.pending[key] == call {
+		delete(debouncer.pending, key)
+	}
+}
+
+// Value hides the dispatch scope of the dispatch that opened the window.
+func (ctx sharedCallContext) Value(key any) any {
+	if _, ok := key.(dispatchScopeKey); ok {
+		return nil
+	}
+	return ctx.Context.Value(key)
+}
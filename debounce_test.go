@@ -0,0 +1,278 @@
+package gocqrs
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type invalidateCacheCommand struct {
+	Key string
+}
+
+// invalidateCacheCommandHandler counts its executions.
+type invalidateCacheCommandHandler struct {
+	executions atomic.Int32
+}
+
+func (h *invalidateCacheCommandHandler) Handle(ctx context.Context, command invalidateCacheCommand) (int32, error) {
+	return h.executions.Add(1), nil
+}
+
+// waitForWaiters blocks until n dispatches joined the window open for key.
+func waitForWaiters(t *testing.T, debouncer *debouncer, key string, n int) {
+	assert.Eventually(t, func() bool {
+		debouncer.mutex.Lock()
+		defer debouncer.mutex.Unlock()
+		call, ok := debouncer.pending[key]
+		return ok && call.waiters == n
+	}, time.Second, time.Millisecond)
+}
+
+// sendConcurrently runs next n times through the debouncer and returns the collected results.
+func sendConcurrently(debouncer *debouncer, n int, request invalidateCacheCommand, next NextFunc) (chan any, *sync.WaitGroup) {
+	results := make(chan any, n)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out, _ := debouncer.behavior(context.Background(), request, next)
+			results <- out
+		}()
+	}
+	return results, wg
+}
+
+// TestWithDebounce tests that five dispatches in a window are executed once and share the result.
+func TestWithDebounce(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	handler := &invalidateCacheCommandHandler{}
+	AddCommandHandler[invalidateCacheCommand, int32](handler).
+		WithDebounce(time.Second, func(request any) string { return request.(invalidateCacheCommand).Key }, DebounceLeading())
+
+	for i := 0; i < 5; i++ {
+		response, err := SendCommand[int32](context.Background(), invalidateCacheCommand{Key: "users"})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), response, "Every caller should receive the single execution's result")
+	}
+	assert.Equal(t, int32(1), handler.executions.Load(), "Coalesced dispatches should execute once")
+
+	response, _ := SendCommand[int32](context.Background(), invalidateCacheCommand{Key: "orders"})
+	assert.Equal(t, int32(2), response, "Other keys should not be coalesced")
+}
+
+// TestDebouncer_Trailing tests that concurrent dispatches are executed once the window closes.
+func TestDebouncer_Trailing(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	var executions atomic.Int32
+	next := func(ctx context.Context, request any) (any, error) {
+		return executions.Add(1), nil
+	}
+	debouncer := &debouncer{window: time.Second, keyFn: func(any) string { return "k" }, pending: make(map[string]*debouncedCall)}
+
+	results, wg := sendConcurrently(debouncer, 5, invalidateCacheCommand{}, next)
+	waitForWaiters(t, debouncer, "k", 5)
+	assert.Equal(t, int32(0), executions.Load(), "Nothing should run before the window closes")
+
+	clock.Advance(time.Second)
+	wg.Wait()
+	assert.Equal(t, int32(1), executions.Load())
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, int32(1), <-results)
+	}
+}
+
+// TestDebouncer_WindowBoundaries tests that a dispatch after the window closes opens a new window.
+func TestDebouncer_WindowBoundaries(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	var executions atomic.Int32
+	next := func(ctx context.Context, request any) (any, error) {
+		return executions.Add(1), nil
+	}
+	debouncer := &debouncer{window: time.Second, keyFn: func(any) string { return "k" }, pending: make(map[string]*debouncedCall)}
+
+	first, wg := sendConcurrently(debouncer, 5, invalidateCacheCommand{}, next)
+	waitForWaiters(t, debouncer, "k", 5)
+	clock.Advance(time.Second)
+	wg.Wait()
+
+	second, wg := sendConcurrently(debouncer, 2, invalidateCacheCommand{}, next)
+	waitForWaiters(t, debouncer, "k", 2)
+	clock.Advance(time.Second)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), executions.Load())
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, int32(1), <-first)
+	}
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, int32(2), <-second)
+	}
+}
+
+// TestDebouncer_Leading tests that the first dispatch runs immediately and later ones are suppressed.
+func TestDebouncer_Leading(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	var executions atomic.Int32
+	next := func(ctx context.Context, request any) (any, error) {
+		return executions.Add(1), nil
+	}
+	debouncer := &debouncer{window: time.Second, keyFn: func(any) string { return "k" }, leading: true, pending: make(map[string]*debouncedCall)}
+
+	out, err := debouncer.behavior(context.Background(), invalidateCacheCommand{}, next)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), out, "The first dispatch should not wait for the window")
+
+	out, _ = debouncer.behavior(context.Background(), invalidateCacheCommand{}, next)
+	assert.Equal(t, int32(1), out, "Dispatches within the window should be suppressed")
+
+	clock.Advance(time.Second)
+	out, _ = debouncer.behavior(context.Background(), invalidateCacheCommand{}, next)
+	assert.Equal(t, int32(2), out, "A new window should execute again")
+}
+
+// TestDebouncer_WaiterCancellation tests that a canceled waiter does not affect the others.
+func TestDebouncer_WaiterCancellation(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	var executions atomic.Int32
+	next := func(ctx context.Context, request any) (any, error) {
+		assert.NoError(t, ctx.Err(), "The shared execution should not inherit a waiter's cancellation")
+		return executions.Add(1), nil
+	}
+	debouncer := &debouncer{window: time.Second, keyFn: func(any) string { return "k" }, pending: make(map[string]*debouncedCall)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := debouncer.behavior(ctx, invalidateCacheCommand{}, next)
+		canceled <- err
+	}()
+	waitForWaiters(t, debouncer, "k", 1)
+
+	others, wg := sendConcurrently(debouncer, 2, invalidateCacheCommand{}, next)
+	waitForWaiters(t, debouncer, "k", 3)
+
+	cancel()
+	assert.ErrorIs(t, <-canceled, context.Canceled)
+
+	clock.Advance(time.Second)
+	wg.Wait()
+	assert.Equal(t, int32(1), <-others)
+	assert.Equal(t, int32(1), <-others)
+	assert.Equal(t, int32(1), executions.Load())
+}
+
+// TestDebouncer_Panic tests that a panicking execution fails every coalesced dispatch instead of leaving them waiting.
+func TestDebouncer_Panic(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	next := func(ctx context.Context, request any) (any, error) {
+		panic("cache backend unreachable")
+	}
+	debouncer := &debouncer{window: time.Second, keyFn: func(any) string { return "k" }, pending: make(map[string]*debouncedCall)}
+
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, err := debouncer.behavior(context.Background(), invalidateCacheCommand{}, next)
+			errs <- err
+		}()
+	}
+	waitForWaiters(t, debouncer, "k", 3)
+
+	assert.NotPanics(t, func() { clock.Advance(time.Second) })
+	for i := 0; i < 3; i++ {
+		assert.EqualError(t, <-errs, "debounced handler panicked: cache backend unreachable")
+	}
+}
+
+type rebuildIndexCommand struct{}
+
+type indexRebuiltEvent struct{}
+
+// rebuildIndexCommandHandler produces an event, raises a warning and registers a dispatch end hook.
+type rebuildIndexCommandHandler struct {
+	ended atomic.Int32
+}
+
+func (h *rebuildIndexCommandHandler) Handle(ctx context.Context, command rebuildIndexCommand) (string, []any, error) {
+	AddWarning(ctx, "index rebuilt from a stale snapshot")
+	OnDispatchEnd(ctx, func(ctx context.Context, err error) { h.ended.Add(1) })
+	return "rebuilt", []any{indexRebuiltEvent{}}, nil
+}
+
+type indexRebuiltEventHandler struct {
+	calls atomic.Int32
+}
+
+func (h *indexRebuiltEventHandler) Handle(ctx context.Context, event indexRebuiltEvent) error {
+	h.calls.Add(1)
+	return nil
+}
+
+// TestWithDebounce_DispatchState tests that the shared execution keeps its own dispatch state, so its hooks,
+// produced events and warnings are not lost when the dispatch that opened the window stops waiting.
+func TestWithDebounce_DispatchState(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	handler := &indexRebuiltEventHandler{}
+	assert.NoError(t, AddEventHandlers[indexRebuiltEvent](handler))
+	command := &rebuildIndexCommandHandler{}
+	AddEventProducingHandler[rebuildIndexCommand, string](command).
+		WithDebounce(time.Second, func(request any) string { return "index" })
+	waiting := func(n int) func() bool {
+		return func() bool {
+			return DebounceStats()["*gocqrs.rebuildIndexCommandHandler"] == DebounceStat{Windows: 1, Waiting: n}
+		}
+	}
+
+	first, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := SendCommand[string](first, rebuildIndexCommand{})
+		canceled <- err
+	}()
+	assert.Eventually(t, waiting(1), time.Second, time.Millisecond)
+
+	second := WithWarnings(context.Background())
+	responses := make(chan string, 1)
+	go func() {
+		response, _ := SendCommand[string](second, rebuildIndexCommand{})
+		responses <- response
+	}()
+	assert.Eventually(t, waiting(2), time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-canceled, context.Canceled)
+
+	clock.Advance(time.Second)
+	assert.Equal(t, "rebuilt", <-responses)
+	assert.Equal(t, []string{"index rebuilt from a stale snapshot"}, WarningsFromContext(second))
+	assert.Equal(t, int32(1), handler.calls.Load(), "The produced event should be published once")
+	assert.Equal(t, int32(1), command.ended.Load(), "The hook should run once the shared execution ended")
+	assert.Equal(t, DebounceStat{}, DebounceStats()["*gocqrs.rebuildIndexCommandHandler"])
+}
@@ -0,0 +1,172 @@
+package gocqrs
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// DeliveryGuarantee is a delivery guarantee an event handler relies on, declared with the Expects options.
+type DeliveryGuarantee int
+
+const (
+	// AtLeastOnce expects every event to reach the handler, or its failure to reach the publisher so it can retry.
+	AtLeastOnce DeliveryGuarantee = iota
+	// ExactlyOnce expects every event to be handled successfully once, retries of the publisher included: a retry
+	// after a failed delivery is handled again, a retry after a successful one is skipped.
+	ExactlyOnce
+	// Ordered expects events to be handled one at a time, in publication order, even with concurrent publishers.
+	Ordered
+)
+
+// deliveryExpectation is a guarantee expected by a registered event handler.
+type deliveryExpectation struct {
+	eventType   string
+	handlerName string
+	guarantee   DeliveryGuarantee
+	mailbox     *mailboxConfig
+}
+
+var (
+	deliveryAssertions   atomic.Bool
+	deliveryMutex        sync.RWMutex
+	deliveryExpectations []deliveryExpectation
+)
+
+// String returns the name of the guarantee.
+func (guarantee DeliveryGuarantee) String() string {
+	switch guarantee {
+	case AtLeastOnce:
+		return "at-least-once"
+	case ExactlyOnce:
+		return "exactly-once"
+	case Ordered:
+		return "ordered"
+	default:
+		return "unknown"
+	}
+}
+
+// ExpectsAtLeastOnce declares that the handler relies on at-least-once delivery, which excludes a mailbox,
+// whose handler errors never reach the publisher, and a rate limit dropping excess events.
+func ExpectsAtLeastOnce() EventHandlerOption {
+	return expects(AtLeastOnce)
+}
+
+// ExpectsExactlyOnce declares that the handler relies on exactly-once delivery: at-least-once delivery, plus
+// event deduplication set with SetEventDeduplication to absorb the retries of the publishers. Deduplication only
+// remembers the events delivered without error, so the failures still returned to the publishers are retried
+// rather than lost. Retries are absorbed within the deduplication window only.
+func ExpectsExactlyOnce() EventHandlerOption {
+	return expects(ExactlyOnce)
+}
+
+// ExpectsOrdered declares that the handler relies on ordered delivery, which requires a mailbox: without one,
+// concurrent publishers run the handler concurrently. Delaying excess events with DelayExcessEvents is excluded,
+// as delayed publishers may be overtaken.
+func ExpectsOrdered() EventHandlerOption {
+	return expects(Ordered)
+}
+
+// expects returns the option declaring guarantee.
+func expects(guarantee DeliveryGuarantee) EventHandlerOption {
+	return func(config *eventHandlerConfig) {
+		config.expectations = append(config.expectations, guarantee)
+	}
+}
+
+// EnableDeliveryAssertions makes AddEventHandler and Verify check the guarantees declared by event handlers
+// against the configuration: AddEventHandler returns ErrDeliveryExpectation instead of registering a handler
+// whose guarantees are not met, and Verify reports the guarantees broken by configuration changed afterwards,
+// such as a rate limit set after the registration.
+func EnableDeliveryAssertions(enabled bool) {
+	deliveryAssertions.Store(enabled)
+}
+
+// recordDeliveryExpectations checks and records the guarantees expected by an event handler being registered.
+func recordDeliveryExpectations(eventType, handlerName string, config eventHandlerConfig) error {
+	expectations := make([]deliveryExpectation, 0, len(config.expectations))
+	for _, guarantee := range config.expectations {
+		expectation := deliveryExpectation{eventType: eventType, handlerName: handlerName, guarantee: guarantee, mailbox: config.mailbox}
+		if deliveryAssertions.Load() {
+			if err := expectation.check(); err != nil {
+				return err
+			}
+		}
+		expectations = append(expectations, expectation)
+	}
+
+	deliveryMutex.Lock()
+	defer deliveryMutex.Unlock()
+	deliveryExpectations = append(deliveryExpectations[:len(deliveryExpectations):len(deliveryExpectations)], expectations...)
+	return nil
+}
+
+// verifyDeliveryExpectations checks the guarantees expected by the registered event handlers, if enabled.
+func verifyDeliveryExpectations() []error {
+	if !deliveryAssertions.Load() {
+		return nil
+	}
+	deliveryMutex.RLock()
+	expectations := deliveryExpectations
+	deliveryMutex.RUnlock()
+
+	var problems []error
+	for _, expectation := range expectations {
+		if err := expectation.check(); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Error() < problems[j].Error()
+	})
+	return problems
+}
+
+// check returns why the configuration does not provide the expected guarantee, if it does not.
+func (expectation deliveryExpectation) check() error {
+	switch expectation.guarantee {
+	case AtLeastOnce, ExactlyOnce:
+		if expectation.mailbox != nil {
+			return expectation.broken("its mailbox reports handler errors instead of returning them to the publisher")
+		}
+		if limiter, ok := eventRateLimiterFor(expectation.eventType); ok && !limiter.delay {
+			return expectation.broken("the rate limit of the event drops excess events; use DelayExcessEvents")
+		}
+		// Deduplication forgets the events whose delivery failed, so it keeps at-least-once delivery and
+		// only skips the retries of successful deliveries.
+		if expectation.guarantee == ExactlyOnce && !eventDeduplicationEnabled() {
+			return expectation.broken("retried events are handled again; enable SetEventDeduplication")
+		}
+	case Ordered:
+		if expectation.mailbox == nil {
+			return expectation.broken("concurrent publishers run it concurrently; use WithMailbox")
+		}
+		if limiter, ok := eventRateLimiterFor(expectation.eventType); ok && limiter.delay {
+			return expectation.broken("events delayed by the rate limit of the event may be overtaken")
+		}
+	}
+	return nil
+}
+
+// broken returns the error of an unmet expectation.
+func (expectation deliveryExpectation) broken(reason string) error {
+	return fmt.Errorf("%w: %v expects %v delivery of %v, but %v",
+		ErrDeliveryExpectation, expectation.handlerName, expectation.guarantee, expectation.eventType, reason)
+}
+
+// eventRateLimiterFor returns the rate limiter of an event type, if it has one.
+func eventRateLimiterFor(eventType string) (*eventRateLimiter, bool) {
+	eventRateLimitMutex.RLock()
+	defer eventRateLimitMutex.RUnlock()
+	limiter, ok := eventRateLimiters[eventType]
+	return limiter, ok
+}
+
+// eventDeduplicationEnabled reports whether SetEventDeduplication is in effect.
+func eventDeduplicationEnabled() bool {
+	eventDeduplicatorMutex.RLock()
+	defer eventDeduplicatorMutex.RUnlock()
+	return eventDeduplication != nil
+}
@@ -0,0 +1,112 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ledgerEntryEvent struct{ ID string }
+
+type ledgerEntryOrderedEvent struct{ ID string }
+
+type ledgerEntryHandler struct{}
+
+func (h *ledgerEntryHandler) Handle(ctx context.Context, event ledgerEntryEvent) error {
+	return nil
+}
+
+type ledgerProjectionHandler struct{}
+
+func (h *ledgerProjectionHandler) Handle(ctx context.Context, event ledgerEntryEvent) error {
+	return nil
+}
+
+type ledgerOrderedHandler struct{}
+
+func (h *ledgerOrderedHandler) Handle(ctx context.Context, event ledgerEntryOrderedEvent) error {
+	return nil
+}
+
+// TestDeliveryAssertions tests each expectation against compatible and incompatible configurations.
+func TestDeliveryAssertions(t *testing.T) {
+	EnableDeliveryAssertions(true)
+	defer EnableDeliveryAssertions(false)
+
+	// At-least-once delivery cannot go through a mailbox.
+	err := AddEventHandler[ledgerEntryEvent](&ledgerEntryHandler{}, ExpectsAtLeastOnce(), WithMailbox(10, OverflowBlock))
+	assert.ErrorIs(t, err, ErrDeliveryExpectation)
+	assert.EqualError(t, err, "delivery expectation not met: *gocqrs.ledgerEntryHandler expects at-least-once delivery of "+
+		"gocqrs.ledgerEntryEvent, but its mailbox reports handler errors instead of returning them to the publisher")
+	assert.NoError(t, AddEventHandler[ledgerEntryEvent](&ledgerEntryHandler{}, ExpectsAtLeastOnce()))
+
+	// Exactly-once delivery requires deduplication.
+	assert.ErrorIs(t, AddEventHandler[ledgerEntryEvent](&ledgerProjectionHandler{}, ExpectsExactlyOnce()), ErrDeliveryExpectation)
+	SetEventDeduplication(time.Minute, func(event any) string { return "" })
+	defer SetEventDeduplication(0, nil)
+	assert.NoError(t, AddEventHandler[ledgerEntryEvent](&ledgerProjectionHandler{}, ExpectsExactlyOnce()))
+	assert.NoError(t, Verify())
+
+	// A rate limit dropping events set after the registrations is caught by Verify.
+	SetEventRateLimit[ledgerEntryEvent](100)
+	defer SetEventRateLimit[ledgerEntryEvent](0)
+	err = Verify()
+	assert.ErrorIs(t, err, ErrDeliveryExpectation)
+	assert.Contains(t, err.Error(), "*gocqrs.ledgerEntryHandler expects at-least-once")
+	assert.Contains(t, err.Error(), "*gocqrs.ledgerProjectionHandler expects exactly-once")
+	SetEventRateLimit[ledgerEntryEvent](100, DelayExcessEvents())
+	assert.NoError(t, Verify())
+
+	// Ordered delivery requires a mailbox and no delayed events.
+	assert.ErrorIs(t, AddEventHandler[ledgerEntryOrderedEvent](&ledgerOrderedHandler{}, ExpectsOrdered()), ErrDeliveryExpectation)
+	SetEventRateLimit[ledgerEntryOrderedEvent](100, DelayExcessEvents())
+	assert.ErrorIs(t, AddEventHandler[ledgerEntryOrderedEvent](&ledgerOrderedHandler{}, ExpectsOrdered(), WithMailbox(10, OverflowBlock)), ErrDeliveryExpectation)
+	SetEventRateLimit[ledgerEntryOrderedEvent](0)
+	assert.NoError(t, AddEventHandler[ledgerEntryOrderedEvent](&ledgerOrderedHandler{}, ExpectsOrdered(), WithMailbox(10, OverflowBlock)))
+	assert.NoError(t, Verify())
+}
+
+// TestDeliveryAssertions_Disabled tests that expectations are not checked unless assertions are enabled.
+func TestDeliveryAssertions_Disabled(t *testing.T) {
+	type unassertedEvent struct{}
+	handler := EventHandlerFunc[unassertedEvent](func(ctx context.Context, event unassertedEvent) error { return nil })
+	assert.NoError(t, AddEventHandler[unassertedEvent](handler, ExpectsOrdered()))
+}
+
+type ledgerRetriedEvent struct{ ID string }
+
+type ledgerRetriedHandler struct {
+	calls int
+	fail  bool
+}
+
+func (h *ledgerRetriedHandler) Handle(ctx context.Context, event ledgerRetriedEvent) error {
+	h.calls++
+	if h.fail {
+		return errors.New("ledger unavailable")
+	}
+	return nil
+}
+
+// TestDeliveryAssertions_ExactlyOnceRetries tests that the configuration approved for exactly-once delivery
+// handles the retry of a failed delivery again and skips the retry of a successful one.
+func TestDeliveryAssertions_ExactlyOnceRetries(t *testing.T) {
+	EnableDeliveryAssertions(true)
+	defer EnableDeliveryAssertions(false)
+	SetEventDeduplication(time.Minute, func(event any) string { return event.(ledgerRetriedEvent).ID })
+	defer SetEventDeduplication(0, nil)
+
+	handler := &ledgerRetriedHandler{fail: true}
+	assert.NoError(t, AddEventHandler[ledgerRetriedEvent](handler, ExpectsExactlyOnce()))
+
+	assert.Error(t, PublishEvent(context.Background(), ledgerRetriedEvent{ID: "1"}))
+	handler.fail = false
+	assert.NoError(t, PublishEvent(context.Background(), ledgerRetriedEvent{ID: "1"}))
+	duplicate := false
+	assert.NoError(t, PublishEvent(context.Background(), ledgerRetriedEvent{ID: "1"}, OnDuplicate(func(any) { duplicate = true })))
+	assert.True(t, duplicate)
+	assert.Equal(t, 2, handler.calls)
+}
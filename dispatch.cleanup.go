@@ -0,0 +1,76 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type (
+	// dispatchCleanups is the stack of hooks registered with OnDispatchEnd during a dispatch.
+	dispatchCleanups struct {
+		mutex sync.Mutex
+		hooks []func(ctx context.Context, err error)
+		ended bool
+		ctx   context.Context // Context the hooks run with, once the dispatch ended.
+		err   error           // Final error of the dispatch, once it ended.
+	}
+
+	dispatchCleanupsKey struct{}
+)
+
+// OnDispatchEnd registers fn to run when the dispatch ctx belongs to ends, however it ends: success, error,
+// panic of the handler or cancellation by the caller. Hooks run in LIFO order after the post middlewares,
+// with the final error of the dispatch and a context that is never canceled, before SendCommand or SendQuery
+// returns. A panicking hook is reported with the ErrorReporter and does not prevent the others from running.
+// Nested dispatches have their own hooks. A hook registered after its dispatch ended, e.g. by a handler
+// still running on its executor, runs immediately.
+// Calling it outside a dispatch is a programmer error.
+func OnDispatchEnd(ctx context.Context, fn func(ctx context.Context, err error)) {
+	cleanups, ok := ctx.Value(dispatchCleanupsKey{}).(*dispatchCleanups)
+	if !ok {
+		reportError(ctx, brokenInvariant("dispatch_scope", fmt.Errorf("OnDispatchEnd called outside a dispatch")))
+		return
+	}
+
+	cleanups.mutex.Lock()
+	if !cleanups.ended {
+		cleanups.hooks = append(cleanups.hooks, fn)
+		cleanups.mutex.Unlock()
+		return
+	}
+	endCtx, err := cleanups.ctx, cleanups.err
+	cleanups.mutex.Unlock()
+	runDispatchEndHook(endCtx, fn, err)
+}
+
+// withDispatchCleanups returns a context carrying a new, empty stack of dispatch end hooks.
+func withDispatchCleanups(ctx context.Context) (context.Context, *dispatchCleanups) {
+	cleanups := &dispatchCleanups{}
+	return context.WithValue(ctx, dispatchCleanupsKey{}, cleanups), cleanups
+}
+
+// run ends the dispatch with err and runs its hooks, the last registered first.
+func (cleanups *dispatchCleanups) run(ctx context.Context, err error) {
+	// The hooks release resources, so they must run even if the dispatch was canceled.
+	ctx = context.WithoutCancel(ctx)
+
+	cleanups.mutex.Lock()
+	hooks := cleanups.hooks
+	cleanups.hooks, cleanups.ended, cleanups.ctx, cleanups.err = nil, true, ctx, err
+	cleanups.mutex.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		runDispatchEndHook(ctx, hooks[i], err)
+	}
+}
+
+// runDispatchEndHook calls fn, reporting its panic with the ErrorReporter.
+func runDispatchEndHook(ctx context.Context, fn func(ctx context.Context, err error), err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			reportError(ctx, fmt.Errorf("dispatch end hook panicked: %v", r))
+		}
+	}()
+	fn(ctx, err)
+}
@@ -0,0 +1,141 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cleanupCommand struct {
+	Mode string
+}
+
+type nestedCleanupCommand struct{}
+
+// cleanupRecorder records the steps of the dispatches of cleanupCommand.
+type cleanupRecorder struct {
+	mutex sync.Mutex
+	steps []string
+	errs  []error
+}
+
+func (r *cleanupRecorder) record(step string, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.steps = append(r.steps, step)
+	r.errs = append(r.errs, err)
+}
+
+func (r *cleanupRecorder) reset() ([]string, []error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	steps, errs := r.steps, r.errs
+	r.steps, r.errs = nil, nil
+	return steps, errs
+}
+
+var cleanupSteps = &cleanupRecorder{}
+
+type cleanupHandler struct{}
+
+func (h *cleanupHandler) Handle(ctx context.Context, command cleanupCommand) (string, error) {
+	OnDispatchEnd(ctx, func(ctx context.Context, err error) {
+		if ctx.Err() != nil {
+			cleanupSteps.record("first with a canceled context", err)
+			return
+		}
+		cleanupSteps.record("first", err)
+	})
+	OnDispatchEnd(ctx, func(ctx context.Context, err error) { panic("broken hook") })
+	OnDispatchEnd(ctx, func(ctx context.Context, err error) { cleanupSteps.record("second", err) })
+
+	switch command.Mode {
+	case "error":
+		return "", errors.New("handler failed")
+	case "panic":
+		panic("handler panicked")
+	case "wait":
+		<-ctx.Done()
+		return "", ctx.Err()
+	case "nested":
+		_, err := SendCommand[string](ctx, nestedCleanupCommand{})
+		return "done", err
+	}
+	return "done", nil
+}
+
+type nestedCleanupHandler struct{}
+
+func (h *nestedCleanupHandler) Handle(ctx context.Context, command nestedCleanupCommand) (string, error) {
+	OnDispatchEnd(ctx, func(ctx context.Context, err error) { cleanupSteps.record("nested", err) })
+	return "nested", nil
+}
+
+// TestOnDispatchEnd tests that the dispatch end hooks run in LIFO order after the post middlewares,
+// with the final error, however the dispatch ends.
+func TestOnDispatchEnd(t *testing.T) {
+	var reported []error
+	SetErrorReporter(func(ctx context.Context, err error) { reported = append(reported, err) })
+	defer SetErrorReporter(nil)
+
+	AddCommandHandler[cleanupCommand, string](&cleanupHandler{}).
+		WithTimeout(20 * time.Millisecond).
+		PostMiddleware(func(ctx context.Context, request any) (context.Context, any, bool) {
+			cleanupSteps.record("post", nil)
+			return ctx, request, true
+		})
+	AddCommandHandler[nestedCleanupCommand, string](&nestedCleanupHandler{})
+
+	canceled, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(5*time.Millisecond, cancel)
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		mode  string
+		check func(t *testing.T, err error)
+	}{
+		{name: "success", ctx: context.Background(), check: func(t *testing.T, err error) { assert.NoError(t, err) }},
+		{name: "error", ctx: context.Background(), mode: "error", check: func(t *testing.T, err error) { assert.EqualError(t, err, "handler failed") }},
+		{name: "timeout", ctx: context.Background(), mode: "wait", check: func(t *testing.T, err error) { assert.ErrorIs(t, err, context.DeadlineExceeded) }},
+		{name: "caller cancellation", ctx: canceled, mode: "wait", check: func(t *testing.T, err error) { assert.ErrorIs(t, err, context.Canceled) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reported = nil
+			_, err := SendCommand[string](test.ctx, cleanupCommand{Mode: test.mode})
+			test.check(t, err)
+
+			steps, errs := cleanupSteps.reset()
+			assert.Equal(t, []string{"post", "second", "first"}, steps)
+			assert.Equal(t, []error{nil, err, err}, errs)
+			assert.Len(t, reported, 1, "the panicking hook should be reported")
+		})
+	}
+
+	t.Run("panic", func(t *testing.T) {
+		assert.PanicsWithValue(t, "handler panicked", func() {
+			_, _ = SendCommand[string](context.Background(), cleanupCommand{Mode: "panic"})
+		})
+		steps, errs := cleanupSteps.reset()
+		assert.Equal(t, []string{"second", "first"}, steps)
+		assert.EqualError(t, errs[0], "dispatch panicked: handler panicked")
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		_, err := SendCommand[string](context.Background(), cleanupCommand{Mode: "nested"})
+		assert.NoError(t, err)
+		steps, _ := cleanupSteps.reset()
+		assert.Equal(t, []string{"nested", "post", "second", "first"}, steps, "the nested dispatch should run its own hooks only")
+	})
+}
+
+// TestOnDispatchEnd_OutsideDispatch tests that registering a hook outside a dispatch is a programmer error.
+func TestOnDispatchEnd_OutsideDispatch(t *testing.T) {
+	assert.Panics(t, func() { OnDispatchEnd(context.Background(), func(ctx context.Context, err error) {}) })
+}
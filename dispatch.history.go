@@ -0,0 +1,247 @@
+package gocqrs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// DispatchRecord describes a command or query dispatch kept by the dispatch history.
+	DispatchRecord struct {
+		RequestType string
+		Handler     string
+		Outcome     string        // As returned by DispatchOutcome.
+		Duration    time.Duration // Duration of the dispatch, middlewares included.
+		Request     string        // The request as formatted by SafeString.
+		Time        time.Time     // When the dispatch started.
+	}
+
+	// DispatchFilter selects the records returned by RecentDispatches.
+	DispatchFilter func(record DispatchRecord) bool
+
+	// dispatchHistory is a lock-free ring of the most recent dispatches.
+	dispatchHistory struct {
+		next  atomic.Uint64
+		slots []atomic.Pointer[historySlot]
+	}
+
+	// historySlot is a record along with its position in the history, to restore the dispatch order.
+	historySlot struct {
+		seq    uint64
+		record DispatchRecord
+	}
+)
+
+const (
+	// maxSafeStringLength is the length SafeString truncates the formatted requests to.
+	maxSafeStringLength = 256
+	// redactedValue replaces the fields tagged `gocqrs:"redact"` in SafeString.
+	redactedValue = "[REDACTED]"
+)
+
+// history is the dispatch history, nil while disabled.
+var history atomic.Pointer[dispatchHistory]
+
+// EnableDispatchHistory keeps the last size command and query dispatches in memory, for RecentDispatches and
+// SystemInfo. Enabling it again discards the kept dispatches; a size of zero disables it.
+// Recording a dispatch is lock-free, and costs a single atomic load while the history is disabled.
+func EnableDispatchHistory(size int) {
+	if size <= 0 {
+		history.Store(nil)
+		return
+	}
+	history.Store(&dispatchHistory{slots: make([]atomic.Pointer[historySlot], size)})
+}
+
+// RecentDispatches returns the kept dispatches accepted by every filter, most recent first.
+// It returns nil while the dispatch history is disabled.
+func RecentDispatches(filters ...DispatchFilter) []DispatchRecord {
+	h := history.Load()
+	if h == nil {
+		return nil
+	}
+
+	slots := make([]*historySlot, 0, len(h.slots))
+	for i := range h.slots {
+		if slot := h.slots[i].Load(); slot != nil {
+			slots = append(slots, slot)
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].seq > slots[j].seq
+	})
+
+	records := make([]DispatchRecord, 0, len(slots))
+next:
+	for _, slot := range slots {
+		for _, filter := range filters {
+			if !filter(slot.record) {
+				continue next
+			}
+		}
+		records = append(records, slot.record)
+	}
+	return records
+}
+
+// DispatchesOfType selects the dispatches of a request type, by type name.
+func DispatchesOfType(requestType string) DispatchFilter {
+	return func(record DispatchRecord) bool {
+		return record.RequestType == requestType
+	}
+}
+
+// DispatchesWithOutcome selects the dispatches with an outcome returned by DispatchOutcome, e.g. OutcomeError.
+func DispatchesWithOutcome(outcome string) DispatchFilter {
+	return func(record DispatchRecord) bool {
+		return record.Outcome == outcome
+	}
+}
+
+// DispatchesSince selects the dispatches started at or after t.
+func DispatchesSince(t time.Time) DispatchFilter {
+	return func(record DispatchRecord) bool {
+		return !record.Time.Before(t)
+	}
+}
+
+// recordHistory adds a dispatch to the dispatch history, if enabled.
+func recordHistory(requestType, handlerName string, request any, start time.Time, duration time.Duration, err error) {
+	h := history.Load()
+	if h == nil {
+		return
+	}
+	seq := h.next.Add(1) - 1
+	h.slots[seq%uint64(len(h.slots))].Store(&historySlot{
+		seq: seq,
+		record: DispatchRecord{
+			RequestType: requestType,
+			Handler:     handlerName,
+			Outcome:     DispatchOutcome(err),
+			Duration:    duration,
+			Request:     SafeString(request),
+			Time:        start,
+		},
+	})
+}
+
+// SafeString formats a request like %+v for logs and debugging, replacing the value of the struct fields
+// tagged `gocqrs:"redact"` with "[REDACTED]" wherever the structs are nested, in pointers, interfaces, slices,
+// arrays and maps included, and truncating the result to 256 bytes. A pointer cycle is printed as "<cycle>".
+func SafeString(request any) string {
+	w := &safeWriter{visiting: make(map[uintptr]bool)}
+	w.write(reflect.ValueOf(request))
+	s := w.b.String()
+	if len(s) > maxSafeStringLength {
+		s = s[:maxSafeStringLength] + "..."
+	}
+	return s
+}
+
+// safeWriter formats values for SafeString.
+type safeWriter struct {
+	b        strings.Builder
+	visiting map[uintptr]bool // Pointers and maps being formatted, to detect cycles.
+}
+
+// write formats v, redacting the tagged fields of the structs it contains.
+func (w *safeWriter) write(v reflect.Value) {
+	// The result is truncated anyway, so stop formatting once it is long enough.
+	if w.b.Len() > maxSafeStringLength {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		w.b.WriteString("<nil>")
+	case reflect.Interface:
+		if v.IsNil() {
+			w.b.WriteString("<nil>")
+			return
+		}
+		w.write(v.Elem())
+	case reflect.Pointer:
+		switch v.Elem().Kind() {
+		case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+			w.visit(v.Pointer(), func() {
+				w.b.WriteByte('&')
+				w.write(v.Elem())
+			})
+		default:
+			fmt.Fprintf(&w.b, "%+v", v)
+		}
+	case reflect.Struct:
+		w.b.WriteByte('{')
+		for i := 0; i < v.NumField(); i++ {
+			if i > 0 {
+				w.b.WriteByte(' ')
+			}
+			field := v.Type().Field(i)
+			w.b.WriteString(field.Name)
+			w.b.WriteByte(':')
+			if field.Tag.Get("gocqrs") == "redact" {
+				w.b.WriteString(redactedValue)
+				continue
+			}
+			w.write(v.Field(i))
+		}
+		w.b.WriteByte('}')
+	case reflect.Slice, reflect.Array:
+		w.b.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				w.b.WriteByte(' ')
+			}
+			w.write(v.Index(i))
+		}
+		w.b.WriteByte(']')
+	case reflect.Map:
+		if v.IsNil() {
+			w.b.WriteString("map[]")
+			return
+		}
+		w.visit(v.Pointer(), func() { w.writeMap(v) })
+	default:
+		fmt.Fprintf(&w.b, "%+v", v)
+	}
+}
+
+// writeMap formats the entries of a map sorted by their formatted key, like fmt.
+func (w *safeWriter) writeMap(v reflect.Value) {
+	type entry struct{ key, value string }
+	entries := make([]entry, 0, v.Len())
+	for iter := v.MapRange(); iter.Next(); {
+		key := &safeWriter{visiting: w.visiting}
+		key.write(iter.Key())
+		value := &safeWriter{visiting: w.visiting}
+		value.write(iter.Value())
+		entries = append(entries, entry{key: key.b.String(), value: value.b.String()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	w.b.WriteString("map[")
+	for i, e := range entries {
+		if i > 0 {
+			w.b.WriteByte(' ')
+		}
+		w.b.WriteString(e.key)
+		w.b.WriteByte(':')
+		w.b.WriteString(e.value)
+	}
+	w.b.WriteByte(']')
+}
+
+// visit calls format unless the value at ptr is already being formatted, which means it contains itself.
+func (w *safeWriter) visit(ptr uintptr, format func()) {
+	if w.visiting[ptr] {
+		w.b.WriteString("<cycle>")
+		return
+	}
+	w.visiting[ptr] = true
+	defer delete(w.visiting, ptr)
+	format()
+}
@@ -0,0 +1,116 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	payByCardCommand struct {
+		Customer string
+		Card     cardDetails
+	}
+	cardDetails struct {
+		Number string `gocqrs:"redact"`
+		Expiry string
+	}
+	lookupBalanceQuery struct {
+		Account int
+	}
+
+	payByCardCommandHandler   struct{}
+	lookupBalanceQueryHandler struct{}
+)
+
+func (h *payByCardCommandHandler) Handle(ctx context.Context, command payByCardCommand) (bool, error) {
+	if command.Customer == "" {
+		return false, errors.New("missing customer")
+	}
+	return true, nil
+}
+
+func (h *lookupBalanceQueryHandler) Handle(ctx context.Context, query lookupBalanceQuery) (int, error) {
+	return 100, nil
+}
+
+// TestRecentDispatches tests that the dispatch history wraps around, filters and redacts the tagged fields.
+func TestRecentDispatches(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+	EnableDispatchHistory(4)
+	defer EnableDispatchHistory(0)
+
+	AddCommandHandler[payByCardCommand, bool](&payByCardCommandHandler{})
+	AddQueryHandler[lookupBalanceQuery, int](&lookupBalanceQueryHandler{})
+
+	for account := 1; account <= 3; account++ {
+		_, _ = SendQuery[int](context.Background(), lookupBalanceQuery{Account: account})
+	}
+	clock.Advance(time.Minute)
+	since := clock.Now()
+	_, _ = SendCommand[bool](context.Background(), payByCardCommand{Customer: "alice", Card: cardDetails{Number: "4111", Expiry: "12/30"}})
+	_, _ = SendCommand[bool](context.Background(), payByCardCommand{})
+
+	// The oldest dispatch was overwritten.
+	records := RecentDispatches()
+	if assert.Len(t, records, 4) {
+		assert.Equal(t, "{Customer: Card:{Number:[REDACTED] Expiry:}}", records[0].Request)
+		assert.Equal(t, OutcomeError, records[0].Outcome)
+		assert.Equal(t, "{Customer:alice Card:{Number:[REDACTED] Expiry:12/30}}", records[1].Request)
+		assert.Equal(t, "*gocqrs.payByCardCommandHandler", records[1].Handler)
+		assert.Equal(t, "{Account:3}", records[2].Request)
+		assert.Equal(t, "{Account:2}", records[3].Request)
+	}
+
+	assert.Len(t, RecentDispatches(DispatchesOfType("gocqrs.lookupBalanceQuery")), 2)
+	assert.Len(t, RecentDispatches(DispatchesOfType("gocqrs.payByCardCommand"), DispatchesWithOutcome(OutcomeOK)), 1)
+	assert.Len(t, RecentDispatches(DispatchesSince(since)), 2)
+
+	EnableDispatchHistory(0)
+	assert.Nil(t, RecentDispatches())
+}
+
+// TestSafeString tests that long requests are truncated.
+func TestSafeString(t *testing.T) {
+	long := SafeString(payByCardCommand{Customer: string(make([]byte, 300))})
+	assert.Len(t, long, maxSafeStringLength+len("..."))
+	assert.Equal(t, "&{Account:1}", SafeString(&lookupBalanceQuery{Account: 1}))
+	assert.Equal(t, "<nil>", SafeString(nil))
+}
+
+type (
+	importCardsCommand struct {
+		Cards   []cardDetails
+		Primary any
+		ByOwner map[string]cardDetails
+		Backup  [1]*cardDetails
+	}
+	cardNode struct {
+		Card cardDetails
+		Next *cardNode
+	}
+)
+
+// TestSafeString_Nested tests that the tagged fields are redacted inside slices, arrays, maps and interfaces,
+// and that pointer cycles are cut.
+func TestSafeString_Nested(t *testing.T) {
+	command := importCardsCommand{
+		Cards:   []cardDetails{{Number: "4111-1", Expiry: "01/30"}},
+		Primary: cardDetails{Number: "4111-2"},
+		ByOwner: map[string]cardDetails{"bob": {Number: "4111-4"}, "ada": {Number: "4111-3"}},
+		Backup:  [1]*cardDetails{{Number: "4111-5"}},
+	}
+	assert.Equal(t, "{Cards:[{Number:[REDACTED] Expiry:01/30}] Primary:{Number:[REDACTED] Expiry:} "+
+		"ByOwner:map[ada:{Number:[REDACTED] Expiry:} bob:{Number:[REDACTED] Expiry:}] Backup:[&{Number:[REDACTED] Expiry:}]}",
+		SafeString(command))
+	assert.Equal(t, "[{Number:[REDACTED] Expiry:}]", SafeString([]cardDetails{{Number: "4111-6"}}))
+
+	node := &cardNode{Card: cardDetails{Number: "4111-7"}}
+	node.Next = node
+	assert.Equal(t, "&{Card:{Number:[REDACTED] Expiry:} Next:<cycle>}", SafeString(node))
+}
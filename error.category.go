@@ -0,0 +1,170 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrorCategory classifies errors so resilience features can decide how to react to them
+// without each one needing its own predicate.
+type ErrorCategory int
+
+const (
+	// CategoryUnknown is used for errors that cannot be classified.
+	CategoryUnknown ErrorCategory = iota
+	// CategoryTransient errors are expected to succeed when retried.
+	CategoryTransient
+	// CategoryPermanent errors fail again whenever they are retried.
+	CategoryPermanent
+	// CategoryResourceExhausted errors signal a saturated resource; retrying later may succeed.
+	CategoryResourceExhausted
+	// CategoryUnauthorized errors signal missing or insufficient credentials.
+	CategoryUnauthorized
+	// CategoryCanceled errors signal that the caller gave up.
+	CategoryCanceled
+	// CategoryTimeout errors signal that a deadline was exceeded.
+	CategoryTimeout
+)
+
+type (
+	// Categorized is implemented by domain errors that know their own category.
+	Categorized interface {
+		Category() ErrorCategory
+	}
+
+	// RetryAfterHint is implemented by errors telling how long to wait before retrying, such as the
+	// *LimitExceededError of a dispatch rejected by an adaptive concurrency limit or the *OverloadedError of a
+	// request shed by LoadShedMiddleware.
+	RetryAfterHint interface {
+		RetryAfter() time.Duration
+	}
+
+	// Categorizer classifies errors into categories.
+	Categorizer interface {
+		Categorize(err error) ErrorCategory
+	}
+
+	// defaultCategorizer recognizes Categorized errors, context errors and the package's own sentinels.
+	// The state loading errors wrapped by ErrStateLoad are transient, unless their cause is Categorized or a context error.
+	defaultCategorizer struct{}
+)
+
+var (
+	categorizerMutex sync.RWMutex
+	categorizer      Categorizer = defaultCategorizer{}
+)
+
+// String returns the name of the category.
+func (category ErrorCategory) String() string {
+	switch category {
+	case CategoryTransient:
+		return "transient"
+	case CategoryPermanent:
+		return "permanent"
+	case CategoryResourceExhausted:
+		return "resource_exhausted"
+	case CategoryUnauthorized:
+		return "unauthorized"
+	case CategoryCanceled:
+		return "canceled"
+	case CategoryTimeout:
+		return "timeout"
+	default:
+		return "unknown"
+	}
+}
+
+// Retryable reports whether errors of the category are worth retrying: transient and resource exhausted ones.
+func (category ErrorCategory) Retryable() bool {
+	return category == CategoryTransient || category == CategoryResourceExhausted
+}
+
+// RetryAfter returns the delay hinted by the first RetryAfterHint in the chain of err, e.g. for a transport adapter
+// to set a Retry-After header. It returns false if err carries no hint.
+func RetryAfter(err error) (time.Duration, bool) {
+	var hint RetryAfterHint
+	if !errors.As(err, &hint) {
+		return 0, false
+	}
+	return hint.RetryAfter(), true
+}
+
+// SetCategorizer replaces the Categorizer used by Categorize. Passing nil restores the default one.
+func SetCategorizer(c Categorizer) {
+	categorizerMutex.Lock()
+	defer categorizerMutex.Unlock()
+	if c == nil {
+		c = defaultCategorizer{}
+	}
+	categorizer = c
+}
+
+// Categorize classifies err with the configured Categorizer. A nil error is CategoryUnknown.
+func Categorize(err error) ErrorCategory {
+	if err == nil {
+		return CategoryUnknown
+	}
+	categorizerMutex.RLock()
+	c := categorizer
+	categorizerMutex.RUnlock()
+	return c.Categorize(err)
+}
+
+// DefaultCategorizer returns the Categorizer used when none is configured.
+// Custom categorizers can delegate to it for the errors they do not recognize.
+func DefaultCategorizer() Categorizer {
+	return defaultCategorizer{}
+}
+
+// Categorize implements Categorizer.
+func (defaultCategorizer) Categorize(err error) ErrorCategory {
+	// Errors declaring their own category take precedence, wherever they are in the chain.
+	var categorized Categorized
+	if errors.As(err, &categorized) {
+		return categorized.Category()
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return CategoryCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return CategoryTimeout
+	case errors.Is(err, ErrCallerCanceled), errors.Is(err, ErrPublishCanceled):
+		return CategoryCanceled
+	case errors.Is(err, ErrHandlerTimeout):
+		return CategoryTimeout
+	case errors.Is(err, ErrLimitExceeded),
+		errors.Is(err, ErrOverloaded),
+		errors.Is(err, ErrMailboxFull),
+		errors.Is(err, ErrEventBufferFull):
+		return CategoryResourceExhausted
+	case errors.Is(err, ErrQuiesced), errors.Is(err, ErrStateLoad):
+		return CategoryTransient
+	case errors.Is(err, ErrExecutorClosed),
+		errors.Is(err, ErrMailboxClosed),
+		errors.Is(err, ErrDeliveryDropped),
+		errors.Is(err, ErrDuplicateMiddleware),
+		errors.Is(err, ErrUnknownHandler),
+		errors.Is(err, ErrHandlerNotInitialized),
+		errors.Is(err, ErrUnknownMiddleware),
+		errors.Is(err, ErrUnknownRequestType),
+		errors.Is(err, ErrWriterRequired),
+		errors.Is(err, ErrDeliveryExpectation),
+		errors.Is(err, ErrMissingContextValue),
+		errors.Is(err, ErrMiddlewareTypeMismatch),
+		errors.Is(err, ErrDependencyCycle),
+		errors.Is(err, ErrMissingCompensation),
+		errors.Is(err, ErrUnresolvedDependency),
+		errors.Is(err, ErrRequestTooLarge),
+		errors.Is(err, ErrInvalidResponse),
+		errors.Is(err, ErrInvalidState):
+		return CategoryPermanent
+	case errors.Is(err, ErrDuplicateEvent), errors.Is(err, ErrStopPropagation):
+		// Markers rather than failures: retrying them, or counting them as failures, makes no sense.
+		return CategoryPermanent
+	default:
+		return CategoryUnknown
+	}
+}
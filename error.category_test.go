@@ -0,0 +1,130 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// throttledError is a domain error declaring its own category.
+type throttledError struct{}
+
+func (throttledError) Error() string { return "throttled" }
+
+func (throttledError) Category() ErrorCategory { return CategoryResourceExhausted }
+
+// TestDefaultCategorizer tests the classification of known errors.
+func TestDefaultCategorizer(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected ErrorCategory
+	}{
+		{nil, CategoryUnknown},
+		{errors.New("boom"), CategoryUnknown},
+		{context.Canceled, CategoryCanceled},
+		{fmt.Errorf("calling: %w", context.DeadlineExceeded), CategoryTimeout},
+		{fmt.Errorf("%w: x", ErrUnknownRequestType), CategoryPermanent},
+		{ErrExecutorClosed, CategoryPermanent},
+		{fmt.Errorf("wrapped: %w", throttledError{}), CategoryResourceExhausted},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Categorize(test.err), "Categorize(%v)", test.err)
+	}
+}
+
+// TestDefaultCategorizer_Sentinels tests the category of every sentinel error of the package.
+func TestDefaultCategorizer_Sentinels(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected ErrorCategory
+	}{
+		{ErrExecutorClosed, CategoryPermanent},
+		{ErrDuplicateMiddleware, CategoryPermanent},
+		{ErrUnknownHandler, CategoryPermanent},
+		{ErrHandlerNotInitialized, CategoryPermanent},
+		{ErrUnknownMiddleware, CategoryPermanent},
+		{ErrUnknownRequestType, CategoryPermanent},
+		{ErrStopPropagation, CategoryPermanent},
+		{ErrWriterRequired, CategoryPermanent},
+		{ErrMailboxFull, CategoryResourceExhausted},
+		{ErrMailboxClosed, CategoryPermanent},
+		{ErrDuplicateEvent, CategoryPermanent},
+		{ErrDeliveryDropped, CategoryPermanent},
+		{ErrEventBufferFull, CategoryResourceExhausted},
+		{ErrDeliveryExpectation, CategoryPermanent},
+		{ErrMissingContextValue, CategoryPermanent},
+		{ErrMiddlewareTypeMismatch, CategoryPermanent},
+		{ErrDependencyCycle, CategoryPermanent},
+		{ErrMissingCompensation, CategoryPermanent},
+		{ErrQuiesced, CategoryTransient},
+		{ErrLimitExceeded, CategoryResourceExhausted},
+		{ErrCallerCanceled, CategoryCanceled},
+		{ErrHandlerTimeout, CategoryTimeout},
+		{ErrPublishCanceled, CategoryCanceled},
+		{ErrRequestTooLarge, CategoryPermanent},
+		{ErrInvalidResponse, CategoryPermanent},
+		{ErrInvalidState, CategoryPermanent},
+		{ErrStateLoad, CategoryTransient},
+		{ErrOverloaded, CategoryResourceExhausted},
+		{ErrUnresolvedDependency, CategoryPermanent},
+	}
+
+	// Every sentinel declared in errors.go is in the table.
+	file, err := parser.ParseFile(token.NewFileSet(), "errors.go", nil, 0)
+	assert.NoError(t, err)
+	sentinels := 0
+	for _, object := range file.Scope.Objects {
+		if object.Kind == ast.Var && strings.HasPrefix(object.Name, "Err") {
+			sentinels++
+		}
+	}
+	assert.Len(t, tests, sentinels)
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Categorize(test.err), "Categorize(%v)", test.err)
+		assert.Equal(t, test.expected, Categorize(fmt.Errorf("wrapped: %w", test.err)), "Categorize(wrapped %v)", test.err)
+	}
+
+	// Wrapped context errors take precedence over the sentinel wrapping them.
+	assert.Equal(t, CategoryTimeout, Categorize(fmt.Errorf("%w: %w", ErrCallerCanceled, context.DeadlineExceeded)))
+	assert.Equal(t, CategoryCanceled, Categorize(fmt.Errorf("%w: %w", ErrStateLoad, context.Canceled)))
+}
+
+// TestErrorCategory_Retryable tests which categories are retried by default.
+func TestErrorCategory_Retryable(t *testing.T) {
+	assert.True(t, CategoryTransient.Retryable())
+	assert.True(t, CategoryResourceExhausted.Retryable())
+	assert.False(t, CategoryPermanent.Retryable())
+	assert.False(t, CategoryUnauthorized.Retryable())
+	assert.False(t, CategoryCanceled.Retryable())
+	assert.False(t, CategoryUnknown.Retryable())
+	assert.Equal(t, "resource_exhausted", CategoryResourceExhausted.String())
+}
+
+// categorizerFunc adapts a function to the Categorizer interface.
+type categorizerFunc func(err error) ErrorCategory
+
+func (f categorizerFunc) Categorize(err error) ErrorCategory { return f(err) }
+
+// TestSetCategorizer tests replacing the categorizer and delegating to the default one.
+func TestSetCategorizer(t *testing.T) {
+	errFlaky := errors.New("flaky")
+	SetCategorizer(categorizerFunc(func(err error) ErrorCategory {
+		if errors.Is(err, errFlaky) {
+			return CategoryTransient
+		}
+		return DefaultCategorizer().Categorize(err)
+	}))
+	defer SetCategorizer(nil)
+
+	assert.Equal(t, CategoryTransient, Categorize(errFlaky))
+	assert.Equal(t, CategoryCanceled, Categorize(context.Canceled))
+}
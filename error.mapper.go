@@ -0,0 +1,73 @@
+package gocqrs
+
+import (
+	"errors"
+	"sync"
+)
+
+type (
+	// ErrorMapper translates handler errors into transport status codes (HTTP, gRPC, ...).
+	// Mappings are evaluated in registration order and the first matching one wins.
+	ErrorMapper struct {
+		mutex    sync.RWMutex
+		mappings []errorMapping
+	}
+
+	// errorMapping associates an error matcher with a status code.
+	errorMapping struct {
+		matcher func(error) bool
+		code    int
+	}
+)
+
+// defaultErrorMapper is the mapping table shared through RegisterErrorMapping and MapError.
+var defaultErrorMapper = NewErrorMapper()
+
+// NewErrorMapper creates an empty ErrorMapper.
+func NewErrorMapper() *ErrorMapper {
+	return &ErrorMapper{}
+}
+
+// Register adds a mapping from the errors accepted by matcher to code.
+func (errorMapper *ErrorMapper) Register(matcher func(error) bool, code int) {
+	errorMapper.mutex.Lock()
+	defer errorMapper.mutex.Unlock()
+	errorMapper.mappings = append(errorMapper.mappings, errorMapping{matcher: matcher, code: code})
+}
+
+// Map returns the code of the first mapping matching err.
+// It returns false when err is nil or no mapping matches.
+func (errorMapper *ErrorMapper) Map(err error) (int, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	// The matchers run without the lock, so one registering a mapping does not deadlock.
+	// Register only appends, so the mappings read here are never modified.
+	errorMapper.mutex.RLock()
+	mappings := errorMapper.mappings
+	errorMapper.mutex.RUnlock()
+	for _, mapping := range mappings {
+		if mapping.matcher(err) {
+			return mapping.code, true
+		}
+	}
+	return 0, false
+}
+
+// MatchError returns a matcher accepting any error that wraps target, as reported by errors.Is.
+func MatchError(target error) func(error) bool {
+	return func(err error) bool {
+		return errors.Is(err, target)
+	}
+}
+
+// RegisterErrorMapping adds a mapping to the shared mapping table used by MapError.
+func RegisterErrorMapping(matcher func(error) bool, code int) {
+	defaultErrorMapper.Register(matcher, code)
+}
+
+// MapError resolves err to a code using the shared mapping table.
+func MapError(err error) (code int, ok bool) {
+	return defaultErrorMapper.Map(err)
+}
@@ -0,0 +1,85 @@
+package gocqrs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errMapperNotFound   = errors.New("not found")
+	errMapperValidation = errors.New("validation failed")
+)
+
+// TestErrorMapper_Map tests resolving errors, including wrapped ones, to their codes.
+func TestErrorMapper_Map(t *testing.T) {
+	mapper := NewErrorMapper()
+	mapper.Register(MatchError(errMapperNotFound), 404)
+	mapper.Register(MatchError(errMapperValidation), 400)
+
+	code, ok := mapper.Map(fmt.Errorf("loading order: %w", errMapperNotFound))
+	assert.True(t, ok, "Wrapped error should be mapped")
+	assert.Equal(t, 404, code)
+
+	code, ok = mapper.Map(errMapperValidation)
+	assert.True(t, ok)
+	assert.Equal(t, 400, code)
+
+	_, ok = mapper.Map(errors.New("unexpected"))
+	assert.False(t, ok, "Unknown error should not be mapped")
+
+	_, ok = mapper.Map(nil)
+	assert.False(t, ok, "Nil error should not be mapped")
+}
+
+// TestErrorMapper_FirstMatchWins tests that mappings are evaluated in registration order.
+func TestErrorMapper_FirstMatchWins(t *testing.T) {
+	mapper := NewErrorMapper()
+	mapper.Register(MatchError(errMapperNotFound), 404)
+	mapper.Register(func(err error) bool { return err != nil }, 500)
+
+	code, _ := mapper.Map(errMapperNotFound)
+	assert.Equal(t, 404, code)
+
+	code, _ = mapper.Map(errors.New("boom"))
+	assert.Equal(t, 500, code)
+}
+
+// TestRegisterErrorMapping tests the shared mapping table.
+func TestRegisterErrorMapping(t *testing.T) {
+	errConflict := errors.New("conflict")
+	RegisterErrorMapping(MatchError(errConflict), 409)
+
+	code, ok := MapError(fmt.Errorf("saving: %w", errConflict))
+	assert.True(t, ok)
+	assert.Equal(t, 409, code)
+}
+
+// TestErrorMapper_MatcherRegisters tests that a matcher registering a mapping does not deadlock Map.
+func TestErrorMapper_MatcherRegisters(t *testing.T) {
+	mapper := NewErrorMapper()
+	mapper.Register(func(err error) bool {
+		// E.g. a matcher lazily registering the mappings of a module on first use.
+		mapper.Register(MatchError(errMapperValidation), 422)
+		return false
+	}, 500)
+
+	mapped := make(chan int, 1)
+	go func() {
+		code, _ := mapper.Map(errMapperValidation)
+		mapped <- code
+	}()
+	select {
+	case code := <-mapped:
+		assert.Equal(t, 0, code, "the mapping registered during Map applies from the next call")
+	case <-time.After(time.Second):
+		t.Fatal("Map deadlocked")
+	}
+
+	code, ok := mapper.Map(errMapperValidation)
+	assert.True(t, ok)
+	assert.Equal(t, 422, code)
+}
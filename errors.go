@@ -0,0 +1,104 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+var (
+	// ErrExecutorClosed is returned when a dispatch is routed to a dedicated executor that has been shut down.
+	ErrExecutorClosed = errors.New("dedicated executor is closed")
+	// ErrDuplicateMiddleware is returned when the duplicate middleware policy rejects a middleware.
+	ErrDuplicateMiddleware = errors.New("middleware is already registered")
+	// ErrUnknownHandler is returned when a handler name does not match any registered handler,
+	// and for an event published without handler where breaking an invariant would come too late, e.g. an outcome event.
+	ErrUnknownHandler = errors.New("unknown handler")
+	// ErrHandlerNotInitialized is returned when a handler is invoked without a valid Handle method.
+	ErrHandlerNotInitialized = errors.New("handler Handle method is not initialized")
+	// ErrUnknownMiddleware is returned when a middleware name does not match any named middleware.
+	ErrUnknownMiddleware = errors.New("unknown middleware")
+	// ErrUnknownRequestType is returned when a request type name does not match any registered handler.
+	ErrUnknownRequestType = errors.New("unknown request type")
+	// ErrStopPropagation is returned by an event handler to signal that it fully consumed the event.
+	// PublishEvent does not invoke the remaining handlers and does not treat it as a failure.
+	ErrStopPropagation = errors.New("stop event propagation")
+	// ErrWriterRequired is returned when a writer command handler is dispatched without SendToWriter.
+	ErrWriterRequired = errors.New("writer command must be sent with SendToWriter")
+	// ErrMailboxFull is returned when an event is delivered to a full mailbox with the OverflowError policy.
+	ErrMailboxFull = errors.New("event handler mailbox is full")
+	// ErrMailboxClosed is returned when an event is delivered to a mailbox that has been shut down.
+	ErrMailboxClosed = errors.New("event handler mailbox is closed")
+	// ErrDuplicateEvent is the result given by PublishEvents to an event skipped by SetEventDeduplication.
+	// It marks an event already handled rather than a failure.
+	ErrDuplicateEvent = errors.New("duplicate event")
+	// ErrDeliveryDropped is reported for each event left in the mailbox of an event handler removed with RemoveEventHandler.
+	ErrDeliveryDropped = errors.New("event delivery dropped")
+	// ErrEventBufferFull is returned when an event is published while paused and the buffer of PauseEvents is full.
+	ErrEventBufferFull = errors.New("paused event buffer is full")
+	// ErrDeliveryExpectation is returned when the delivery guarantee expected by an event handler is not met.
+	ErrDeliveryExpectation = errors.New("delivery expectation not met")
+	// ErrMissingContextValue is returned when the context lacks a value required by the handler.
+	ErrMissingContextValue = errors.New("missing context value")
+	// ErrMiddlewareTypeMismatch is returned when middleware type checking catches a middleware changing the request type.
+	ErrMiddlewareTypeMismatch = errors.New("middleware changed the request type")
+	// ErrDependencyCycle is returned when handler dependencies declared with DependsOn are circular.
+	ErrDependencyCycle = errors.New("handler dependency cycle")
+	// ErrMissingCompensation is reported by Verify for a compensable command without a registered compensation.
+	ErrMissingCompensation = errors.New("missing compensation")
+	// ErrQuiesced is returned when a request type quiesced with Quiesce is dispatched.
+	ErrQuiesced = errors.New("request type is quiesced")
+	// ErrLimitExceeded is returned when an adaptive concurrency limit in LimitReject mode is reached.
+	ErrLimitExceeded = errors.New("concurrency limit exceeded")
+	// ErrCallerCanceled wraps the context error of a dispatch whose caller's context was canceled or expired.
+	ErrCallerCanceled = errors.New("caller canceled the dispatch")
+	// ErrHandlerTimeout wraps the context error of a dispatch whose handler exceeded the timeout set with WithTimeout.
+	ErrHandlerTimeout = errors.New("handler timed out")
+	// ErrPublishCanceled wraps the context error of a publish whose context was canceled or expired.
+	ErrPublishCanceled = errors.New("publish canceled")
+	// ErrRequestTooLarge is returned when MaxRequestSizeMiddleware rejects a request exceeding its size limit.
+	ErrRequestTooLarge = errors.New("request is too large")
+	// ErrInvalidResponse wraps the error of a ResponseValidator rejecting the response of a handler.
+	ErrInvalidResponse = errors.New("invalid response")
+	// ErrInvalidState is wrapped by the *InvalidStateError of a command rejected by WithStateGuard.
+	ErrInvalidState = errors.New("invalid state")
+	// ErrStateLoad wraps the error of the StateLoader of a guard added with WithStateGuard.
+	ErrStateLoad = errors.New("loading state")
+	// ErrOverloaded is returned when LoadShedMiddleware sheds a request whose type exceeds its latency objective.
+	ErrOverloaded = errors.New("request type is overloaded")
+	// ErrUnresolvedDependency is returned by AutoRegister for a constructor parameter without exactly one matching binding.
+	ErrUnresolvedDependency = errors.New("unresolved dependency")
+)
+
+// ErrorReporter receives errors that cannot be returned to a caller, such as
+// panics recovered on background goroutines.
+type ErrorReporter func(ctx context.Context, err error)
+
+var (
+	errorReporterMutex sync.RWMutex
+	errorReporter      ErrorReporter = defaultErrorReporter
+)
+
+// SetErrorReporter replaces the function used to report errors that cannot be returned to a caller.
+// Passing nil restores the default reporter, which writes to the standard logger.
+func SetErrorReporter(reporter ErrorReporter) {
+	errorReporterMutex.Lock()
+	defer errorReporterMutex.Unlock()
+	if reporter == nil {
+		reporter = defaultErrorReporter
+	}
+	errorReporter = reporter
+}
+
+// reportError forwards err to the configured ErrorReporter.
+func reportError(ctx context.Context, err error) {
+	errorReporterMutex.RLock()
+	reporter := errorReporter
+	errorReporterMutex.RUnlock()
+	reporter(ctx, err)
+}
+
+func defaultErrorReporter(_ context.Context, err error) {
+	log.Printf("gocqrs: %v", err)
+}
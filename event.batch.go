@@ -0,0 +1,144 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type (
+	// Reversible is implemented by event handlers able to undo the handling of an event.
+	// PublishEvents asks them to compensate the events of an EventGroup that failed.
+	Reversible interface {
+		Compensate(ctx context.Context, event any) error
+	}
+
+	// EventBatchGroup is a set of events PublishEvents handles all-or-nothing. Create it with EventGroup.
+	EventBatchGroup struct {
+		events []any
+	}
+
+	// EventGroupError is returned by PublishEvents for an EventGroup whose handling failed.
+	// It unwraps to the error of the failed event; the failures of the compensations are kept apart.
+	EventGroupError struct {
+		Index              int     // Index of the failed event within its group.
+		Event              any     // Failed event.
+		Err                error   // Errors returned by the handlers of the failed event.
+		CompensationErrors []error // Errors returned by Compensate.
+	}
+
+	// PublishResult is the outcome of an event, or of an EventGroup, passed to PublishEvents.
+	PublishResult struct {
+		Event any   // Published event, or the EventBatchGroup.
+		Err   error // Errors returned by the handlers of the event, or the *EventGroupError of the group; nil on success, ErrDuplicateEvent for a skipped duplicate.
+	}
+
+	// reversibleHandler is implemented by the registered event handler wrappers.
+	reversibleHandler interface {
+		reversible() (Reversible, bool)
+	}
+
+	// handledEvent is an event handled by a handler, as recorded for compensation.
+	handledEvent struct {
+		handler IHandler[T, T]
+		event   any
+	}
+)
+
+// EventGroup groups events handled all-or-nothing when passed to PublishEvents.
+func EventGroup(events ...any) EventBatchGroup {
+	return EventBatchGroup{events: events}
+}
+
+// PublishEvents publishes a batch of events, in order, to their event handlers like PublishEvent.
+// A failing event does not prevent the next ones from being published, except within an EventGroup:
+// the events of a group stop at the first handler error, and every handler that implements Reversible
+// is asked to compensate the events of the group it already handled, in reverse order.
+// This is weaker than a transaction, handlers that are not Reversible keep their effects.
+// The errors of every event and group are returned joined; a failed group contributes an *EventGroupError.
+// The results hold the outcome of every event and group, in order, even when the error is not nil,
+// so a caller such as an outbox can commit the events that succeeded and retry the others.
+// An event without handler fails with ErrUnknownHandler rather than panicking like PublishEvent.
+// An event skipped by SetEventDeduplication has ErrDuplicateEvent as result and is not an error of the batch.
+func PublishEvents(ctx context.Context, events ...any) ([]PublishResult, error) {
+	results := make([]PublishResult, 0, len(events))
+	var batchErrors []error
+	for _, event := range events {
+		var err error
+		if group, ok := event.(EventBatchGroup); ok {
+			err = publishGroup(ctx, group)
+		} else {
+			err = errors.Join(publishBatchEvent(ctx, event, publishConfig{})...)
+		}
+		if err != nil && !IsDuplicate(err) {
+			batchErrors = append(batchErrors, err)
+		}
+		results = append(results, PublishResult{Event: event, Err: err})
+	}
+	return results, classifyPublishCancellation(ctx, errors.Join(batchErrors...))
+}
+
+// publishGroup publishes the events of a group until one fails, then compensates the handled events.
+func publishGroup(ctx context.Context, group EventBatchGroup) error {
+	var handled []handledEvent
+	for i, event := range group.events {
+		eventErrors := publishBatchEvent(ctx, event, publishConfig{
+			failFast: true,
+			handled: func(handler IHandler[T, T]) {
+				handled = append(handled, handledEvent{handler: handler, event: event})
+			},
+		})
+		if len(eventErrors) > 0 && !isDuplicatePublish(eventErrors) {
+			return &EventGroupError{
+				Index:              i,
+				Event:              event,
+				Err:                errors.Join(eventErrors...),
+				CompensationErrors: compensateEvents(context.WithoutCancel(ctx), handled),
+			}
+		}
+	}
+	return nil
+}
+
+// publishBatchEvent publishes an event of a batch. Unlike PublishEvent, an event without handler does not panic,
+// which would abandon the results and the compensations of the batch, but fails with ErrUnknownHandler.
+func publishBatchEvent(ctx context.Context, event any, config publishConfig) []error {
+	handlerErrors, ok := publish(ctx, event, config)
+	if !ok {
+		return []error{errNoEventHandler(event)}
+	}
+	return handlerErrors
+}
+
+// compensateEvents asks the Reversible handlers to compensate the handled events in reverse order.
+func compensateEvents(ctx context.Context, handled []handledEvent) []error {
+	var compensationErrors []error
+	for i := len(handled) - 1; i >= 0; i-- {
+		wrapper, ok := handled[i].handler.(reversibleHandler)
+		if !ok {
+			continue
+		}
+		reversible, ok := wrapper.reversible()
+		if !ok {
+			continue
+		}
+		if err := reversible.Compensate(ctx, handled[i].event); err != nil {
+			compensationErrors = append(compensationErrors, fmt.Errorf("compensating %T: %w", handled[i].event, err))
+		}
+	}
+	return compensationErrors
+}
+
+// Error describes the failed event and how many of the compensations failed.
+func (e *EventGroupError) Error() string {
+	msg := fmt.Sprintf("event %d (%T) of group failed: %v", e.Index, e.Event, e.Err)
+	if len(e.CompensationErrors) > 0 {
+		msg += fmt.Sprintf("; %d compensation(s) failed: %v", len(e.CompensationErrors), errors.Join(e.CompensationErrors...))
+	}
+	return msg
+}
+
+// Unwrap returns the error of the failed event.
+func (e *EventGroupError) Unwrap() error {
+	return e.Err
+}
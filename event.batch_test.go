@@ -0,0 +1,181 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	projectedOrderPlaced  struct{ ID int }
+	projectedOrderPaid    struct{ ID int }
+	projectedOrderShipped struct{ ID int }
+	unrelatedBatchEvent   struct{}
+)
+
+// projectionLog records the events handled and compensated by the batch test projections.
+type projectionLog struct {
+	entries []string
+}
+
+// projection is a Reversible event handler, failing the events for which fail returns true.
+type projection[TEvent T] struct {
+	log              *projectionLog
+	fail             func(event TEvent) bool
+	failCompensation bool
+}
+
+func (p *projection[TEvent]) Handle(ctx context.Context, event TEvent) error {
+	if p.fail != nil && p.fail(event) {
+		return fmt.Errorf("projecting %T failed", event)
+	}
+	p.log.entries = append(p.log.entries, fmt.Sprintf("handle %T", event))
+	return nil
+}
+
+func (p *projection[TEvent]) Compensate(ctx context.Context, event any) error {
+	if p.failCompensation {
+		return errors.New("projection is read-only")
+	}
+	p.log.entries = append(p.log.entries, fmt.Sprintf("compensate %T", event))
+	return nil
+}
+
+type unrelatedBatchEventHandler struct {
+	calls int
+}
+
+func (h *unrelatedBatchEventHandler) Handle(ctx context.Context, event unrelatedBatchEvent) error {
+	h.calls++
+	return nil
+}
+
+// TestPublishEvents_GroupCompensation tests that the handled events of a failed group are compensated in reverse order.
+func TestPublishEvents_GroupCompensation(t *testing.T) {
+	log := &projectionLog{}
+	paid := &projection[projectedOrderPaid]{log: log}
+	assert.NoError(t, AddEventHandlers[projectedOrderPlaced](&projection[projectedOrderPlaced]{log: log}))
+	assert.NoError(t, AddEventHandlers[projectedOrderPaid](paid))
+	assert.NoError(t, AddEventHandlers[projectedOrderShipped](&projection[projectedOrderShipped]{
+		log:  log,
+		fail: func(event projectedOrderShipped) bool { return event.ID == 0 },
+	}))
+	unrelated := &unrelatedBatchEventHandler{}
+	assert.NoError(t, AddEventHandlers[unrelatedBatchEvent](unrelated))
+
+	_, err := PublishEvents(context.Background(),
+		EventGroup(projectedOrderPlaced{ID: 0}, projectedOrderPaid{ID: 0}, projectedOrderShipped{ID: 0}),
+		unrelatedBatchEvent{},
+	)
+
+	var groupErr *EventGroupError
+	assert.ErrorAs(t, err, &groupErr)
+	assert.Equal(t, 2, groupErr.Index)
+	assert.Equal(t, projectedOrderShipped{ID: 0}, groupErr.Event)
+	assert.EqualError(t, groupErr.Err, "projecting gocqrs.projectedOrderShipped failed")
+	assert.Empty(t, groupErr.CompensationErrors)
+	assert.EqualError(t, err, "event 2 (gocqrs.projectedOrderShipped) of group failed: projecting gocqrs.projectedOrderShipped failed")
+	assert.Equal(t, []string{
+		"handle gocqrs.projectedOrderPlaced",
+		"handle gocqrs.projectedOrderPaid",
+		"compensate gocqrs.projectedOrderPaid",
+		"compensate gocqrs.projectedOrderPlaced",
+	}, log.entries)
+	assert.Equal(t, 1, unrelated.calls, "events outside the group are published regardless")
+
+	// A failing compensation is reported apart from the event error.
+	log.entries = nil
+	paid.failCompensation = true
+	_, err = PublishEvents(context.Background(),
+		EventGroup(projectedOrderPlaced{ID: 1}, projectedOrderPaid{ID: 1}, projectedOrderShipped{ID: 0}),
+	)
+	assert.ErrorAs(t, err, &groupErr)
+	if assert.Len(t, groupErr.CompensationErrors, 1) {
+		assert.EqualError(t, groupErr.CompensationErrors[0], "compensating gocqrs.projectedOrderPaid: projection is read-only")
+	}
+	assert.Equal(t, "compensate gocqrs.projectedOrderPlaced", log.entries[len(log.entries)-1])
+
+	// Groups that succeed are not compensated.
+	log.entries = nil
+	_, err = PublishEvents(context.Background(), EventGroup(projectedOrderPlaced{ID: 2}, projectedOrderShipped{ID: 2}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"handle gocqrs.projectedOrderPlaced", "handle gocqrs.projectedOrderShipped"}, log.entries)
+}
+
+type outboxDrained struct{ ID int }
+
+type outboxDrainedHandler struct{}
+
+func (h *outboxDrainedHandler) Handle(ctx context.Context, event outboxDrained) error {
+	if event.ID%2 == 1 {
+		return fmt.Errorf("outbox row %d rejected", event.ID)
+	}
+	return nil
+}
+
+// TestPublishEvents_PartialResults tests that the outcome of every event is returned along with the joined failures.
+func TestPublishEvents_PartialResults(t *testing.T) {
+	assert.NoError(t, AddEventHandlers[outboxDrained](&outboxDrainedHandler{}))
+
+	results, err := PublishEvents(context.Background(), outboxDrained{ID: 0}, outboxDrained{ID: 1}, outboxDrained{ID: 2}, outboxDrained{ID: 3})
+	assert.EqualError(t, err, "outbox row 1 rejected\noutbox row 3 rejected")
+	if assert.Len(t, results, 4) {
+		for i, result := range results {
+			assert.Equal(t, outboxDrained{ID: i}, result.Event)
+			if i%2 == 0 {
+				assert.NoError(t, result.Err)
+			} else {
+				assert.EqualError(t, result.Err, fmt.Sprintf("outbox row %d rejected", i))
+			}
+		}
+	}
+
+	results, err = PublishEvents(context.Background(), outboxDrained{ID: 4}, EventGroup(outboxDrained{ID: 6}, outboxDrained{ID: 7}))
+	var groupErr *EventGroupError
+	assert.ErrorAs(t, err, &groupErr)
+	if assert.Len(t, results, 2) {
+		assert.NoError(t, results[0].Err)
+		assert.ErrorAs(t, results[1].Err, &groupErr)
+		assert.Equal(t, 1, groupErr.Index)
+	}
+}
+
+type (
+	invoiceIssued       struct{ ID int }
+	invoiceUnsubscribed struct{ ID int }
+)
+
+// TestPublishEvents_WithoutHandler tests that an event without handler fails with ErrUnknownHandler instead of
+// panicking, keeping the results of the batch and compensating its group.
+func TestPublishEvents_WithoutHandler(t *testing.T) {
+	log := &projectionLog{}
+	assert.NoError(t, AddEventHandlers[invoiceIssued](&projection[invoiceIssued]{log: log}))
+
+	var results []PublishResult
+	var err error
+	assert.NotPanics(t, func() {
+		results, err = PublishEvents(context.Background(),
+			invoiceIssued{ID: 1},
+			invoiceUnsubscribed{ID: 1},
+			EventGroup(invoiceIssued{ID: 2}, invoiceUnsubscribed{ID: 2}),
+		)
+	})
+	assert.ErrorIs(t, err, ErrUnknownHandler)
+	if assert.Len(t, results, 3) {
+		assert.NoError(t, results[0].Err)
+		assert.ErrorIs(t, results[1].Err, ErrUnknownHandler)
+		var groupErr *EventGroupError
+		if assert.ErrorAs(t, results[2].Err, &groupErr) {
+			assert.Equal(t, 1, groupErr.Index)
+			assert.ErrorIs(t, groupErr, ErrUnknownHandler)
+		}
+	}
+	assert.Equal(t, []string{
+		"handle gocqrs.invoiceIssued",
+		"handle gocqrs.invoiceIssued",
+		"compensate gocqrs.invoiceIssued",
+	}, log.entries)
+}
@@ -0,0 +1,132 @@
+package gocqrs
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// eventDeduplicationCapacity bounds the number of event IDs remembered for deduplication.
+const eventDeduplicationCapacity = 10000
+
+type (
+	// eventDeduplicator remembers recently published event IDs in a bounded LRU.
+	eventDeduplicator struct {
+		mutex    sync.Mutex
+		window   time.Duration
+		idFunc   func(event any) string
+		capacity int
+		order    *list.List               // Most recently seen IDs first.
+		entries  map[string]*list.Element // Event ID to its element in order.
+	}
+
+	// seenEvent is an entry of the deduplication LRU.
+	seenEvent struct {
+		id     string
+		seenAt time.Time
+	}
+)
+
+var (
+	eventDeduplicatorMutex sync.RWMutex
+	eventDeduplication     *eventDeduplicator
+)
+
+// SetEventDeduplication makes PublishEvent skip events whose ID, as returned by idFunc, was already
+// delivered within window, turning the at-least-once delivery of a source into effectively-once handling.
+// Skipped events invoke no handler and PublishEvent returns nil; the OnDuplicate option tells them apart, and
+// PublishEvents gives them the ErrDuplicateEvent marker as result.
+// An ID is only remembered once its event was delivered without error: a redelivery of a failed or
+// rate-limited event is handled again. A redelivery arriving while the first delivery is still running is skipped.
+// Events for which idFunc returns an empty string are never deduplicated.
+// At most 10000 IDs are remembered; the least recently seen ones are forgotten first.
+// A zero window or a nil idFunc disables deduplication.
+func SetEventDeduplication(window time.Duration, idFunc func(event any) string) {
+	eventDeduplicatorMutex.Lock()
+	defer eventDeduplicatorMutex.Unlock()
+
+	if window <= 0 || idFunc == nil {
+		eventDeduplication = nil
+		return
+	}
+	eventDeduplication = newEventDeduplicator(window, idFunc, eventDeduplicationCapacity)
+}
+
+// IsDuplicate reports whether err, such as the Err of a PublishResult, is the marker of an event skipped by
+// SetEventDeduplication.
+func IsDuplicate(err error) bool {
+	return errors.Is(err, ErrDuplicateEvent)
+}
+
+// claimEvent records the ID of event as delivered and reports whether it already was within the deduplication window.
+// The returned function forgets the ID again, for a delivery that failed.
+func claimEvent(event any) (forget func(), duplicate bool) {
+	eventDeduplicatorMutex.RLock()
+	deduplicator := eventDeduplication
+	eventDeduplicatorMutex.RUnlock()
+
+	if deduplicator == nil {
+		return func() {}, false
+	}
+	return deduplicator.claim(event, now())
+}
+
+// isDuplicatePublish reports whether the errors of a publish are the marker of a skipped duplicate.
+func isDuplicatePublish(handlerErrors []error) bool {
+	return len(handlerErrors) == 1 && handlerErrors[0] == ErrDuplicateEvent
+}
+
+// newEventDeduplicator creates an empty eventDeduplicator.
+func newEventDeduplicator(window time.Duration, idFunc func(event any) string, capacity int) *eventDeduplicator {
+	return &eventDeduplicator{
+		window:   window,
+		idFunc:   idFunc,
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// claim records the event ID at now and reports whether it was already seen within the window.
+// The returned function removes the record, unless the ID was claimed again since.
+func (deduplicator *eventDeduplicator) claim(event any, now time.Time) (forget func(), duplicate bool) {
+	id := deduplicator.idFunc(event)
+	if id == "" {
+		return func() {}, false
+	}
+
+	deduplicator.mutex.Lock()
+	defer deduplicator.mutex.Unlock()
+
+	if element, ok := deduplicator.entries[id]; ok {
+		if now.Sub(element.Value.(*seenEvent).seenAt) < deduplicator.window {
+			deduplicator.order.MoveToFront(element)
+			return func() {}, true
+		}
+		// The previous occurrence is outside the window: start a new one.
+		deduplicator.order.Remove(element)
+	}
+
+	entry := &seenEvent{id: id, seenAt: now}
+	deduplicator.entries[id] = deduplicator.order.PushFront(entry)
+
+	// Forget the least recently seen IDs beyond capacity.
+	for deduplicator.order.Len() > deduplicator.capacity {
+		oldest := deduplicator.order.Back()
+		deduplicator.order.Remove(oldest)
+		delete(deduplicator.entries, oldest.Value.(*seenEvent).id)
+	}
+	return func() { deduplicator.forget(entry) }, false
+}
+
+// forget removes the record of a claimed event ID, unless it was replaced since.
+func (deduplicator *eventDeduplicator) forget(entry *seenEvent) {
+	deduplicator.mutex.Lock()
+	defer deduplicator.mutex.Unlock()
+
+	if element, ok := deduplicator.entries[entry.id]; ok && element.Value == entry {
+		deduplicator.order.Remove(element)
+		delete(deduplicator.entries, entry.id)
+	}
+}
@@ -0,0 +1,200 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type paymentReceivedEvent struct {
+	ID string
+}
+
+type paymentReceivedEventHandler struct {
+	calls atomic.Int32
+}
+
+func (h *paymentReceivedEventHandler) Handle(ctx context.Context, event paymentReceivedEvent) error {
+	h.calls.Add(1)
+	return nil
+}
+
+// TestSetEventDeduplication tests that an event redelivered within the window is handled once.
+func TestSetEventDeduplication(t *testing.T) {
+	SetEventDeduplication(time.Minute, func(event any) string {
+		if e, ok := event.(paymentReceivedEvent); ok {
+			return e.ID
+		}
+		return ""
+	})
+	defer SetEventDeduplication(0, nil)
+
+	handler := &paymentReceivedEventHandler{}
+	assert.NoError(t, AddEventHandlers[paymentReceivedEvent](handler))
+
+	assert.NoError(t, PublishEvent(context.Background(), paymentReceivedEvent{ID: "p-1"}))
+	var skipped []any
+	onDuplicate := OnDuplicate(func(event any) { skipped = append(skipped, event) })
+	assert.NoError(t, PublishEvent(context.Background(), paymentReceivedEvent{ID: "p-1"}, onDuplicate), "a duplicate is not a failure")
+	assert.Equal(t, []any{paymentReceivedEvent{ID: "p-1"}}, skipped)
+	assert.NoError(t, PublishEvent(context.Background(), paymentReceivedEvent{ID: "p-2"}))
+
+	assert.Equal(t, int32(2), handler.calls.Load(), "Handlers should run once per event ID")
+}
+
+type refundIssuedEvent struct {
+	ID string
+}
+
+// flakyRefundHandler fails the first delivery of every event.
+type flakyRefundHandler struct {
+	calls atomic.Int32
+}
+
+func (h *flakyRefundHandler) Handle(ctx context.Context, event refundIssuedEvent) error {
+	if h.calls.Add(1) == 1 {
+		return errors.New("projection unavailable")
+	}
+	return nil
+}
+
+// TestSetEventDeduplication_RedeliveryAfterFailure tests that an event whose delivery failed is handled again
+// when redelivered within the window, and deduplicated once delivered.
+func TestSetEventDeduplication_RedeliveryAfterFailure(t *testing.T) {
+	SetEventDeduplication(time.Minute, func(event any) string {
+		if e, ok := event.(refundIssuedEvent); ok {
+			return e.ID
+		}
+		return ""
+	})
+	defer SetEventDeduplication(0, nil)
+
+	handler := &flakyRefundHandler{}
+	assert.NoError(t, AddEventHandlers[refundIssuedEvent](handler))
+
+	assert.EqualError(t, PublishEvent(context.Background(), refundIssuedEvent{ID: "r-1"}), "projection unavailable")
+	assert.NoError(t, PublishEvent(context.Background(), refundIssuedEvent{ID: "r-1"}), "the redelivery should be handled")
+	duplicate := false
+	assert.NoError(t, PublishEvent(context.Background(), refundIssuedEvent{ID: "r-1"}, OnDuplicate(func(any) { duplicate = true })))
+	assert.True(t, duplicate)
+	assert.Equal(t, int32(2), handler.calls.Load())
+}
+
+type chargeCapturedEvent struct {
+	ID string
+}
+
+type chargeCapturedEventHandler struct {
+	calls atomic.Int32
+}
+
+func (h *chargeCapturedEventHandler) Handle(ctx context.Context, event chargeCapturedEvent) error {
+	h.calls.Add(1)
+	return nil
+}
+
+// TestSetEventDeduplication_RedeliveryAfterRateLimit tests that an event dropped by the rate limit is not
+// remembered, so its redelivery is handled once the limit admits it.
+func TestSetEventDeduplication_RedeliveryAfterRateLimit(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+	SetEventDeduplication(time.Minute, func(event any) string {
+		if e, ok := event.(chargeCapturedEvent); ok {
+			return e.ID
+		}
+		return ""
+	})
+	defer SetEventDeduplication(0, nil)
+	SetEventRateLimit[chargeCapturedEvent](1)
+	defer SetEventRateLimit[chargeCapturedEvent](0)
+
+	handler := &chargeCapturedEventHandler{}
+	assert.NoError(t, AddEventHandlers[chargeCapturedEvent](handler))
+
+	assert.NoError(t, PublishEvent(context.Background(), chargeCapturedEvent{ID: "c-1"}))
+	assert.NoError(t, PublishEvent(context.Background(), chargeCapturedEvent{ID: "c-2"}), "dropped by the rate limit")
+	assert.Equal(t, int32(1), handler.calls.Load())
+
+	clock.Advance(time.Second)
+	assert.NoError(t, PublishEvent(context.Background(), chargeCapturedEvent{ID: "c-2"}), "the redelivery should be handled")
+	assert.Equal(t, int32(2), handler.calls.Load())
+}
+
+// isClaimed claims the ID of event at now and reports whether it was a duplicate.
+func isClaimed(deduplicator *eventDeduplicator, event any, now time.Time) bool {
+	_, duplicate := deduplicator.claim(event, now)
+	return duplicate
+}
+
+// TestEventDeduplicator_Forget tests that a forgotten ID is accepted again, unless it was claimed again since.
+func TestEventDeduplicator_Forget(t *testing.T) {
+	deduplicator := newEventDeduplicator(time.Second, func(event any) string { return event.(string) }, 10)
+	now := time.Now()
+
+	forget, _ := deduplicator.claim("a", now)
+	forget()
+	assert.False(t, isClaimed(deduplicator, "a", now))
+
+	stale, _ := deduplicator.claim("b", now)
+	assert.False(t, isClaimed(deduplicator, "b", now.Add(2*time.Second)))
+	stale()
+	assert.True(t, isClaimed(deduplicator, "b", now.Add(2*time.Second)), "a stale forget should keep the new claim")
+}
+
+// TestEventDeduplicator_Window tests that IDs are accepted again once the window has elapsed.
+func TestEventDeduplicator_Window(t *testing.T) {
+	deduplicator := newEventDeduplicator(time.Second, func(event any) string { return event.(string) }, 10)
+	now := time.Now()
+
+	assert.False(t, isClaimed(deduplicator, "a", now))
+	assert.True(t, isClaimed(deduplicator, "a", now.Add(500*time.Millisecond)))
+	assert.False(t, isClaimed(deduplicator, "a", now.Add(2*time.Second)), "ID should be accepted after the window")
+	assert.False(t, isClaimed(deduplicator, "", now), "Empty IDs are never deduplicated")
+	assert.False(t, isClaimed(deduplicator, "", now))
+}
+
+// TestEventDeduplicator_Capacity tests that the least recently seen IDs are evicted beyond capacity.
+func TestEventDeduplicator_Capacity(t *testing.T) {
+	deduplicator := newEventDeduplicator(time.Hour, func(event any) string { return event.(string) }, 2)
+	now := time.Now()
+
+	isClaimed(deduplicator, "a", now)
+	isClaimed(deduplicator, "b", now)
+	isClaimed(deduplicator, "c", now)
+
+	assert.Equal(t, 2, deduplicator.order.Len())
+	assert.False(t, isClaimed(deduplicator, "a", now), "Evicted ID should be accepted again")
+	assert.True(t, isClaimed(deduplicator, "c", now))
+}
+
+type receiptIssuedEvent struct {
+	ID string
+}
+
+type receiptIssuedEventHandler struct{}
+
+func (h *receiptIssuedEventHandler) Handle(ctx context.Context, event receiptIssuedEvent) error {
+	return nil
+}
+
+// TestSetEventDeduplication_Batch tests that PublishEvents marks a skipped duplicate in its results only.
+func TestSetEventDeduplication_Batch(t *testing.T) {
+	SetEventDeduplication(time.Minute, func(event any) string {
+		if e, ok := event.(receiptIssuedEvent); ok {
+			return e.ID
+		}
+		return ""
+	})
+	defer SetEventDeduplication(0, nil)
+	assert.NoError(t, AddEventHandlers[receiptIssuedEvent](&receiptIssuedEventHandler{}))
+
+	results, err := PublishEvents(context.Background(), receiptIssuedEvent{ID: "rc-1"}, receiptIssuedEvent{ID: "rc-1"})
+	assert.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+	assert.True(t, IsDuplicate(results[1].Err))
+}
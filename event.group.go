@@ -0,0 +1,207 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// GroupOption configures an event handler group created by AddEventHandlerGroup.
+	GroupOption func(*eventHandlerGroup)
+
+	// eventHandlerGroup is a set of event handlers of which exactly one handles each published event.
+	eventHandlerGroup struct {
+		name      string
+		eventType string
+		members   []eventGroupMember
+		next      atomic.Uint64          // Round-robin position.
+		keyFunc   func(event any) string // Partition key, when partitioning is enabled.
+	}
+
+	// eventGroupMember is a single handler instance of an event handler group.
+	eventGroupMember struct {
+		handler      any // The registered IEventHandler, used to identify the member on removal.
+		typeName     string
+		eventHandler IHandler[T, T]
+	}
+)
+
+var (
+	eventGroupMutex sync.RWMutex
+	eventGroups     = make(map[string]*eventHandlerGroup)   // Group name to group.
+	eventTypeGroups = make(map[string][]*eventHandlerGroup) // Event type to its groups.
+)
+
+// WithPartitionKey makes the group select its member by hashing the key returned by keyFunc,
+// so events sharing a key are always handled by the same member. Round-robin is used otherwise.
+func WithPartitionKey(keyFunc func(event any) string) GroupOption {
+	return func(group *eventHandlerGroup) {
+		group.keyFunc = keyFunc
+	}
+}
+
+// AddEventHandlerGroup registers handlers as members of a consumption group for TEvent.
+// PublishEvent delivers each event to exactly one member of every group, selected round-robin by default,
+// while ungrouped handlers and other groups still all receive the event.
+// Calling it again with the same group name adds members to the existing group.
+func AddEventHandlerGroup[TEvent T](groupName string, handlers []IEventHandler[TEvent], opts ...GroupOption) error {
+	typedEvent := eventTypeKey(reflect.TypeOf(new(TEvent)).Elem())
+
+	eventGroupMutex.Lock()
+	defer eventGroupMutex.Unlock()
+
+	group, ok := eventGroups[groupName]
+	if !ok {
+		group = &eventHandlerGroup{name: groupName, eventType: typedEvent}
+		eventGroups[groupName] = group
+		eventTypeGroups[typedEvent] = append(eventTypeGroups[typedEvent], group)
+	} else if group.eventType != typedEvent {
+		return fmt.Errorf("event handler group %v handles %v, not %v", groupName, group.eventType, typedEvent)
+	}
+
+	for _, opt := range opts {
+		opt(group)
+	}
+
+	members := append([]eventGroupMember(nil), group.members...)
+	for _, handler := range handlers {
+		start := registrationStart()
+		typedHandlerName := reflect.TypeOf(handler).String()
+		recordRegistration(typedHandlerName)
+		members = append(members, eventGroupMember{
+			handler:      handler,
+			typeName:     typedHandlerName,
+			eventHandler: newEventHandlerWrapper[TEvent](handler, typedHandlerName),
+		})
+		recordRegistrationTiming(RegistrationEventHandler, typedHandlerName, start)
+	}
+	group.members = members
+	return nil
+}
+
+// EventHandlerGroupMembers returns the handler type names of the members of a group, in registration order.
+// It returns false if the group does not exist.
+func EventHandlerGroupMembers(groupName string) ([]string, bool) {
+	eventGroupMutex.RLock()
+	defer eventGroupMutex.RUnlock()
+
+	group, ok := eventGroups[groupName]
+	if !ok {
+		return nil, false
+	}
+	names := make([]string, 0, len(group.members))
+	for _, member := range group.members {
+		names = append(names, member.typeName)
+	}
+	return names, true
+}
+
+// RemoveEventHandlerGroupMember removes a handler instance from a group.
+// It returns false if the handler is not a member of the group.
+func RemoveEventHandlerGroupMember[TEvent T](groupName string, handler IEventHandler[TEvent]) bool {
+	eventGroupMutex.Lock()
+	defer eventGroupMutex.Unlock()
+
+	group, ok := eventGroups[groupName]
+	if !ok {
+		return false
+	}
+	for i, member := range group.members {
+		if sameHandler(member.handler, handler) {
+			members := append([]eventGroupMember(nil), group.members[:i]...)
+			group.members = append(members, group.members[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveEventHandlerGroup removes a group and all its members.
+// It returns false if the group does not exist.
+func RemoveEventHandlerGroup(groupName string) bool {
+	eventGroupMutex.Lock()
+	defer eventGroupMutex.Unlock()
+
+	group, ok := eventGroups[groupName]
+	if !ok {
+		return false
+	}
+	delete(eventGroups, groupName)
+
+	groups := make([]*eventHandlerGroup, 0, len(eventTypeGroups[group.eventType]))
+	for _, g := range eventTypeGroups[group.eventType] {
+		if g != group {
+			groups = append(groups, g)
+		}
+	}
+	if len(groups) == 0 {
+		delete(eventTypeGroups, group.eventType)
+	} else {
+		eventTypeGroups[group.eventType] = groups
+	}
+	return true
+}
+
+// groupsForEvent returns the groups registered for an event type.
+func groupsForEvent(typedEvent string) ([]*eventHandlerGroup, bool) {
+	eventGroupMutex.RLock()
+	defer eventGroupMutex.RUnlock()
+	groups, ok := eventTypeGroups[typedEvent]
+	return groups, ok
+}
+
+// publishToGroups delivers event to one member of every group and returns the errors of the selected members.
+// It stops at the first member returning ErrStopPropagation, or at the first error if config is fail-fast.
+func publishToGroups(ctx context.Context, groups []*eventHandlerGroup, event any, config publishConfig) []error {
+	groupErrors := make([]error, 0)
+	for _, group := range groups {
+		member, ok := group.selectMember(event)
+		if !ok {
+			continue
+		}
+		_, err := member.eventHandler.Handle(ctx, event)
+		if err != nil && !errors.Is(err, ErrStopPropagation) {
+			groupErrors = append(groupErrors, err)
+			if config.failFast {
+				return groupErrors
+			}
+			continue
+		}
+		config.onHandled(member.eventHandler)
+		if err != nil {
+			return groupErrors
+		}
+	}
+	return groupErrors
+}
+
+// selectMember picks the member handling event according to the group strategy.
+func (group *eventHandlerGroup) selectMember(event any) (eventGroupMember, bool) {
+	eventGroupMutex.RLock()
+	members, keyFunc := group.members, group.keyFunc
+	eventGroupMutex.RUnlock()
+
+	if len(members) == 0 {
+		return eventGroupMember{}, false
+	}
+
+	if keyFunc != nil {
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(keyFunc(event)))
+		return members[hash.Sum32()%uint32(len(members))], true
+	}
+	return members[(group.next.Add(1)-1)%uint64(len(members))], true
+}
+
+// sameHandler reports whether two handler values are the same instance.
+func sameHandler(a, b any) bool {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
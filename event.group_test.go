@@ -0,0 +1,106 @@
+package gocqrs
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type shardedWorkEvent struct {
+	ID  int
+	Key string
+}
+
+// shardedWorkEventHandler records the events it handled.
+type shardedWorkEventHandler struct {
+	mutex  sync.Mutex
+	events []int
+}
+
+func (h *shardedWorkEventHandler) Handle(ctx context.Context, event shardedWorkEvent) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.events = append(h.events, event.ID)
+	return nil
+}
+
+// TestAddEventHandlerGroup_RoundRobin tests that each event is delivered to exactly one member, evenly.
+func TestAddEventHandlerGroup_RoundRobin(t *testing.T) {
+	members := []*shardedWorkEventHandler{{}, {}, {}}
+	ungrouped := &shardedWorkEventHandler{}
+	defer RemoveEventHandlerGroup("workers.round-robin")
+
+	assert.NoError(t, AddEventHandlers[shardedWorkEvent](ungrouped))
+	assert.NoError(t, AddEventHandlerGroup[shardedWorkEvent]("workers.round-robin", []IEventHandler[shardedWorkEvent]{members[0], members[1], members[2]}))
+
+	const events = 30
+	for i := 0; i < events; i++ {
+		assert.NoError(t, PublishEvent(context.Background(), shardedWorkEvent{ID: i}))
+	}
+
+	delivered := make(map[int]int)
+	for _, member := range members {
+		assert.Len(t, member.events, events/3, "Events should be split evenly")
+		for _, id := range member.events {
+			delivered[id]++
+		}
+	}
+	assert.Len(t, delivered, events)
+	for id, count := range delivered {
+		assert.Equal(t, 1, count, "Event %d should be delivered to exactly one member", id)
+	}
+	assert.Len(t, ungrouped.events, events, "Ungrouped handlers should receive every event")
+
+	names, ok := EventHandlerGroupMembers("workers.round-robin")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"*gocqrs.shardedWorkEventHandler", "*gocqrs.shardedWorkEventHandler", "*gocqrs.shardedWorkEventHandler"}, names)
+}
+
+// TestAddEventHandlerGroup_PartitionKey tests that events sharing a key are handled by the same member.
+func TestAddEventHandlerGroup_PartitionKey(t *testing.T) {
+	members := []*shardedWorkEventHandler{{}, {}, {}}
+	defer RemoveEventHandlerGroup("workers.partitioned")
+
+	assert.NoError(t, AddEventHandlerGroup[shardedWorkEvent]("workers.partitioned",
+		[]IEventHandler[shardedWorkEvent]{members[0], members[1], members[2]},
+		WithPartitionKey(func(event any) string { return event.(shardedWorkEvent).Key }),
+	))
+
+	for i := 0; i < 12; i++ {
+		assert.NoError(t, PublishEvent(context.Background(), shardedWorkEvent{ID: i, Key: "order-42"}))
+	}
+
+	handledBy := 0
+	for _, member := range members {
+		if len(member.events) > 0 {
+			handledBy++
+			assert.Len(t, member.events, 12)
+		}
+	}
+	assert.Equal(t, 1, handledBy, "A single member should handle every event of a partition")
+}
+
+// TestRemoveEventHandlerGroupMember tests removing members and groups.
+func TestRemoveEventHandlerGroupMember(t *testing.T) {
+	first, second := &shardedWorkEventHandler{}, &shardedWorkEventHandler{}
+	assert.NoError(t, AddEventHandlerGroup[shardedWorkEvent]("workers.removable", []IEventHandler[shardedWorkEvent]{first, second}))
+
+	assert.True(t, RemoveEventHandlerGroupMember[shardedWorkEvent]("workers.removable", first))
+	assert.False(t, RemoveEventHandlerGroupMember[shardedWorkEvent]("workers.removable", first))
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, PublishEvent(context.Background(), shardedWorkEvent{ID: i}))
+	}
+	assert.Empty(t, first.events)
+	assert.Len(t, second.events, 4)
+
+	assert.True(t, RemoveEventHandlerGroup("workers.removable"))
+	_, ok := EventHandlerGroupMembers("workers.removable")
+	assert.False(t, ok)
+
+	assert.NoError(t, AddEventHandlerGroup[paymentReceivedEvent]("workers.typed", nil))
+	assert.Error(t, AddEventHandlerGroup[shardedWorkEvent]("workers.typed", nil), "A group handles a single event type")
+	RemoveEventHandlerGroup("workers.typed")
+}
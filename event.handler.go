@@ -0,0 +1,82 @@
+package gocqrs
+
+import (
+	"reflect"
+)
+
+type (
+	// EventHandlerOption configures an event handler registered with AddEventHandler.
+	EventHandlerOption func(*eventHandlerConfig)
+
+	// eventHandlerConfig holds the options of an event handler registration.
+	eventHandlerConfig struct {
+		mailbox *mailboxConfig       // Mailbox the deliveries are routed through, if any.
+		name    string               // Name replacing the default handler name, if set.
+		filter  func(event any) bool // Events the handler is interested in, all if nil.
+
+		expectations []DeliveryGuarantee // Guarantees declared with the Expects options.
+	}
+)
+
+// WithEventHandlerName registers the handler under name instead of its type name, or the symbol name of a function.
+// Registering another handler under the same name for the same event is a no-op.
+func WithEventHandlerName(name string) EventHandlerOption {
+	return func(config *eventHandlerConfig) {
+		config.name = name
+	}
+}
+
+// WithEventFilter delivers to the handler only the events for which filter returns true.
+// Filtered out events are not queued in the mailbox of the handler.
+func WithEventFilter(filter func(event any) bool) EventHandlerOption {
+	return func(config *eventHandlerConfig) {
+		config.filter = filter
+	}
+}
+
+// AddEventHandler registers a single event handler for TEvent, configured with opts.
+// Registering a handler whose type is already registered for TEvent is a no-op.
+func AddEventHandler[TEvent T](handler IEventHandler[TEvent], opts ...EventHandlerOption) error {
+	return addEventHandlerNamed[TEvent](handler, reflect.TypeOf(handler).String(), opts...)
+}
+
+// addEventHandlerNamed registers an event handler under typedHandlerName, unless WithEventHandlerName renames it.
+func addEventHandlerNamed[TEvent T](handler IEventHandler[TEvent], typedHandlerName string, opts ...EventHandlerOption) error {
+	start := registrationStart()
+	config := eventHandlerConfig{name: typedHandlerName}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	typedEvent := eventTypeKey(reflect.TypeOf(new(TEvent)).Elem())
+	typedHandlerName = config.name
+	defer recordRegistrationTiming(RegistrationEventHandler, typedHandlerName, start)
+
+	eventHandlerMutex.Lock()
+	defer eventHandlerMutex.Unlock()
+
+	registeredHandlers := eventHandlers[typedEvent]
+	if checkTypeNameInEventHandlers(typedHandlerName, registeredHandlers) {
+		return nil
+	}
+	if err := recordDeliveryExpectations(typedEvent, typedHandlerName, config); err != nil {
+		return err
+	}
+
+	recordRegistration(typedHandlerName)
+
+	sub := &subscription{}
+	var eventHandler IHandler[T, T] = newEventHandlerWrapper[TEvent](handler, typedHandlerName)
+	if config.mailbox != nil {
+		eventHandler = startMailbox(typedHandlerName, eventHandler, *config.mailbox, sub)
+	}
+
+	eventHandlers[typedEvent] = append(registeredHandlers[:len(registeredHandlers):len(registeredHandlers)], eventHandlersType{
+		typeName:     typedHandlerName,
+		eventHandler: eventHandler,
+		handler:      handler,
+		sub:          sub,
+		filter:       config.filter,
+	})
+	return nil
+}
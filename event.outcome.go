@@ -0,0 +1,61 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// outcomeEvents builds the events published after the dispatches of a handler, set with PublishOutcome.
+type outcomeEvents struct {
+	success func(command, response any) any
+	failure func(command any, err error) any
+}
+
+var (
+	outcomeEventMutex sync.RWMutex
+	outcomeEventsOf   = make(map[string]outcomeEvents) // Handler name to its outcome events.
+)
+
+// PublishOutcome publishes an event after every dispatch of the current handler: the event returned by success
+// once the dispatch succeeded, or the one returned by failure, given the error, once it failed.
+// Either function may be nil or return nil to publish nothing.
+//
+// The event is published like PublishEvent once the behaviors returned, so after a transaction behavior committed
+// or rolled back, and before the post-middlewares. It is published with a context detached from the cancellation of
+// the dispatch, so failure events are published even when the dispatch was canceled. Errors returned by the event
+// handlers, and ErrUnknownHandler for an outcome event without any handler, are reported with the ErrorReporter
+// and never change the result of the dispatch.
+func (middlewareBuilder *AddMiddlewareBuilder) PublishOutcome(success func(command, response any) any, failure func(command any, err error) any) *AddMiddlewareBuilder {
+	handlerName := middlewareBuilder.currentHandler()
+
+	outcomeEventMutex.Lock()
+	defer outcomeEventMutex.Unlock()
+	outcomeEventsOf[handlerName] = outcomeEvents{success: success, failure: failure}
+	return middlewareBuilder
+}
+
+// publishOutcome publishes the outcome event of a dispatch by a handler, if it has one.
+func publishOutcome(ctx context.Context, handlerName string, command, response any, err error) {
+	outcomeEventMutex.RLock()
+	outcome, ok := outcomeEventsOf[handlerName]
+	outcomeEventMutex.RUnlock()
+	if !ok {
+		return
+	}
+
+	var event any
+	switch {
+	case err == nil && outcome.success != nil:
+		event = outcome.success(command, response)
+	case err != nil && outcome.failure != nil:
+		event = outcome.failure(command, err)
+	}
+	if event == nil {
+		return
+	}
+
+	if publishErr := publishFollowUpEvent(context.WithoutCancel(ctx), event, publishConfig{}); publishErr != nil {
+		reportError(ctx, fmt.Errorf("publishing outcome event %T of %v: %w", event, handlerName, publishErr))
+	}
+}
@@ -0,0 +1,154 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	shipOrderCommand struct {
+		OrderID string
+		Fail    bool
+	}
+	orderShippedEvent struct {
+		OrderID    string
+		TrackingID string
+	}
+	orderShippingFailedEvent struct {
+		OrderID string
+		Reason  string
+	}
+
+	shipOrderCommandHandler struct{}
+
+	// shippingOutcomeHandler records the outcome events, along with the transaction steps seen so far.
+	shippingOutcomeHandler struct {
+		steps *[]string
+		fail  bool
+	}
+)
+
+func (h *shipOrderCommandHandler) Handle(ctx context.Context, command shipOrderCommand) (string, error) {
+	if command.Fail {
+		return "", errors.New("carrier unavailable")
+	}
+	return "TRK-" + command.OrderID, nil
+}
+
+func (h *shippingOutcomeHandler) Handle(ctx context.Context, event any) error {
+	switch event := event.(type) {
+	case orderShippedEvent:
+		*h.steps = append(*h.steps, "shipped "+event.TrackingID)
+	case orderShippingFailedEvent:
+		*h.steps = append(*h.steps, "failed "+event.Reason)
+	}
+	if h.fail {
+		return errors.New("outbox full")
+	}
+	return nil
+}
+
+// TestPublishOutcome tests that the outcome events are published after the transaction behavior committed or
+// rolled back, and that their publication errors do not change the result of the dispatch.
+func TestPublishOutcome(t *testing.T) {
+	var steps []string
+	outcomeHandler := &shippingOutcomeHandler{steps: &steps}
+	assert.NoError(t, AddEventHandlerFunc(func(ctx context.Context, event orderShippedEvent) error {
+		return outcomeHandler.Handle(ctx, event)
+	}))
+	assert.NoError(t, AddEventHandlerFunc(func(ctx context.Context, event orderShippingFailedEvent) error {
+		return outcomeHandler.Handle(ctx, event)
+	}))
+
+	transaction := func(ctx context.Context, request any, next NextFunc) (any, error) {
+		steps = append(steps, "begin")
+		response, err := next(ctx, request)
+		if err != nil {
+			steps = append(steps, "rollback")
+		} else {
+			steps = append(steps, "commit")
+		}
+		return response, err
+	}
+	AddCommandHandler[shipOrderCommand, string](&shipOrderCommandHandler{}).
+		Behavior(transaction).
+		PublishOutcome(
+			func(command, response any) any {
+				return orderShippedEvent{OrderID: command.(shipOrderCommand).OrderID, TrackingID: response.(string)}
+			},
+			func(command any, err error) any {
+				if command.(shipOrderCommand).OrderID == "" {
+					return nil
+				}
+				return orderShippingFailedEvent{OrderID: command.(shipOrderCommand).OrderID, Reason: err.Error()}
+			},
+		)
+
+	trackingID, err := SendCommand[string](context.Background(), shipOrderCommand{OrderID: "42"})
+	assert.NoError(t, err)
+	assert.Equal(t, "TRK-42", trackingID)
+	assert.Equal(t, []string{"begin", "commit", "shipped TRK-42"}, steps)
+
+	steps = nil
+	_, err = SendCommand[string](context.Background(), shipOrderCommand{OrderID: "43", Fail: true})
+	assert.EqualError(t, err, "carrier unavailable")
+	assert.Equal(t, []string{"begin", "rollback", "failed carrier unavailable"}, steps)
+
+	// A nil event is not published.
+	steps = nil
+	_, err = SendCommand[string](context.Background(), shipOrderCommand{Fail: true})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"begin", "rollback"}, steps)
+
+	// Publication errors are reported, not returned.
+	var reported []error
+	SetErrorReporter(func(ctx context.Context, err error) {
+		reported = append(reported, err)
+	})
+	defer SetErrorReporter(nil)
+	outcomeHandler.fail = true
+	trackingID, err = SendCommand[string](context.Background(), shipOrderCommand{OrderID: "44"})
+	assert.NoError(t, err)
+	assert.Equal(t, "TRK-44", trackingID)
+	if assert.Len(t, reported, 1) {
+		assert.ErrorContains(t, reported[0], "outbox full")
+	}
+}
+
+type (
+	archiveOrderCommand struct{ OrderID string }
+	orderArchivedEvent  struct{ OrderID string }
+	archiveOrderHandler struct{}
+)
+
+func (h *archiveOrderHandler) Handle(ctx context.Context, command archiveOrderCommand) (string, error) {
+	return "archived " + command.OrderID, nil
+}
+
+// TestPublishOutcome_WithoutHandler tests that an outcome event without any handler is reported instead of panicking.
+func TestPublishOutcome_WithoutHandler(t *testing.T) {
+	var reported []error
+	SetErrorReporter(func(ctx context.Context, err error) {
+		reported = append(reported, err)
+	})
+	defer SetErrorReporter(nil)
+
+	AddCommandHandler[archiveOrderCommand, string](&archiveOrderHandler{}).
+		PublishOutcome(func(command, response any) any {
+			return orderArchivedEvent{OrderID: command.(archiveOrderCommand).OrderID}
+		}, nil)
+
+	var response string
+	var err error
+	assert.NotPanics(t, func() {
+		response, err = SendCommand[string](context.Background(), archiveOrderCommand{OrderID: "45"})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "archived 45", response)
+	if assert.Len(t, reported, 1) {
+		assert.ErrorIs(t, reported[0], ErrUnknownHandler)
+	}
+}
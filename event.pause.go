@@ -0,0 +1,138 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type (
+	// PausedEventsStat describes the state of the event buffer of PauseEvents.
+	PausedEventsStat struct {
+		Paused   bool
+		Buffered int    // Number of events waiting for ResumeEvents.
+		Capacity int    // Maximum number of waiting events.
+		Dropped  uint64 // Number of events discarded by the overflow policy.
+	}
+
+	// pausedEvent is an event published while event processing is paused.
+	pausedEvent struct {
+		ctx    context.Context
+		event  any
+		config publishConfig // Options of the PublishEvent call.
+	}
+)
+
+var (
+	eventPauseMutex sync.Mutex
+	eventsPaused    bool
+	pauseCapacity   int
+	pauseOverflow   OverflowMode
+	pauseBuffer     []pausedEvent
+	pauseDropped    uint64
+	pauseRoom       = make(chan struct{}) // Closed and replaced whenever the buffer shrinks or processing resumes.
+)
+
+// PauseEvents stops dispatching the events passed to PublishEvent, e.g. during a maintenance window:
+// they are buffered, up to capacity, until ResumeEvents, and PublishEvent returns nil once the event is buffered.
+// overflow defines what happens when an event is published while the buffer is full; OverflowBlock waits for
+// ResumeEvents or the context of the publisher, and OverflowError returns ErrEventBufferFull.
+// Calling it while paused changes the capacity and the overflow policy. PublishEvents is not paused.
+func PauseEvents(capacity int, overflow OverflowMode) {
+	eventPauseMutex.Lock()
+	defer eventPauseMutex.Unlock()
+	if capacity < 1 {
+		capacity = 1
+	}
+	eventsPaused = true
+	pauseCapacity = capacity
+	pauseOverflow = overflow
+}
+
+// ResumeEvents publishes the buffered events like PublishEvent, in publication order, with the context
+// they were published with, then resumes dispatching. Events published meanwhile are published after the
+// buffered ones. It returns the errors of the handlers joined, including ErrUnknownHandler for an event whose
+// handlers were removed while it was buffered.
+func ResumeEvents() error {
+	var resumeErrors []error
+	for {
+		eventPauseMutex.Lock()
+		if len(pauseBuffer) == 0 {
+			eventsPaused = false
+			signalPauseRoom()
+			eventPauseMutex.Unlock()
+			return errors.Join(resumeErrors...)
+		}
+		paused := pauseBuffer[0]
+		pauseBuffer = pauseBuffer[1:]
+		signalPauseRoom()
+		eventPauseMutex.Unlock()
+
+		if err := tryPublishEvent(paused.ctx, paused.event, paused.config); err != nil {
+			resumeErrors = append(resumeErrors, err)
+		}
+	}
+}
+
+// PausedEvents returns the state of the event buffer.
+func PausedEvents() PausedEventsStat {
+	eventPauseMutex.Lock()
+	defer eventPauseMutex.Unlock()
+	return PausedEventsStat{
+		Paused:   eventsPaused,
+		Buffered: len(pauseBuffer),
+		Capacity: pauseCapacity,
+		Dropped:  pauseDropped,
+	}
+}
+
+// bufferPausedEvent buffers event if event processing is paused, applying the overflow policy when the buffer
+// is full. It returns false if events are not paused, or if the event has no handler: it must fail when
+// published, as when not paused, rather than be accepted and fail on ResumeEvents.
+func bufferPausedEvent(ctx context.Context, event any, config publishConfig) (bool, error) {
+	eventPauseMutex.Lock()
+	defer eventPauseMutex.Unlock()
+
+	if !eventsPaused {
+		return false, nil
+	}
+	if _, _, ok := eventSubscribers(ctx, eventTypeName(event)); !ok {
+		return false, nil
+	}
+
+	for eventsPaused && len(pauseBuffer) >= pauseCapacity {
+		switch pauseOverflow {
+		case OverflowDropNewest:
+			pauseDropped++
+			return true, nil
+		case OverflowDropOldest:
+			pauseBuffer = pauseBuffer[1:]
+			pauseDropped++
+		case OverflowError:
+			return true, fmt.Errorf("%w: %v", ErrEventBufferFull, eventTypeName(event))
+		default:
+			room := pauseRoom
+			eventPauseMutex.Unlock()
+			select {
+			case <-room:
+				eventPauseMutex.Lock()
+			case <-ctx.Done():
+				eventPauseMutex.Lock()
+				return true, ctx.Err()
+			}
+		}
+	}
+	if !eventsPaused {
+		return false, nil
+	}
+	// The handlers run after PublishEvent returned, so they must not be canceled with the publisher's context.
+	pauseBuffer = append(pauseBuffer, pausedEvent{ctx: context.WithoutCancel(ctx), event: event, config: config})
+	return true, nil
+}
+
+// signalPauseRoom wakes up the publishers waiting for room in the buffer. eventPauseMutex must be held.
+func signalPauseRoom() {
+	close(pauseRoom)
+	pauseRoom = make(chan struct{})
+}
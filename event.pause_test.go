@@ -0,0 +1,79 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type maintenanceEvent struct {
+	Value int
+}
+
+type maintenanceEventHandler struct {
+	values []int
+}
+
+func (h *maintenanceEventHandler) Handle(ctx context.Context, event maintenanceEvent) error {
+	h.values = append(h.values, event.Value)
+	return nil
+}
+
+// TestPauseEvents tests that events published while paused are handled, in order, only after ResumeEvents.
+func TestPauseEvents(t *testing.T) {
+	handler := &maintenanceEventHandler{}
+	assert.NoError(t, AddEventHandlers[maintenanceEvent](handler))
+
+	PauseEvents(2, OverflowDropOldest)
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, PublishEvent(context.Background(), maintenanceEvent{Value: i}))
+	}
+	assert.Empty(t, handler.values)
+	assert.Equal(t, PausedEventsStat{Paused: true, Buffered: 2, Capacity: 2, Dropped: 1}, PausedEvents())
+
+	assert.NoError(t, ResumeEvents())
+	assert.Equal(t, []int{2, 3}, handler.values)
+	assert.False(t, PausedEvents().Paused)
+
+	assert.NoError(t, PublishEvent(context.Background(), maintenanceEvent{Value: 4}))
+	assert.Equal(t, []int{2, 3, 4}, handler.values)
+}
+
+// TestPauseEvents_Overflow tests the error and blocking overflow policies of a full buffer.
+func TestPauseEvents_Overflow(t *testing.T) {
+	handler := &maintenanceEventHandler{}
+	assert.NoError(t, AddEventHandlers[maintenanceEvent](handler))
+	defer func() { _ = ResumeEvents() }()
+
+	PauseEvents(1, OverflowError)
+	assert.NoError(t, PublishEvent(context.Background(), maintenanceEvent{Value: 1}))
+	assert.ErrorIs(t, PublishEvent(context.Background(), maintenanceEvent{Value: 2}), ErrEventBufferFull)
+
+	PauseEvents(1, OverflowBlock)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, PublishEvent(ctx, maintenanceEvent{Value: 3}), context.Canceled)
+
+	published := make(chan error)
+	go func() { published <- PublishEvent(context.Background(), maintenanceEvent{Value: 4}) }()
+	assert.NoError(t, ResumeEvents())
+	assert.NoError(t, <-published)
+}
+
+type unsubscribedMaintenanceEvent struct{}
+
+// TestPauseEvents_WithoutHandler tests that an event without handler fails when published while paused,
+// as when not paused, instead of being buffered and failing on ResumeEvents.
+func TestPauseEvents_WithoutHandler(t *testing.T) {
+	PauseEvents(2, OverflowError)
+
+	assert.Panics(t, func() { _ = PublishEvent(context.Background(), unsubscribedMaintenanceEvent{}) })
+	SetNoPanics(true)
+	var internalErr *InternalError
+	assert.ErrorAs(t, PublishEvent(context.Background(), unsubscribedMaintenanceEvent{}), &internalErr)
+	SetNoPanics(false)
+	assert.Equal(t, 0, PausedEvents().Buffered)
+
+	assert.NotPanics(t, func() { assert.NoError(t, ResumeEvents()) })
+}
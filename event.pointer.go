@@ -0,0 +1,43 @@
+package gocqrs
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// noEventPointerConversion is set by SetEventPointerConversion(false).
+var noEventPointerConversion atomic.Bool
+
+// SetEventPointerConversion sets whether events published as a value reach the handlers registered for a pointer to
+// their type and vice versa, which is the default. Both forms of an event type share its handlers; an event is
+// converted to the form of each handler:
+//   - A value delivered to a pointer handler is copied and the handler receives the address of the copy, so the
+//     publisher does not observe the changes the handler makes through the pointer.
+//   - A pointer delivered to a value handler is dereferenced, so the handler receives a copy.
+//   - A pointer delivered to a pointer handler, or a value to a value handler, is passed as is.
+//
+// Disable it to only deliver events in the exact form their handlers were registered for, e.g. to rely on pointer
+// identity; the handlers of the other form then fail with an incorrect request type error.
+func SetEventPointerConversion(enabled bool) {
+	noEventPointerConversion.Store(!enabled)
+}
+
+// convertEventForm converts an event to T1 if one of them is a pointer to the other.
+// It returns false if they are unrelated, the event is a nil pointer or the conversion is disabled.
+func convertEventForm[T1 T](event any) (converted T1, ok bool) {
+	if noEventPointerConversion.Load() || event == nil {
+		return converted, false
+	}
+
+	target := reflect.TypeOf(&converted).Elem()
+	value := reflect.ValueOf(event)
+	switch {
+	case target.Kind() == reflect.Pointer && target.Elem() == value.Type():
+		pointer := reflect.New(value.Type())
+		pointer.Elem().Set(value)
+		converted, ok = pointer.Interface().(T1)
+	case value.Kind() == reflect.Pointer && value.Type().Elem() == target && !value.IsNil():
+		converted, ok = value.Elem().Interface().(T1)
+	}
+	return converted, ok
+}
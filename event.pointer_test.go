@@ -0,0 +1,83 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type parcelShipped struct {
+	Status string
+}
+
+// parcelShippedHandler records the status of the events it handled, as a value.
+type parcelShippedHandler struct {
+	statuses []string
+}
+
+func (h *parcelShippedHandler) Handle(ctx context.Context, event parcelShipped) error {
+	h.statuses = append(h.statuses, event.Status)
+	return nil
+}
+
+// parcelShippedPointerHandler records the events it handled, as a pointer, and marks them handled.
+type parcelShippedPointerHandler struct {
+	events []*parcelShipped
+}
+
+func (h *parcelShippedPointerHandler) Handle(ctx context.Context, event *parcelShipped) error {
+	h.events = append(h.events, event)
+	event.Status = "handled"
+	return nil
+}
+
+// TestEventPointerConversion tests every combination of value and pointer registrations and publications.
+func TestEventPointerConversion(t *testing.T) {
+	valueHandler := &parcelShippedHandler{}
+	pointerHandler := &parcelShippedPointerHandler{}
+	assert.NoError(t, AddEventHandlers[parcelShipped](valueHandler))
+	assert.NoError(t, AddEventHandlers[*parcelShipped](pointerHandler))
+
+	// Published as a value: the pointer handler changes a copy the publisher does not observe.
+	event := parcelShipped{Status: "shipped"}
+	assert.NoError(t, PublishEvent(context.Background(), event))
+	assert.Equal(t, []string{"shipped"}, valueHandler.statuses)
+	assert.Len(t, pointerHandler.events, 1)
+	assert.Equal(t, "handled", pointerHandler.events[0].Status)
+	assert.Equal(t, "shipped", event.Status)
+
+	// Published as a pointer: the value handler gets a copy and the pointer handler the published pointer.
+	pointer := &parcelShipped{Status: "delivered"}
+	assert.NoError(t, PublishEvent(context.Background(), pointer))
+	assert.Equal(t, []string{"shipped", "delivered"}, valueHandler.statuses)
+	assert.Len(t, pointerHandler.events, 2)
+	assert.Same(t, pointer, pointerHandler.events[1])
+	assert.Equal(t, "handled", pointer.Status)
+}
+
+type parcelReturned struct {
+	Reason string
+}
+
+type parcelReturnedPointerHandler struct {
+	handled int
+}
+
+func (h *parcelReturnedPointerHandler) Handle(ctx context.Context, event *parcelReturned) error {
+	h.handled++
+	return nil
+}
+
+// TestEventPointerConversion_Disabled tests that events only reach the handlers of their exact form when disabled.
+func TestEventPointerConversion_Disabled(t *testing.T) {
+	handler := &parcelReturnedPointerHandler{}
+	assert.NoError(t, AddEventHandlers[*parcelReturned](handler))
+
+	SetEventPointerConversion(false)
+	defer SetEventPointerConversion(true)
+
+	assert.NoError(t, PublishEvent(context.Background(), &parcelReturned{Reason: "damaged"}))
+	assert.EqualError(t, PublishEvent(context.Background(), parcelReturned{Reason: "refused"}), "incorrect request type: gocqrs.parcelReturned")
+	assert.Equal(t, 1, handler.handled)
+}
@@ -0,0 +1,54 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// producedEvents collects the events returned by the event producing handlers of a dispatch.
+type producedEvents struct {
+	mutex  sync.Mutex
+	events []any
+}
+
+// producedEventsKey is the context key of the producedEvents of a dispatch.
+type producedEventsKey struct{}
+
+// AddEventProducingHandler registers a command handler returning the domain events it produced.
+// Once the dispatch succeeded, behaviors included, the events are published in order with PublishEvents
+// and the response is returned to the caller. No event is published if the dispatch fails; an event
+// publishing error, such as ErrUnknownHandler for an event without handler, is returned along with the response.
+func AddEventProducingHandler[Command T, CommandResponse T](handler IEventProducingHandler[Command, CommandResponse], opts ...HandlerOption) *AddMiddlewareBuilder {
+	typedHandlerName := reflect.TypeOf(handler).String()
+	return addRequestNamed[Command, CommandResponse](&eventProducingHandlerAdapter[Command, CommandResponse]{eventProducingHandler: handler}, typedHandlerName, opts...)
+}
+
+// withProducedEvents returns a context collecting the events produced during a dispatch.
+func withProducedEvents(ctx context.Context) (context.Context, *producedEvents) {
+	produced := &producedEvents{}
+	return context.WithValue(ctx, producedEventsKey{}, produced), produced
+}
+
+// addProducedEvents adds events to the producedEvents of the dispatch.
+func addProducedEvents(ctx context.Context, events []any) {
+	if produced, ok := ctx.Value(producedEventsKey{}).(*producedEvents); ok {
+		produced.mutex.Lock()
+		produced.events = append(produced.events, events...)
+		produced.mutex.Unlock()
+	}
+}
+
+// publish publishes the collected events, if any.
+func (produced *producedEvents) publish(ctx context.Context) error {
+	produced.mutex.Lock()
+	events := produced.events
+	produced.events = nil
+	produced.mutex.Unlock()
+
+	if len(events) == 0 {
+		return nil
+	}
+	_, err := PublishEvents(ctx, events...)
+	return err
+}
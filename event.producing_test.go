@@ -0,0 +1,81 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	openAccountCommand struct {
+		Owner string
+		Fail  bool
+	}
+	accountOpened  struct{ Owner string }
+	welcomeOffered struct{ Owner string }
+)
+
+type openAccountCommandHandler struct{}
+
+func (h *openAccountCommandHandler) Handle(ctx context.Context, command openAccountCommand) (string, []any, error) {
+	events := []any{accountOpened{Owner: command.Owner}, welcomeOffered{Owner: command.Owner}}
+	if command.Fail {
+		return "", events, errors.New("owner is blocked")
+	}
+	return "account-" + command.Owner, events, nil
+}
+
+// accountEventRecorder records the account events it handles.
+type accountEventRecorder[TEvent T] struct {
+	handled *[]any
+}
+
+func (h *accountEventRecorder[TEvent]) Handle(ctx context.Context, event TEvent) error {
+	*h.handled = append(*h.handled, event)
+	return nil
+}
+
+// TestAddEventProducingHandler tests that the returned events are published only when the dispatch succeeds.
+func TestAddEventProducingHandler(t *testing.T) {
+	var handled []any
+	assert.NoError(t, AddEventHandlers[accountOpened](&accountEventRecorder[accountOpened]{handled: &handled}))
+	assert.NoError(t, AddEventHandlers[welcomeOffered](&accountEventRecorder[welcomeOffered]{handled: &handled}))
+	AddEventProducingHandler[openAccountCommand, string](&openAccountCommandHandler{})
+
+	response, err := SendCommand[string](context.Background(), openAccountCommand{Owner: "ada"})
+	assert.NoError(t, err)
+	assert.Equal(t, "account-ada", response)
+	assert.Equal(t, []any{accountOpened{Owner: "ada"}, welcomeOffered{Owner: "ada"}}, handled)
+
+	handled = nil
+	_, err = SendCommand[string](context.Background(), openAccountCommand{Owner: "eve", Fail: true})
+	assert.EqualError(t, err, "owner is blocked")
+	assert.Empty(t, handled, "no event is published when the handler fails")
+}
+
+type (
+	closeAccountCommand struct{ Owner string }
+	accountClosed       struct{ Owner string }
+)
+
+type closeAccountCommandHandler struct{}
+
+func (h *closeAccountCommandHandler) Handle(ctx context.Context, command closeAccountCommand) (string, []any, error) {
+	return "closed-" + command.Owner, []any{accountClosed{Owner: command.Owner}}, nil
+}
+
+// TestAddEventProducingHandler_WithoutSubscriber tests that a produced event without handler is returned as an
+// error along with the response, instead of panicking once the handler committed its work.
+func TestAddEventProducingHandler_WithoutSubscriber(t *testing.T) {
+	AddEventProducingHandler[closeAccountCommand, string](&closeAccountCommandHandler{})
+
+	var response string
+	var err error
+	assert.NotPanics(t, func() {
+		response, err = SendCommand[string](context.Background(), closeAccountCommand{Owner: "ada"})
+	})
+	assert.ErrorIs(t, err, ErrUnknownHandler)
+	assert.Equal(t, "closed-ada", response)
+}
@@ -0,0 +1,141 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// EventRateLimitOption configures the rate limit of an event type.
+	EventRateLimitOption func(*eventRateLimiter)
+
+	// EventRateLimitStat holds the counters of the rate limit of an event type.
+	EventRateLimitStat struct {
+		Dropped uint64 // Number of events discarded for exceeding the rate.
+		Delayed uint64 // Number of events held back for exceeding the rate.
+	}
+
+	// eventRateLimiter is a token bucket refilled at perSecond tokens per second, holding up to perSecond tokens.
+	eventRateLimiter struct {
+		mutex     sync.Mutex
+		perSecond float64
+		delay     bool
+		tokens    float64
+		last      time.Time
+		dropped   atomic.Uint64
+		delayed   atomic.Uint64
+	}
+)
+
+var (
+	eventRateLimitMutex sync.RWMutex
+	eventRateLimiters   = make(map[string]*eventRateLimiter) // Event type name to its rate limiter.
+)
+
+// DelayExcessEvents makes events exceeding the rate wait for their turn instead of being dropped.
+// A delayed PublishEvent returns the context error if ctx is done first.
+func DelayExcessEvents() EventRateLimitOption {
+	return func(limiter *eventRateLimiter) {
+		limiter.delay = true
+	}
+}
+
+// SetEventRateLimit protects the handlers of TEvent from event storms: PublishEvent accepts bursts of up to
+// perSecond events, then perSecond events per second. Excess events are dropped without invoking any handler,
+// like deduplicated events, unless DelayExcessEvents is given. Dropped and delayed events are counted in
+// EventRateLimitStats. A non-positive perSecond removes the limit.
+func SetEventRateLimit[TEvent T](perSecond int, opts ...EventRateLimitOption) {
+	typedEvent := eventTypeKey(reflect.TypeOf(new(TEvent)).Elem())
+
+	eventRateLimitMutex.Lock()
+	defer eventRateLimitMutex.Unlock()
+	if perSecond <= 0 {
+		delete(eventRateLimiters, typedEvent)
+		return
+	}
+	limiter := &eventRateLimiter{perSecond: float64(perSecond), tokens: float64(perSecond), last: now()}
+	for _, opt := range opts {
+		opt(limiter)
+	}
+	eventRateLimiters[typedEvent] = limiter
+}
+
+// EventRateLimitStats returns the counters of the rate limited event types, keyed by event type name.
+func EventRateLimitStats() map[string]EventRateLimitStat {
+	eventRateLimitMutex.RLock()
+	defer eventRateLimitMutex.RUnlock()
+
+	snapshot := make(map[string]EventRateLimitStat, len(eventRateLimiters))
+	for typedEvent, limiter := range eventRateLimiters {
+		snapshot[typedEvent] = EventRateLimitStat{
+			Dropped: limiter.dropped.Load(),
+			Delayed: limiter.delayed.Load(),
+		}
+	}
+	return snapshot
+}
+
+// admitEvent applies the rate limit of an event type. It reports whether the event must be delivered,
+// after waiting for its turn if excess events are delayed, and returns ctx's error if ctx is done first.
+func admitEvent(ctx context.Context, typedEvent string) (bool, error) {
+	eventRateLimitMutex.RLock()
+	limiter, ok := eventRateLimiters[typedEvent]
+	eventRateLimitMutex.RUnlock()
+
+	if !ok {
+		return true, nil
+	}
+
+	wait, admitted := limiter.reserve(now())
+	if !admitted {
+		limiter.dropped.Add(1)
+		return false, nil
+	}
+	if wait <= 0 {
+		return true, nil
+	}
+
+	limiter.delayed.Add(1)
+	ready := make(chan struct{})
+	timer := getClock().AfterFunc(wait, func() { close(ready) })
+	select {
+	case <-ready:
+		return true, nil
+	case <-ctx.Done():
+		timer.Stop()
+		limiter.cancel()
+		return false, ctx.Err()
+	}
+}
+
+// reserve takes a token and returns how long to wait before using it.
+// It reports false if no token is left and excess events are dropped.
+func (limiter *eventRateLimiter) reserve(at time.Time) (time.Duration, bool) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	if elapsed := at.Sub(limiter.last); elapsed > 0 {
+		limiter.tokens = min(limiter.perSecond, limiter.tokens+elapsed.Seconds()*limiter.perSecond)
+		limiter.last = at
+	}
+	if limiter.tokens < 1 && !limiter.delay {
+		return 0, false
+	}
+
+	// Delayed events borrow from future tokens, queueing behind the ones already waiting.
+	limiter.tokens--
+	if limiter.tokens >= 0 {
+		return 0, true
+	}
+	return time.Duration(-limiter.tokens / limiter.perSecond * float64(time.Second)), true
+}
+
+// cancel gives back the token of a delayed event that gave up waiting.
+func (limiter *eventRateLimiter) cancel() {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	limiter.tokens++
+}
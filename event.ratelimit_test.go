@@ -0,0 +1,87 @@
+package gocqrs
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	stormEvent   struct{}
+	delayedEvent struct{}
+)
+
+// countingEventHandler counts the events it handles.
+type countingEventHandler[TEvent T] struct {
+	calls atomic.Int64
+}
+
+func (h *countingEventHandler[TEvent]) Handle(ctx context.Context, event TEvent) error {
+	h.calls.Add(1)
+	return nil
+}
+
+// TestSetEventRateLimit_Drop tests that events beyond the rate are dropped until tokens refill.
+func TestSetEventRateLimit_Drop(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	handler := &countingEventHandler[stormEvent]{}
+	assert.NoError(t, AddEventHandlers[stormEvent](handler))
+	SetEventRateLimit[stormEvent](10)
+	defer SetEventRateLimit[stormEvent](0)
+
+	for i := 0; i < 15; i++ {
+		assert.NoError(t, PublishEvent(context.Background(), stormEvent{}))
+	}
+	assert.Equal(t, int64(10), handler.calls.Load())
+	assert.Equal(t, EventRateLimitStat{Dropped: 5}, EventRateLimitStats()["gocqrs.stormEvent"])
+
+	clock.Advance(500 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, PublishEvent(context.Background(), stormEvent{}))
+	}
+	assert.Equal(t, int64(15), handler.calls.Load())
+	assert.Equal(t, EventRateLimitStat{Dropped: 10}, EventRateLimitStats()["gocqrs.stormEvent"])
+}
+
+// TestSetEventRateLimit_Delay tests that events beyond the rate wait for their turn.
+func TestSetEventRateLimit_Delay(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	handler := &countingEventHandler[delayedEvent]{}
+	assert.NoError(t, AddEventHandlers[delayedEvent](handler))
+	SetEventRateLimit[delayedEvent](2, DelayExcessEvents())
+	defer SetEventRateLimit[delayedEvent](0)
+
+	assert.NoError(t, PublishEvent(context.Background(), delayedEvent{}))
+	assert.NoError(t, PublishEvent(context.Background(), delayedEvent{}))
+
+	published := make(chan error)
+	go func() {
+		published <- PublishEvent(context.Background(), delayedEvent{})
+	}()
+	assert.Eventually(t, func() bool { return clock.Pending() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, int64(2), handler.calls.Load())
+
+	clock.Advance(500 * time.Millisecond)
+	assert.NoError(t, <-published)
+	assert.Equal(t, int64(3), handler.calls.Load())
+	assert.Equal(t, EventRateLimitStat{Delayed: 1}, EventRateLimitStats()["gocqrs.delayedEvent"])
+
+	// A delayed publish gives up when its context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		published <- PublishEvent(ctx, delayedEvent{})
+	}()
+	assert.Eventually(t, func() bool { return clock.Pending() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-published, context.Canceled)
+	assert.Equal(t, int64(3), handler.calls.Load())
+}
@@ -0,0 +1,148 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	scheduledEventPending int32 = iota
+	scheduledEventFired
+	scheduledEventCanceled
+)
+
+type (
+	// ScheduledHandle refers to an event scheduled with ScheduleEvent.
+	ScheduledHandle struct {
+		scheduled *scheduledEvent
+	}
+
+	// scheduledEvent is an event waiting to be published at a given time.
+	scheduledEvent struct {
+		ctx      context.Context
+		event    any
+		typeName string
+		at       time.Time
+		opts     []PublishOption
+		timer    Timer        // Guarded by scheduledEventMutex.
+		state    atomic.Int32 // Pending until either fired or canceled, whichever comes first.
+	}
+)
+
+var (
+	scheduledEventMutex sync.Mutex
+	scheduledEvents     = make(map[string][]*scheduledEvent) // Event type name to its pending events.
+)
+
+// ScheduleEvent publishes event at the given time with PublishEvent and opts, e.g. to emit OrderExpired once
+// a payment is overdue. The event is published with the values of ctx, such as correlation identifiers, but
+// not its cancellation; handler errors, ErrUnknownHandler for an event type without handler by then, and the
+// panics of handlers are reported with the ErrorReporter since no caller awaits them.
+// An event scheduled in the past is published immediately. Scheduled events are kept in memory only and
+// time is measured with the configured Clock; gocqrstest.FakeClock publishes them deterministically in tests.
+func ScheduleEvent(ctx context.Context, event any, at time.Time, opts ...PublishOption) ScheduledHandle {
+	scheduled := &scheduledEvent{
+		ctx:      context.WithoutCancel(ctx),
+		event:    event,
+		typeName: eventTypeName(event),
+		at:       at,
+		opts:     opts,
+	}
+
+	scheduledEventMutex.Lock()
+	defer scheduledEventMutex.Unlock()
+	scheduledEvents[scheduled.typeName] = append(scheduledEvents[scheduled.typeName], scheduled)
+	scheduled.timer = getClock().AfterFunc(at.Sub(now()), scheduled.fire)
+	return ScheduledHandle{scheduled: scheduled}
+}
+
+// CancelScheduledEventsMatching cancels the pending scheduled events of type TEvent for which predicate returns
+// true, e.g. the expiry of an order whose payment was received, and returns how many it canceled.
+// An event being published concurrently is either canceled or published, never both.
+func CancelScheduledEventsMatching[TEvent T](predicate func(event TEvent) bool) int {
+	typeName := eventTypeKey(reflect.TypeOf(new(TEvent)).Elem())
+
+	scheduledEventMutex.Lock()
+	candidates := append([]*scheduledEvent(nil), scheduledEvents[typeName]...)
+	scheduledEventMutex.Unlock()
+
+	canceled := 0
+	for _, scheduled := range candidates {
+		event, ok := scheduled.event.(TEvent)
+		if !ok {
+			event, ok = convertEventForm[TEvent](scheduled.event)
+		}
+		if ok && predicate(event) && scheduled.cancel() {
+			canceled++
+		}
+	}
+	return canceled
+}
+
+// Cancel prevents the event from being published. It returns false if it was already published or canceled.
+func (handle ScheduledHandle) Cancel() bool {
+	return handle.scheduled.cancel()
+}
+
+// Event returns the scheduled event.
+func (handle ScheduledHandle) Event() any {
+	return handle.scheduled.event
+}
+
+// At returns the time the event is published at.
+func (handle ScheduledHandle) At() time.Time {
+	return handle.scheduled.at
+}
+
+// fire publishes the event unless it was canceled. It runs on a timer goroutine, so an event without handler
+// and a panicking handler are reported instead of crashing the process.
+func (scheduled *scheduledEvent) fire() {
+	if !scheduled.state.CompareAndSwap(scheduledEventPending, scheduledEventFired) {
+		return
+	}
+	scheduled.remove()
+
+	defer func() {
+		if r := recover(); r != nil {
+			reportError(scheduled.ctx, fmt.Errorf("scheduled event %v panicked: %v", scheduled.typeName, r))
+		}
+	}()
+	err := publishFollowUpEvent(scheduled.ctx, scheduled.event, newPublishConfig(scheduled.opts))
+	if err != nil {
+		reportError(scheduled.ctx, err)
+	}
+}
+
+// cancel cancels the event unless it was already fired or canceled.
+func (scheduled *scheduledEvent) cancel() bool {
+	if !scheduled.state.CompareAndSwap(scheduledEventPending, scheduledEventCanceled) {
+		return false
+	}
+	timer := scheduled.remove()
+	timer.Stop()
+	return true
+}
+
+// remove forgets the event and returns its timer.
+func (scheduled *scheduledEvent) remove() Timer {
+	scheduledEventMutex.Lock()
+	defer scheduledEventMutex.Unlock()
+
+	pending := scheduledEvents[scheduled.typeName]
+	remaining := make([]*scheduledEvent, 0, len(pending))
+	for _, s := range pending {
+		if s != scheduled {
+			remaining = append(remaining, s)
+		}
+	}
+	if len(remaining) == 0 {
+		delete(scheduledEvents, scheduled.typeName)
+	} else {
+		scheduledEvents[scheduled.typeName] = remaining
+	}
+	return scheduled.timer
+}
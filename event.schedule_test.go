@@ -0,0 +1,193 @@
+package gocqrs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type orderExpired struct {
+	OrderID int
+}
+
+type correlationKey struct{}
+
+// orderExpiredHandler records the expired orders and the correlation of their publication.
+type orderExpiredHandler struct {
+	mutex        sync.Mutex
+	orders       []int
+	correlations []any
+}
+
+func (h *orderExpiredHandler) Handle(ctx context.Context, event orderExpired) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.orders = append(h.orders, event.OrderID)
+	h.correlations = append(h.correlations, ctx.Value(correlationKey{}))
+	return nil
+}
+
+func (h *orderExpiredHandler) expired() []int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return append([]int(nil), h.orders...)
+}
+
+// TestScheduleEvent tests that a scheduled event is published when due with the values of the scheduling context.
+func TestScheduleEvent(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	handler := &orderExpiredHandler{}
+	assert.NoError(t, AddEventHandlers[orderExpired](handler))
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), correlationKey{}, "checkout-1"))
+	handle := ScheduleEvent(ctx, orderExpired{OrderID: 1}, clock.Now().Add(24*time.Hour))
+	cancel()
+	assert.Equal(t, orderExpired{OrderID: 1}, handle.Event())
+	assert.Equal(t, clock.Now().Add(24*time.Hour), handle.At())
+
+	clock.Advance(23 * time.Hour)
+	assert.Empty(t, handler.expired())
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, []int{1}, handler.expired())
+	assert.Equal(t, []any{"checkout-1"}, handler.correlations, "the scheduling context's values should be kept")
+	assert.False(t, handle.Cancel(), "a published event cannot be canceled")
+}
+
+type paymentOverdue struct {
+	OrderID int
+}
+
+type paymentOverdueHandler struct {
+	mutex  sync.Mutex
+	orders []int
+}
+
+func (h *paymentOverdueHandler) Handle(ctx context.Context, event paymentOverdue) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.orders = append(h.orders, event.OrderID)
+	return nil
+}
+
+// TestCancelScheduledEventsMatching tests that only the pending events matching the predicate are canceled.
+func TestCancelScheduledEventsMatching(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	handler := &paymentOverdueHandler{}
+	assert.NoError(t, AddEventHandlers[paymentOverdue](handler))
+
+	ScheduleEvent(context.Background(), paymentOverdue{OrderID: 1}, clock.Now().Add(time.Hour))
+	ScheduleEvent(context.Background(), &paymentOverdue{OrderID: 2}, clock.Now().Add(time.Hour))
+	handle := ScheduleEvent(context.Background(), paymentOverdue{OrderID: 3}, clock.Now().Add(time.Hour))
+
+	canceled := CancelScheduledEventsMatching(func(event paymentOverdue) bool { return event.OrderID != 3 })
+	assert.Equal(t, 2, canceled)
+	assert.Equal(t, 0, CancelScheduledEventsMatching(func(event paymentOverdue) bool { return event.OrderID == 1 }))
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, []int{3}, handler.orders)
+	assert.False(t, handle.Cancel())
+	assert.Equal(t, 0, clock.Pending())
+}
+
+type reservationLapsed struct {
+	ID int
+}
+
+type reservationLapsedHandler struct {
+	mutex  sync.Mutex
+	lapsed map[int]int
+}
+
+func (h *reservationLapsedHandler) Handle(ctx context.Context, event reservationLapsed) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.lapsed[event.ID]++
+	return nil
+}
+
+// TestScheduleEvent_CancelRacingFiring tests that a cancellation racing the publication never lets both happen.
+func TestScheduleEvent_CancelRacingFiring(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	handler := &reservationLapsedHandler{lapsed: make(map[int]int)}
+	assert.NoError(t, AddEventHandlers[reservationLapsed](handler))
+
+	const events = 200
+	handles := make([]ScheduledHandle, events)
+	for i := range handles {
+		handles[i] = ScheduleEvent(context.Background(), reservationLapsed{ID: i}, clock.Now().Add(time.Minute))
+	}
+
+	canceled := make([]bool, events)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		clock.Advance(time.Minute)
+	}()
+	go func() {
+		defer wg.Done()
+		for i := events - 1; i >= 0; i-- {
+			canceled[i] = handles[i].Cancel()
+		}
+	}()
+	wg.Wait()
+
+	for i := 0; i < events; i++ {
+		published := handler.lapsed[i]
+		if canceled[i] {
+			assert.Equal(t, 0, published, "event %d was canceled and published", i)
+		} else {
+			assert.Equal(t, 1, published, "event %d was neither canceled nor published once", i)
+		}
+	}
+}
+
+type (
+	reminderUnsubscribed struct{}
+	reminderExploded     struct{}
+)
+
+// TestScheduleEvent_FailuresAreReported tests that a scheduled event without handler or with a panicking handler
+// is reported instead of crashing the timer goroutine.
+func TestScheduleEvent_FailuresAreReported(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	var mutex sync.Mutex
+	var reported []error
+	SetErrorReporter(func(ctx context.Context, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		reported = append(reported, err)
+	})
+	defer SetErrorReporter(nil)
+
+	assert.NoError(t, AddEventHandlerFunc(func(ctx context.Context, event reminderExploded) error {
+		panic("reminder template missing")
+	}))
+
+	ScheduleEvent(context.Background(), reminderUnsubscribed{}, clock.Now().Add(time.Hour))
+	ScheduleEvent(context.Background(), reminderExploded{}, clock.Now().Add(time.Hour))
+	assert.NotPanics(t, func() { clock.Advance(time.Hour) })
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if assert.Len(t, reported, 2) {
+		assert.ErrorIs(t, reported[0], ErrUnknownHandler)
+		assert.EqualError(t, reported[1], "scheduled event gocqrs.reminderExploded panicked: reminder template missing")
+	}
+}
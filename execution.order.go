@@ -0,0 +1,40 @@
+package gocqrs
+
+import (
+	"context"
+	"sync"
+)
+
+// ExecutionOrder records the order in which the middlewares and the handler of a dispatch executed.
+// It is meant for tests asserting that middlewares are configured in the expected order.
+type ExecutionOrder struct {
+	mutex sync.Mutex
+	steps []string
+}
+
+// executionOrderKey is the context key of the ExecutionOrder of a dispatch.
+type executionOrderKey struct{}
+
+// RecordExecutionOrder returns a context that makes dispatches record their execution order.
+// Steps are recorded as "pre:<middleware>", "handler" and "post:<middleware>", where <middleware>
+// is the middleware name given to PreMiddlewareNamed/PostMiddlewareNamed or its function symbol.
+func RecordExecutionOrder(ctx context.Context) (context.Context, *ExecutionOrder) {
+	order := &ExecutionOrder{}
+	return context.WithValue(ctx, executionOrderKey{}, order), order
+}
+
+// Steps returns the steps recorded so far, in execution order.
+func (order *ExecutionOrder) Steps() []string {
+	order.mutex.Lock()
+	defer order.mutex.Unlock()
+	return append([]string(nil), order.steps...)
+}
+
+// recordStep appends a step to the ExecutionOrder carried by ctx, if any.
+func recordStep(ctx context.Context, step string) {
+	if order, ok := ctx.Value(executionOrderKey{}).(*ExecutionOrder); ok {
+		order.mutex.Lock()
+		order.steps = append(order.steps, step)
+		order.mutex.Unlock()
+	}
+}
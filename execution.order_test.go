@@ -0,0 +1,37 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type orderedCommand struct{}
+
+type orderedCommandHandler struct{}
+
+func (h *orderedCommandHandler) Handle(ctx context.Context, command orderedCommand) (string, error) {
+	return "ok", nil
+}
+
+// TestRecordExecutionOrder tests that the middlewares and the handler are recorded in execution order.
+func TestRecordExecutionOrder(t *testing.T) {
+	passThrough := func(ctx context.Context, request any) (context.Context, any, bool) {
+		return ctx, request, true
+	}
+	AddCommandHandler[orderedCommand, string](&orderedCommandHandler{}).
+		PreMiddlewareNamed("Auth", passThrough).
+		PreMiddlewareNamed("Validate", passThrough).
+		PostMiddlewareNamed("Log", passThrough)
+
+	ctx, order := RecordExecutionOrder(context.Background())
+	_, err := SendCommand[string](ctx, orderedCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pre:Auth", "pre:Validate", "handler", "post:Log"}, order.Steps())
+
+	// Dispatches without the recording context are not traced.
+	_, err = SendCommand[string](context.Background(), orderedCommand{})
+	assert.NoError(t, err)
+	assert.Len(t, order.Steps(), 4)
+}
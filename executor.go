@@ -0,0 +1,221 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// defaultExecutorQueueSize is the number of dispatches that can wait for a dedicated executor.
+const defaultExecutorQueueSize = 64
+
+type (
+	// ExecutorOption configures a dedicated executor created by WithDedicatedExecutor.
+	ExecutorOption func(*executorConfig)
+
+	executorConfig struct {
+		lockOSThread bool
+		queueSize    int
+	}
+
+	// dedicatedExecutor runs every dispatch of a handler on a single long-lived goroutine.
+	// Dispatches are serialized through the jobs channel in the order they were enqueued.
+	dedicatedExecutor struct {
+		jobs      chan executorJob
+		quit      chan struct{}
+		done      chan struct{}
+		closeOnce sync.Once
+	}
+
+	// executorJob is a single dispatch waiting to run on a dedicated executor.
+	executorJob struct {
+		ctx    context.Context
+		run    func(ctx context.Context) (any, error)
+		result chan executorResult
+	}
+
+	executorResult struct {
+		out any
+		err error
+	}
+)
+
+var (
+	executorMutex sync.RWMutex
+	executors     = make(map[string]*dedicatedExecutor)
+)
+
+// LockOSThread pins the dedicated executor goroutine to its OS thread for its whole lifetime.
+// Use it for handlers wrapping libraries that require all calls from the same OS thread.
+func LockOSThread() ExecutorOption {
+	return func(config *executorConfig) {
+		config.lockOSThread = true
+	}
+}
+
+// ExecutorQueueSize sets how many dispatches can wait for the dedicated executor before enqueueing blocks.
+func ExecutorQueueSize(size int) ExecutorOption {
+	return func(config *executorConfig) {
+		if size >= 0 {
+			config.queueSize = size
+		}
+	}
+}
+
+// WithDedicatedExecutor routes every dispatch of the current handler onto a single long-lived goroutine.
+// Dispatches are executed one at a time in the order they were enqueued. The executor is stopped by Shutdown,
+// after which the dispatches of the handler fail with ErrExecutorClosed until it is configured again.
+func (middlewareBuilder *AddMiddlewareBuilder) WithDedicatedExecutor(opts ...ExecutorOption) *AddMiddlewareBuilder {
+	config := executorConfig{queueSize: defaultExecutorQueueSize}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	handlerName := middlewareBuilder.currentHandler()
+
+	executorMutex.Lock()
+	defer executorMutex.Unlock()
+
+	// Keep the running executor if the handler was already configured, replace a closed one.
+	if executor, ok := executors[handlerName]; !ok || executor.closed() {
+		executors[handlerName] = newDedicatedExecutor(config)
+	}
+	return middlewareBuilder
+}
+
+// executorFor returns the dedicated executor registered for a handler, if any.
+func executorFor(handlerName string) (*dedicatedExecutor, bool) {
+	executorMutex.RLock()
+	defer executorMutex.RUnlock()
+	executor, ok := executors[handlerName]
+	return executor, ok
+}
+
+// closeExecutors stops every dedicated executor and waits until their goroutines exit or ctx is done.
+// The closed executors stay registered, so the dispatches of their handlers fail rather than run inline.
+func closeExecutors(ctx context.Context) error {
+	executorMutex.RLock()
+	closing := make([]*dedicatedExecutor, 0, len(executors))
+	for _, executor := range executors {
+		closing = append(closing, executor)
+	}
+	executorMutex.RUnlock()
+
+	for _, executor := range closing {
+		executor.close()
+	}
+	for _, executor := range closing {
+		select {
+		case <-executor.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// newDedicatedExecutor creates an executor and starts its goroutine.
+func newDedicatedExecutor(config executorConfig) *dedicatedExecutor {
+	executor := &dedicatedExecutor{
+		jobs: make(chan executorJob, config.queueSize),
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go executor.loop(config.lockOSThread)
+	return executor
+}
+
+// loop processes queued jobs until the executor is closed.
+func (executor *dedicatedExecutor) loop(lockOSThread bool) {
+	defer close(executor.done)
+	if lockOSThread {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+	}
+
+	for {
+		select {
+		case <-executor.quit:
+			return
+		case job := <-executor.jobs:
+			executor.execute(job)
+		}
+	}
+}
+
+// execute runs a single job, recovering any panic so the executor survives it.
+func (executor *dedicatedExecutor) execute(job executorJob) {
+	// The caller gave up while the job was waiting in the queue.
+	if err := job.ctx.Err(); err != nil {
+		job.result <- executorResult{err: err}
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Errorf("handler panicked on dedicated executor: %v", r)
+			reportError(job.ctx, err)
+			job.result <- executorResult{err: err}
+		}
+	}()
+
+	out, err := job.run(job.ctx)
+	job.result <- executorResult{out: out, err: err}
+}
+
+// submit enqueues run on the executor and waits for its result.
+// Both enqueueing and waiting honor ctx cancellation.
+func (executor *dedicatedExecutor) submit(ctx context.Context, run func(ctx context.Context) (any, error)) (any, error) {
+	job := executorJob{
+		ctx:    ctx,
+		run:    run,
+		result: make(chan executorResult, 1),
+	}
+
+	select {
+	case <-executor.quit:
+		return nil, ErrExecutorClosed
+	default:
+	}
+
+	select {
+	case executor.jobs <- job:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-executor.quit:
+		return nil, ErrExecutorClosed
+	}
+
+	select {
+	case result := <-job.result:
+		return result.out, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-executor.done:
+		// The job may have completed right before the executor stopped.
+		select {
+		case result := <-job.result:
+			return result.out, result.err
+		default:
+			return nil, ErrExecutorClosed
+		}
+	}
+}
+
+// closed reports whether the executor was stopped.
+func (executor *dedicatedExecutor) closed() bool {
+	select {
+	case <-executor.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// close stops the executor. Jobs still waiting in the queue are abandoned.
+func (executor *dedicatedExecutor) close() {
+	executor.closeOnce.Do(func() {
+		close(executor.quit)
+	})
+}
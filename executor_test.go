@@ -0,0 +1,222 @@
+package gocqrs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type executorCommand struct {
+	Value int
+}
+
+// executorCommandHandler records the goroutine and order of every invocation.
+type executorCommandHandler struct {
+	mutex      sync.Mutex
+	goroutines []uint64
+	values     []int
+	block      chan struct{}
+}
+
+func (h *executorCommandHandler) Handle(ctx context.Context, command executorCommand) (int, error) {
+	h.mutex.Lock()
+	h.goroutines = append(h.goroutines, currentGoroutineID())
+	h.values = append(h.values, command.Value)
+	block := h.block
+	h.mutex.Unlock()
+
+	if block != nil {
+		<-block
+	}
+	if command.Value < 0 {
+		panic("negative value")
+	}
+	return command.Value * 2, nil
+}
+
+// currentGoroutineID parses the id of the calling goroutine from its stack header.
+func currentGoroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	buf = buf[:bytes.IndexByte(buf, ' ')]
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
+
+// TestDedicatedExecutor_SameGoroutine tests that every dispatch runs on the same goroutine.
+func TestDedicatedExecutor_SameGoroutine(t *testing.T) {
+	handler := &executorCommandHandler{}
+	AddCommandHandler[executorCommand, int](handler).WithDedicatedExecutor(LockOSThread())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(value int) {
+			defer wg.Done()
+			response, err := SendCommand[int](context.Background(), executorCommand{Value: value})
+			assert.NoError(t, err)
+			assert.Equal(t, value*2, response)
+		}(i)
+	}
+	wg.Wait()
+
+	handler.mutex.Lock()
+	defer handler.mutex.Unlock()
+	assert.Len(t, handler.goroutines, 20)
+	for _, id := range handler.goroutines {
+		assert.Equal(t, handler.goroutines[0], id, "all dispatches should run on the same goroutine")
+	}
+	assert.NotEqual(t, currentGoroutineID(), handler.goroutines[0])
+}
+
+// TestDedicatedExecutor_QueueOrder tests that dispatches run in the order they were enqueued.
+func TestDedicatedExecutor_QueueOrder(t *testing.T) {
+	handler := &executorCommandHandler{}
+	executor := newDedicatedExecutor(executorConfig{queueSize: 10})
+	defer executor.close()
+
+	results := make([]chan executorResult, 5)
+	for i := range results {
+		results[i] = make(chan executorResult, 1)
+		value := i
+		executor.jobs <- executorJob{
+			ctx: context.Background(),
+			run: func(ctx context.Context) (any, error) {
+				return handler.Handle(ctx, executorCommand{Value: value})
+			},
+			result: results[i],
+		}
+	}
+	for _, result := range results {
+		<-result
+	}
+
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, handler.values)
+}
+
+// TestDedicatedExecutor_CancelWhileEnqueued tests that a dispatch canceled while waiting never runs.
+func TestDedicatedExecutor_CancelWhileEnqueued(t *testing.T) {
+	handler := &executorCommandHandler{block: make(chan struct{})}
+	executor := newDedicatedExecutor(executorConfig{queueSize: 10})
+	defer executor.close()
+
+	run := func(value int) func(ctx context.Context) (any, error) {
+		return func(ctx context.Context) (any, error) {
+			return handler.Handle(ctx, executorCommand{Value: value})
+		}
+	}
+
+	// Occupy the executor.
+	firstDone := make(chan struct{})
+	go func() {
+		defer close(firstDone)
+		_, _ = executor.submit(context.Background(), run(1))
+	}()
+	assert.Eventually(t, func() bool {
+		handler.mutex.Lock()
+		defer handler.mutex.Unlock()
+		return len(handler.values) == 1
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := executor.submit(ctx, run(2))
+		canceled <- err
+	}()
+	cancel()
+	assert.ErrorIs(t, <-canceled, context.Canceled)
+
+	close(handler.block)
+	<-firstDone
+	_, err := executor.submit(context.Background(), run(3))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []int{1, 3}, handler.values)
+}
+
+// TestDedicatedExecutor_SurvivesPanic tests that a panicking dispatch is reported and the executor keeps running.
+func TestDedicatedExecutor_SurvivesPanic(t *testing.T) {
+	var reported []error
+	SetErrorReporter(func(ctx context.Context, err error) {
+		reported = append(reported, err)
+	})
+	defer SetErrorReporter(nil)
+
+	handler := &executorCommandHandler{}
+	executor := newDedicatedExecutor(executorConfig{queueSize: 1})
+	defer executor.close()
+
+	_, err := executor.submit(context.Background(), func(ctx context.Context) (any, error) {
+		return handler.Handle(ctx, executorCommand{Value: -1})
+	})
+	assert.Error(t, err)
+	assert.Len(t, reported, 1)
+
+	out, err := executor.submit(context.Background(), func(ctx context.Context) (any, error) {
+		return handler.Handle(ctx, executorCommand{Value: 4})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, out)
+	assert.Equal(t, handler.goroutines[0], handler.goroutines[1])
+}
+
+// TestDedicatedExecutor_Shutdown tests that Shutdown stops executors and later dispatches are rejected.
+func TestDedicatedExecutor_Shutdown(t *testing.T) {
+	executor := newDedicatedExecutor(executorConfig{queueSize: 1})
+	executorMutex.Lock()
+	executors["shutdownTestHandler"] = executor
+	executorMutex.Unlock()
+	defer func() {
+		executorMutex.Lock()
+		delete(executors, "shutdownTestHandler")
+		executorMutex.Unlock()
+	}()
+
+	assert.NoError(t, Shutdown(context.Background()))
+	<-executor.done
+
+	registered, ok := executorFor("shutdownTestHandler")
+	assert.True(t, ok, "a closed executor stays registered")
+	assert.True(t, registered.closed())
+
+	_, err := executor.submit(context.Background(), func(ctx context.Context) (any, error) {
+		return nil, nil
+	})
+	assert.True(t, errors.Is(err, ErrExecutorClosed))
+}
+
+type shutdownExecutorCommand struct{}
+
+type shutdownExecutorHandler struct{}
+
+func (h *shutdownExecutorHandler) Handle(ctx context.Context, command shutdownExecutorCommand) (string, error) {
+	return "handled", nil
+}
+
+// TestDedicatedExecutor_DispatchAfterShutdown tests that a handler configured with a dedicated executor is not run
+// inline once Shutdown stopped the executor, and runs again once configured again.
+func TestDedicatedExecutor_DispatchAfterShutdown(t *testing.T) {
+	AddCommandHandler[shutdownExecutorCommand, string](&shutdownExecutorHandler{}).WithDedicatedExecutor()
+
+	response, err := SendCommand[string](context.Background(), shutdownExecutorCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, "handled", response)
+
+	assert.NoError(t, Shutdown(context.Background()))
+	_, err = SendCommand[string](context.Background(), shutdownExecutorCommand{})
+	assert.ErrorIs(t, err, ErrExecutorClosed)
+
+	AddCommandHandler[shutdownExecutorCommand, string](&shutdownExecutorHandler{}).WithDedicatedExecutor()
+	response, err = SendCommand[string](context.Background(), shutdownExecutorCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, "handled", response)
+}
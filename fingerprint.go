@@ -0,0 +1,120 @@
+package gocqrs
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// nonIdempotentTag is the cqrs struct tag value excluding a field from request fingerprints.
+const nonIdempotentTag = "nonidem"
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// RequestFingerprint returns a stable content hash of a request, made of its type name and a canonical
+// JSON serialization of its value. Semantically equal requests get the same fingerprint whatever the
+// iteration order of their maps, in any process. Fields tagged `cqrs:"nonidem"`, such as request IDs
+// or timestamps, are excluded so retries of the same request share their fingerprint.
+func RequestFingerprint(request any) (string, error) {
+	if request == nil {
+		return "", fmt.Errorf("cannot fingerprint a nil request")
+	}
+
+	canonical, err := json.Marshal(canonicalValue(reflect.ValueOf(request)))
+	if err != nil {
+		return "", fmt.Errorf("cannot fingerprint %T: %w", request, err)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(reflect.TypeOf(request).String()))
+	hash.Write([]byte{0})
+	hash.Write(canonical)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// canonicalValue converts v into maps, slices and scalars that encoding/json serializes deterministically:
+// structs become maps keyed by their JSON field names, which json.Marshal sorts like any other map key.
+func canonicalValue(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	// Values with their own JSON representation are serialized as they are.
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return canonicalValue(v.Elem())
+	case reflect.Struct:
+		fields := make(map[string]any)
+		canonicalFields(v, fields)
+		return fields
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		entries := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			entries[fmt.Sprint(canonicalValue(iter.Key()))] = canonicalValue(iter.Value())
+		}
+		return entries
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = canonicalValue(v.Index(i))
+		}
+		return items
+	}
+	return v.Interface()
+}
+
+// canonicalFields adds the exported fields of a struct to fields, flattening embedded structs like encoding/json.
+func canonicalFields(v reflect.Value, fields map[string]any) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Tag.Get("cqrs") == nonIdempotentTag {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		value := v.Field(i)
+		if field.Anonymous && name == "" {
+			if value.Kind() == reflect.Pointer {
+				if value.IsNil() {
+					continue
+				}
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				canonicalFields(value, fields)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = canonicalValue(value)
+	}
+}
@@ -0,0 +1,77 @@
+package gocqrs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fingerprintBase struct {
+	Tenant string
+}
+
+type placeOrderCommand struct {
+	fingerprintBase
+	RequestID string            `cqrs:"nonidem"`
+	Customer  string            `json:"customer"`
+	Lines     map[string]int    `json:"lines"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Notes     *string
+	At        time.Time
+	internal  int
+}
+
+// TestRequestFingerprint_Equal tests that semantically equal requests share their fingerprint.
+func TestRequestFingerprint_Equal(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	first := placeOrderCommand{
+		fingerprintBase: fingerprintBase{Tenant: "acme"},
+		RequestID:       "retry-1",
+		Customer:        "alice",
+		Lines:           map[string]int{"apple": 1, "pear": 2, "plum": 3},
+		At:              at,
+		internal:        1,
+	}
+	second := first
+	second.RequestID = "retry-2"
+	second.Lines = map[string]int{"plum": 3, "apple": 1, "pear": 2}
+	second.internal = 2
+
+	firstFingerprint, err := RequestFingerprint(first)
+	assert.NoError(t, err)
+	secondFingerprint, err := RequestFingerprint(&second)
+	assert.NoError(t, err)
+	assert.NotEqual(t, firstFingerprint, secondFingerprint, "pointer and value requests are different request types")
+
+	secondFingerprint, err = RequestFingerprint(second)
+	assert.NoError(t, err)
+	assert.Equal(t, firstFingerprint, secondFingerprint)
+
+	// The fingerprint is stable across runs and processes.
+	assert.Equal(t, "622eea763b49b472d709a0cd2293fb683aefef0fbbf91b9a89bef9e33f4ef168", firstFingerprint)
+}
+
+// TestRequestFingerprint_Different tests that changing an idempotency-relevant field changes the fingerprint.
+func TestRequestFingerprint_Different(t *testing.T) {
+	base := placeOrderCommand{Customer: "alice", Lines: map[string]int{"apple": 1}}
+	baseFingerprint, err := RequestFingerprint(base)
+	assert.NoError(t, err)
+
+	changes := []func(command *placeOrderCommand){
+		func(command *placeOrderCommand) { command.Customer = "bob" },
+		func(command *placeOrderCommand) { command.Lines = map[string]int{"apple": 2} },
+		func(command *placeOrderCommand) { command.Tenant = "globex" },
+		func(command *placeOrderCommand) { notes := "gift"; command.Notes = &notes },
+	}
+	for _, change := range changes {
+		changed := base
+		change(&changed)
+		fingerprint, err := RequestFingerprint(changed)
+		assert.NoError(t, err)
+		assert.NotEqual(t, baseFingerprint, fingerprint)
+	}
+
+	_, err = RequestFingerprint(nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// gatherHandler is a handler registered with AddGatherHandlers.
+type gatherHandler struct {
+	typeName string
+	handler  any // IHandler[TEvent, TResult] of its registration.
+}
+
+var (
+	gatherMutex    sync.RWMutex
+	gatherHandlers = make(map[string][]gatherHandler) // Event and result type names to their handlers.
+)
+
+// AddGatherHandlers registers handlers answering TEvent with a TResult, to be asked all at once with Gather.
+// Registering a handler whose type is already registered for TEvent and TResult is a no-op.
+func AddGatherHandlers[TEvent T, TResult T](handlers ...IHandler[TEvent, TResult]) {
+	key := gatherKey[TEvent, TResult]()
+
+	gatherMutex.Lock()
+	defer gatherMutex.Unlock()
+	registered := gatherHandlers[key]
+	for _, handler := range handlers {
+		typedHandlerName := reflect.TypeOf(handler).String()
+		known := false
+		for _, r := range registered {
+			known = known || r.typeName == typedHandlerName
+		}
+		if !known {
+			registered = append(registered[:len(registered):len(registered)], gatherHandler{typeName: typedHandlerName, handler: handler})
+		}
+	}
+	gatherHandlers[key] = registered
+}
+
+// Gather asks every handler registered with AddGatherHandlers for TEvent and TResult, in registration order,
+// and returns their results, e.g. to find which plugins can handle a file. Zero results, such as nil, are
+// skipped. The errors of the handlers are joined; the results of the other handlers are still returned.
+// Without any registered handler, Gather returns no result.
+func Gather[TEvent T, TResult T](ctx context.Context, event TEvent) ([]TResult, error) {
+	gatherMutex.RLock()
+	registered := gatherHandlers[gatherKey[TEvent, TResult]()]
+	gatherMutex.RUnlock()
+
+	results := make([]TResult, 0, len(registered))
+	var handlerErrors []error
+	for _, r := range registered {
+		result, err := r.handler.(IHandler[TEvent, TResult]).Handle(ctx, event)
+		if err != nil {
+			handlerErrors = append(handlerErrors, err)
+			continue
+		}
+		if !reflect.ValueOf(&result).Elem().IsZero() {
+			results = append(results, result)
+		}
+	}
+	return results, errors.Join(handlerErrors...)
+}
+
+// gatherKey returns the key of the gather handlers answering TEvent with a TResult.
+func gatherKey[TEvent T, TResult T]() string {
+	return typeKey(reflect.TypeOf(new(TEvent)).Elem()) + "->" + typeKey(reflect.TypeOf(new(TResult)).Elem())
+}
@@ -0,0 +1,57 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type canHandleFileEvent struct {
+	Name string
+}
+
+type imagePlugin struct{}
+
+func (p *imagePlugin) Handle(ctx context.Context, event canHandleFileEvent) (string, error) {
+	if strings.HasSuffix(event.Name, ".png") {
+		return "image", nil
+	}
+	return "", nil
+}
+
+type archivePlugin struct{}
+
+func (p *archivePlugin) Handle(ctx context.Context, event canHandleFileEvent) (string, error) {
+	if strings.HasSuffix(event.Name, ".zip") {
+		return "", errors.New("archive plugin is disabled")
+	}
+	return "", nil
+}
+
+type previewPlugin struct{}
+
+func (p *previewPlugin) Handle(ctx context.Context, event canHandleFileEvent) (string, error) {
+	return "preview", nil
+}
+
+// TestGather tests that the non-zero results of every handler are collected along with their errors.
+func TestGather(t *testing.T) {
+	AddGatherHandlers[canHandleFileEvent, string](&imagePlugin{}, &archivePlugin{}, &previewPlugin{})
+	AddGatherHandlers[canHandleFileEvent, string](&imagePlugin{})
+
+	plugins, err := Gather[canHandleFileEvent, string](context.Background(), canHandleFileEvent{Name: "cat.png"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"image", "preview"}, plugins)
+
+	plugins, err = Gather[canHandleFileEvent, string](context.Background(), canHandleFileEvent{Name: "docs.zip"})
+	assert.EqualError(t, err, "archive plugin is disabled")
+	assert.Equal(t, []string{"preview"}, plugins)
+
+	// Handlers answering with another result type are not asked.
+	counts, err := Gather[canHandleFileEvent, int](context.Background(), canHandleFileEvent{Name: "cat.png"})
+	assert.NoError(t, err)
+	assert.Empty(t, counts)
+}
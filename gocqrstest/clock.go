@@ -0,0 +1,119 @@
+// Package gocqrstest provides helpers for testing code built on gocqrs.
+package gocqrstest
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	gocqrs "github.com/victoragudo/go-cqrs"
+)
+
+type (
+	// FakeClock is a gocqrs.Clock whose time only moves when Advance or AdvanceTo is called, so the events
+	// scheduled with gocqrs.ScheduleEvent, and everything else timed with the Clock, fire deterministically.
+	FakeClock struct {
+		mutex  sync.Mutex
+		now    time.Time
+		timers []*fakeTimer
+	}
+
+	// fakeTimer is a call pending on a FakeClock.
+	fakeTimer struct {
+		clock *FakeClock
+		at    time.Time
+		f     func()
+	}
+)
+
+// NewFakeClock returns a FakeClock set to start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// UseFakeClock sets a FakeClock starting at start as the Clock of gocqrs, and restores the system clock once
+// the test ends.
+func UseFakeClock(t testing.TB, start time.Time) *FakeClock {
+	clock := NewFakeClock(start)
+	gocqrs.SetClock(clock)
+	t.Cleanup(func() { gocqrs.SetClock(nil) })
+	return clock
+}
+
+// Now implements gocqrs.Clock.
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// AfterFunc implements gocqrs.Clock. f runs on the goroutine advancing the clock past now plus d.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) gocqrs.Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	timer := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+	return timer
+}
+
+// Pending returns the due times of the calls waiting to run, earliest first.
+func (c *FakeClock) Pending() []time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	pending := make([]time.Time, len(c.timers))
+	for i, timer := range c.timers {
+		pending[i] = timer.at
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Before(pending[j]) })
+	return pending
+}
+
+// Advance moves the time forward by d. See AdvanceTo.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.AdvanceTo(c.Now().Add(d))
+}
+
+// AdvanceTo moves the time forward to t and runs the calls due by then on the calling goroutine, in the order
+// of their due times, before returning. The time is set to the due time of each call while it runs, and calls
+// scheduled by a running call run too if they are due by t. Advance(0) runs the calls already due, such as
+// the event scheduled in the past.
+func (c *FakeClock) AdvanceTo(t time.Time) {
+	for {
+		c.mutex.Lock()
+		next := -1
+		for i, timer := range c.timers {
+			if !timer.at.After(t) && (next < 0 || timer.at.Before(c.timers[next].at)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			if t.After(c.now) {
+				c.now = t
+			}
+			c.mutex.Unlock()
+			return
+		}
+		timer := c.timers[next]
+		c.timers = append(c.timers[:next:next], c.timers[next+1:]...)
+		if timer.at.After(c.now) {
+			c.now = timer.at
+		}
+		c.mutex.Unlock()
+
+		timer.f()
+	}
+}
+
+// Stop implements gocqrs.Timer.
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	for i, timer := range t.clock.timers {
+		if timer == t {
+			t.clock.timers = append(t.clock.timers[:i:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package gocqrstest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	gocqrs "github.com/victoragudo/go-cqrs"
+)
+
+type (
+	orderPlacedEvent   struct{ OrderID string }
+	paymentPaidEvent   struct{ OrderID string }
+	orderExpiredEvent  struct{ OrderID string }
+	expiredOrderRecord struct {
+		mutex   sync.Mutex
+		expired []string
+	}
+)
+
+// orderSaga schedules the expiry of every placed order and cancels it once the order is paid.
+type orderSaga struct {
+	clock *FakeClock
+}
+
+func (s *orderSaga) HandlePlaced(ctx context.Context, event orderPlacedEvent) error {
+	gocqrs.ScheduleEvent(ctx, orderExpiredEvent{OrderID: event.OrderID}, s.clock.Now().Add(time.Hour))
+	return nil
+}
+
+func (s *orderSaga) HandlePaid(ctx context.Context, event paymentPaidEvent) error {
+	gocqrs.CancelScheduledEventsMatching(func(expired orderExpiredEvent) bool { return expired.OrderID == event.OrderID })
+	return nil
+}
+
+func (r *expiredOrderRecord) Handle(ctx context.Context, event orderExpiredEvent) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.expired = append(r.expired, event.OrderID)
+	return nil
+}
+
+// TestFakeClock_ScheduledEvents tests that a saga's scheduled timeout fires once due unless an event cancels it first.
+func TestFakeClock_ScheduledEvents(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	clock := UseFakeClock(t, start)
+
+	saga := &orderSaga{clock: clock}
+	record := &expiredOrderRecord{}
+	assert.NoError(t, gocqrs.AddEventHandlerFunc(saga.HandlePlaced))
+	assert.NoError(t, gocqrs.AddEventHandlerFunc(saga.HandlePaid))
+	assert.NoError(t, gocqrs.AddEventHandlers[orderExpiredEvent](record))
+
+	ctx := context.Background()
+	assert.NoError(t, gocqrs.PublishEvent(ctx, orderPlacedEvent{OrderID: "paid"}))
+	assert.NoError(t, gocqrs.PublishEvent(ctx, orderPlacedEvent{OrderID: "abandoned"}))
+	assert.Equal(t, []time.Time{start.Add(time.Hour), start.Add(time.Hour)}, clock.Pending())
+
+	clock.Advance(30 * time.Minute)
+	assert.NoError(t, gocqrs.PublishEvent(ctx, paymentPaidEvent{OrderID: "paid"}))
+	assert.Len(t, clock.Pending(), 1)
+	assert.Empty(t, record.expired)
+
+	clock.AdvanceTo(start.Add(time.Hour))
+	assert.Equal(t, []string{"abandoned"}, record.expired)
+	assert.Empty(t, clock.Pending())
+	assert.Equal(t, start.Add(time.Hour), clock.Now())
+}
+
+// TestFakeClock_AdvanceTo tests that due calls run in order, including the ones scheduled by a running call.
+func TestFakeClock_AdvanceTo(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	var ran []time.Time
+	clock.AfterFunc(2*time.Second, func() { ran = append(ran, clock.Now()) })
+	clock.AfterFunc(time.Second, func() {
+		ran = append(ran, clock.Now())
+		clock.AfterFunc(500*time.Millisecond, func() { ran = append(ran, clock.Now()) })
+	})
+	stopped := clock.AfterFunc(time.Second, func() { t.Error("a stopped call should not run") })
+	assert.True(t, stopped.Stop())
+
+	clock.Advance(3 * time.Second)
+	assert.Equal(t, []time.Time{start.Add(time.Second), start.Add(1500 * time.Millisecond), start.Add(2 * time.Second)}, ran)
+	assert.Equal(t, start.Add(3*time.Second), clock.Now())
+	assert.False(t, stopped.Stop())
+}
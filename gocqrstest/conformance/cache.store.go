@@ -0,0 +1,153 @@
+// Package conformance exports test suites checking that implementations of the extension points of gocqrs,
+// such as gocqrs.CacheStore, honor their contract. Call them from the tests of an implementation:
+//
+//	func TestRedisCacheStore(t *testing.T) {
+//		conformance.RunCacheStoreTests(t, func() gocqrs.CacheStore { return newRedisCacheStore(t) })
+//	}
+package conformance
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	gocqrs "github.com/victoragudo/go-cqrs"
+)
+
+// RunCacheStoreTests runs the conformance suite of gocqrs.CacheStore, each test against a new, empty store
+// returned by newStore. A store may evict entries, e.g. to bound its size, but must never return an entry
+// other than the last one stored for a key.
+func RunCacheStoreTests(t *testing.T, newStore func() gocqrs.CacheStore) {
+	t.Run("LoadMissing", func(t *testing.T) { testCacheStoreLoadMissing(t, newStore()) })
+	t.Run("StoreLoad", func(t *testing.T) { testCacheStoreStoreLoad(t, newStore()) })
+	t.Run("Replace", func(t *testing.T) { testCacheStoreReplace(t, newStore()) })
+	t.Run("Delete", func(t *testing.T) { testCacheStoreDelete(t, newStore()) })
+	t.Run("Concurrent", func(t *testing.T) { testCacheStoreConcurrent(t, newStore()) })
+	t.Run("Eviction", func(t *testing.T) { testCacheStoreEviction(t, newStore()) })
+}
+
+// testCacheStoreLoadMissing tests that a key never stored has no entry and is not an error.
+func testCacheStoreLoadMissing(t *testing.T, store gocqrs.CacheStore) {
+	_, ok, err := store.Load("missing")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+// testCacheStoreStoreLoad tests that a stored entry is loaded back with its value, version and expiry.
+func testCacheStoreStoreLoad(t *testing.T, store gocqrs.CacheStore) {
+	expiresAt := time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC)
+	assert.NoError(t, store.Store("report", gocqrs.CacheEntry{Value: []byte(`{"total":42}`), Version: 3, ExpiresAt: expiresAt}))
+
+	entry, ok, err := store.Load("report")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assertCacheEntry(t, gocqrs.CacheEntry{Value: []byte(`{"total":42}`), Version: 3, ExpiresAt: expiresAt}, entry)
+
+	// Keys are independent.
+	_, ok, err = store.Load("report#2")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+// testCacheStoreReplace tests that storing a key again replaces its entry.
+func testCacheStoreReplace(t *testing.T, store gocqrs.CacheStore) {
+	expiresAt := time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC)
+	assert.NoError(t, store.Store("report", gocqrs.CacheEntry{Value: []byte(`"first"`), Version: 1, ExpiresAt: expiresAt}))
+	assert.NoError(t, store.Store("report", gocqrs.CacheEntry{Value: []byte(`"second"`), Version: 2, ExpiresAt: expiresAt.Add(time.Hour)}))
+
+	entry, ok, err := store.Load("report")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assertCacheEntry(t, gocqrs.CacheEntry{Value: []byte(`"second"`), Version: 2, ExpiresAt: expiresAt.Add(time.Hour)}, entry)
+}
+
+// testCacheStoreDelete tests that a deleted entry is gone, and that deleting a missing key is not an error.
+func testCacheStoreDelete(t *testing.T, store gocqrs.CacheStore) {
+	assert.NoError(t, store.Store("report", gocqrs.CacheEntry{Value: []byte(`1`)}))
+	assert.NoError(t, store.Store("other", gocqrs.CacheEntry{Value: []byte(`2`)}))
+	assert.NoError(t, store.Delete("report"))
+
+	_, ok, err := store.Load("report")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	entry, ok, err := store.Load("other")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte(`2`), entry.Value)
+
+	assert.NoError(t, store.Delete("report"))
+	assert.NoError(t, store.Delete("missing"))
+}
+
+// testCacheStoreConcurrent tests that concurrent loads, stores and deletes neither fail nor mix up entries.
+func testCacheStoreConcurrent(t *testing.T, store gocqrs.CacheStore) {
+	const workers, rounds = 8, 20
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			key := fmt.Sprintf("worker-%d", worker)
+			for round := 0; round < rounds; round++ {
+				value := []byte(fmt.Sprintf(`"%v-%d"`, key, round))
+				assert.NoError(t, store.Store(key, gocqrs.CacheEntry{Value: value, Version: round}))
+				entry, ok, err := store.Load(key)
+				assert.NoError(t, err)
+				if ok {
+					assertCacheEntry(t, gocqrs.CacheEntry{Value: value, Version: round}, entry)
+				}
+				// The shared key is written by every worker, so it holds the entry of any of them.
+				assert.NoError(t, store.Store("shared", gocqrs.CacheEntry{Value: value}))
+				if round%5 == 0 {
+					assert.NoError(t, store.Delete("shared"))
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	for worker := 0; worker < workers; worker++ {
+		key := fmt.Sprintf("worker-%d", worker)
+		entry, ok, err := store.Load(key)
+		assert.NoError(t, err)
+		if ok {
+			assertCacheEntry(t, gocqrs.CacheEntry{Value: []byte(fmt.Sprintf(`"%v-%d"`, key, rounds-1)), Version: rounds - 1}, entry)
+		}
+	}
+}
+
+// testCacheStoreEviction tests that storing many entries, which may make the store evict some of them, never
+// returns a stale or mixed up entry, and that the most recently stored entry is kept.
+func testCacheStoreEviction(t *testing.T, store gocqrs.CacheStore) {
+	const entries = 200
+
+	value := func(i int) []byte {
+		return []byte(fmt.Sprintf(`{"entry":%d,"padding":"%0100d"}`, i, i))
+	}
+	for i := 0; i < entries; i++ {
+		assert.NoError(t, store.Store(fmt.Sprintf("entry-%d", i), gocqrs.CacheEntry{Value: value(i), Version: i}))
+	}
+
+	for i := 0; i < entries; i++ {
+		entry, ok, err := store.Load(fmt.Sprintf("entry-%d", i))
+		assert.NoError(t, err)
+		if ok {
+			assertCacheEntry(t, gocqrs.CacheEntry{Value: value(i), Version: i}, entry)
+		}
+	}
+	entry, ok, err := store.Load(fmt.Sprintf("entry-%d", entries-1))
+	assert.NoError(t, err)
+	assert.True(t, ok, "the most recently stored entry should be kept")
+	assertCacheEntry(t, gocqrs.CacheEntry{Value: value(entries - 1), Version: entries - 1}, entry)
+}
+
+// assertCacheEntry asserts that entry equals expected, comparing the expiry times as instants.
+func assertCacheEntry(t *testing.T, expected gocqrs.CacheEntry, entry gocqrs.CacheEntry) {
+	t.Helper()
+	assert.Equal(t, string(expected.Value), string(entry.Value))
+	assert.Equal(t, expected.Version, entry.Version)
+	assert.True(t, expected.ExpiresAt.Equal(entry.ExpiresAt), "expected expiry %v, got %v", expected.ExpiresAt, entry.ExpiresAt)
+}
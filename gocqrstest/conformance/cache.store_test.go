@@ -0,0 +1,17 @@
+package conformance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	gocqrs "github.com/victoragudo/go-cqrs"
+)
+
+// TestDiskCacheStore runs the CacheStore suite against a DiskCacheStore, small enough for the eviction test to evict.
+func TestDiskCacheStore(t *testing.T) {
+	RunCacheStoreTests(t, func() gocqrs.CacheStore {
+		store, err := gocqrs.NewDiskCacheStore(t.TempDir(), 4096)
+		assert.NoError(t, err)
+		return store
+	})
+}
@@ -0,0 +1,69 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// eventFuncSequence numbers the event handler functions, so every registration gets its own default name.
+	eventFuncSequence atomic.Uint64
+
+	// funcNames caches the symbol names of function entry points, costly to look up.
+	funcNames sync.Map
+)
+
+// HandlerFunc adapts an ordinary function to the IHandler interface.
+type HandlerFunc[TRequest T, TResponse T] func(ctx context.Context, request TRequest) (TResponse, error)
+
+// Handle calls f(ctx, request).
+func (f HandlerFunc[TRequest, TResponse]) Handle(ctx context.Context, request TRequest) (TResponse, error) {
+	return f(ctx, request)
+}
+
+// EventHandlerFunc adapts an ordinary function to the IEventHandler interface.
+type EventHandlerFunc[TEvent T] func(ctx context.Context, event TEvent) error
+
+// Handle calls f(ctx, event).
+func (f EventHandlerFunc[TEvent]) Handle(ctx context.Context, event TEvent) error {
+	return f(ctx, event)
+}
+
+// AddEventHandlerFunc registers a function, typically a closure capturing its dependencies, as an event handler
+// for TEvent, configured with opts like AddEventHandler.
+// The handler is named after the function's symbol and a registration number, e.g. "orders.init.func1#3", so
+// several closures, even created by the same function literal, are all registered. WithEventHandlerName sets a
+// stable name, under which registering another function is a no-op, and RemoveEventHandlerNamed removes it.
+func AddEventHandlerFunc[TEvent T](fn func(ctx context.Context, event TEvent) error, opts ...EventHandlerOption) error {
+	name := fmt.Sprintf("%v#%d", funcName(fn), eventFuncSequence.Add(1))
+	return addEventHandlerNamed[TEvent](EventHandlerFunc[TEvent](fn), name, opts...)
+}
+
+// AddCommandFunc registers a function as the handler of Command.
+// The handler is named after the function's symbol, e.g. "orders.(*CreateOrderHandler).Handle-fm" for a method value.
+// It is the registration path used by the code generated by cmd/gocqrs-gen.
+func AddCommandFunc[Command T, CommandResponse T](fn func(ctx context.Context, command Command) (CommandResponse, error), opts ...HandlerOption) *AddMiddlewareBuilder {
+	return addRequestNamed[Command, CommandResponse](HandlerFunc[Command, CommandResponse](fn), funcName(fn), opts...)
+}
+
+// AddQueryFunc registers a function as the handler of Query.
+// The handler is named after the function's symbol, e.g. "orders.(*GetOrderHandler).Handle-fm" for a method value.
+// It is the registration path used by the code generated by cmd/gocqrs-gen.
+func AddQueryFunc[Query T, QueryResponse T](fn func(ctx context.Context, query Query) (QueryResponse, error), opts ...HandlerOption) *AddMiddlewareBuilder {
+	return addRequestNamed[Query, QueryResponse](HandlerFunc[Query, QueryResponse](fn), funcName(fn), opts...)
+}
+
+// funcName returns the runtime symbol name of a function value.
+func funcName(fn any) string {
+	pc := reflect.ValueOf(fn).Pointer()
+	if name, ok := funcNames.Load(pc); ok {
+		return name.(string)
+	}
+	name := runtime.FuncForPC(pc).Name()
+	funcNames.Store(pc, name)
+	return name
+}
@@ -0,0 +1,91 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type renameUserCommand struct {
+	Name string
+}
+
+type findUserQuery struct {
+	ID int
+}
+
+type userService struct{}
+
+func (s *userService) Rename(ctx context.Context, command renameUserCommand) (string, error) {
+	return "renamed to " + command.Name, nil
+}
+
+// TestAddCommandFunc tests registering functions and method values as handlers.
+func TestAddCommandFunc(t *testing.T) {
+	var middlewareCalls int
+	service := &userService{}
+	AddCommandFunc[renameUserCommand, string](service.Rename).
+		PreMiddleware(func(ctx context.Context, request any) (context.Context, any, bool) {
+			middlewareCalls++
+			return ctx, request, true
+		})
+	AddQueryFunc[findUserQuery, string](func(ctx context.Context, query findUserQuery) (string, error) {
+		return "user", nil
+	})
+
+	response, err := SendCommand[string](context.Background(), renameUserCommand{Name: "ada"})
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed to ada", response)
+	assert.Equal(t, 1, middlewareCalls)
+
+	response, err = SendQuery[string](context.Background(), findUserQuery{ID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "user", response)
+
+	_, ok := resolveHandlerName("github.com/victoragudo/go-cqrs.(*userService).Rename-fm")
+	assert.True(t, ok, "Function handlers should be named after their symbol")
+}
+
+type shipmentEvent struct {
+	Carrier string
+}
+
+// TestAddEventHandlerFunc tests that closures registered for the same event are delivered according to their filters.
+func TestAddEventHandlerFunc(t *testing.T) {
+	var fedex, ups []string
+	subscribe := func(carrier string, shipped *[]string) {
+		assert.NoError(t, AddEventHandlerFunc(func(ctx context.Context, event shipmentEvent) error {
+			*shipped = append(*shipped, event.Carrier)
+			return nil
+		}, WithEventFilter(func(event any) bool {
+			return event.(shipmentEvent).Carrier == carrier
+		})))
+	}
+	// Both closures come from the same function literal and must not be deduplicated.
+	subscribe("fedex", &fedex)
+	subscribe("ups", &ups)
+
+	assert.NoError(t, PublishEvent(context.Background(), shipmentEvent{Carrier: "fedex"}))
+	assert.NoError(t, PublishEvent(context.Background(), shipmentEvent{Carrier: "ups"}))
+	assert.NoError(t, PublishEvent(context.Background(), shipmentEvent{Carrier: "dhl"}))
+
+	assert.Equal(t, []string{"fedex"}, fedex)
+	assert.Equal(t, []string{"ups"}, ups)
+}
+
+type invoiceSentEvent struct{}
+
+// TestAddEventHandlerFunc_Name tests that a function registered under an existing name is not registered again.
+func TestAddEventHandlerFunc_Name(t *testing.T) {
+	var calls int
+	handle := func(ctx context.Context, event invoiceSentEvent) error {
+		calls++
+		return nil
+	}
+	assert.NoError(t, AddEventHandlerFunc(handle, WithEventHandlerName("invoice-notifier")))
+	assert.NoError(t, AddEventHandlerFunc(handle, WithEventHandlerName("invoice-notifier")))
+
+	assert.NoError(t, PublishEvent(context.Background(), invoiceSentEvent{}))
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,48 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+type handlerRunHooks struct {
+	before func(ctx context.Context, requestType string)
+	after  func(ctx context.Context, requestType string, err error)
+}
+
+var (
+	runHooksMutex sync.RWMutex
+	runHooks      handlerRunHooks
+)
+
+// SetHandlerRunHooks sets functions called immediately before and after every request handler runs.
+// The hooks are given the type key of the request, as normalized by SetTypeKeyNormalizer.
+// Unlike middlewares, both are called synchronously on the goroutine running the handler,
+// including the dedicated goroutine of WithDedicatedExecutor, so they can set up and tear down goroutine-local state.
+// A nil function removes the corresponding hook.
+func SetHandlerRunHooks(before func(ctx context.Context, requestType string), after func(ctx context.Context, requestType string, err error)) {
+	runHooksMutex.Lock()
+	defer runHooksMutex.Unlock()
+	runHooks = handlerRunHooks{before: before, after: after}
+}
+
+// runWithHooks runs the handler on the calling goroutine between the handler run hooks.
+func runWithHooks[Response T](ctx context.Context, handler IHandler[T, Response], in any) (response Response, err error) {
+	runHooksMutex.RLock()
+	hooks := runHooks
+	runHooksMutex.RUnlock()
+
+	if hooks.before == nil && hooks.after == nil {
+		return handler.Handle(ctx, in)
+	}
+
+	requestType := typeKey(reflect.TypeOf(in))
+	if hooks.before != nil {
+		hooks.before(ctx, requestType)
+	}
+	if hooks.after != nil {
+		defer func() { hooks.after(ctx, requestType, err) }()
+	}
+	return handler.Handle(ctx, in)
+}
@@ -0,0 +1,129 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hookedCommand struct {
+	Fail bool
+}
+
+type hookedCommandHandler struct {
+	goroutine uint64
+}
+
+func (h *hookedCommandHandler) Handle(ctx context.Context, command hookedCommand) (string, error) {
+	h.goroutine = currentGoroutineID()
+	if command.Fail {
+		return "", errors.New("hooked failure")
+	}
+	return "done", nil
+}
+
+// TestHandlerRunHooks_SameGoroutine tests that the hooks run around the handler on its goroutine.
+func TestHandlerRunHooks_SameGoroutine(t *testing.T) {
+	handler := &hookedCommandHandler{}
+	AddCommandHandler[hookedCommand, string](handler).WithDedicatedExecutor()
+
+	var (
+		mutex  sync.Mutex
+		calls  []string
+		before uint64
+		after  uint64
+		runErr error
+	)
+	SetHandlerRunHooks(
+		func(ctx context.Context, requestType string) {
+			if requestType != "gocqrs.hookedCommand" {
+				return
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			calls = append(calls, "before")
+			before = currentGoroutineID()
+		},
+		func(ctx context.Context, requestType string, err error) {
+			if requestType != "gocqrs.hookedCommand" {
+				return
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			calls = append(calls, "after")
+			after = currentGoroutineID()
+			runErr = err
+		},
+	)
+	defer SetHandlerRunHooks(nil, nil)
+
+	response, err := SendCommand[string](context.Background(), hookedCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, "done", response)
+
+	mutex.Lock()
+	assert.Equal(t, []string{"before", "after"}, calls)
+	assert.Equal(t, handler.goroutine, before)
+	assert.Equal(t, handler.goroutine, after)
+	assert.NotEqual(t, currentGoroutineID(), before)
+	mutex.Unlock()
+
+	_, err = SendCommand[string](context.Background(), hookedCommand{Fail: true})
+	assert.Error(t, err)
+
+	mutex.Lock()
+	assert.EqualError(t, runErr, "hooked failure")
+	mutex.Unlock()
+}
+
+type (
+	hookedRename struct{ Name string }
+	// HookedRename stands for the same request declared by another module.
+	HookedRename struct{ Name string }
+)
+
+type hookedRenameHandler struct{}
+
+func (h *hookedRenameHandler) Handle(ctx context.Context, command hookedRename) (string, error) {
+	return "renamed " + command.Name, nil
+}
+
+// TestHandlerRunHooks_TypeKeyNormalizer tests that the hooks are given the normalized type key of the request.
+func TestHandlerRunHooks_TypeKeyNormalizer(t *testing.T) {
+	SetTypeKeyNormalizer(func(typeName string) string {
+		return strings.ToLower(typeName[strings.LastIndex(typeName, ".")+1:])
+	})
+	defer SetTypeKeyNormalizer(nil)
+	AddCommandHandler[hookedRename, string](&hookedRenameHandler{})
+
+	var (
+		mutex sync.Mutex
+		seen  []string
+	)
+	record := func(requestType string) {
+		if requestType != "hookedrename" {
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		seen = append(seen, requestType)
+	}
+	SetHandlerRunHooks(
+		func(ctx context.Context, requestType string) { record(requestType) },
+		func(ctx context.Context, requestType string, err error) { record(requestType) },
+	)
+	defer SetHandlerRunHooks(nil, nil)
+
+	_, err := SendCommand[string](context.Background(), hookedRename{Name: "ada"})
+	assert.NoError(t, err)
+	_, err = SendCommand[string](context.Background(), HookedRename{Name: "grace"})
+	assert.NoError(t, err)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Len(t, seen, 4)
+}
@@ -0,0 +1,89 @@
+package gocqrs
+
+import (
+	"reflect"
+	"sync"
+)
+
+type (
+	// HandlerOption configures a command or query handler at registration.
+	HandlerOption func(*handlerConfig)
+
+	// handlerConfig holds the options of a command or query handler registration.
+	handlerConfig struct {
+		meta        *HandlerMeta
+		compensable bool
+	}
+
+	// HandlerMeta is descriptive information about a handler, meant for catalogs, docs and dashboards.
+	HandlerMeta struct {
+		Summary    string   // Short description of what the handler does.
+		Version    string   // Version of the request contract.
+		Deprecated bool     // Whether callers should stop sending the request.
+		Tags       []string // Free-form labels used to group handlers, the same as the tags set with Tags.
+	}
+)
+
+var (
+	handlerMetaMutex sync.RWMutex
+	handlerMetas     = make(map[string]HandlerMeta) // Request type name to the metadata of its handler, without its tags.
+)
+
+// WithMeta attaches descriptive metadata to the handler, retrievable with HandlerMetadata.
+// The tags of meta label the handler like Tags.
+func WithMeta(meta HandlerMeta) HandlerOption {
+	return func(config *handlerConfig) {
+		config.meta = &meta
+	}
+}
+
+// HandlerMetadata returns the metadata attached to the handler of the request type of requestType,
+// which is a value of the request type. It returns false if no metadata was attached.
+func HandlerMetadata(requestType any) (HandlerMeta, bool) {
+	typed := typeKey(reflect.TypeOf(requestType))
+	value, ok := getMapValue(handlers, typed, &handlerMutex)
+	if !ok {
+		return HandlerMeta{}, false
+	}
+	nameField, ok := getField(value, "Name")
+	if !ok {
+		return HandlerMeta{}, false
+	}
+	return handlerMeta(typed, nameField.String())
+}
+
+// handlerMeta returns the metadata of handlerName, the handler of the request type typed, with its tags.
+func handlerMeta(typed, handlerName string) (HandlerMeta, bool) {
+	handlerMetaMutex.RLock()
+	meta, ok := handlerMetas[typed]
+	handlerMetaMutex.RUnlock()
+	if ok {
+		meta.Tags = append([]string(nil), tagsOf(handlerName)...)
+	}
+	return meta, ok
+}
+
+// applyHandlerOptions applies the registration options of handlerName, the handler of a request type.
+func applyHandlerOptions(typed, handlerName string, opts []HandlerOption) {
+	config := handlerConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	markCompensable(typed, config.compensable)
+
+	if config.meta != nil {
+		// The tags of the handler are its only record of tags.
+		tagHandler(handlerName, config.meta.Tags)
+	}
+
+	handlerMetaMutex.Lock()
+	defer handlerMetaMutex.Unlock()
+	if config.meta != nil {
+		meta := *config.meta
+		meta.Tags = nil
+		handlerMetas[typed] = meta
+	} else {
+		// Registering a new handler for the type drops the metadata of the previous one.
+		delete(handlerMetas, typed)
+	}
+}
@@ -0,0 +1,44 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type issueRefundCommand struct{}
+
+type issueRefundCommandHandler struct{}
+
+func (h *issueRefundCommandHandler) Handle(ctx context.Context, command issueRefundCommand) (bool, error) {
+	return true, nil
+}
+
+// TestHandlerMetadata tests that metadata attached at registration can be read back.
+func TestHandlerMetadata(t *testing.T) {
+	meta := HandlerMeta{
+		Summary:    "Refunds a payment",
+		Version:    "v2",
+		Deprecated: true,
+		Tags:       []string{"billing"},
+	}
+	AddCommandHandler[issueRefundCommand, bool](&issueRefundCommandHandler{}, WithMeta(meta))
+
+	got, ok := HandlerMetadata(issueRefundCommand{})
+	assert.True(t, ok)
+	assert.Equal(t, meta, got)
+
+	// The returned tags are a copy.
+	got.Tags[0] = "changed"
+	got, _ = HandlerMetadata(issueRefundCommand{})
+	assert.Equal(t, []string{"billing"}, got.Tags)
+
+	_, ok = HandlerMetadata(struct{ Unknown bool }{})
+	assert.False(t, ok)
+
+	// Registering the handler again without metadata drops it.
+	AddCommandHandler[issueRefundCommand, bool](&issueRefundCommandHandler{})
+	_, ok = HandlerMetadata(issueRefundCommand{})
+	assert.False(t, ok)
+}
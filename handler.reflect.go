@@ -0,0 +1,94 @@
+package gocqrs
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// handlerReflection is the reflect metadata of a handler wrapper needed to dispatch a request to it.
+type handlerReflection struct {
+	handler      reflect.Value // The dynamic value of the Handler field.
+	handleMethod reflect.Value // The Handle method bound to the handler.
+	name         string        // The value of the Name field.
+	requestType  reflect.Type  // The request argument of Handle.
+	responseType reflect.Type  // The first result of Handle.
+}
+
+// handlerReflections caches the metadata of the registered handler wrappers, keyed by wrapper.
+// Overriding and resolved handlers are built per dispatch, so they are not cached.
+var handlerReflections sync.Map
+
+// WarmUp computes the cached reflect metadata of every registered command and query handler,
+// so the first dispatch of each request type does not pay for it.
+// It is idempotent and meant to be called once all the handlers are registered;
+// handlers registered afterwards are cached on their first dispatch.
+func WarmUp() error {
+	handlerMutex.RLock()
+	wrappers := make([]any, 0, len(handlers))
+	for _, wrapper := range handlers {
+		wrappers = append(wrappers, wrapper)
+	}
+	handlerMutex.RUnlock()
+
+	for _, wrapper := range wrappers {
+		if _, err := cachedHandlerReflection(wrapper); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cachedHandlerReflection returns the metadata of a registered handler wrapper, computing it on first use.
+func cachedHandlerReflection(wrapper any) (*handlerReflection, error) {
+	if cached, ok := handlerReflections.Load(wrapper); ok {
+		return cached.(*handlerReflection), nil
+	}
+	reflection, err := reflectHandler(wrapper)
+	if err != nil {
+		return nil, err
+	}
+	cached, _ := handlerReflections.LoadOrStore(wrapper, reflection)
+	return cached.(*handlerReflection), nil
+}
+
+// forgetHandlerReflection drops the cached metadata of a handler wrapper that is no longer registered.
+func forgetHandlerReflection(wrapper any) {
+	handlerReflections.Delete(wrapper)
+}
+
+// reflectHandler computes the metadata of a handler wrapper.
+// A wrapper without a Handler field holding a Handle method, or without a Name field, is a broken invariant.
+func reflectHandler(wrapper any) (*handlerReflection, error) {
+	handlerField, ok := getField(wrapper, "Handler")
+	if !ok {
+		return nil, brokenInvariant("handler_field", fmt.Errorf("no Handler field found for: %v", wrapper))
+	}
+	// Use the dynamic type of the handler, as resolved handlers are held as any.
+	if handlerField.Kind() == reflect.Interface && !handlerField.IsNil() {
+		handlerField = handlerField.Elem()
+	}
+
+	handleMethod, ok := getMethodByName(handlerField, "Handle")
+	if !ok {
+		return nil, brokenInvariant("handle_method", fmt.Errorf("no Handle method found for: %v", handlerField))
+	}
+
+	handlerNameField, ok := getField(wrapper, "Name")
+	if !ok {
+		return nil, brokenInvariant("name_field", fmt.Errorf("no Handler name field found for: %v", wrapper))
+	}
+
+	reflection := &handlerReflection{
+		handler:      handlerField,
+		handleMethod: handleMethod,
+		name:         (handlerNameField.Interface()).(string),
+	}
+	if methodType := handleMethod.Type(); methodType.NumIn() > 1 {
+		reflection.requestType = methodType.In(1)
+	}
+	if methodType := handleMethod.Type(); methodType.NumOut() > 0 {
+		reflection.responseType = methodType.Out(0)
+	}
+	return reflection, nil
+}
@@ -0,0 +1,48 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type warmUpQuery struct{}
+
+type warmUpQueryHandler struct{}
+
+func (h *warmUpQueryHandler) Handle(ctx context.Context, query warmUpQuery) (int, error) {
+	return 42, nil
+}
+
+// TestWarmUp tests that WarmUp caches the reflect metadata of the registered handlers, which dispatches then use.
+func TestWarmUp(t *testing.T) {
+	AddQueryHandler[warmUpQuery, int](&warmUpQueryHandler{})
+	wrapper, ok := getMapValue(handlers, "gocqrs.warmUpQuery", &handlerMutex)
+	assert.True(t, ok)
+
+	assert.NoError(t, WarmUp())
+	cached, ok := handlerReflections.Load(wrapper)
+	if assert.True(t, ok) {
+		reflection := cached.(*handlerReflection)
+		assert.Equal(t, "*gocqrs.warmUpQueryHandler", reflection.name)
+		assert.True(t, reflection.handleMethod.IsValid())
+		assert.Equal(t, reflect.TypeOf(warmUpQuery{}), reflection.requestType)
+		assert.Equal(t, reflect.TypeOf(0), reflection.responseType)
+	}
+
+	// Calling it again keeps the cached metadata.
+	assert.NoError(t, WarmUp())
+	again, _ := handlerReflections.Load(wrapper)
+	assert.Same(t, cached, again)
+
+	response, err := SendQuery[int](context.Background(), warmUpQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, response)
+
+	// Registering another handler forgets the metadata of the replaced one.
+	AddQueryHandler[warmUpQuery, int](&warmUpQueryHandler{})
+	_, ok = handlerReflections.Load(wrapper)
+	assert.False(t, ok)
+}
@@ -0,0 +1,97 @@
+package gocqrs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// DispatchInfo describes a command or query being dispatched.
+	DispatchInfo struct {
+		ID          uint64    // Identifier to pass to CancelDispatch.
+		RequestType string    // Type name of the request.
+		HandlerName string    // Name of the handler processing the request.
+		Tags        []string  // Tags of the handler, set with Tags.
+		StartedAt   time.Time // Moment the dispatch started.
+	}
+
+	// inFlightDispatch is a running dispatch and the function canceling its context.
+	inFlightDispatch struct {
+		info   DispatchInfo
+		cancel context.CancelCauseFunc
+	}
+)
+
+// dispatchIDKey is the context key of the id of the current dispatch.
+type dispatchIDKey struct{}
+
+var (
+	inFlightMutex  sync.RWMutex
+	inFlight       = make(map[uint64]inFlightDispatch)
+	lastDispatchID atomic.Uint64
+)
+
+// InFlight returns the dispatches currently running, oldest first.
+func InFlight() []DispatchInfo {
+	inFlightMutex.RLock()
+	defer inFlightMutex.RUnlock()
+
+	dispatches := make([]DispatchInfo, 0, len(inFlight))
+	for _, dispatch := range inFlight {
+		dispatches = append(dispatches, dispatch.info)
+	}
+	sort.Slice(dispatches, func(i, j int) bool {
+		return dispatches[i].ID < dispatches[j].ID
+	})
+	return dispatches
+}
+
+// dispatchInfo returns the description of a running dispatch.
+func dispatchInfo(id uint64) (DispatchInfo, bool) {
+	inFlightMutex.RLock()
+	defer inFlightMutex.RUnlock()
+	dispatch, ok := inFlight[id]
+	return dispatch.info, ok
+}
+
+// CancelDispatch cancels the context of a running dispatch. The dispatch fails with a *CanceledError
+// whose reason is ReasonManualCancel if it returns the context error.
+// It returns false if no dispatch with that id is running.
+func CancelDispatch(id uint64) bool {
+	inFlightMutex.RLock()
+	dispatch, ok := inFlight[id]
+	inFlightMutex.RUnlock()
+
+	if ok {
+		dispatch.cancel(causeManualCancel)
+	}
+	return ok
+}
+
+// trackDispatch registers a dispatch as in flight and derives a cancelable context carrying its id.
+// The returned function must be called once the dispatch completes.
+func trackDispatch(ctx context.Context, requestType, handlerName string) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	info := DispatchInfo{
+		ID:          lastDispatchID.Add(1),
+		RequestType: requestType,
+		HandlerName: handlerName,
+		Tags:        tagsOf(handlerName),
+		StartedAt:   now(),
+	}
+	ctx = context.WithValue(ctx, dispatchIDKey{}, info.ID)
+
+	inFlightMutex.Lock()
+	inFlight[info.ID] = inFlightDispatch{info: info, cancel: cancel}
+	inFlightMutex.Unlock()
+
+	return ctx, func() {
+		inFlightMutex.Lock()
+		delete(inFlight, info.ID)
+		inFlightMutex.Unlock()
+		cancel(nil)
+	}
+}
@@ -0,0 +1,58 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type slowReportQuery struct{}
+
+// slowReportQueryHandler blocks until its context is canceled.
+type slowReportQueryHandler struct {
+	started chan struct{}
+}
+
+func (h *slowReportQueryHandler) Handle(ctx context.Context, query slowReportQuery) (string, error) {
+	close(h.started)
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+// TestCancelDispatch tests listing a running dispatch and canceling it.
+func TestCancelDispatch(t *testing.T) {
+	handler := &slowReportQueryHandler{started: make(chan struct{})}
+	AddQueryHandler[slowReportQuery, string](handler)
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := SendQuery[string](context.Background(), slowReportQuery{})
+		result <- err
+	}()
+	<-handler.started
+
+	var dispatch DispatchInfo
+	for _, info := range InFlight() {
+		if info.RequestType == "gocqrs.slowReportQuery" {
+			dispatch = info
+		}
+	}
+	assert.NotZero(t, dispatch.ID, "The running dispatch should be listed")
+	assert.Equal(t, "*gocqrs.slowReportQueryHandler", dispatch.HandlerName)
+	assert.False(t, dispatch.StartedAt.IsZero())
+
+	assert.True(t, CancelDispatch(dispatch.ID))
+	select {
+	case err := <-result:
+		assert.ErrorIs(t, err, context.Canceled, "The handler's context should be canceled")
+	case <-time.After(time.Second):
+		t.Fatal("The dispatch was not canceled")
+	}
+
+	for _, info := range InFlight() {
+		assert.NotEqual(t, dispatch.ID, info.ID, "Completed dispatches should be removed")
+	}
+	assert.False(t, CancelDispatch(dispatch.ID))
+}
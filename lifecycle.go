@@ -0,0 +1,216 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type (
+	// IStartable is an optional interface for command and query handlers that must be started before serving,
+	// e.g. to warm a projection. Handlers are started by StartAll.
+	IStartable interface {
+		Start(ctx context.Context) error
+	}
+
+	// IStoppable is an optional interface for command and query handlers holding resources released by Shutdown.
+	IStoppable interface {
+		Stop(ctx context.Context) error
+	}
+)
+
+var (
+	dependencyMutex     sync.RWMutex
+	handlerDependencies = make(map[string][]string) // Handler name to the names of the handlers it depends on.
+
+	startedMutex  sync.Mutex
+	startedLevels [][]string // Levels of handlers started by StartAll, in start order.
+)
+
+// DependsOn declares that the current handler must be started after the given handlers, referenced by the
+// name set with Named or by their handler type name. Shutdown stops handlers in the reverse order.
+func (middlewareBuilder *AddMiddlewareBuilder) DependsOn(handlerNames ...string) *AddMiddlewareBuilder {
+	handlerName := middlewareBuilder.currentHandler()
+
+	dependencyMutex.Lock()
+	defer dependencyMutex.Unlock()
+	dependencies := handlerDependencies[handlerName]
+	handlerDependencies[handlerName] = append(dependencies[:len(dependencies):len(dependencies)], handlerNames...)
+	return middlewareBuilder
+}
+
+// StartOrder returns the order in which StartAll starts the registered command and query handlers, as levels:
+// every handler of a level only depends on handlers of previous levels, so the handlers of a level start in parallel.
+// It returns ErrDependencyCycle listing the cycle if the dependencies are circular, and ErrUnknownHandler if a
+// dependency does not match any registered handler.
+func StartOrder() ([][]string, error) {
+	graph, err := dependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	// Kahn's algorithm, one level at a time.
+	remaining := make(map[string]int, len(graph))
+	dependents := make(map[string][]string)
+	for handlerName, dependencies := range graph {
+		remaining[handlerName] = len(dependencies)
+		for _, dependency := range dependencies {
+			dependents[dependency] = append(dependents[dependency], handlerName)
+		}
+	}
+
+	levels := make([][]string, 0)
+	for len(remaining) > 0 {
+		level := make([]string, 0)
+		for handlerName, count := range remaining {
+			if count == 0 {
+				level = append(level, handlerName)
+			}
+		}
+		if len(level) == 0 {
+			return nil, fmt.Errorf("%w: %v", ErrDependencyCycle, strings.Join(findCycle(graph, remaining), " -> "))
+		}
+		sort.Strings(level)
+		for _, handlerName := range level {
+			delete(remaining, handlerName)
+			for _, dependent := range dependents[handlerName] {
+				remaining[dependent]--
+			}
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// StartAll starts every registered handler implementing IStartable in dependency order.
+// Handlers of the same level start in parallel; StartAll stops at the first level with a failing handler
+// and returns the errors of that level. Handlers already started are stopped by Shutdown.
+func StartAll(ctx context.Context) error {
+	levels, err := StartOrder()
+	if err != nil {
+		return err
+	}
+	instances := handlerInstances()
+
+	for _, level := range levels {
+		levelErrors := make([]error, len(level))
+		var wg sync.WaitGroup
+		for i, handlerName := range level {
+			startable, ok := instances[handlerName].(IStartable)
+			if !ok {
+				continue
+			}
+			wg.Add(1)
+			go func(i int, handlerName string, startable IStartable) {
+				defer wg.Done()
+				if err := startable.Start(ctx); err != nil {
+					levelErrors[i] = fmt.Errorf("starting %v: %w", handlerName, err)
+				}
+			}(i, handlerName, startable)
+		}
+		wg.Wait()
+
+		startedMutex.Lock()
+		startedLevels = append(startedLevels, level)
+		startedMutex.Unlock()
+
+		if err := errors.Join(levelErrors...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopHandlers stops the handlers started by StartAll that implement IStoppable, in reverse start order.
+func stopHandlers(ctx context.Context) error {
+	startedMutex.Lock()
+	levels := startedLevels
+	startedLevels = nil
+	startedMutex.Unlock()
+
+	instances := handlerInstances()
+	stopErrors := make([]error, 0)
+	for i := len(levels) - 1; i >= 0; i-- {
+		for j := len(levels[i]) - 1; j >= 0; j-- {
+			handlerName := levels[i][j]
+			if stoppable, ok := instances[handlerName].(IStoppable); ok {
+				if err := stoppable.Stop(ctx); err != nil {
+					stopErrors = append(stopErrors, fmt.Errorf("stopping %v: %w", handlerName, err))
+				}
+			}
+		}
+	}
+	return errors.Join(stopErrors...)
+}
+
+// dependencyGraph returns the resolved dependencies of every registered command and query handler.
+func dependencyGraph() (map[string][]string, error) {
+	graph := make(map[string][]string)
+	for handlerName := range handlerInstances() {
+		graph[handlerName] = nil
+	}
+
+	dependencyMutex.RLock()
+	defer dependencyMutex.RUnlock()
+
+	unknown := make([]error, 0)
+	for handlerName, dependencies := range handlerDependencies {
+		if _, ok := graph[handlerName]; !ok {
+			continue
+		}
+		for _, dependency := range dependencies {
+			resolved, ok := resolveHandlerName(dependency)
+			if !ok {
+				unknown = append(unknown, fmt.Errorf("%w: %v, a dependency of %v", ErrUnknownHandler, dependency, handlerName))
+				continue
+			}
+			graph[handlerName] = append(graph[handlerName], resolved)
+		}
+	}
+	return graph, errors.Join(unknown...)
+}
+
+// findCycle returns a dependency cycle among the handlers left in remaining, starting and ending with the same handler.
+func findCycle(graph map[string][]string, remaining map[string]int) []string {
+	names := make([]string, 0, len(remaining))
+	for handlerName := range remaining {
+		names = append(names, handlerName)
+	}
+	sort.Strings(names)
+
+	// Every handler left depends on another handler left, so following dependencies always closes a cycle.
+	position := make(map[string]int)
+	path := make([]string, 0)
+	for current := names[0]; ; {
+		if i, seen := position[current]; seen {
+			return append(path[i:], current)
+		}
+		position[current] = len(path)
+		path = append(path, current)
+		for _, dependency := range graph[current] {
+			if _, ok := remaining[dependency]; ok {
+				current = dependency
+				break
+			}
+		}
+	}
+}
+
+// handlerInstances returns the registered command and query handlers keyed by handler name.
+func handlerInstances() map[string]any {
+	handlerMutex.RLock()
+	defer handlerMutex.RUnlock()
+
+	instances := make(map[string]any, len(handlers))
+	for _, value := range handlers {
+		nameField, nameOk := getField(value, "Name")
+		handlerField, handlerOk := getField(value, "Handler")
+		if nameOk && handlerOk {
+			instances[nameField.String()] = handlerInstance(handlerField.Interface())
+		}
+	}
+	return instances
+}
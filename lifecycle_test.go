@@ -0,0 +1,138 @@
+package gocqrs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lifecycleLog records the start and stop of lifecycle handlers.
+type lifecycleLog struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (l *lifecycleLog) record(event string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.events = append(l.events, event)
+}
+
+type lifecycleQuery[TTag T] struct{}
+
+// lifecycleHandler is a startable and stoppable handler; its tag only makes every instantiation a distinct handler.
+type lifecycleHandler[TTag T] struct {
+	name    string
+	log     *lifecycleLog
+	barrier *sync.WaitGroup // When set, Start waits until every handler sharing it has started.
+}
+
+func (h *lifecycleHandler[TTag]) Handle(ctx context.Context, query lifecycleQuery[TTag]) (string, error) {
+	return h.name, nil
+}
+
+func (h *lifecycleHandler[TTag]) Start(ctx context.Context) error {
+	h.log.record("start:" + h.name)
+	if h.barrier != nil {
+		h.barrier.Done()
+		waited := make(chan struct{})
+		go func() {
+			h.barrier.Wait()
+			close(waited)
+		}()
+		select {
+		case <-waited:
+		case <-time.After(time.Second):
+			h.log.record("timeout:" + h.name)
+		}
+	}
+	return nil
+}
+
+func (h *lifecycleHandler[TTag]) Stop(ctx context.Context) error {
+	h.log.record("stop:" + h.name)
+	return nil
+}
+
+type (
+	ordersStore      struct{}
+	ordersProjection struct{}
+	ordersSearch     struct{}
+	ordersQuery      struct{}
+	cycleA           struct{}
+	cycleB           struct{}
+)
+
+// TestStartAll_Diamond tests that a diamond dependency graph starts in levels and stops in reverse order.
+func TestStartAll_Diamond(t *testing.T) {
+	log := &lifecycleLog{}
+	barrier := &sync.WaitGroup{}
+	barrier.Add(2)
+
+	AddQueryHandler[lifecycleQuery[ordersStore], string](&lifecycleHandler[ordersStore]{name: "store", log: log}).
+		Named("orders.store")
+	AddQueryHandler[lifecycleQuery[ordersProjection], string](&lifecycleHandler[ordersProjection]{name: "projection", log: log, barrier: barrier}).
+		Named("orders.projection").
+		DependsOn("orders.store")
+	AddQueryHandler[lifecycleQuery[ordersSearch], string](&lifecycleHandler[ordersSearch]{name: "search", log: log, barrier: barrier}).
+		Named("orders.search").
+		DependsOn("orders.store")
+	AddQueryHandler[lifecycleQuery[ordersQuery], string](&lifecycleHandler[ordersQuery]{name: "query", log: log}).
+		DependsOn("orders.projection", "orders.search")
+
+	levels, err := StartOrder()
+	assert.NoError(t, err)
+	position := make(map[string]int)
+	for i, level := range levels {
+		for _, handlerName := range level {
+			position[handlerName] = i
+		}
+	}
+	store := position["*gocqrs.lifecycleHandler[github.com/victoragudo/go-cqrs.ordersStore]"]
+	projection := position["*gocqrs.lifecycleHandler[github.com/victoragudo/go-cqrs.ordersProjection]"]
+	search := position["*gocqrs.lifecycleHandler[github.com/victoragudo/go-cqrs.ordersSearch]"]
+	query := position["*gocqrs.lifecycleHandler[github.com/victoragudo/go-cqrs.ordersQuery]"]
+	assert.Less(t, store, projection)
+	assert.Equal(t, projection, search, "independent handlers should share a level")
+	assert.Less(t, search, query)
+
+	assert.NoError(t, StartAll(context.Background()))
+	assert.NoError(t, Shutdown(context.Background()))
+
+	assert.NotContains(t, log.events, "timeout:projection", "handlers of a level should start in parallel")
+	assert.Equal(t, "start:store", log.events[0])
+	assert.ElementsMatch(t, []string{"start:projection", "start:search"}, log.events[1:3])
+	assert.Equal(t, []string{"start:query", "stop:query"}, log.events[3:5])
+	assert.ElementsMatch(t, []string{"stop:projection", "stop:search"}, log.events[5:7])
+	assert.Equal(t, "stop:store", log.events[7])
+}
+
+// TestStartOrder_Cycle tests that circular dependencies are reported with the cycle.
+func TestStartOrder_Cycle(t *testing.T) {
+	log := &lifecycleLog{}
+	AddQueryHandler[lifecycleQuery[cycleA], string](&lifecycleHandler[cycleA]{name: "a", log: log}).
+		Named("cycle.a").
+		DependsOn("cycle.b")
+	AddQueryHandler[lifecycleQuery[cycleB], string](&lifecycleHandler[cycleB]{name: "b", log: log}).
+		Named("cycle.b").
+		DependsOn("cycle.a")
+	defer func() {
+		dependencyMutex.Lock()
+		delete(handlerDependencies, "*gocqrs.lifecycleHandler[github.com/victoragudo/go-cqrs.cycleA]")
+		delete(handlerDependencies, "*gocqrs.lifecycleHandler[github.com/victoragudo/go-cqrs.cycleB]")
+		dependencyMutex.Unlock()
+	}()
+
+	_, err := StartOrder()
+	assert.ErrorIs(t, err, ErrDependencyCycle)
+	assert.EqualError(t, err, "handler dependency cycle: "+
+		"*gocqrs.lifecycleHandler[github.com/victoragudo/go-cqrs.cycleA] -> "+
+		"*gocqrs.lifecycleHandler[github.com/victoragudo/go-cqrs.cycleB] -> "+
+		"*gocqrs.lifecycleHandler[github.com/victoragudo/go-cqrs.cycleA]")
+
+	assert.ErrorIs(t, StartAll(context.Background()), ErrDependencyCycle)
+	assert.Empty(t, log.events)
+}
@@ -0,0 +1,169 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// LoadShedConfig configures the latency objective enforced by LoadShedMiddleware.
+	LoadShedConfig struct {
+		Threshold  time.Duration // Latency percentile above which new requests are shed, 1 second if zero.
+		Percentile float64       // Percentile compared with Threshold, 0.99 if not in (0, 1].
+		Window     time.Duration // Age of the latencies taken into account, 10 seconds if zero.
+		MinSamples int           // Latencies needed within Window before shedding, 10 if zero.
+		MaxSamples int           // Most recent latencies kept per request type, 200 if zero.
+	}
+
+	// OverloadedError is returned when LoadShedMiddleware sheds a request. It wraps ErrOverloaded.
+	OverloadedError struct {
+		RequestType string        // Name of the shed request type.
+		Threshold   time.Duration // Latency threshold the request type exceeds.
+		retryAfter  time.Duration // Time until the latency window is evaluated again.
+	}
+
+	// loadShedder tracks the recent latencies of the request types it protects.
+	loadShedder struct {
+		config LoadShedConfig
+		mutex  sync.Mutex
+		types  map[string]*latencyWindow // Request type name to its latencies.
+	}
+
+	// latencyWindow holds the recent latencies of a request type, oldest first.
+	latencyWindow struct {
+		samples    []latencySample
+		overloaded bool      // Verdict as of the last evaluation.
+		expiry     time.Time // When the oldest sample leaves the window and the verdict must be evaluated again.
+	}
+
+	// latencySample is the latency of a completed dispatch.
+	latencySample struct {
+		at      time.Time // Completion time.
+		latency time.Duration
+	}
+)
+
+// LoadShedMiddleware returns a pipeline behavior shedding load from the request types whose recent latency
+// degrades: once the configured percentile of the latencies completed within the window exceeds the threshold,
+// new requests of the type fail immediately with an *OverloadedError instead of running. Shedding stops as soon as
+// the slow latencies leave the window, letting requests through to measure the latency again.
+// The latencies are tracked per request type, so a single behavior can protect several handlers, and measured
+// with the configured Clock, from the behavior to the completion of the rest of the pipeline.
+func LoadShedMiddleware(config LoadShedConfig) PipelineBehavior {
+	if config.Threshold <= 0 {
+		config.Threshold = time.Second
+	}
+	if config.Percentile <= 0 || config.Percentile > 1 {
+		config.Percentile = 0.99
+	}
+	if config.Window <= 0 {
+		config.Window = 10 * time.Second
+	}
+	if config.MinSamples <= 0 {
+		config.MinSamples = 10
+	}
+	if config.MaxSamples <= 0 {
+		config.MaxSamples = 200
+	}
+	shedder := &loadShedder{config: config, types: make(map[string]*latencyWindow)}
+	return shedder.behavior
+}
+
+// behavior is the pipeline behavior shedding load.
+func (shedder *loadShedder) behavior(ctx context.Context, request any, next NextFunc) (any, error) {
+	typeName := typeKey(reflect.TypeOf(request))
+	if retryAfter, overloaded := shedder.overloaded(typeName); overloaded {
+		return nil, &OverloadedError{RequestType: typeName, Threshold: shedder.config.Threshold, retryAfter: retryAfter}
+	}
+
+	start := now()
+	response, err := next(ctx, request)
+	shedder.record(typeName, now().Sub(start))
+	return response, err
+}
+
+// Error describes the shed request type.
+func (e *OverloadedError) Error() string {
+	return fmt.Sprintf("%v: %v exceeds its latency threshold of %v", ErrOverloaded, e.RequestType, e.Threshold)
+}
+
+// Unwrap returns ErrOverloaded.
+func (e *OverloadedError) Unwrap() error {
+	return ErrOverloaded
+}
+
+// RetryAfter returns how long to wait before retrying the request: the time until the oldest latency leaves
+// the window and the verdict is evaluated again.
+func (e *OverloadedError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// overloaded reports whether the requests of a type are shed and, if so, how long until the verdict is
+// evaluated again.
+func (shedder *loadShedder) overloaded(typeName string) (time.Duration, bool) {
+	shedder.mutex.Lock()
+	defer shedder.mutex.Unlock()
+
+	window, ok := shedder.types[typeName]
+	if !ok {
+		return 0, false
+	}
+	current := now()
+	if !window.expiry.IsZero() && !current.Before(window.expiry) {
+		shedder.evaluate(window, current)
+	}
+	if !window.overloaded {
+		return 0, false
+	}
+	return window.expiry.Sub(current), true
+}
+
+// record adds the latency of a completed dispatch of a type.
+func (shedder *loadShedder) record(typeName string, latency time.Duration) {
+	shedder.mutex.Lock()
+	defer shedder.mutex.Unlock()
+
+	current := now()
+	window, ok := shedder.types[typeName]
+	if !ok {
+		window = &latencyWindow{}
+		shedder.types[typeName] = window
+	}
+	window.samples = append(window.samples, latencySample{at: current, latency: latency})
+	if excess := len(window.samples) - shedder.config.MaxSamples; excess > 0 {
+		window.samples = append(window.samples[:0:0], window.samples[excess:]...)
+	}
+	shedder.evaluate(window, current)
+}
+
+// evaluate drops the latencies that left the window and compares the percentile with the threshold.
+// The caller must hold the shedder's lock.
+func (shedder *loadShedder) evaluate(window *latencyWindow, current time.Time) {
+	cutoff := current.Add(-shedder.config.Window)
+	expired := sort.Search(len(window.samples), func(i int) bool {
+		return window.samples[i].at.After(cutoff)
+	})
+	window.samples = window.samples[expired:]
+
+	window.expiry = time.Time{}
+	if len(window.samples) > 0 {
+		window.expiry = window.samples[0].at.Add(shedder.config.Window)
+	}
+	if len(window.samples) < shedder.config.MinSamples {
+		window.overloaded = false
+		return
+	}
+
+	latencies := make([]time.Duration, len(window.samples))
+	for i, sample := range window.samples {
+		latencies[i] = sample.latency
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	rank := int(math.Ceil(shedder.config.Percentile*float64(len(latencies)))) - 1
+	window.overloaded = latencies[max(rank, 0)] > shedder.config.Threshold
+}
@@ -0,0 +1,131 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sheddedReportQuery struct{}
+
+// sheddedReportQueryHandler takes latency to complete, as measured by its fake clock.
+type sheddedReportQueryHandler struct {
+	clock   *fakeClock
+	latency time.Duration
+	calls   int
+}
+
+func (h *sheddedReportQueryHandler) Handle(ctx context.Context, query sheddedReportQuery) (string, error) {
+	h.calls++
+	h.clock.Advance(h.latency)
+	return "report", nil
+}
+
+// TestLoadShedMiddleware tests that a degraded request type is shed until its slow latencies leave the window.
+func TestLoadShedMiddleware(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	handler := &sheddedReportQueryHandler{clock: clock, latency: 10 * time.Millisecond}
+	AddQueryHandler[sheddedReportQuery, string](handler).Behavior(LoadShedMiddleware(LoadShedConfig{
+		Threshold:  100 * time.Millisecond,
+		Percentile: 0.9,
+		Window:     time.Minute,
+		MinSamples: 5,
+	}))
+
+	for i := 0; i < 5; i++ {
+		_, err := SendQuery[string](context.Background(), sheddedReportQuery{})
+		assert.NoError(t, err)
+	}
+
+	// A slow completion pushes the percentile over the threshold.
+	handler.latency = 500 * time.Millisecond
+	_, err := SendQuery[string](context.Background(), sheddedReportQuery{})
+	assert.NoError(t, err)
+
+	_, err = SendQuery[string](context.Background(), sheddedReportQuery{})
+	assert.True(t, errors.Is(err, ErrOverloaded))
+	assert.EqualError(t, err, "request type is overloaded: gocqrs.sheddedReportQuery exceeds its latency threshold of 100ms")
+	assert.Equal(t, 6, handler.calls, "shed requests should not reach the handler")
+
+	// Once the slow latency leaves the window, requests go through again.
+	handler.latency = 10 * time.Millisecond
+	clock.Advance(time.Minute)
+	_, err = SendQuery[string](context.Background(), sheddedReportQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, handler.calls)
+}
+
+// TestLoadShedMiddleware_MinSamples tests that a few slow completions do not shed load on their own.
+func TestLoadShedMiddleware_MinSamples(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	behavior := LoadShedMiddleware(LoadShedConfig{Threshold: time.Millisecond})
+	slow := func(ctx context.Context, request any) (any, error) {
+		clock.Advance(time.Second)
+		return nil, nil
+	}
+	for i := 0; i < 9; i++ {
+		_, err := behavior(context.Background(), sheddedReportQuery{}, slow)
+		assert.NoError(t, err)
+	}
+	_, err := behavior(context.Background(), sheddedReportQuery{}, slow)
+	assert.NoError(t, err, "the tenth completion reaches the default minimum of samples")
+	_, err = behavior(context.Background(), sheddedReportQuery{}, slow)
+	assert.ErrorIs(t, err, ErrOverloaded)
+}
+
+// TestLoadShedMiddleware_RetryAfter tests that a shed request hints to retry once the slow latency leaves the window.
+func TestLoadShedMiddleware_RetryAfter(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	behavior := LoadShedMiddleware(LoadShedConfig{Threshold: 100 * time.Millisecond, Window: time.Minute, MinSamples: 1})
+	slow := func(ctx context.Context, request any) (any, error) {
+		clock.Advance(500 * time.Millisecond)
+		return nil, nil
+	}
+	_, err := behavior(context.Background(), sheddedReportQuery{}, slow)
+	assert.NoError(t, err)
+
+	clock.Advance(20 * time.Second)
+	_, err = behavior(context.Background(), sheddedReportQuery{}, slow)
+	var overloaded *OverloadedError
+	assert.ErrorAs(t, err, &overloaded)
+	assert.Equal(t, "gocqrs.sheddedReportQuery", overloaded.RequestType)
+	retryAfter, ok := RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, 40*time.Second, retryAfter)
+}
+
+// TestLoadShedMiddleware_DefaultThreshold tests that a zero threshold defaults to a second instead of shedding everything.
+func TestLoadShedMiddleware_DefaultThreshold(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	latency := 10 * time.Millisecond
+	next := func(ctx context.Context, request any) (any, error) {
+		clock.Advance(latency)
+		return nil, nil
+	}
+	behavior := LoadShedMiddleware(LoadShedConfig{MinSamples: 1})
+	for i := 0; i < 3; i++ {
+		_, err := behavior(context.Background(), sheddedReportQuery{}, next)
+		assert.NoError(t, err)
+	}
+
+	latency = 2 * time.Second
+	_, err := behavior(context.Background(), sheddedReportQuery{}, next)
+	assert.NoError(t, err)
+	_, err = behavior(context.Background(), sheddedReportQuery{}, next)
+	assert.ErrorIs(t, err, ErrOverloaded)
+}
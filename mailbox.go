@@ -0,0 +1,240 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowMode defines what happens when an event is delivered to a full mailbox.
+type OverflowMode int
+
+const (
+	// OverflowBlock makes PublishEvent wait until the mailbox has room or its context is done.
+	OverflowBlock OverflowMode = iota
+	// OverflowDropNewest discards the event being delivered.
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest event waiting in the mailbox to make room.
+	OverflowDropOldest
+	// OverflowError makes PublishEvent return ErrMailboxFull for the handler.
+	OverflowError
+)
+
+type (
+	// MailboxStat describes the state of the mailbox of an event handler.
+	MailboxStat struct {
+		Depth    int    // Number of events waiting to be handled.
+		Capacity int    // Maximum number of waiting events.
+		Dropped  uint64 // Number of events discarded by the overflow policy.
+	}
+
+	mailboxConfig struct {
+		size     int
+		overflow OverflowMode
+	}
+
+	// mailbox queues the deliveries of an event handler, handled one at a time by its own goroutine.
+	mailbox struct {
+		handlerName string
+		handler     IHandler[T, T]
+		overflow    OverflowMode
+		queue       chan mailboxItem
+		mutex       sync.RWMutex // Held for writing while closing queue.
+		closed      bool
+		dropped     atomic.Uint64
+		done        chan struct{}
+		sub         *subscription // Subscription of the handler, ending the deliveries once removed.
+	}
+
+	mailboxItem struct {
+		ctx   context.Context
+		event any
+	}
+)
+
+var (
+	mailboxMutex sync.RWMutex
+	mailboxes    = make(map[string][]*mailbox) // Handler name to its mailboxes, one per subscribed event type.
+)
+
+// WithMailbox routes the deliveries of the handler through a bounded mailbox consumed by a dedicated goroutine,
+// so a slow handler only delays itself and handles events in publication order.
+// PublishEvent returns once the event is enqueued, applying overflow when the mailbox is full.
+// Errors returned by the handler are reported with the ErrorReporter. Shutdown drains the mailbox.
+func WithMailbox(size int, overflow OverflowMode) EventHandlerOption {
+	return func(config *eventHandlerConfig) {
+		if size < 1 {
+			size = 1
+		}
+		config.mailbox = &mailboxConfig{size: size, overflow: overflow}
+	}
+}
+
+// MailboxStats returns the state of every mailbox, keyed by handler type name.
+// The mailboxes of a handler subscribed to several event types are summed up.
+func MailboxStats() map[string]MailboxStat {
+	mailboxMutex.RLock()
+	defer mailboxMutex.RUnlock()
+
+	snapshot := make(map[string]MailboxStat, len(mailboxes))
+	for handlerName, boxes := range mailboxes {
+		var stat MailboxStat
+		for _, box := range boxes {
+			stat.Depth += len(box.queue)
+			stat.Capacity += cap(box.queue)
+			stat.Dropped += box.dropped.Load()
+		}
+		snapshot[handlerName] = stat
+	}
+	return snapshot
+}
+
+// startMailbox creates the mailbox of a handler and starts its goroutine.
+func startMailbox(handlerName string, handler IHandler[T, T], config mailboxConfig, sub *subscription) *mailbox {
+	box := &mailbox{
+		handlerName: handlerName,
+		handler:     handler,
+		overflow:    config.overflow,
+		queue:       make(chan mailboxItem, config.size),
+		done:        make(chan struct{}),
+		sub:         sub,
+	}
+	go box.loop()
+
+	mailboxMutex.Lock()
+	mailboxes[handlerName] = append(mailboxes[handlerName], box)
+	mailboxMutex.Unlock()
+	return box
+}
+
+// closeMailboxes stops accepting deliveries and waits until every mailbox is drained or ctx is done.
+func closeMailboxes(ctx context.Context) error {
+	mailboxMutex.Lock()
+	closing := mailboxes
+	mailboxes = make(map[string][]*mailbox)
+	mailboxMutex.Unlock()
+
+	for _, boxes := range closing {
+		for _, box := range boxes {
+			box.close()
+		}
+	}
+	for _, boxes := range closing {
+		for _, box := range boxes {
+			select {
+			case <-box.done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+// Handle enqueues event according to the overflow policy.
+func (box *mailbox) Handle(ctx context.Context, event T) (T, error) {
+	box.mutex.RLock()
+	defer box.mutex.RUnlock()
+
+	if box.closed {
+		return nil, fmt.Errorf("%w: %v", ErrMailboxClosed, box.handlerName)
+	}
+
+	// The handler runs after PublishEvent returned, so it must not be canceled with the publisher's context.
+	item := mailboxItem{ctx: context.WithoutCancel(ctx), event: event}
+	switch box.overflow {
+	case OverflowDropNewest:
+		select {
+		case box.queue <- item:
+		default:
+			box.dropped.Add(1)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case box.queue <- item:
+				return nil, nil
+			default:
+			}
+			select {
+			case <-box.queue:
+				box.dropped.Add(1)
+			default:
+			}
+		}
+	case OverflowError:
+		select {
+		case box.queue <- item:
+		default:
+			return nil, fmt.Errorf("%w: %v", ErrMailboxFull, box.handlerName)
+		}
+	default:
+		select {
+		case box.queue <- item:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return nil, nil
+}
+
+// loop handles queued events until the mailbox is closed and drained.
+func (box *mailbox) loop() {
+	defer close(box.done)
+	for item := range box.queue {
+		if !box.sub.enter() {
+			box.drop(item)
+			continue
+		}
+		box.deliver(item)
+		box.sub.leave()
+	}
+}
+
+// drop discards an event queued for a handler that was removed.
+func (box *mailbox) drop(item mailboxItem) {
+	box.dropped.Add(1)
+	reportError(item.ctx, fmt.Errorf("%w: %T queued for the removed event handler %v", ErrDeliveryDropped, item.event, box.handlerName))
+}
+
+// deliver calls the handler, reporting its error or panic.
+func (box *mailbox) deliver(item mailboxItem) {
+	defer func() {
+		if r := recover(); r != nil {
+			reportError(item.ctx, fmt.Errorf("event handler %v panicked in its mailbox: %v", box.handlerName, r))
+		}
+	}()
+	if _, err := box.handler.Handle(item.ctx, item.event); err != nil {
+		reportError(item.ctx, err)
+	}
+}
+
+// close stops accepting deliveries; the events already queued are still handled.
+func (box *mailbox) close() {
+	box.mutex.Lock()
+	defer box.mutex.Unlock()
+	if !box.closed {
+		box.closed = true
+		close(box.queue)
+	}
+}
+
+// remove closes the mailbox of a removed handler and stops reporting it in MailboxStats.
+func (box *mailbox) remove() {
+	box.close()
+	mailboxMutex.Lock()
+	defer mailboxMutex.Unlock()
+	boxes := mailboxes[box.handlerName]
+	for i, registered := range boxes {
+		if registered != box {
+			continue
+		}
+		if len(boxes) == 1 {
+			delete(mailboxes, box.handlerName)
+		} else {
+			mailboxes[box.handlerName] = append(boxes[:i:i], boxes[i+1:]...)
+		}
+		return
+	}
+}
@@ -0,0 +1,208 @@
+package gocqrs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mailboxEvent struct {
+	Value int
+}
+
+// slowMailboxHandler blocks on release before recording every event it handles.
+type slowMailboxHandler struct {
+	mutex   sync.Mutex
+	started chan struct{}
+	release chan struct{}
+	values  []int
+}
+
+func (h *slowMailboxHandler) Handle(ctx context.Context, event mailboxEvent) error {
+	h.started <- struct{}{}
+	<-h.release
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.values = append(h.values, event.Value)
+	return nil
+}
+
+type fastMailboxHandler struct {
+	mutex  sync.Mutex
+	values []int
+}
+
+func (h *fastMailboxHandler) Handle(ctx context.Context, event mailboxEvent) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.values = append(h.values, event.Value)
+	return nil
+}
+
+type orderedMailboxEvent struct {
+	Value int
+}
+
+type orderedMailboxHandler struct {
+	values []int
+}
+
+func (h *orderedMailboxHandler) Handle(ctx context.Context, event orderedMailboxEvent) error {
+	time.Sleep(time.Microsecond)
+	h.values = append(h.values, event.Value)
+	return nil
+}
+
+type overflowMailboxEvent struct{}
+
+type overflowMailboxHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *overflowMailboxHandler) Handle(ctx context.Context, event overflowMailboxEvent) error {
+	h.started <- struct{}{}
+	<-h.release
+	return nil
+}
+
+type dropMailboxHandler struct {
+	overflowMailboxHandler
+}
+
+// TestWithMailbox_SlowHandlerDoesNotDelaySibling tests that publishing returns while a mailboxed handler is still busy.
+func TestWithMailbox_SlowHandlerDoesNotDelaySibling(t *testing.T) {
+	slow := &slowMailboxHandler{started: make(chan struct{}, 10), release: make(chan struct{})}
+	fast := &fastMailboxHandler{}
+	assert.NoError(t, AddEventHandler[mailboxEvent](slow, WithMailbox(10, OverflowBlock)))
+	assert.NoError(t, AddEventHandler[mailboxEvent](fast))
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, PublishEvent(context.Background(), mailboxEvent{Value: i}))
+	}
+	assert.Equal(t, []int{0, 1, 2}, fast.values, "the fast handler should not wait for the slow one")
+	<-slow.started
+	assert.Equal(t, 2, MailboxStats()["*gocqrs.slowMailboxHandler"].Depth)
+
+	close(slow.release)
+	assert.NoError(t, Shutdown(context.Background()))
+	assert.Equal(t, []int{0, 1, 2}, slow.values, "Shutdown should drain the mailbox")
+}
+
+// TestWithMailbox_Ordering tests that a mailbox delivers events in publication order.
+func TestWithMailbox_Ordering(t *testing.T) {
+	handler := &orderedMailboxHandler{}
+	assert.NoError(t, AddEventHandler[orderedMailboxEvent](handler, WithMailbox(8, OverflowBlock)))
+
+	expected := make([]int, 0, 100)
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, PublishEvent(context.Background(), orderedMailboxEvent{Value: i}))
+		expected = append(expected, i)
+	}
+	assert.NoError(t, Shutdown(context.Background()))
+	assert.Equal(t, expected, handler.values)
+
+	err := PublishEvent(context.Background(), orderedMailboxEvent{Value: 100})
+	assert.ErrorIs(t, err, ErrMailboxClosed)
+}
+
+// TestWithMailbox_Overflow tests the overflow policies of a full mailbox.
+func TestWithMailbox_Overflow(t *testing.T) {
+	rejecting := &overflowMailboxHandler{started: make(chan struct{}, 10), release: make(chan struct{})}
+	dropping := &dropMailboxHandler{overflowMailboxHandler{started: make(chan struct{}, 10), release: make(chan struct{})}}
+	assert.NoError(t, AddEventHandler[overflowMailboxEvent](rejecting, WithMailbox(1, OverflowError)))
+	assert.NoError(t, AddEventHandler[overflowMailboxEvent](dropping, WithMailbox(1, OverflowDropNewest)))
+
+	// The first event is being handled, the second fills both mailboxes.
+	assert.NoError(t, PublishEvent(context.Background(), overflowMailboxEvent{}))
+	<-rejecting.started
+	<-dropping.started
+	assert.NoError(t, PublishEvent(context.Background(), overflowMailboxEvent{}))
+
+	err := PublishEvent(context.Background(), overflowMailboxEvent{})
+	assert.ErrorIs(t, err, ErrMailboxFull)
+	assert.Equal(t, uint64(1), MailboxStats()["*gocqrs.dropMailboxHandler"].Dropped)
+
+	close(rejecting.release)
+	close(dropping.release)
+	assert.NoError(t, Shutdown(context.Background()))
+}
+
+type tracedMailboxEvent struct{}
+
+type mailboxTraceKey struct{}
+
+type tracedMailboxHandler struct {
+	traces chan any
+}
+
+func (h *tracedMailboxHandler) Handle(ctx context.Context, event tracedMailboxEvent) error {
+	h.traces <- ctx.Value(mailboxTraceKey{})
+	return nil
+}
+
+// TestWithMailbox_KeepsContextValues tests that a mailboxed handler sees the values of the publishing context,
+// such as a span stored by a tracing library, after the publisher's context was canceled.
+func TestWithMailbox_KeepsContextValues(t *testing.T) {
+	handler := &tracedMailboxHandler{traces: make(chan any, 1)}
+	assert.NoError(t, AddEventHandler[tracedMailboxEvent](handler, WithMailbox(1, OverflowBlock)))
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), mailboxTraceKey{}, "publish-span"))
+	assert.NoError(t, PublishEvent(ctx, tracedMailboxEvent{}))
+	cancel()
+
+	assert.Equal(t, "publish-span", <-handler.traces)
+}
+
+type (
+	auditedCreateEvent struct{}
+	auditedDeleteEvent struct{}
+)
+
+// auditMailboxHandler handles two event types, blocking on release before recording each of them.
+type auditMailboxHandler struct {
+	mutex   sync.Mutex
+	started chan struct{}
+	release chan struct{}
+	handled []string
+}
+
+func (h *auditMailboxHandler) record(kind string) error {
+	h.started <- struct{}{}
+	<-h.release
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.handled = append(h.handled, kind)
+	return nil
+}
+
+func (h *auditMailboxHandler) HandleCreate(ctx context.Context, event auditedCreateEvent) error {
+	return h.record("create")
+}
+
+func (h *auditMailboxHandler) HandleDelete(ctx context.Context, event auditedDeleteEvent) error {
+	return h.record("delete")
+}
+
+// TestWithMailbox_SeveralEventTypes tests that a handler keeps a mailbox per event type, all drained by Shutdown.
+func TestWithMailbox_SeveralEventTypes(t *testing.T) {
+	handler := &auditMailboxHandler{started: make(chan struct{}, 4), release: make(chan struct{})}
+	assert.NoError(t, AddEventHandlerFunc(handler.HandleCreate, WithEventHandlerName("auditMailboxHandler"), WithMailbox(2, OverflowBlock)))
+	assert.NoError(t, AddEventHandlerFunc(handler.HandleDelete, WithEventHandlerName("auditMailboxHandler"), WithMailbox(2, OverflowBlock)))
+
+	assert.NoError(t, PublishEvent(context.Background(), auditedCreateEvent{}))
+	assert.NoError(t, PublishEvent(context.Background(), auditedDeleteEvent{}))
+	<-handler.started
+	<-handler.started
+	assert.NoError(t, PublishEvent(context.Background(), auditedCreateEvent{}))
+	assert.NoError(t, PublishEvent(context.Background(), auditedDeleteEvent{}))
+	assert.Equal(t, MailboxStat{Depth: 2, Capacity: 4}, MailboxStats()["auditMailboxHandler"])
+
+	close(handler.release)
+	assert.NoError(t, Shutdown(context.Background()))
+	assert.ElementsMatch(t, []string{"create", "create", "delete", "delete"}, handler.handled, "Shutdown should drain both mailboxes")
+	assert.NotContains(t, MailboxStats(), "auditMailboxHandler")
+}
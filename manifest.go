@@ -0,0 +1,118 @@
+package gocqrs
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+type (
+	// Manifest is the machine-readable description of the registry produced by ExportManifest.
+	Manifest struct {
+		Handlers []ManifestHandler `json:"handlers"` // Command and query handlers, by request type name.
+		Events   []ManifestEvent   `json:"events"`   // Event types, by name.
+	}
+
+	// ManifestHandler describes a command or query handler; the registry does not tell them apart.
+	ManifestHandler struct {
+		RequestType     string        `json:"requestType"`
+		Handler         string        `json:"handler"`
+		Tags            []string      `json:"tags,omitempty"`
+		Meta            *ManifestMeta `json:"meta,omitempty"`
+		PreMiddlewares  []string      `json:"preMiddlewares,omitempty"`  // In execution order.
+		PostMiddlewares []string      `json:"postMiddlewares,omitempty"` // In execution order.
+		Behaviors       []string      `json:"behaviors,omitempty"`       // Outermost first.
+		Timeout         string        `json:"timeout,omitempty"`
+		Internal        bool          `json:"internal,omitempty"`
+	}
+
+	// ManifestMeta is the HandlerMeta attached to a handler. Its tags are the Tags of the handler.
+	ManifestMeta struct {
+		Summary    string `json:"summary,omitempty"`
+		Version    string `json:"version,omitempty"`
+		Deprecated bool   `json:"deprecated,omitempty"`
+	}
+
+	// ManifestEvent describes the handlers and consumption groups of an event type.
+	ManifestEvent struct {
+		EventType string               `json:"eventType"`
+		Handlers  []string             `json:"handlers,omitempty"` // In delivery order.
+		Groups    []ManifestEventGroup `json:"groups,omitempty"`   // By name.
+	}
+
+	// ManifestEventGroup describes an event handler group.
+	ManifestEventGroup struct {
+		Name    string   `json:"name"`
+		Members []string `json:"members"` // In registration order.
+	}
+)
+
+// ExportManifest returns the registrations as JSON, e.g. to feed an API catalog or detect drift between
+// deployments: every command and query handler with its tags, metadata, middlewares and behaviors, and every
+// event type with its handlers and consumption groups. The output is sorted, so identical registrations produce
+// identical manifests.
+func ExportManifest() ([]byte, error) {
+	return json.MarshalIndent(buildManifest(), "", "  ")
+}
+
+// buildManifest takes a snapshot of the registrations.
+func buildManifest() Manifest {
+	view := newRegistryView()
+	manifest := Manifest{
+		Handlers: make([]ManifestHandler, 0, len(view.handlers)),
+		Events:   make([]ManifestEvent, 0),
+	}
+
+	for _, handler := range view.Handlers() {
+		entry := ManifestHandler{
+			RequestType:     handler.RequestType,
+			Handler:         handler.Handler,
+			Tags:            handler.Tags,
+			PreMiddlewares:  handler.PreMiddlewares,
+			PostMiddlewares: handler.PostMiddlewares,
+			Behaviors:       handler.Behaviors,
+			Internal:        handler.Internal,
+		}
+		if meta := handler.Meta; meta.Summary != "" || meta.Version != "" || meta.Deprecated {
+			entry.Meta = &ManifestMeta{Summary: meta.Summary, Version: meta.Version, Deprecated: meta.Deprecated}
+		}
+		if handler.Timeout > 0 {
+			entry.Timeout = handler.Timeout.String()
+		}
+		manifest.Handlers = append(manifest.Handlers, entry)
+	}
+
+	events := make(map[string]*ManifestEvent)
+	eventFor := func(eventType string) *ManifestEvent {
+		if event, ok := events[eventType]; ok {
+			return event
+		}
+		event := &ManifestEvent{EventType: eventType}
+		events[eventType] = event
+		return event
+	}
+	for eventType, names := range view.EventSubscribers() {
+		eventFor(eventType).Handlers = names
+	}
+
+	eventGroupMutex.RLock()
+	for _, group := range eventGroups {
+		members := make([]string, 0, len(group.members))
+		for _, member := range group.members {
+			members = append(members, member.typeName)
+		}
+		event := eventFor(group.eventType)
+		event.Groups = append(event.Groups, ManifestEventGroup{Name: group.name, Members: members})
+	}
+	eventGroupMutex.RUnlock()
+
+	for _, event := range events {
+		sort.Slice(event.Groups, func(i, j int) bool {
+			return event.Groups[i].Name < event.Groups[j].Name
+		})
+		manifest.Events = append(manifest.Events, *event)
+	}
+	sort.Slice(manifest.Events, func(i, j int) bool {
+		return manifest.Events[i].EventType < manifest.Events[j].EventType
+	})
+	return manifest
+}
@@ -0,0 +1,65 @@
+package gocqrs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	manifestShipOrder   struct{}
+	manifestOrderLoaded struct{}
+)
+
+type manifestShipOrderHandler struct{}
+
+func (h *manifestShipOrderHandler) Handle(ctx context.Context, command manifestShipOrder) (string, error) {
+	return "shipped", nil
+}
+
+type manifestOrderLoadedHandler struct{}
+
+func (h *manifestOrderLoadedHandler) Handle(ctx context.Context, event manifestOrderLoaded) error {
+	return nil
+}
+
+func manifestAudit(ctx context.Context, request any) (context.Context, any, bool) {
+	return ctx, request, true
+}
+
+// TestExportManifest tests that the manifest describes a registered handler and is stable.
+func TestExportManifest(t *testing.T) {
+	AddCommandHandler[manifestShipOrder, string](&manifestShipOrderHandler{}, WithMeta(HandlerMeta{Summary: "Ships an order", Version: "v2"})).
+		Tags("orders").
+		WithTimeout(5 * time.Second).
+		PreMiddleware(manifestAudit)
+	assert.NoError(t, AddEventHandlers[manifestOrderLoaded](&manifestOrderLoadedHandler{}))
+
+	data, err := ExportManifest()
+	assert.NoError(t, err)
+
+	var manifest Manifest
+	assert.NoError(t, json.Unmarshal(data, &manifest))
+
+	var handler *ManifestHandler
+	for i := range manifest.Handlers {
+		if manifest.Handlers[i].RequestType == "gocqrs.manifestShipOrder" {
+			handler = &manifest.Handlers[i]
+		}
+	}
+	if assert.NotNil(t, handler) {
+		assert.Equal(t, "*gocqrs.manifestShipOrderHandler", handler.Handler)
+		assert.Equal(t, []string{"orders"}, handler.Tags)
+		assert.Equal(t, &ManifestMeta{Summary: "Ships an order", Version: "v2"}, handler.Meta)
+		assert.Equal(t, []string{"github.com/victoragudo/go-cqrs.manifestAudit"}, handler.PreMiddlewares)
+		assert.Equal(t, "5s", handler.Timeout)
+	}
+	assert.Contains(t, manifest.Events, ManifestEvent{EventType: "gocqrs.manifestOrderLoaded", Handlers: []string{"*gocqrs.manifestOrderLoadedHandler"}})
+
+	again, err := ExportManifest()
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), string(again), "identical registrations should produce identical manifests")
+}
@@ -26,33 +26,54 @@ func init() {
 	handlerMutex = sync.RWMutex{}
 	eventHandlerMutex = sync.RWMutex{}
 	eventHandlers = make(map[string][]eventHandlersType)
-	middlewareBuilder = AddMiddlewareBuilder{
-		preMiddlewares:  make(map[string][]middlewareStruct),
-		postMiddlewares: make(map[string][]middlewareStruct),
-	}
 }
 
 // AddQueryHandler registers a command handler.
-func AddQueryHandler[Query T, QueryResponse T](handler IHandler[Query, QueryResponse]) *AddMiddlewareBuilder {
-	return addRequest[Query, QueryResponse](handler)
+func AddQueryHandler[Query T, QueryResponse T](handler IHandler[Query, QueryResponse], opts ...HandlerOption) *AddMiddlewareBuilder {
+	return addRequest[Query, QueryResponse](handler, opts...)
 }
 
 // AddCommandHandler registers a command handler.
-func AddCommandHandler[Command T, CommandResponse T](handler IHandler[Command, CommandResponse]) *AddMiddlewareBuilder {
-	return addRequest[Command, CommandResponse](handler)
+func AddCommandHandler[Command T, CommandResponse T](handler IHandler[Command, CommandResponse], opts ...HandlerOption) *AddMiddlewareBuilder {
+	return addRequest[Command, CommandResponse](handler, opts...)
 }
 
-func addRequest[T1 T, T2 T](handler IHandler[T1, T2]) *AddMiddlewareBuilder {
-	// Determine the type name of the TCommand generic parameter, removing the pointer symbol if present.
-	typed := reflect.TypeOf(new(T1)).Elem().String()
-
+func addRequest[T1 T, T2 T](handler IHandler[T1, T2], opts ...HandlerOption) *AddMiddlewareBuilder {
 	// Determine the type name of the handler parameter, removing the pointer symbol if present.
 	typedHandlerName := reflect.TypeOf(handler).String()
 
-	// Store command handler for a specific command as a wrapper
-	storeMapValue(handlers, typed, newHandlerWrapper[T1, T2](handler, typedHandlerName), &handlerMutex)
+	return addRequestNamed[T1, T2](handler, typedHandlerName, opts...)
+}
+
+// addRequestNamed registers a command or query handler under an explicit handler name.
+func addRequestNamed[T1 T, T2 T](handler IHandler[T1, T2], typedHandlerName string, opts ...HandlerOption) *AddMiddlewareBuilder {
+	return registerHandler(reflect.TypeOf(new(T1)).Elem(), newHandlerWrapper[T1, T2](handler, typedHandlerName), typedHandlerName, opts)
+}
+
+// registerHandler stores the wrapper of the handler of a request type and makes it the current handler of the builder.
+func registerHandler(requestType reflect.Type, wrapper any, typedHandlerName string, opts []HandlerOption) *AddMiddlewareBuilder {
+	defer recordRegistrationTiming(RegistrationHandler, typedHandlerName, registrationStart())
+
+	// Determine the type name of the request type, removing the pointer symbol if present.
+	typed := typeKey(requestType)
+
+	// Store command handler for a specific command as a wrapper, forgetting the metadata of the replaced one
+	if replaced, ok := getMapValue(handlers, typed, &handlerMutex); ok {
+		forgetHandlerReflection(replaced)
+	}
+	storeMapValue(handlers, typed, wrapper, &handlerMutex)
+	recordRegistration(typedHandlerName)
+
+	// Remember the request type so it can be built from its name.
+	storeRequestType(typed, requestType)
 
+	// Apply the registration options, such as metadata.
+	applyHandlerOptions(typed, typedHandlerName, opts)
+
+	middlewareBuilder.mutex.Lock()
 	middlewareBuilder.currentHandlerName = typedHandlerName
+	middlewareBuilder.err = nil
+	middlewareBuilder.mutex.Unlock()
 	return &middlewareBuilder
 }
 
@@ -60,13 +81,14 @@ func addRequest[T1 T, T2 T](handler IHandler[T1, T2]) *AddMiddlewareBuilder {
 // It uses generics to allow any event type and ensures type safety for handlers.
 func AddEventHandlers[TEvent T](handlers ...IEventHandler[TEvent]) error {
 	// Get the type name of the event, removing the pointer prefix if present.
-	typedEvent := reflect.TypeOf(new(TEvent)).Elem().String()
+	typedEvent := eventTypeKey(reflect.TypeOf(new(TEvent)).Elem())
 
 	// Load the registered handlers for this event type, if any.
 	registeredHandlers := loadOrStoreEventHandlers(eventHandlers, typedEvent, &eventHandlerMutex)
 
 	// Iterate through the provided handlers and add them to the registered handlers.
 	for _, handler := range handlers {
+		start := registrationStart()
 		typedHandlerName := reflect.TypeOf(handler).String()
 		hWrapper := newEventHandlerWrapper[TEvent](handler, typedHandlerName)
 
@@ -74,13 +96,19 @@ func AddEventHandlers[TEvent T](handlers ...IEventHandler[TEvent]) error {
 			evtHandler := eventHandlersType{
 				typeName:     typedHandlerName,
 				eventHandler: hWrapper,
+				handler:      handler,
+				sub:          &subscription{},
 			}
 			registeredHandlers = append(registeredHandlers, evtHandler)
+			recordRegistration(typedHandlerName)
 		}
+		recordRegistrationTiming(RegistrationEventHandler, typedHandlerName, start)
 	}
 
 	// Update the eventHandlers map with the newly added handlers.
+	eventHandlerMutex.Lock()
 	eventHandlers[typedEvent] = registeredHandlers
+	eventHandlerMutex.Unlock()
 	return nil
 }
 
@@ -92,44 +120,176 @@ func SendCommand[CommandResponse T](ctx context.Context, command any) (CommandRe
 
 // SendQuery executes a query by finding the appropriate handler.
 // It is a generic function parameterized by 'QueryResponse T', where 'T' is the expected response type.
+// Streamed responses such as an io.ReadCloser are returned as the handler produced them, unread and open;
+// the caller owns closing them.
 func SendQuery[QueryResponse T](ctx context.Context, query any) (QueryResponse, error) {
 	return send[QueryResponse](ctx, query)
 }
 
+// Send executes a command or a query by finding the appropriate handler, for callers agnostic of the kind of request.
+// Commands and queries share their handler registry and middlewares, so it behaves exactly like SendCommand and SendQuery.
+func Send[Response T](ctx context.Context, request any) (Response, error) {
+	return send[Response](ctx, request)
+}
+
 func send[Response T](ctx context.Context, in any) (Response, error) {
-	// Retrieve the type of the request as a string
-	typedIn := reflect.TypeOf(in).String()
+	// Remember the caller's context to tell its cancellation apart from the ones caused by the dispatch.
+	caller := ctx
+
+	// Retrieve the type of the request as a string, as rewritten by the request key rewriter
+	typedIn := rewriteRequestKey(typeKey(reflect.TypeOf(in)))
 
 	var value any
 	var ok bool
+	var response Response
 
 	value, ok = getMapValue(handlers, typedIn, &handlerMutex)
+	registered := ok
 
-	// If no handler is found for the command or query, throws a panic
+	// Without a registered handler, ask the handler resolver, if any.
 	if !ok {
-		panic(fmt.Sprintf("no handler found for: %v", typedIn))
+		var err error
+		if value, ok, err = resolveHandler(reflect.TypeOf(in)); err != nil {
+			return response, err
+		}
 	}
 
-	handlerField, ok := getField(value, "Handler")
-	if !ok {
-		panic(fmt.Sprintf("no Handler field found for: %v", value))
+	// A handler override carried by ctx replaces the registered handler for this dispatch.
+	if override, overridden := handlerOverrideFor(ctx, typedIn, value); overridden {
+		value, ok, registered = override, true, false
 	}
+	ctx = withNestedOverrides(ctx)
 
-	handleMethod, ok := getMethodByName(handlerField, "Handle")
+	// If no handler is found for the command or query, panics or returns an InternalError
 	if !ok {
-		panic(fmt.Sprintf("no Handle method found for: %v", handlerField))
+		return response, brokenInvariant("handler_registered", fmt.Errorf("no handler found for: %v", typedIn))
 	}
 
-	handlerNameField, ok := getField(value, "Name")
-	if !ok {
-		panic(fmt.Sprintf("no Handler name field found for: %v", value))
+	// Only the registered handlers live long enough for their reflect metadata to be cached.
+	var reflection *handlerReflection
+	var err error
+	if registered {
+		reflection, err = cachedHandlerReflection(value)
+	} else {
+		reflection, err = reflectHandler(value)
+	}
+	if err != nil {
+		return response, err
+	}
+	handlerField, handleMethod, handlerName := reflection.handler, reflection.handleMethod, reflection.name
+
+	// A request routed to the handler of another type is converted to that type.
+	if typedIn != reflect.TypeOf(in).String() {
+		if in, err = convertRewrittenRequest(in, typedIn); err != nil {
+			return response, err
+		}
+	}
+
+	// Fail at the boundary if the caller did not provide the context values the handler depends on.
+	if err := checkRequiredContextKeys(ctx, handlerField, handlerName); err != nil {
+		return response, err
+	}
+
+	// Refuse or hold the dispatch while its request type is quiesced.
+	leaveQuiesce, err := enterQuiesce(ctx, typedIn)
+	if err != nil {
+		return response, err
 	}
+	defer leaveQuiesce()
+
+	// Grant the share of the parent's remaining deadline declared for this request type, if any.
+	ctx, cancelBudget := withChildBudget(ctx, typedIn, handlerName)
+	defer cancelBudget()
+
+	// Bound the dispatch to the handler's timeout, if it has one.
+	ctx, cancelTimeout := withHandlerTimeout(ctx, handlerName)
+	defer cancelTimeout()
+
+	// Track the dispatch so it can be listed and canceled while it runs.
+	ctx, untrack := trackDispatch(ctx, typedIn, handlerName)
+	defer untrack()
+	dispatch := ctx
 
-	handlerName := (handlerNameField.Interface()).(string)
+	// Tie the goroutines spawned with Go to the dispatch.
+	scope := newDispatchScope(ctx, handlerName)
+	ctx = withResponseType[Response](scope.ctx)
 
-	in = middlewareBuilder.executePreMiddlewares(ctx, in, handlerName)               // execute pre middlewares
-	response, err := createReflectiveHandler[Response](handleMethod).Handle(ctx, in) // execute Handle method
-	middlewareBuilder.executePostMiddlewares(ctx, in, handlerName)                   // execute post middlewares
+	// Collect the warnings raised by the handler, in the caller's collector if it provided one.
+	ctx = WithWarnings(ctx)
+
+	// Collect the events returned by event producing handlers, published once the dispatch succeeded.
+	ctx, produced := withProducedEvents(ctx)
+
+	// Let behaviors such as caches act on the stages following the handler.
+	ctx, pipeline := withPipelineState(ctx)
+
+	// Run the hooks registered with OnDispatchEnd last, even if the handler panics.
+	ctx, cleanups := withDispatchCleanups(ctx)
+	defer func() {
+		if r := recover(); r != nil {
+			cleanups.run(ctx, fmt.Errorf("dispatch panicked: %v", r))
+			panic(r)
+		}
+		cleanups.run(ctx, err)
+	}()
+
+	// Internal requests skip the middlewares.
+	internal := isInternalRequest(typedIn)
+
+	start := now()
+	if !internal {
+		in, err = middlewareBuilder.executePreMiddlewares(ctx, in, handlerName) // execute pre middlewares
+	}
+	if err == nil {
+		response, err = invokePipeline[Response](ctx, handleMethod, handlerName, in) // execute behaviors and Handle method
+	}
+	err = scope.close(err) // wait for or detach spawned goroutines
+	if err == nil {
+		// Publish the events produced by the handler; closing the scope canceled ctx.
+		err = produced.publish(context.WithoutCancel(ctx))
+	}
+	err = classifyCancellation(caller, dispatch, err)                     // attach the reason of a cancellation
+	response, err = processPipelineResponse(ctx, pipeline, response, err) // execute the global response processor
+	publishOutcome(ctx, handlerName, in, response, err)                   // publish the outcome event, if any
+	if !internal && (err == nil || !skipPostOnError.Load()) {
+		middlewareBuilder.executePostMiddlewares(ctx, in, handlerName) // execute post middlewares
+	}
+	duration := now().Sub(start)
+	recordDispatch(typedIn, duration, err)
+	recordUsage(handlerName)
+	recordHistory(typedIn, handlerName, in, start, duration, err)
+	return response, err
+}
+
+// invokeHandler executes the Handle method, on the handler's dedicated executor when one is configured.
+func invokeHandler[Response T](ctx context.Context, handleMethod reflect.Value, handlerName string, in any) (response Response, err error) {
+	handler := createReflectiveHandler[Response](handleMethod)
+
+	// Wait for a slot in the handler's pool, if it belongs to one.
+	release, err := acquirePool(ctx, handlerName)
+	if err != nil {
+		return response, err
+	}
+	defer release()
+
+	// Wait for a slot under the handler's adaptive concurrency limit, if it has one.
+	releaseLimit, err := acquireAdaptiveLimit(ctx, handlerName)
+	if err != nil {
+		return response, err
+	}
+	defer func() { releaseLimit(err) }()
+
+	recordStep(ctx, "handler")
+
+	executor, ok := executorFor(handlerName)
+	if !ok {
+		return runWithHooks(ctx, handler, in) // execute Handle method
+	}
+
+	out, err := executor.submit(ctx, func(ctx context.Context) (any, error) {
+		return runWithHooks(ctx, handler, in)
+	})
+	response, _ = out.(Response)
 	return response, err
 }
 
@@ -137,41 +297,223 @@ func send[Response T](ctx context.Context, in any) (Response, error) {
 // It performs the following steps:
 // 1. Identifies the event type and retrieves the corresponding event handlers.
 // 2. If no handlers are found for the event type, it returns an error.
-// 3. For each found handler, it calls the Handle method, passing the current context and event.
-// 4. Collects and returns any errors from the handlers. If multiple errors occur, they are combined into a single error.
+// 3. If event deduplication is enabled and the event was recently delivered, or if the event exceeds the rate limit of its type, it returns nil without invoking any handler. The OnDuplicate option tells a skipped duplicate apart.
+// 4. For each found handler, it calls the Handle method, passing the current context and event. Consumption groups only invoke the member selected for the event.
+// A handler returning ErrStopPropagation prevents the remaining handlers and groups from running; it is not reported as an error.
+// 5. Collects and returns any errors from the handlers. If multiple errors occur, they are combined into a single error.
 // This function is crucial for an event-driven architecture, allowing for flexible and scalable handling of various event types.
-func PublishEvent(ctx context.Context, event T) error {
-	// Obtain the type of the event as a string using reflection.
-	// This strips the "*" prefix, which indicates a pointer type, to get the base type name.
-	typedEvent := strings.TrimPrefix(reflect.TypeOf(event).String(), "*")
+// Options such as FailFast or BestEffort change the error semantics of this call only.
+func PublishEvent(ctx context.Context, event T, opts ...PublishOption) error {
+	config := newPublishConfig(opts)
 
-	// Attempt to load the registered event handlers for the specific event type.
-	registeredEventHandlers, ok := eventHandlers[typedEvent]
-	// If no event handlers are found for the type, return an error.
+	// Hold the event while event processing is paused with PauseEvents.
+	if buffered, err := bufferPausedEvent(ctx, event, config); buffered {
+		return err
+	}
+	return publishEvent(ctx, event, config)
+}
+
+// publishEvent delivers an event to its handlers, panicking if it has none.
+func publishEvent(ctx context.Context, event T, config publishConfig) error {
+	handlerErrors, ok := publish(ctx, event, config)
+
+	// If no event handlers are found for the type, panics or returns an InternalError.
 	if !ok {
-		panic(fmt.Sprintf("no handler found for: %v", typedEvent))
+		return brokenInvariant("event_handler_registered", fmt.Errorf("no handler found for: %v", eventTypeName(event)))
 	}
+	return publishError(ctx, handlerErrors, config)
+}
 
-	// Initialize a slice to collect errors from the event handlers.
-	handlerErrors := make([]error, 0)
+// publishFollowUpEvent publishes an event like PublishEvent, pausing included, on behalf of work already done,
+// such as the dispatch an outcome event follows. Breaking an invariant would come too late there, so an event
+// without handler returns an error wrapping ErrUnknownHandler instead.
+func publishFollowUpEvent(ctx context.Context, event T, config publishConfig) error {
+	if buffered, err := bufferPausedEvent(ctx, event, config); buffered {
+		return err
+	}
+	return tryPublishEvent(ctx, event, config)
+}
 
-	// Iterate over the registered event handlers.
-	for _, eventHandler := range registeredEventHandlers {
-		// Call the event handler and pass the context and the event.
-		// If the handler returns an error, append it to the handlerErrors slice.
-		_, err := eventHandler.eventHandler.Handle(ctx, event)
-		if err != nil {
-			handlerErrors = append(handlerErrors, err)
-		}
+// tryPublishEvent delivers an event to its handlers, returning an error wrapping ErrUnknownHandler if it has none.
+func tryPublishEvent(ctx context.Context, event T, config publishConfig) error {
+	handlerErrors, ok := publish(ctx, event, config)
+	if !ok {
+		return errNoEventHandler(event)
+	}
+	return publishError(ctx, handlerErrors, config)
+}
+
+// errNoEventHandler returns the error of an event published without any handler for its type.
+func errNoEventHandler(event T) error {
+	return fmt.Errorf("%w: no event handler found for %v", ErrUnknownHandler, eventTypeName(event))
+}
+
+// publishError returns the error of a publish given the errors returned by the handlers.
+func publishError(ctx context.Context, handlerErrors []error, config publishConfig) error {
+	// An event skipped by the deduplication is not a failure.
+	if isDuplicatePublish(handlerErrors) {
+		return nil
 	}
 
 	// If there were any errors collected from the handlers, return them joined together.
 	// This combines multiple errors into a single error.
 	if len(handlerErrors) > 0 {
-		return errors.Join(handlerErrors...)
+		err := classifyPublishCancellation(ctx, errors.Join(handlerErrors...))
+		if config.bestEffort {
+			reportError(ctx, err)
+			return nil
+		}
+		return err
 	}
 
 	// If execution reaches here, it means all handlers executed without error.
 	// Return nil indicating successful execution.
 	return nil
 }
+
+// publishConfig tunes how publish delivers an event.
+type publishConfig struct {
+	failFast       bool                         // Stop at the first handler error.
+	bestEffort     bool                         // Report the handler errors instead of returning them.
+	parallel       bool                         // Deliver to the handlers concurrently.
+	maxConcurrency int                          // Concurrent deliveries of a parallel publish, zero for no limit.
+	maxErrors      int                          // Handler errors collected, zero for no limit.
+	handled        func(handler IHandler[T, T]) // Called for every handler that handled the event successfully.
+	duplicate      func(event any)              // Called if the event is skipped by the deduplication.
+}
+
+// publish delivers an event to its handlers and consumption groups and returns the errors they returned.
+// It returns false if no handler is registered for the event type.
+func publish(ctx context.Context, event T, config publishConfig) ([]error, bool) {
+	// Obtain the type of the event as a string using reflection.
+	typedEvent := eventTypeName(event)
+
+	registeredEventHandlers, groups, ok := eventSubscribers(ctx, typedEvent)
+	ctx = withNestedOverrides(ctx)
+
+	if !ok {
+		return nil, false
+	}
+
+	// Drop or delay events exceeding the rate limit of their type, if configured. It comes first so a dropped
+	// event is not remembered by the deduplication, and its redelivery is handled.
+	if admitted, err := admitEvent(ctx, typedEvent); !admitted {
+		if err != nil {
+			return []error{err}, true
+		}
+		return nil, true
+	}
+
+	// Skip events already delivered within the deduplication window, if configured.
+	forget, duplicate := claimEvent(event)
+	if duplicate {
+		if config.duplicate != nil {
+			config.duplicate(event)
+		}
+		return []error{ErrDuplicateEvent}, true
+	}
+
+	handlerErrors := deliverEvent(ctx, registeredEventHandlers, groups, event, config)
+	if len(handlerErrors) > 0 {
+		// Let a redelivery of the failed event be handled again.
+		forget()
+	}
+	return handlerErrors, true
+}
+
+// eventSubscribers returns the registered handlers and consumption groups of an event type, or the handlers
+// overridden by ctx. It returns false if the event type has neither.
+func eventSubscribers(ctx context.Context, typedEvent string) ([]eventHandlersType, []*eventHandlerGroup, bool) {
+	// Subscribers overridden by ctx replace the registered handlers and groups.
+	if overridden, isOverridden := eventHandlersOverrideFor(ctx, typedEvent); isOverridden {
+		return overridden, nil, true
+	}
+
+	// Attempt to load the registered event handlers for the specific event type.
+	eventHandlerMutex.RLock()
+	registeredEventHandlers, ok := eventHandlers[typedEvent]
+	eventHandlerMutex.RUnlock()
+
+	// Load the consumption groups for the event type as well.
+	groups, hasGroups := groupsForEvent(typedEvent)
+	return registeredEventHandlers, groups, ok || hasGroups
+}
+
+// deliverEvent delivers an event to the registered handlers and consumption groups and returns the errors they returned.
+func deliverEvent(ctx context.Context, registeredEventHandlers []eventHandlersType, groups []*eventHandlerGroup, event T, config publishConfig) []error {
+	// Collect the errors from the event handlers, up to the configured limit.
+	handlerErrors := newErrorCollector(config.maxErrors)
+
+	if config.parallel {
+		if publishParallel(ctx, registeredEventHandlers, event, config, handlerErrors) {
+			return handlerErrors.result()
+		}
+		return append(handlerErrors.result(), publishToGroups(ctx, groups, event, config)...)
+	}
+
+	// Iterate over the registered event handlers.
+	for _, eventHandler := range registeredEventHandlers {
+		// Call the event handler and pass the context and the event, unless it was removed meanwhile.
+		// If the handler returns an error, collect it.
+		delivered, err := eventHandler.deliver(ctx, event)
+		if !delivered {
+			continue
+		}
+		if err != nil && !errors.Is(err, ErrStopPropagation) {
+			handlerErrors.add(eventHandler.typeName, err)
+			if config.failFast {
+				return handlerErrors.result()
+			}
+			continue
+		}
+		config.onHandled(eventHandler.eventHandler)
+
+		// The handler consumed the event, skip the remaining handlers and groups.
+		if err != nil {
+			return handlerErrors.result()
+		}
+	}
+
+	// Deliver the event to a single member of every consumption group.
+	return append(handlerErrors.result(), publishToGroups(ctx, groups, event, config)...)
+}
+
+// publishParallel delivers an event to the registered handlers concurrently and reports whether it aborted
+// on the first error of a fail-fast publish, canceling the context of the deliveries still running.
+// ErrStopPropagation does not stop the deliveries already running.
+func publishParallel(ctx context.Context, registeredEventHandlers []eventHandlersType, event T, config publishConfig, handlerErrors *errorCollector) bool {
+	group := newHandlerGroup(ctx, config.maxConcurrency, config.failFast, handlerErrors)
+	var handledMutex sync.Mutex
+	for _, eventHandler := range registeredEventHandlers {
+		eventHandler := eventHandler
+		group.goDeliver(eventHandler.typeName, func(ctx context.Context) error {
+			delivered, err := eventHandler.deliver(ctx, event)
+			if !delivered || (err != nil && !errors.Is(err, ErrStopPropagation)) {
+				return err
+			}
+			handledMutex.Lock()
+			defer handledMutex.Unlock()
+			config.onHandled(eventHandler.eventHandler)
+			return nil
+		})
+	}
+	return group.wait(ctx)
+}
+
+// onHandled reports a handler that handled the event successfully.
+func (config publishConfig) onHandled(handler IHandler[T, T]) {
+	if config.handled != nil {
+		config.handled(handler)
+	}
+}
+
+// eventTypeName returns the type name events are registered under.
+func eventTypeName(event T) string {
+	return eventTypeKey(reflect.TypeOf(event))
+}
+
+// eventTypeKey returns the type name the events of type typ are registered under.
+// This strips the "*" prefix, which indicates a pointer type, so both forms of an event type share their handlers.
+func eventTypeKey(typ reflect.Type) string {
+	return normalizeTypeKey(strings.TrimPrefix(typ.String(), "*"))
+}
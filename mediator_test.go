@@ -2,7 +2,9 @@ package gocqrs
 
 import (
 	"context"
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"reflect"
 	"strings"
 	"testing"
@@ -88,6 +90,43 @@ func TestSendCommand(t *testing.T) {
 	})
 }
 
+type sendAgnosticCommand struct{ Name string }
+
+type sendAgnosticQuery struct{ ID int }
+
+type sendAgnosticCommandHandler struct{}
+
+func (h *sendAgnosticCommandHandler) Handle(ctx context.Context, command sendAgnosticCommand) (string, error) {
+	return "created " + command.Name, nil
+}
+
+type sendAgnosticQueryHandler struct{}
+
+func (h *sendAgnosticQueryHandler) Handle(ctx context.Context, query sendAgnosticQuery) (int, error) {
+	return query.ID * 2, nil
+}
+
+// TestSend tests that Send dispatches both commands and queries through their middlewares.
+func TestSend(t *testing.T) {
+	var seen []any
+	record := func(ctx context.Context, request any) (context.Context, any, bool) {
+		seen = append(seen, request)
+		return ctx, request, true
+	}
+	AddCommandHandler[sendAgnosticCommand, string](&sendAgnosticCommandHandler{}).PreMiddleware(record)
+	AddQueryHandler[sendAgnosticQuery, int](&sendAgnosticQueryHandler{}).PreMiddleware(record)
+
+	created, err := Send[string](context.Background(), sendAgnosticCommand{Name: "order"})
+	assert.NoError(t, err)
+	assert.Equal(t, "created order", created)
+
+	doubled, err := Send[int](context.Background(), sendAgnosticQuery{ID: 21})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, doubled)
+
+	assert.Equal(t, []any{sendAgnosticCommand{Name: "order"}, sendAgnosticQuery{ID: 21}}, seen)
+}
+
 // MockEventHandler for events
 type MockEventHandler struct{}
 
@@ -116,6 +155,85 @@ func TestPublishEvent(t *testing.T) {
 	})
 }
 
+type stopPropagationEvent struct{}
+
+type consumingEventHandler struct {
+	calls int
+}
+
+func (h *consumingEventHandler) Handle(ctx context.Context, event stopPropagationEvent) error {
+	h.calls++
+	return ErrStopPropagation
+}
+
+type skippedEventHandler struct {
+	calls int
+}
+
+func (h *skippedEventHandler) Handle(ctx context.Context, event stopPropagationEvent) error {
+	h.calls++
+	return nil
+}
+
+// TestPublishEvent_StopPropagation tests that a handler returning ErrStopPropagation stops the remaining handlers without failing.
+func TestPublishEvent_StopPropagation(t *testing.T) {
+	first := &consumingEventHandler{}
+	second := &skippedEventHandler{}
+	assert.NoError(t, AddEventHandlers[stopPropagationEvent](first, second))
+
+	err := PublishEvent(context.Background(), stopPropagationEvent{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, first.calls)
+	assert.Equal(t, 0, second.calls)
+}
+
+type downloadQuery struct {
+	size int
+}
+
+// trackedReader is a streamed payload recording whether it was closed.
+type trackedReader struct {
+	io.Reader
+	closed bool
+}
+
+func (r *trackedReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+type downloadQueryHandler struct {
+	reader *trackedReader
+}
+
+func (h *downloadQueryHandler) Handle(ctx context.Context, query downloadQuery) (io.ReadCloser, error) {
+	if query.size == 0 {
+		return nil, errors.New("empty download")
+	}
+	h.reader = &trackedReader{Reader: strings.NewReader(strings.Repeat("x", query.size))}
+	return h.reader, nil
+}
+
+// TestSendQuery_ReadCloser tests that a streamed response reaches the caller unread and open.
+func TestSendQuery_ReadCloser(t *testing.T) {
+	handler := &downloadQueryHandler{}
+	AddQueryHandler[downloadQuery, io.ReadCloser](handler)
+
+	reader, err := SendQuery[io.ReadCloser](context.Background(), downloadQuery{size: 1 << 20})
+	assert.NoError(t, err)
+	assert.Same(t, handler.reader, reader)
+	assert.False(t, handler.reader.closed, "the caller owns closing the reader")
+
+	content, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Len(t, content, 1<<20)
+	assert.NoError(t, reader.Close())
+
+	reader, err = SendQuery[io.ReadCloser](context.Background(), downloadQuery{})
+	assert.EqualError(t, err, "empty download")
+	assert.Nil(t, reader)
+}
+
 // TestSendCommand_Concurrency tests the SendCommand function for concurrent access.
 func TestSendCommand_Concurrency(t *testing.T) {
 	ctx := context.Background()
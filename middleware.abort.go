@@ -0,0 +1,16 @@
+package gocqrs
+
+import "context"
+
+// abortedRequest is the request returned by a pre-middleware aborting the dispatch with AbortWith.
+type abortedRequest struct {
+	err error
+}
+
+// AbortWith is returned by a pre-middleware to abort the dispatch: the remaining pre-middlewares and the handler
+// are skipped and the dispatch returns err. Post-middlewares still run with the original request.
+//
+//	return gocqrs.AbortWith(ctx, ErrForbidden)
+func AbortWith(ctx context.Context, err error) (context.Context, any, bool) {
+	return ctx, &abortedRequest{err: err}, false
+}
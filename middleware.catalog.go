@@ -0,0 +1,141 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Attachment describes a named middleware to attach to a named handler in a given phase.
+// It is the unit of configuration accepted by ApplyAttachmentConfig.
+type Attachment struct {
+	Handler    string // Name of the handler, either set with Named or the registered handler type name.
+	Middleware string // Name of a middleware registered with RegisterNamedMiddleware.
+	Phase      Phase  // Whether the middleware runs before or after the handler.
+}
+
+var (
+	namedMiddlewareMutex sync.RWMutex
+	namedMiddlewares     = make(map[string]func(ctx context.Context, request any) (context.Context, any, bool))
+
+	handlerAliasMutex sync.RWMutex
+	handlerAliases    = make(map[string]string)
+
+	// attachmentMutex serializes attachment configurations so they are applied atomically.
+	attachmentMutex sync.Mutex
+)
+
+// Named assigns a stable name to the current handler. The name can be used instead of the
+// reflected handler type name, which changes with refactors, to attach middlewares by name.
+func (middlewareBuilder *AddMiddlewareBuilder) Named(name string) *AddMiddlewareBuilder {
+	handlerName := middlewareBuilder.currentHandler()
+
+	handlerAliasMutex.Lock()
+	defer handlerAliasMutex.Unlock()
+	handlerAliases[name] = handlerName
+	return middlewareBuilder
+}
+
+// RegisterNamedMiddleware adds a middleware to the catalog of middlewares that can be attached by name.
+// Registering the same name again replaces the catalog entry; already attached middlewares are not affected.
+func RegisterNamedMiddleware(name string, middlewareFunc func(ctx context.Context, request any) (context.Context, any, bool)) {
+	namedMiddlewareMutex.Lock()
+	defer namedMiddlewareMutex.Unlock()
+	namedMiddlewares[name] = middlewareFunc
+}
+
+// AttachByName attaches the catalog middleware middlewareName to the handler handlerName in the given phase.
+func AttachByName(handlerName, middlewareName string, phase Phase) error {
+	return ApplyAttachmentConfig([]Attachment{{Handler: handlerName, Middleware: middlewareName, Phase: phase}})
+}
+
+// ApplyAttachmentConfig attaches every middleware described by attachments.
+// All attachments are validated before any of them is applied, and the configuration is applied
+// atomically: if one attachment fails, the ones already applied are rolled back.
+func ApplyAttachmentConfig(attachments []Attachment) error {
+	attachmentMutex.Lock()
+	defer attachmentMutex.Unlock()
+
+	type resolvedAttachment struct {
+		handlerName string
+		middleware  middlewareStruct
+		phase       Phase
+	}
+
+	// Validate the whole configuration first, collecting every error.
+	resolved := make([]resolvedAttachment, 0, len(attachments))
+	validationErrors := make([]error, 0)
+	for _, attachment := range attachments {
+		handlerName, handlerFound := resolveHandlerName(attachment.Handler)
+		if !handlerFound {
+			validationErrors = append(validationErrors, fmt.Errorf("%w: %v", ErrUnknownHandler, attachment.Handler))
+		}
+
+		namedMiddlewareMutex.RLock()
+		middlewareFunc, middlewareFound := namedMiddlewares[attachment.Middleware]
+		namedMiddlewareMutex.RUnlock()
+		if !middlewareFound {
+			validationErrors = append(validationErrors, fmt.Errorf("%w: %v", ErrUnknownMiddleware, attachment.Middleware))
+		}
+
+		resolved = append(resolved, resolvedAttachment{
+			handlerName: handlerName,
+			middleware:  middlewareStruct{middlewareName: attachment.Middleware, middlewareFunc: middlewareFunc},
+			phase:       attachment.Phase,
+		})
+	}
+	if len(validationErrors) > 0 {
+		return errors.Join(validationErrors...)
+	}
+
+	middlewareBuilder.mutex.Lock()
+	defer middlewareBuilder.mutex.Unlock()
+
+	// Keep the current chains of every touched handler to roll back on failure.
+	snapshots := make(map[Phase]map[string][]middlewareStruct)
+	for _, attachment := range resolved {
+		if snapshots[attachment.phase] == nil {
+			snapshots[attachment.phase] = make(map[string][]middlewareStruct)
+		}
+		if _, ok := snapshots[attachment.phase][attachment.handlerName]; !ok {
+			snapshots[attachment.phase][attachment.handlerName], _ = middlewareBuilder.chain(attachment.phase, attachment.handlerName)
+		}
+	}
+
+	for _, attachment := range resolved {
+		if err := middlewareBuilder.addMiddlewareLocked(attachment.phase, attachment.handlerName, attachment.middleware); err != nil {
+			for phase, chains := range snapshots {
+				registered := middlewareBuilder.middlewaresFor(phase)
+				for handlerName, chain := range chains {
+					if chain == nil {
+						registered.Delete(handlerName)
+						continue
+					}
+					registered.Store(handlerName, chain)
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveHandlerName returns the registered handler name for a name set with Named or a handler type name.
+func resolveHandlerName(name string) (string, bool) {
+	handlerAliasMutex.RLock()
+	handlerName, ok := handlerAliases[name]
+	handlerAliasMutex.RUnlock()
+	if ok {
+		return handlerName, true
+	}
+
+	handlerMutex.RLock()
+	defer handlerMutex.RUnlock()
+	for _, value := range handlers {
+		if nameField, ok := getField(value, "Name"); ok && nameField.String() == name {
+			return name, true
+		}
+	}
+	return "", false
+}
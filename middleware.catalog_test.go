@@ -0,0 +1,97 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type catalogCommand struct{}
+
+type catalogCommandHandler struct{}
+
+func (h *catalogCommandHandler) Handle(ctx context.Context, command catalogCommand) (string, error) {
+	return "handled", nil
+}
+
+type catalogQuery struct{}
+
+type catalogQueryHandler struct{}
+
+func (h *catalogQueryHandler) Handle(ctx context.Context, query catalogQuery) (string, error) {
+	return "handled", nil
+}
+
+// catalogCounter returns a middleware counting its invocations.
+func catalogCounter(counter *int) func(ctx context.Context, request any) (context.Context, any, bool) {
+	return func(ctx context.Context, request any) (context.Context, any, bool) {
+		*counter++
+		return ctx, request, true
+	}
+}
+
+// TestApplyAttachmentConfig tests attaching catalog middlewares to named handlers.
+func TestApplyAttachmentConfig(t *testing.T) {
+	var rateLimitCalls, auditCalls int
+	RegisterNamedMiddleware("catalog.ratelimit", catalogCounter(&rateLimitCalls))
+	RegisterNamedMiddleware("catalog.audit", catalogCounter(&auditCalls))
+	AddCommandHandler[catalogCommand, string](&catalogCommandHandler{}).Named("catalog.create")
+
+	err := ApplyAttachmentConfig([]Attachment{
+		{Handler: "catalog.create", Middleware: "catalog.ratelimit", Phase: PrePhase},
+		{Handler: "catalog.create", Middleware: "catalog.audit", Phase: PostPhase},
+	})
+	assert.NoError(t, err)
+
+	_, err = SendCommand[string](context.Background(), catalogCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rateLimitCalls)
+	assert.Equal(t, 1, auditCalls)
+}
+
+// TestAttachByName_UnknownNames tests the errors returned for unknown handlers and middlewares.
+func TestAttachByName_UnknownNames(t *testing.T) {
+	var calls int
+	RegisterNamedMiddleware("catalog.known", catalogCounter(&calls))
+	AddQueryHandler[catalogQuery, string](&catalogQueryHandler{})
+
+	err := AttachByName("catalog.missing", "catalog.known", PrePhase)
+	assert.True(t, errors.Is(err, ErrUnknownHandler))
+
+	err = AttachByName("*gocqrs.catalogQueryHandler", "catalog.missing", PrePhase)
+	assert.True(t, errors.Is(err, ErrUnknownMiddleware))
+
+	// The reflected handler type name is accepted as well.
+	err = AttachByName("*gocqrs.catalogQueryHandler", "catalog.known", PrePhase)
+	assert.NoError(t, err)
+}
+
+// TestApplyAttachmentConfig_Rollback tests that a failing configuration leaves no attachment behind.
+func TestApplyAttachmentConfig_Rollback(t *testing.T) {
+	defer SetDuplicateMiddlewarePolicy(DuplicateMiddlewareIgnore)
+
+	var calls int
+	RegisterNamedMiddleware("catalog.rollback", catalogCounter(&calls))
+	AddCommandHandler[catalogCommand, string](&catalogCommandHandler{}).Named("catalog.rollback.handler")
+	handlerName, _ := resolveHandlerName("catalog.rollback.handler")
+	before := len(middlewareBuilder.chainOf(PostPhase, handlerName))
+
+	// Validation failure: nothing is applied.
+	err := ApplyAttachmentConfig([]Attachment{
+		{Handler: "catalog.rollback.handler", Middleware: "catalog.rollback", Phase: PostPhase},
+		{Handler: "catalog.rollback.handler", Middleware: "catalog.unknown", Phase: PostPhase},
+	})
+	assert.True(t, errors.Is(err, ErrUnknownMiddleware))
+	assert.Len(t, middlewareBuilder.chainOf(PostPhase, handlerName), before)
+
+	// Failure while applying: the first attachment is rolled back.
+	SetDuplicateMiddlewarePolicy(DuplicateMiddlewareError)
+	err = ApplyAttachmentConfig([]Attachment{
+		{Handler: "catalog.rollback.handler", Middleware: "catalog.rollback", Phase: PostPhase},
+		{Handler: "catalog.rollback.handler", Middleware: "catalog.rollback", Phase: PostPhase},
+	})
+	assert.True(t, errors.Is(err, ErrDuplicateMiddleware))
+	assert.Len(t, middlewareBuilder.chainOf(PostPhase, handlerName), before)
+}
@@ -0,0 +1,35 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+)
+
+// DryRunMiddleware runs the pre-middlewares then the post-middlewares that a dispatch of request by the handler
+// handlerName would run, without any handler, and returns the resulting context and request.
+// shortCircuited reports whether a middleware stopped its chain or aborted with AbortWith; a stopped
+// pre-middleware chain still lets the post-middlewares run, as in a dispatch, while an abort ends the dry run.
+// It is meant for testing and debugging the ordering of middlewares.
+func DryRunMiddleware(ctx context.Context, request any, handlerName string) (context.Context, any, bool) {
+	if isInternalRequest(rewriteRequestKey(typeKey(reflect.TypeOf(request)))) {
+		return ctx, request, false
+	}
+
+	shortCircuited := false
+	for _, phase := range []Phase{PrePhase, PostPhase} {
+		middlewares, _ := middlewareBuilder.chainFor(phase, handlerName, request)
+		for _, m := range middlewares {
+			var chain bool
+			received := request
+			ctx, request, chain = m.middlewareFunc(ctx, request)
+			if _, aborted := request.(*abortedRequest); aborted {
+				return ctx, received, true
+			}
+			if !chain {
+				shortCircuited = true
+				break
+			}
+		}
+	}
+	return ctx, request, shortCircuited
+}
@@ -0,0 +1,53 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type dryRunQuery struct {
+	Tenant string
+}
+
+type dryRunQueryHandler struct{}
+
+func (h *dryRunQueryHandler) Handle(ctx context.Context, query dryRunQuery) (string, error) {
+	return "", errors.New("the handler must not run")
+}
+
+type dryRunTenantKey struct{}
+
+// TestDryRunMiddleware tests that the chain stopped by a middleware is reported as short-circuited.
+func TestDryRunMiddleware(t *testing.T) {
+	var calls []string
+	stopping := true
+	AddQueryHandler[dryRunQuery, string](&dryRunQueryHandler{}).
+		PreMiddlewareNamed("tenant", func(ctx context.Context, request any) (context.Context, any, bool) {
+			calls = append(calls, "tenant")
+			query := request.(dryRunQuery)
+			query.Tenant = "acme"
+			return context.WithValue(ctx, dryRunTenantKey{}, query.Tenant), query, !stopping
+		}).
+		PreMiddlewareNamed("auth", func(ctx context.Context, request any) (context.Context, any, bool) {
+			calls = append(calls, "auth")
+			return ctx, request, true
+		}).
+		PostMiddlewareNamed("audit", func(ctx context.Context, request any) (context.Context, any, bool) {
+			calls = append(calls, "audit")
+			return ctx, request, true
+		})
+
+	ctx, request, shortCircuited := DryRunMiddleware(context.Background(), dryRunQuery{}, "*gocqrs.dryRunQueryHandler")
+	assert.True(t, shortCircuited)
+	assert.Equal(t, dryRunQuery{Tenant: "acme"}, request)
+	assert.Equal(t, "acme", ctx.Value(dryRunTenantKey{}))
+	assert.Equal(t, []string{"tenant", "audit"}, calls)
+
+	calls, stopping = nil, false
+	_, _, shortCircuited = DryRunMiddleware(context.Background(), dryRunQuery{}, "*gocqrs.dryRunQueryHandler")
+	assert.False(t, shortCircuited)
+	assert.Equal(t, []string{"tenant", "auth", "audit"}, calls)
+}
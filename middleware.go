@@ -2,9 +2,12 @@ package gocqrs
 
 import (
 	"context"
+	"fmt"
 	"reflect"
-	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type (
@@ -18,42 +21,117 @@ type (
 	// AddMiddlewareBuilder is a struct used for building middleware chains
 	// for a specific command/query/event handler. It stores the name of the current handler
 	// and maps of pre- and post-middlewares associated with that handler.
+	//
+	// Middleware chains are never mutated in place: every registration stores a new slice for its handler,
+	// serialized by the builder's lock. Dispatches load the chains without locking, so a registration never blocks
+	// them and a running dispatch keeps seeing the chain as of the moment it read it.
 	AddMiddlewareBuilder struct {
-		currentHandlerName string                        // Name of the handler for which middlewares are being added.
-		preMiddlewares     map[string][]middlewareStruct // Map of pre-middlewares for each handler.
-		postMiddlewares    map[string][]middlewareStruct // Map of post-middlewares for each handler.
+		mutex              sync.RWMutex // Guards currentHandlerName and serializes the registrations.
+		currentHandlerName string       // Name of the handler for which middlewares are being added.
+		err                error        // First registration error of the current handler, returned by Err.
+		preMiddlewares     sync.Map     // Handler name to its pre-middlewares, a []middlewareStruct.
+		postMiddlewares    sync.Map     // Handler name to its post-middlewares, a []middlewareStruct.
+		behaviors          sync.Map     // Handler name to its pipeline behaviors, a []PipelineBehavior.
 	}
 
+	// Phase identifies when a middleware runs relative to the handler.
+	Phase int
+
+	// DuplicateMiddlewarePolicy defines what happens when a middleware is registered twice for the same handler.
+	DuplicateMiddlewarePolicy int
+
 	// middlewareStruct represents a middleware with its name and the function itself.
 	// It is used to store individual middleware functions along with their names.
 	middlewareStruct struct {
 		middlewareName string                                                              // Name of the middleware.
 		middlewareFunc func(ctx context.Context, request any) (context.Context, any, bool) // The middleware function.
+		shared         bool                                                                // References the shared middleware middlewareName, resolved when the chain runs.
 	}
 )
 
+const (
+	// PrePhase runs the middleware before the handler.
+	PrePhase Phase = iota
+	// PostPhase runs the middleware after the handler.
+	PostPhase
+)
+
+const (
+	// DuplicateMiddlewareIgnore silently drops a middleware already registered for the handler. This is the default.
+	DuplicateMiddlewareIgnore DuplicateMiddlewarePolicy = iota
+	// DuplicateMiddlewareError rejects a middleware already registered for the handler with ErrDuplicateMiddleware,
+	// returned by the builder's Err and reported with the ErrorReporter.
+	DuplicateMiddlewareError
+	// DuplicateMiddlewareAllow registers the middleware again, so it runs once per registration.
+	DuplicateMiddlewareAllow
+)
+
+var (
+	duplicateMiddlewarePolicyMutex sync.RWMutex
+	duplicateMiddlewarePolicy      = DuplicateMiddlewareIgnore
+
+	// skipPostOnError is set by SetRunPostOnError(false).
+	skipPostOnError atomic.Bool
+)
+
+// SetDuplicateMiddlewarePolicy configures how PreMiddleware and PostMiddleware treat a middleware
+// that shares its name with one already registered for the same handler.
+func SetDuplicateMiddlewarePolicy(policy DuplicateMiddlewarePolicy) {
+	duplicateMiddlewarePolicyMutex.Lock()
+	defer duplicateMiddlewarePolicyMutex.Unlock()
+	duplicateMiddlewarePolicy = policy
+}
+
+// getDuplicateMiddlewarePolicy returns the configured duplicate middleware policy.
+func getDuplicateMiddlewarePolicy() DuplicateMiddlewarePolicy {
+	duplicateMiddlewarePolicyMutex.RLock()
+	defer duplicateMiddlewarePolicyMutex.RUnlock()
+	return duplicateMiddlewarePolicy
+}
+
+// SetRunPostOnError sets whether post-middlewares run when a dispatch fails, which is the default.
+// Disable it for post-middlewares that assume a valid response: a dispatch whose handler, behaviors or
+// pre-middlewares returned an error then skips them.
+func SetRunPostOnError(enabled bool) {
+	skipPostOnError.Store(!enabled)
+}
+
 // executePreMiddlewares runs pre-middlewares for a given request and context.
-// If any middleware returns false, the chain is stopped.
-func (middlewareBuilder *AddMiddlewareBuilder) executePreMiddlewares(ctx context.Context, request T, handlerName string) T {
-	if middlewares, ok := middlewareBuilder.preMiddlewares[handlerName]; ok {
+// If any middleware returns false, the chain is stopped. A middleware returning AbortWith aborts the dispatch with its error.
+// When middleware type checking is enabled for the handler, a middleware changing the request type returns an error.
+func (middlewareBuilder *AddMiddlewareBuilder) executePreMiddlewares(ctx context.Context, request T, handlerName string) (T, error) {
+	if middlewares, ok := middlewareBuilder.chainFor(PrePhase, handlerName, request); ok {
+		typeChecking := isTypeCheckingEnabled(handlerName)
 		for _, m := range middlewares {
 			var chain bool
+			received := request
+			recordStep(ctx, "pre:"+m.middlewareName)
 			ctx, request, chain = m.middlewareFunc(ctx, request)
+			if aborted, ok := request.(*abortedRequest); ok {
+				// Middleware has aborted the dispatch with AbortWith.
+				return received, aborted.err
+			}
+			if typeChecking {
+				if err := checkMiddlewareType(m.middlewareName, received, request); err != nil {
+					return received, err
+				}
+			}
 			if !chain {
 				// Middleware has stopped the chain.
-				return request
+				return request, nil
 			}
 		}
 	}
-	return request
+	return request, nil
 }
 
 // executePostMiddlewares runs post-middlewares for a given request and context.
 // If any middleware returns false, the chain is stopped.
 func (middlewareBuilder *AddMiddlewareBuilder) executePostMiddlewares(ctx context.Context, request T, handlerName string) {
-	if middlewares, ok := middlewareBuilder.postMiddlewares[handlerName]; ok {
+	if middlewares, ok := middlewareBuilder.chainFor(PostPhase, handlerName, request); ok {
 		for _, m := range middlewares {
 			var chain bool
+			recordStep(ctx, "post:"+m.middlewareName)
 			ctx, request, chain = m.middlewareFunc(ctx, request)
 			if !chain {
 				// Middleware has stopped the chain.
@@ -63,43 +141,58 @@ func (middlewareBuilder *AddMiddlewareBuilder) executePostMiddlewares(ctx contex
 	}
 }
 
-// PreMiddleware adds a pre-middleware to the current handler.
-// middlewareFunc parameter defines a function type used for middleware.
-// It receives a context and a request (of any type). The function returns three values:
-// 1. A potentially modified context, which is the chained context after processing.
-// 2. A result (of any type), which is the chained request parameter after processing.
-// 3. A boolean indicating whether to continue with the chain of middlewares or not.
-func (middlewareBuilder *AddMiddlewareBuilder) PreMiddleware(middlewareFunc func(ctx context.Context, request any) (context.Context, any, bool)) *AddMiddlewareBuilder {
-
-	// Extract the name of the middleware function using reflection and strip the pointer indicator.
-	typedMiddlewareName := strings.TrimPrefix(runtime.FuncForPC(reflect.ValueOf(middlewareFunc).Pointer()).Name(), "*")
+// currentHandler returns the name of the handler for which middlewares are being added.
+func (middlewareBuilder *AddMiddlewareBuilder) currentHandler() string {
+	middlewareBuilder.mutex.RLock()
+	defer middlewareBuilder.mutex.RUnlock()
+	return middlewareBuilder.currentHandlerName
+}
 
-	// Create a middlewareStruct instance with the middleware name and function.
-	middleware := middlewareStruct{
-		middlewareName: typedMiddlewareName,
-		middlewareFunc: middlewareFunc,
+// chainFor returns the middlewares running for a dispatch of request by a handler in the given phase:
+// the middlewares registered for the request type followed by those registered for the handler.
+// Shared middleware references are resolved to their current definitions.
+func (middlewareBuilder *AddMiddlewareBuilder) chainFor(phase Phase, handlerName string, request any) ([]middlewareStruct, bool) {
+	middlewares, ok := middlewareBuilder.chain(phase, handlerName)
+	if typed := requestChain(phase, request); len(typed) > 0 {
+		middlewares, ok = append(typed[:len(typed):len(typed)], middlewares...), true
 	}
+	return resolveSharedMiddlewares(middlewares), ok
+}
 
-	// Retrieve the slice of pre-middlewares associated with the current handler from the middlewareBuilder.
-	middlewares, ok := middlewareBuilder.preMiddlewares[middlewareBuilder.currentHandlerName]
+// registeredChain returns the middlewares running for a dispatch by a handler of the request type registered
+// under requestType in the given phase, as chainFor returns them for a zero value of that type.
+func (middlewareBuilder *AddMiddlewareBuilder) registeredChain(phase Phase, handlerName string, requestType string) ([]middlewareStruct, bool) {
+	var request any
+	if typ, ok := getRequestType(requestType); ok {
+		request = reflect.Zero(typ).Interface()
+	}
+	return middlewareBuilder.chainFor(phase, handlerName, request)
+}
 
-	// If the current handler does not have any pre-middlewares, initialize it with the new middleware.
+// chain returns a snapshot of the middlewares registered for a handler in the given phase.
+func (middlewareBuilder *AddMiddlewareBuilder) chain(phase Phase, handlerName string) ([]middlewareStruct, bool) {
+	middlewares, ok := middlewareBuilder.middlewaresFor(phase).Load(handlerName)
 	if !ok {
-		middlewareBuilder.preMiddlewares[middlewareBuilder.currentHandlerName] = []middlewareStruct{
-			middleware,
-		}
-		return middlewareBuilder
+		return nil, false
 	}
+	return middlewares.([]middlewareStruct), true
+}
 
-	// Add the middleware to the handler if it's not already registered.
-	// This is a check to avoid registering the same middleware multiple times for a handler.
-	if !isMiddlewareRegisteredForHandler(&middlewares, typedMiddlewareName) {
-		middlewareBuilder.preMiddlewares[middlewareBuilder.currentHandlerName] =
-			append(middlewareBuilder.preMiddlewares[middlewareBuilder.currentHandlerName], middleware)
-	}
+// PreMiddleware adds a pre-middleware to the current handler.
+// middlewareFunc parameter defines a function type used for middleware.
+// It receives a context and a request (of any type). The function returns three values:
+// 1. A potentially modified context, which is the chained context after processing.
+// 2. A result (of any type), which is the chained request parameter after processing.
+// 3. A boolean indicating whether to continue with the chain of middlewares or not.
+func (middlewareBuilder *AddMiddlewareBuilder) PreMiddleware(middlewareFunc func(ctx context.Context, request any) (context.Context, any, bool)) *AddMiddlewareBuilder {
+	start := registrationStart()
+	return middlewareBuilder.addMiddleware(PrePhase, middlewareFuncName(middlewareFunc), middlewareFunc, start)
+}
 
-	// Return the middlewareBuilder to allow method chaining.
-	return middlewareBuilder
+// PreMiddlewareNamed adds a pre-middleware to the current handler using an explicit name
+// instead of the runtime symbol of the function. Names are used to detect duplicates.
+func (middlewareBuilder *AddMiddlewareBuilder) PreMiddlewareNamed(middlewareName string, middlewareFunc func(ctx context.Context, request any) (context.Context, any, bool)) *AddMiddlewareBuilder {
+	return middlewareBuilder.addMiddleware(PrePhase, middlewareName, middlewareFunc, registrationStart())
 }
 
 // PreMiddlewares adds a list of middleware functions to be executed before a primary action.
@@ -135,36 +228,113 @@ func (middlewareBuilder *AddMiddlewareBuilder) PostMiddlewares(middlewaresFunc .
 // 2. A result (of any type), which is the chained request parameter after processing.
 // 3. A boolean indicating whether to continue with the chain of middlewares or not.
 func (middlewareBuilder *AddMiddlewareBuilder) PostMiddleware(middlewareFunc func(ctx context.Context, request any) (context.Context, any, bool)) *AddMiddlewareBuilder {
+	start := registrationStart()
+	return middlewareBuilder.addMiddleware(PostPhase, middlewareFuncName(middlewareFunc), middlewareFunc, start)
+}
+
+// PostMiddlewareNamed adds a post-middleware to the current handler using an explicit name
+// instead of the runtime symbol of the function. Names are used to detect duplicates.
+func (middlewareBuilder *AddMiddlewareBuilder) PostMiddlewareNamed(middlewareName string, middlewareFunc func(ctx context.Context, request any) (context.Context, any, bool)) *AddMiddlewareBuilder {
+	return middlewareBuilder.addMiddleware(PostPhase, middlewareName, middlewareFunc, registrationStart())
+}
 
-	// Extract the name of the middleware function using reflection and strip the pointer indicator.
-	typedMiddlewareName := strings.TrimPrefix(runtime.FuncForPC(reflect.ValueOf(middlewareFunc).Pointer()).Name(), "*")
+// addMiddleware adds a middleware to the current handler in the given phase, recording the time spent since start.
+func (middlewareBuilder *AddMiddlewareBuilder) addMiddleware(phase Phase, middlewareName string, middlewareFunc func(ctx context.Context, request any) (context.Context, any, bool), start time.Time) *AddMiddlewareBuilder {
+	defer recordRegistrationTiming(RegistrationMiddleware, middlewareName, start)
 
 	// Create a middlewareStruct instance with the middleware name and function.
 	middleware := middlewareStruct{
-		middlewareName: typedMiddlewareName,
+		middlewareName: middlewareName,
 		middlewareFunc: middlewareFunc,
 	}
 
-	// Retrieve the slice of post-middlewares associated with the current handler from the middlewareBuilder.
-	middlewares, ok := middlewareBuilder.postMiddlewares[middlewareBuilder.currentHandlerName]
+	if err := middlewareBuilder.addCurrentHandlerMiddleware(phase, middleware); err != nil {
+		middlewareBuilder.fail(err)
+	}
 
-	// If the current handler does not have any post-middlewares, initialize it with the new middleware.
-	if !ok {
-		middlewareBuilder.postMiddlewares[middlewareBuilder.currentHandlerName] = []middlewareStruct{
-			middleware,
+	// Return the middlewareBuilder to allow method chaining.
+	return middlewareBuilder
+}
+
+// Err returns the first error of the middlewares added to the current handler since it was registered, such as
+// ErrDuplicateMiddleware under the DuplicateMiddlewareError policy, or nil. Such errors are also reported with the
+// ErrorReporter, since the builder methods are chained without returning them.
+func (middlewareBuilder *AddMiddlewareBuilder) Err() error {
+	middlewareBuilder.mutex.RLock()
+	defer middlewareBuilder.mutex.RUnlock()
+	return middlewareBuilder.err
+}
+
+// fail records a registration error of the current handler and reports it.
+func (middlewareBuilder *AddMiddlewareBuilder) fail(err error) {
+	middlewareBuilder.mutex.Lock()
+	if middlewareBuilder.err == nil {
+		middlewareBuilder.err = err
+	}
+	middlewareBuilder.mutex.Unlock()
+	reportError(context.Background(), err)
+}
+
+// addCurrentHandlerMiddleware registers a middleware for the current handler in the given phase.
+func (middlewareBuilder *AddMiddlewareBuilder) addCurrentHandlerMiddleware(phase Phase, middleware middlewareStruct) error {
+	middlewareBuilder.mutex.Lock()
+	defer middlewareBuilder.mutex.Unlock()
+	return middlewareBuilder.addMiddlewareLocked(phase, middlewareBuilder.currentHandlerName, middleware)
+}
+
+// addMiddlewareLocked registers a middleware for a handler in the given phase.
+// It returns an error when the duplicate middleware policy rejects the middleware.
+// The caller must hold the builder's lock.
+func (middlewareBuilder *AddMiddlewareBuilder) addMiddlewareLocked(phase Phase, handlerName string, middleware middlewareStruct) error {
+	current, _ := middlewareBuilder.chain(phase, handlerName)
+	middlewares, err := withMiddleware(current, handlerName, middleware)
+	if err != nil || len(middlewares) == len(current) {
+		return err
+	}
+	middlewareBuilder.middlewaresFor(phase).Store(handlerName, middlewares)
+	return nil
+}
+
+// appendMiddleware adds a middleware to the chain registered under key in registered, like withMiddleware.
+// The caller must hold the lock guarding registered.
+func appendMiddleware(registered map[string][]middlewareStruct, key string, middleware middlewareStruct) error {
+	middlewares, err := withMiddleware(registered[key], key, middleware)
+	if err != nil {
+		return err
+	}
+	registered[key] = middlewares
+	return nil
+}
+
+// withMiddleware returns the chain registered under key, a handler name or a request type, followed by
+// middleware, unless the duplicate middleware policy rejects it. By default this avoids registering the same
+// middleware multiple times for a key. It returns an error when the policy is DuplicateMiddlewareError.
+func withMiddleware(middlewares []middlewareStruct, key string, middleware middlewareStruct) ([]middlewareStruct, error) {
+	if isMiddlewareRegisteredForHandler(&middlewares, middleware.middlewareName) {
+		switch getDuplicateMiddlewarePolicy() {
+		case DuplicateMiddlewareAllow:
+		case DuplicateMiddlewareError:
+			return middlewares, fmt.Errorf("%w: %v for %v", ErrDuplicateMiddleware, middleware.middlewareName, key)
+		default:
+			return middlewares, nil
 		}
-		return middlewareBuilder
 	}
 
-	// Add the middleware to the handler if it's not already registered.
-	// This is a check to avoid registering the same middleware multiple times for a handler.
-	if !isMiddlewareRegisteredForHandler(&middlewares, typedMiddlewareName) {
-		middlewareBuilder.postMiddlewares[middlewareBuilder.currentHandlerName] =
-			append(middlewareBuilder.postMiddlewares[middlewareBuilder.currentHandlerName], middleware)
+	// Copy the chain so dispatches holding the previous slice never observe the new element.
+	return append(middlewares[:len(middlewares):len(middlewares)], middleware), nil
+}
+
+// middlewaresFor returns the middleware map of the given phase.
+func (middlewareBuilder *AddMiddlewareBuilder) middlewaresFor(phase Phase) *sync.Map {
+	if phase == PostPhase {
+		return &middlewareBuilder.postMiddlewares
 	}
+	return &middlewareBuilder.preMiddlewares
+}
 
-	// Return the middlewareBuilder to allow method chaining.
-	return middlewareBuilder
+// middlewareFuncName extracts the name of the middleware function using reflection and strips the pointer indicator.
+func middlewareFuncName(middlewareFunc func(ctx context.Context, request any) (context.Context, any, bool)) string {
+	return strings.TrimPrefix(funcName(middlewareFunc), "*")
 }
 
 // isMiddlewareRegisteredForHandler checks if a middleware is already registered for a handler.
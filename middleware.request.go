@@ -0,0 +1,131 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+var (
+	requestMiddlewareMutex sync.RWMutex
+	requestPreMiddlewares  = make(map[string][]middlewareStruct) // Pre-middlewares for each request type.
+	requestPostMiddlewares = make(map[string][]middlewareStruct) // Post-middlewares for each request type.
+
+	// resolveEmbeddedMiddlewares enables running the middlewares registered for the types embedded by a request.
+	resolveEmbeddedMiddlewares bool
+
+	// requestTypeChains caches, per request type, the type names whose middlewares apply to it.
+	requestTypeChains sync.Map
+)
+
+// PreMiddlewareFor adds a pre-middleware to every dispatch of a TRequest, whatever its handler.
+// Request type middlewares run before the middlewares registered for the handler.
+// It returns ErrDuplicateMiddleware if the duplicate middleware policy rejects middlewareFunc.
+func PreMiddlewareFor[TRequest T](middlewareFunc func(ctx context.Context, request any) (context.Context, any, bool)) error {
+	return addRequestMiddleware[TRequest](PrePhase, middlewareFunc)
+}
+
+// PostMiddlewareFor adds a post-middleware to every dispatch of a TRequest, whatever its handler.
+// Request type middlewares run before the middlewares registered for the handler.
+// It returns ErrDuplicateMiddleware if the duplicate middleware policy rejects middlewareFunc.
+func PostMiddlewareFor[TRequest T](middlewareFunc func(ctx context.Context, request any) (context.Context, any, bool)) error {
+	return addRequestMiddleware[TRequest](PostPhase, middlewareFunc)
+}
+
+// ResolveEmbeddedMiddlewares makes dispatches also run the request type middlewares registered for the
+// struct types embedded by the request, at any depth, so commands embedding a common base inherit its middlewares.
+// Middlewares of embedded types run first, outermost embedding first, followed by the request's own.
+func ResolveEmbeddedMiddlewares(enabled bool) {
+	requestMiddlewareMutex.Lock()
+	defer requestMiddlewareMutex.Unlock()
+	resolveEmbeddedMiddlewares = enabled
+}
+
+// addRequestMiddleware registers a middleware for a request type, honoring the duplicate middleware policy.
+func addRequestMiddleware[TRequest T](phase Phase, middlewareFunc func(ctx context.Context, request any) (context.Context, any, bool)) error {
+	typed := typeKey(reflect.TypeOf(new(TRequest)).Elem())
+	middleware := middlewareStruct{
+		middlewareName: middlewareFuncName(middlewareFunc),
+		middlewareFunc: middlewareFunc,
+	}
+
+	requestMiddlewareMutex.Lock()
+	defer requestMiddlewareMutex.Unlock()
+
+	registered := requestPreMiddlewares
+	if phase == PostPhase {
+		registered = requestPostMiddlewares
+	}
+	return appendMiddleware(registered, typed, middleware)
+}
+
+// requestChain returns the request type middlewares applying to request in the given phase.
+func requestChain(phase Phase, request any) []middlewareStruct {
+	requestMiddlewareMutex.RLock()
+	defer requestMiddlewareMutex.RUnlock()
+
+	registered := requestPreMiddlewares
+	if phase == PostPhase {
+		registered = requestPostMiddlewares
+	}
+	if len(registered) == 0 {
+		return nil
+	}
+
+	requestType := reflect.TypeOf(request)
+	if requestType == nil {
+		return nil
+	}
+	if !resolveEmbeddedMiddlewares {
+		return registered[typeKey(requestType)]
+	}
+
+	var middlewares []middlewareStruct
+	for _, typeName := range embeddedTypeChain(requestType) {
+		middlewares = append(middlewares, registered[normalizeTypeKey(typeName)]...)
+	}
+	return middlewares
+}
+
+// embeddedTypeChain returns the names of the struct types embedded by requestType, depth first,
+// followed by the name of requestType itself. A type embedding itself, e.g. through a pointer, is walked once.
+func embeddedTypeChain(requestType reflect.Type) []string {
+	if chain, ok := requestTypeChains.Load(requestType); ok {
+		return chain.([]string)
+	}
+
+	var chain []string
+	visited := make(map[reflect.Type]bool)
+	var walk func(typ reflect.Type) bool
+	walk = func(typ reflect.Type) bool {
+		if typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		if visited[typ] {
+			return false
+		}
+		visited[typ] = true
+		if typ.Kind() != reflect.Struct {
+			return true
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			if field := typ.Field(i); field.Anonymous && walk(field.Type) {
+				chain = append(chain, derefTypeName(field.Type))
+			}
+		}
+		return true
+	}
+	walk(requestType)
+	chain = append(chain, requestType.String())
+
+	requestTypeChains.Store(requestType, chain)
+	return chain
+}
+
+// derefTypeName returns the name of typ, or of the type it points to.
+func derefTypeName(typ reflect.Type) string {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.String()
+}
@@ -0,0 +1,156 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type auditedBaseCommand struct {
+	Actor string
+}
+
+type createInvoiceCommand struct {
+	auditedBaseCommand
+	Amount int
+}
+
+type voidInvoiceCommand struct {
+	*auditedBaseCommand
+	InvoiceID string
+}
+
+type createInvoiceCommandHandler struct{}
+
+func (h *createInvoiceCommandHandler) Handle(ctx context.Context, command createInvoiceCommand) (string, error) {
+	return "created", nil
+}
+
+type voidInvoiceCommandHandler struct{}
+
+func (h *voidInvoiceCommandHandler) Handle(ctx context.Context, command voidInvoiceCommand) (string, error) {
+	return "voided", nil
+}
+
+// TestResolveEmbeddedMiddlewares tests that commands embedding a common base run the middleware registered for it.
+func TestResolveEmbeddedMiddlewares(t *testing.T) {
+	var audited []string
+	PreMiddlewareFor[auditedBaseCommand](func(ctx context.Context, request any) (context.Context, any, bool) {
+		switch command := request.(type) {
+		case createInvoiceCommand:
+			audited = append(audited, command.Actor)
+		case voidInvoiceCommand:
+			audited = append(audited, command.Actor)
+		}
+		return ctx, request, true
+	})
+	AddCommandHandler[createInvoiceCommand, string](&createInvoiceCommandHandler{})
+	AddCommandHandler[voidInvoiceCommand, string](&voidInvoiceCommandHandler{})
+
+	create := createInvoiceCommand{auditedBaseCommand: auditedBaseCommand{Actor: "alice"}, Amount: 10}
+	void := voidInvoiceCommand{auditedBaseCommand: &auditedBaseCommand{Actor: "bob"}, InvoiceID: "1"}
+
+	// Without resolution, the middleware of the base type does not apply to embedders.
+	_, err := SendCommand[string](context.Background(), create)
+	assert.NoError(t, err)
+	assert.Empty(t, audited)
+
+	ResolveEmbeddedMiddlewares(true)
+	defer ResolveEmbeddedMiddlewares(false)
+
+	_, err = SendCommand[string](context.Background(), create)
+	assert.NoError(t, err)
+	_, err = SendCommand[string](context.Background(), void)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, audited)
+}
+
+// TestEmbeddedTypeChain tests that embedded types are listed depth first before the request type.
+func TestEmbeddedTypeChain(t *testing.T) {
+	type inner struct{}
+	type middle struct{ inner }
+	type outer struct {
+		*middle
+		Value int
+	}
+
+	chain := embeddedTypeChain(reflect.TypeOf(outer{}))
+	assert.Equal(t, []string{"gocqrs.inner", "gocqrs.middle", "gocqrs.outer"}, chain)
+}
+
+type treeNodeCommand struct {
+	*treeNodeCommand
+	Name string
+}
+
+type treeNodeCommandHandler struct{}
+
+func (h *treeNodeCommandHandler) Handle(ctx context.Context, command treeNodeCommand) (string, error) {
+	return command.Name, nil
+}
+
+// TestEmbeddedTypeChain_SelfEmbedding tests that a request type embedding itself is resolved once, without recursing forever.
+func TestEmbeddedTypeChain_SelfEmbedding(t *testing.T) {
+	assert.Equal(t, []string{"gocqrs.treeNodeCommand"}, embeddedTypeChain(reflect.TypeOf(treeNodeCommand{})))
+
+	calls := 0
+	AddCommandHandler[treeNodeCommand, string](&treeNodeCommandHandler{})
+	PreMiddlewareFor[treeNodeCommand](func(ctx context.Context, request any) (context.Context, any, bool) {
+		calls++
+		return ctx, request, true
+	})
+	ResolveEmbeddedMiddlewares(true)
+	defer ResolveEmbeddedMiddlewares(false)
+
+	name, err := SendCommand[string](context.Background(), treeNodeCommand{Name: "root"})
+	assert.NoError(t, err)
+	assert.Equal(t, "root", name)
+	assert.Equal(t, 1, calls)
+}
+
+type plannedTypedCommand struct{}
+
+type plannedTypedCommandHandler struct{}
+
+func (h *plannedTypedCommandHandler) Handle(ctx context.Context, command plannedTypedCommand) (string, error) {
+	return "planned", nil
+}
+
+func auditPlannedCommand(ctx context.Context, request any) (context.Context, any, bool) {
+	return ctx, request, true
+}
+
+func tracePlannedCommand(ctx context.Context, request any) (context.Context, any, bool) {
+	return ctx, request, true
+}
+
+// TestRequestMiddlewares_Introspection tests that the plan, the registry view and the manifest list the
+// middlewares registered for the request type before those of the handler, as they run.
+func TestRequestMiddlewares_Introspection(t *testing.T) {
+	PreMiddlewareFor[plannedTypedCommand](auditPlannedCommand)
+	AddCommandHandler[plannedTypedCommand, string](&plannedTypedCommandHandler{}).PreMiddleware(tracePlannedCommand)
+	expected := []string{middlewareFuncName(auditPlannedCommand), middlewareFuncName(tracePlannedCommand)}
+
+	steps, ok := PipelinePlan(plannedTypedCommand{})
+	assert.True(t, ok)
+	var planned []string
+	for _, step := range steps {
+		if step.Stage == StagePreMiddlewares {
+			planned = append(planned, step.Name)
+		}
+	}
+	assert.Equal(t, expected, planned)
+
+	handler, ok := newRegistryView().Handler("gocqrs.plannedTypedCommand")
+	if assert.True(t, ok) {
+		assert.Equal(t, expected, handler.PreMiddlewares)
+	}
+
+	for _, entry := range buildManifest().Handlers {
+		if entry.RequestType == "gocqrs.plannedTypedCommand" {
+			assert.Equal(t, expected, entry.PreMiddlewares)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package gocqrs
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	sharedMiddlewareMutex sync.RWMutex
+	sharedMiddlewares     = make(map[string]MiddlewareFunc)
+)
+
+// RegisterSharedMiddleware registers a middleware that handlers reference by name with UseShared or UseSharedPost,
+// instead of storing a copy each. Registering the same name again replaces the definition used by every handler
+// referencing it, starting with the next dispatch.
+func RegisterSharedMiddleware(name string, middlewareFunc MiddlewareFunc) {
+	sharedMiddlewareMutex.Lock()
+	defer sharedMiddlewareMutex.Unlock()
+	sharedMiddlewares[name] = middlewareFunc
+}
+
+// RemoveSharedMiddleware removes a shared middleware and detaches it from every handler referencing it.
+// It returns false if no shared middleware is registered under name.
+func RemoveSharedMiddleware(name string) bool {
+	sharedMiddlewareMutex.Lock()
+	defer sharedMiddlewareMutex.Unlock()
+	if _, ok := sharedMiddlewares[name]; !ok {
+		return false
+	}
+	delete(sharedMiddlewares, name)
+
+	middlewareBuilder.mutex.Lock()
+	defer middlewareBuilder.mutex.Unlock()
+	for _, registered := range []*sync.Map{&middlewareBuilder.preMiddlewares, &middlewareBuilder.postMiddlewares} {
+		registered.Range(func(handlerName, chain any) bool {
+			middlewares := chain.([]middlewareStruct)
+			detached := make([]middlewareStruct, 0, len(middlewares))
+			for _, middleware := range middlewares {
+				if !middleware.shared || middleware.middlewareName != name {
+					detached = append(detached, middleware)
+				}
+			}
+			if len(detached) != len(middlewares) {
+				registered.Store(handlerName, detached)
+			}
+			return true
+		})
+	}
+	return true
+}
+
+// UseShared adds a reference to the shared middleware name to the pre-middlewares of the current handler.
+func (middlewareBuilder *AddMiddlewareBuilder) UseShared(name string) *AddMiddlewareBuilder {
+	return middlewareBuilder.useShared(PrePhase, name)
+}
+
+// UseSharedPost adds a reference to the shared middleware name to the post-middlewares of the current handler.
+func (middlewareBuilder *AddMiddlewareBuilder) UseSharedPost(name string) *AddMiddlewareBuilder {
+	return middlewareBuilder.useShared(PostPhase, name)
+}
+
+// useShared adds a reference to a shared middleware to the current handler in the given phase.
+func (middlewareBuilder *AddMiddlewareBuilder) useShared(phase Phase, name string) *AddMiddlewareBuilder {
+	sharedMiddlewareMutex.RLock()
+	_, ok := sharedMiddlewares[name]
+	sharedMiddlewareMutex.RUnlock()
+	if !ok {
+		middlewareBuilder.fail(fmt.Errorf("%w: shared middleware %v", ErrUnknownMiddleware, name))
+		return middlewareBuilder
+	}
+
+	if err := middlewareBuilder.addCurrentHandlerMiddleware(phase, middlewareStruct{middlewareName: name, shared: true}); err != nil {
+		middlewareBuilder.fail(err)
+	}
+	return middlewareBuilder
+}
+
+// resolveSharedMiddlewares returns chain with its shared middleware references replaced by their current definitions.
+// References to removed shared middlewares are skipped.
+func resolveSharedMiddlewares(chain []middlewareStruct) []middlewareStruct {
+	hasShared := false
+	for _, middleware := range chain {
+		hasShared = hasShared || middleware.shared
+	}
+	if !hasShared {
+		return chain
+	}
+
+	sharedMiddlewareMutex.RLock()
+	defer sharedMiddlewareMutex.RUnlock()
+	resolved := make([]middlewareStruct, 0, len(chain))
+	for _, middleware := range chain {
+		if middleware.shared {
+			middlewareFunc, ok := sharedMiddlewares[middleware.middlewareName]
+			if !ok {
+				continue
+			}
+			middleware.middlewareFunc = middlewareFunc
+		}
+		resolved = append(resolved, middleware)
+	}
+	return resolved
+}
+
+// sharedMiddlewareNames returns the names of the shared middleware references of a chain.
+func sharedMiddlewareNames(chain []middlewareStruct) []string {
+	names := make([]string, 0)
+	for _, middleware := range chain {
+		if middleware.shared {
+			names = append(names, middleware.middlewareName)
+		}
+	}
+	return names
+}
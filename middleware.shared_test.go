@@ -0,0 +1,86 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	sharedAuditQueryA struct{}
+	sharedAuditQueryB struct{}
+	sharedAuditQueryC struct{}
+
+	sharedAuditHandlerA struct{}
+	sharedAuditHandlerB struct{}
+	sharedAuditHandlerC struct{}
+)
+
+func (h *sharedAuditHandlerA) Handle(ctx context.Context, query sharedAuditQueryA) (string, error) {
+	return "a", nil
+}
+
+func (h *sharedAuditHandlerB) Handle(ctx context.Context, query sharedAuditQueryB) (string, error) {
+	return "b", nil
+}
+
+func (h *sharedAuditHandlerC) Handle(ctx context.Context, query sharedAuditQueryC) (string, error) {
+	return "c", nil
+}
+
+// TestSharedMiddleware tests that updating or removing a shared middleware affects every handler referencing it.
+func TestSharedMiddleware(t *testing.T) {
+	var audited []string
+	auditAs := func(label string) MiddlewareFunc {
+		return func(ctx context.Context, request any) (context.Context, any, bool) {
+			audited = append(audited, label)
+			return ctx, request, true
+		}
+	}
+	RegisterSharedMiddleware("audit", auditAs("v1"))
+
+	inline := func(ctx context.Context, request any) (context.Context, any, bool) {
+		return ctx, request, true
+	}
+	AddQueryHandler[sharedAuditQueryA, string](&sharedAuditHandlerA{}).UseShared("audit").PreMiddleware(inline)
+	AddQueryHandler[sharedAuditQueryB, string](&sharedAuditHandlerB{}).UseShared("audit")
+	AddQueryHandler[sharedAuditQueryC, string](&sharedAuditHandlerC{}).UseSharedPost("audit")
+
+	dispatchAll := func() {
+		for _, query := range []any{sharedAuditQueryA{}, sharedAuditQueryB{}, sharedAuditQueryC{}} {
+			_, err := SendQuery[string](context.Background(), query)
+			assert.NoError(t, err)
+		}
+	}
+
+	dispatchAll()
+	assert.Equal(t, []string{"v1", "v1", "v1"}, audited)
+
+	view, ok := newRegistryView().Handler("gocqrs.sharedAuditQueryA")
+	if assert.True(t, ok) {
+		assert.Equal(t, []string{"audit", middlewareFuncName(inline)}, view.PreMiddlewares)
+		assert.Equal(t, []string{"audit"}, view.SharedMiddlewares)
+	}
+
+	audited = nil
+	RegisterSharedMiddleware("audit", auditAs("v2"))
+	dispatchAll()
+	assert.Equal(t, []string{"v2", "v2", "v2"}, audited)
+
+	audited = nil
+	assert.True(t, RemoveSharedMiddleware("audit"))
+	assert.False(t, RemoveSharedMiddleware("audit"))
+	dispatchAll()
+	assert.Empty(t, audited)
+
+	// Registering the name again does not reattach it.
+	RegisterSharedMiddleware("audit", auditAs("v3"))
+	defer RemoveSharedMiddleware("audit")
+	dispatchAll()
+	assert.Empty(t, audited)
+
+	view, _ = newRegistryView().Handler("gocqrs.sharedAuditQueryA")
+	assert.Equal(t, []string{middlewareFuncName(inline)}, view.PreMiddlewares)
+	assert.Empty(t, view.SharedMiddlewares)
+}
@@ -0,0 +1,86 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// MaxRequestSizeMiddleware returns a pre-middleware aborting the dispatch with ErrRequestTooLarge when the size
+// of the request, as estimated by sizeOf, exceeds maxBytes. A nil sizeOf uses EstimateRequestSize.
+func MaxRequestSizeMiddleware(maxBytes int, sizeOf func(request any) int) MiddlewareFunc {
+	if sizeOf == nil {
+		sizeOf = EstimateRequestSize
+	}
+	return func(ctx context.Context, request any) (context.Context, any, bool) {
+		if size := sizeOf(request); size > maxBytes {
+			return AbortWith(ctx, fmt.Errorf("%w: %T is about %d bytes, the limit is %d", ErrRequestTooLarge, request, size, maxBytes))
+		}
+		return ctx, request, true
+	}
+}
+
+// EstimateRequestSize estimates the memory held by a request in bytes: the size of its value plus the contents
+// of the strings, slices, maps and pointers it references, each pointer being followed once.
+func EstimateRequestSize(request any) int {
+	if request == nil {
+		return 0
+	}
+	value := reflect.ValueOf(request)
+	return int(value.Type().Size()) + referencedSize(value, make(map[uintptr]bool))
+}
+
+// referencedSize returns the size of the memory referenced by value, excluding value itself.
+func referencedSize(value reflect.Value, visited map[uintptr]bool) int {
+	switch value.Kind() {
+	case reflect.String:
+		return value.Len()
+	case reflect.Pointer:
+		if value.IsNil() || visited[value.Pointer()] {
+			return 0
+		}
+		visited[value.Pointer()] = true
+		return int(value.Type().Elem().Size()) + referencedSize(value.Elem(), visited)
+	case reflect.Interface:
+		if value.IsNil() {
+			return 0
+		}
+		return int(value.Elem().Type().Size()) + referencedSize(value.Elem(), visited)
+	case reflect.Slice:
+		if value.IsNil() || visited[value.Pointer()] {
+			return 0
+		}
+		visited[value.Pointer()] = true
+		size := value.Cap() * int(value.Type().Elem().Size())
+		for i := 0; i < value.Len(); i++ {
+			size += referencedSize(value.Index(i), visited)
+		}
+		return size
+	case reflect.Array:
+		size := 0
+		for i := 0; i < value.Len(); i++ {
+			size += referencedSize(value.Index(i), visited)
+		}
+		return size
+	case reflect.Map:
+		if value.IsNil() || visited[value.Pointer()] {
+			return 0
+		}
+		visited[value.Pointer()] = true
+		size := 0
+		iter := value.MapRange()
+		for iter.Next() {
+			size += int(iter.Key().Type().Size()) + referencedSize(iter.Key(), visited)
+			size += int(iter.Value().Type().Size()) + referencedSize(iter.Value(), visited)
+		}
+		return size
+	case reflect.Struct:
+		size := 0
+		for i := 0; i < value.NumField(); i++ {
+			size += referencedSize(value.Field(i), visited)
+		}
+		return size
+	default:
+		return 0
+	}
+}
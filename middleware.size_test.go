@@ -0,0 +1,81 @@
+package gocqrs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type uploadDocumentCommand struct {
+	Name    string
+	Content []byte
+}
+
+type uploadDocumentCommandHandler struct {
+	handled int
+}
+
+func (h *uploadDocumentCommandHandler) Handle(ctx context.Context, command uploadDocumentCommand) (string, error) {
+	h.handled++
+	return command.Name, nil
+}
+
+// TestMaxRequestSizeMiddleware tests that oversized requests are rejected before reaching the handler.
+func TestMaxRequestSizeMiddleware(t *testing.T) {
+	handler := &uploadDocumentCommandHandler{}
+	AddCommandHandler[uploadDocumentCommand, string](handler)
+	PreMiddlewareFor[uploadDocumentCommand](MaxRequestSizeMiddleware(1024, nil))
+
+	response, err := SendCommand[string](context.Background(), uploadDocumentCommand{Name: "small", Content: make([]byte, 100)})
+	assert.NoError(t, err)
+	assert.Equal(t, "small", response)
+
+	_, err = SendCommand[string](context.Background(), uploadDocumentCommand{Name: "large", Content: make([]byte, 4096)})
+	assert.ErrorIs(t, err, ErrRequestTooLarge)
+	assert.Equal(t, 1, handler.handled)
+}
+
+// TestMaxRequestSizeMiddleware_CustomSize tests that a custom size function replaces the default estimate.
+func TestMaxRequestSizeMiddleware_CustomSize(t *testing.T) {
+	middleware := MaxRequestSizeMiddleware(3, func(request any) int { return len(request.(string)) })
+
+	_, request, chain := middleware(context.Background(), "abc")
+	assert.True(t, chain)
+	assert.Equal(t, "abc", request)
+
+	_, request, chain = middleware(context.Background(), "abcd")
+	assert.False(t, chain)
+	assert.ErrorIs(t, request.(*abortedRequest).err, ErrRequestTooLarge)
+}
+
+// TestEstimateRequestSize tests the estimate of referenced strings, slices, maps and cyclic pointers.
+func TestEstimateRequestSize(t *testing.T) {
+	type node struct {
+		Value string
+		Next  *node
+	}
+	cyclic := &node{Value: "a"}
+	cyclic.Next = cyclic
+
+	assert.Equal(t, 0, EstimateRequestSize(nil))
+	assert.Equal(t, 8, EstimateRequestSize(int64(1)))
+	assert.Greater(t, EstimateRequestSize(strings.Repeat("x", 1000)), 1000)
+	assert.Greater(t, EstimateRequestSize(map[string][]int{"a": make([]int, 100)}), 800)
+	assert.Greater(t, EstimateRequestSize(cyclic), 0)
+}
+
+// TestAbortWith tests that a pre-middleware aborting the dispatch fails it with its error.
+func TestAbortWith(t *testing.T) {
+	builder := AddMiddlewareBuilder{
+		currentHandlerName: "abortHandler",
+	}
+	builder.PreMiddleware(func(ctx context.Context, request any) (context.Context, any, bool) {
+		return AbortWith(ctx, ErrRequestTooLarge)
+	})
+
+	request, err := builder.executePreMiddlewares(context.Background(), "original", "abortHandler")
+	assert.ErrorIs(t, err, ErrRequestTooLarge)
+	assert.Equal(t, "original", request)
+}
@@ -0,0 +1,41 @@
+package gocqrs
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	typeCheckingMutex    sync.RWMutex
+	typeCheckingHandlers = make(map[string]bool)
+)
+
+// WithMiddlewareTypeChecking makes the dispatches of the current handler verify, after each pre-middleware, that the
+// request it returned has the type of the request it received. A middleware swapping the request type fails the
+// dispatch with ErrMiddlewareTypeMismatch naming the middleware, instead of the handler failing later with a vague
+// error. It is meant for debugging and is off by default.
+func (middlewareBuilder *AddMiddlewareBuilder) WithMiddlewareTypeChecking() *AddMiddlewareBuilder {
+	handlerName := middlewareBuilder.currentHandler()
+
+	typeCheckingMutex.Lock()
+	defer typeCheckingMutex.Unlock()
+	typeCheckingHandlers[handlerName] = true
+	return middlewareBuilder
+}
+
+// isTypeCheckingEnabled reports whether middleware type checking is enabled for a handler.
+func isTypeCheckingEnabled(handlerName string) bool {
+	typeCheckingMutex.RLock()
+	defer typeCheckingMutex.RUnlock()
+	return typeCheckingHandlers[handlerName]
+}
+
+// checkMiddlewareType returns ErrMiddlewareTypeMismatch if the request returned by a middleware cannot replace the one it received.
+func checkMiddlewareType(middlewareName string, received, returned T) error {
+	receivedType, returnedType := reflect.TypeOf(received), reflect.TypeOf(returned)
+	if receivedType == nil || (returnedType != nil && returnedType.AssignableTo(receivedType)) {
+		return nil
+	}
+	return fmt.Errorf("%w: %v received %v and returned %v", ErrMiddlewareTypeMismatch, middlewareName, receivedType, returnedType)
+}
@@ -0,0 +1,49 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typeCheckedCommand struct {
+	Name string
+}
+
+type typeCheckedCommandHandler struct {
+	calls int
+}
+
+func (h *typeCheckedCommandHandler) Handle(ctx context.Context, command typeCheckedCommand) (string, error) {
+	h.calls++
+	return command.Name, nil
+}
+
+// TestWithMiddlewareTypeChecking tests that a middleware swapping the request type is caught and named.
+func TestWithMiddlewareTypeChecking(t *testing.T) {
+	handler := &typeCheckedCommandHandler{}
+	AddCommandHandler[typeCheckedCommand, string](handler).
+		WithMiddlewareTypeChecking().
+		PreMiddlewareNamed("Rename", func(ctx context.Context, request any) (context.Context, any, bool) {
+			command := request.(typeCheckedCommand)
+			command.Name = "renamed"
+			return ctx, command, true
+		}).
+		PreMiddlewareNamed("LogAsMap", func(ctx context.Context, request any) (context.Context, any, bool) {
+			return ctx, map[string]any{"request": request}, true
+		})
+
+	_, err := SendCommand[string](context.Background(), typeCheckedCommand{Name: "original"})
+	assert.ErrorIs(t, err, ErrMiddlewareTypeMismatch)
+	assert.EqualError(t, err, "middleware changed the request type: LogAsMap received gocqrs.typeCheckedCommand and returned map[string]interface {}")
+	assert.Equal(t, 0, handler.calls, "the handler should not run")
+}
+
+// TestCheckMiddlewareType tests which returned requests are accepted.
+func TestCheckMiddlewareType(t *testing.T) {
+	assert.NoError(t, checkMiddlewareType("m", typeCheckedCommand{}, typeCheckedCommand{Name: "changed"}))
+	assert.NoError(t, checkMiddlewareType("m", nil, "anything"))
+	assert.Error(t, checkMiddlewareType("m", typeCheckedCommand{}, nil))
+	assert.Error(t, checkMiddlewareType("m", typeCheckedCommand{}, &typeCheckedCommand{}))
+}
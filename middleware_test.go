@@ -2,7 +2,10 @@ package gocqrs
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -14,37 +17,40 @@ func MockMiddlewareFunc(continueChain bool) func(ctx context.Context, request an
 	}
 }
 
+// chainOf returns the middlewares registered for a handler in the given phase.
+func (middlewareBuilder *AddMiddlewareBuilder) chainOf(phase Phase, handlerName string) []middlewareStruct {
+	middlewares, _ := middlewareBuilder.chain(phase, handlerName)
+	return middlewares
+}
+
 // TestPreMiddleware tests the addition of pre-middlewares.
 func TestPreMiddleware(t *testing.T) {
 	builder := AddMiddlewareBuilder{
 		currentHandlerName: "testHandler",
-		preMiddlewares:     make(map[string][]middlewareStruct),
 	}
 
 	middlewareFunc := MockMiddlewareFunc(true)
 	builder.PreMiddleware(middlewareFunc)
 
-	assert.Len(t, builder.preMiddlewares["testHandler"], 1, "preMiddlewares should contain one middleware for testHandler")
+	assert.Len(t, builder.chainOf(PrePhase, "testHandler"), 1, "preMiddlewares should contain one middleware for testHandler")
 }
 
 // TestPostMiddleware tests the addition of post-middlewares.
 func TestPostMiddleware(t *testing.T) {
 	builder := AddMiddlewareBuilder{
 		currentHandlerName: "testHandler",
-		postMiddlewares:    make(map[string][]middlewareStruct),
 	}
 
 	middlewareFunc := MockMiddlewareFunc(true)
 	builder.PostMiddleware(middlewareFunc)
 
-	assert.Len(t, builder.postMiddlewares["testHandler"], 1, "postMiddlewares should contain one middleware for testHandler")
+	assert.Len(t, builder.chainOf(PostPhase, "testHandler"), 1, "postMiddlewares should contain one middleware for testHandler")
 }
 
 // TestExecutepreMiddlewares tests the execution of pre-middlewares.
 func TestExecutepreMiddlewares(t *testing.T) {
 	builder := AddMiddlewareBuilder{
 		currentHandlerName: "testHandler",
-		preMiddlewares:     make(map[string][]middlewareStruct),
 	}
 
 	// Add middlewares
@@ -52,7 +58,9 @@ func TestExecutepreMiddlewares(t *testing.T) {
 	builder.PreMiddleware(MockMiddlewareFunc(false)) // This should stop the chain
 
 	request := "original"
-	modifiedRequest := builder.executePreMiddlewares(context.Background(), request, "testHandler")
+	modifiedRequest, err := builder.executePreMiddlewares(context.Background(), request, "testHandler")
+
+	assert.NoError(t, err)
 
 	assert.Equal(t, request, modifiedRequest, "Request should not be modified as the chain is stopped by the second middleware")
 }
@@ -61,7 +69,6 @@ func TestExecutepreMiddlewares(t *testing.T) {
 func TestExecutepostMiddlewares(t *testing.T) {
 	builder := AddMiddlewareBuilder{
 		currentHandlerName: "testHandler",
-		postMiddlewares:    make(map[string][]middlewareStruct),
 	}
 
 	// Add middlewares
@@ -89,21 +96,19 @@ func TestIsMiddlewareRegisteredForHandler(t *testing.T) {
 func TestMultipleMiddlewareRegistration(t *testing.T) {
 	builder := AddMiddlewareBuilder{
 		currentHandlerName: "testHandler",
-		preMiddlewares:     make(map[string][]middlewareStruct),
 	}
 
 	middlewareFunc := MockMiddlewareFunc(true)
 	builder.PreMiddleware(middlewareFunc)
 	builder.PreMiddleware(middlewareFunc) // Add the same middleware again
 
-	assert.Len(t, builder.preMiddlewares["testHandler"], 1, "Middleware should only be registered once")
+	assert.Len(t, builder.chainOf(PrePhase, "testHandler"), 1, "Middleware should only be registered once")
 }
 
 // TestMiddlewareFunctionality tests the actual functionality of the middleware.
 func TestMiddlewareFunctionality(t *testing.T) {
 	builder := AddMiddlewareBuilder{
 		currentHandlerName: "testHandler",
-		preMiddlewares:     make(map[string][]middlewareStruct),
 	}
 
 	// Middleware that modifies the request
@@ -113,6 +118,257 @@ func TestMiddlewareFunctionality(t *testing.T) {
 
 	builder.PreMiddleware(modifyingMiddleware)
 
-	modifiedRequest := builder.executePreMiddlewares(context.Background(), "original", "testHandler")
+	modifiedRequest, err := builder.executePreMiddlewares(context.Background(), "original", "testHandler")
+	assert.NoError(t, err)
 	assert.Equal(t, "modified", modifiedRequest, "Request should be modified by the middleware")
 }
+
+// TestDuplicateMiddlewarePolicy tests each duplicate middleware policy.
+func TestDuplicateMiddlewarePolicy(t *testing.T) {
+	defer SetDuplicateMiddlewarePolicy(DuplicateMiddlewareIgnore)
+
+	newBuilder := func() *AddMiddlewareBuilder {
+		return &AddMiddlewareBuilder{
+			currentHandlerName: "testHandler",
+		}
+	}
+	middlewareFunc := MockMiddlewareFunc(true)
+
+	SetDuplicateMiddlewarePolicy(DuplicateMiddlewareIgnore)
+	builder := newBuilder()
+	builder.PreMiddleware(middlewareFunc).PreMiddleware(middlewareFunc)
+	builder.PostMiddleware(middlewareFunc).PostMiddleware(middlewareFunc)
+	assert.Len(t, builder.chainOf(PrePhase, "testHandler"), 1, "Ignore should drop the duplicate pre-middleware")
+	assert.Len(t, builder.chainOf(PostPhase, "testHandler"), 1, "Ignore should drop the duplicate post-middleware")
+
+	SetDuplicateMiddlewarePolicy(DuplicateMiddlewareAllow)
+	builder = newBuilder()
+	builder.PreMiddleware(middlewareFunc).PreMiddleware(middlewareFunc)
+	builder.PostMiddleware(middlewareFunc).PostMiddleware(middlewareFunc)
+	assert.Len(t, builder.chainOf(PrePhase, "testHandler"), 2, "Allow should register the duplicate pre-middleware")
+	assert.Len(t, builder.chainOf(PostPhase, "testHandler"), 2, "Allow should register the duplicate post-middleware")
+
+	var reported []error
+	SetErrorReporter(func(ctx context.Context, err error) {
+		reported = append(reported, err)
+	})
+	defer SetErrorReporter(nil)
+	SetDuplicateMiddlewarePolicy(DuplicateMiddlewareError)
+	builder = newBuilder()
+	builder.PreMiddleware(middlewareFunc).PostMiddleware(middlewareFunc)
+	assert.NoError(t, builder.Err())
+	assert.NotPanics(t, func() { builder.PreMiddleware(middlewareFunc).PostMiddleware(middlewareFunc) })
+	assert.ErrorIs(t, builder.Err(), ErrDuplicateMiddleware, "Error should reject the duplicate middlewares")
+	assert.EqualError(t, builder.Err(), "middleware is already registered: github.com/victoragudo/go-cqrs.MockMiddlewareFunc.func1 for testHandler")
+	assert.Len(t, reported, 2, "Every rejected middleware should be reported")
+	assert.Len(t, builder.chainOf(PrePhase, "testHandler"), 1)
+	assert.Len(t, builder.chainOf(PostPhase, "testHandler"), 1)
+}
+
+type duplicateMiddlewareCommand struct{}
+
+type duplicateMiddlewareCommandHandler struct{}
+
+func (h *duplicateMiddlewareCommandHandler) Handle(ctx context.Context, command duplicateMiddlewareCommand) (string, error) {
+	return "done", nil
+}
+
+// TestDuplicateMiddlewarePolicy_Err tests that the rejected middlewares of a handler are returned by Err until
+// the next handler is registered, and that request type middlewares return theirs.
+func TestDuplicateMiddlewarePolicy_Err(t *testing.T) {
+	SetErrorReporter(func(ctx context.Context, err error) {})
+	defer SetErrorReporter(nil)
+	SetDuplicateMiddlewarePolicy(DuplicateMiddlewareError)
+	defer SetDuplicateMiddlewarePolicy(DuplicateMiddlewareIgnore)
+
+	audit := func(ctx context.Context, request any) (context.Context, any, bool) { return ctx, request, true }
+	builder := AddCommandHandler[duplicateMiddlewareCommand, string](&duplicateMiddlewareCommandHandler{}).
+		PreMiddlewareNamed("audit", audit).
+		PreMiddlewareNamed("audit", audit)
+	assert.ErrorIs(t, builder.Err(), ErrDuplicateMiddleware)
+	assert.NoError(t, AddQueryHandler[string, string](&MockQueryHandler{}).Err(), "Registering a handler should clear the error")
+
+	assert.NoError(t, PreMiddlewareFor[duplicateMiddlewareCommand](audit))
+	assert.ErrorIs(t, PreMiddlewareFor[duplicateMiddlewareCommand](audit), ErrDuplicateMiddleware)
+}
+
+type postOnErrorCommand struct {
+	Fail bool
+}
+
+type postOnErrorCommandHandler struct{}
+
+func (h *postOnErrorCommandHandler) Handle(ctx context.Context, command postOnErrorCommand) (string, error) {
+	if command.Fail {
+		return "", fmt.Errorf("command failed")
+	}
+	return "done", nil
+}
+
+// TestSetRunPostOnError tests that post-middlewares skip failed dispatches only when disabled.
+func TestSetRunPostOnError(t *testing.T) {
+	var posts int
+	AddCommandHandler[postOnErrorCommand, string](&postOnErrorCommandHandler{}).
+		PostMiddleware(func(ctx context.Context, request any) (context.Context, any, bool) {
+			posts++
+			return ctx, request, true
+		})
+
+	_, err := SendCommand[string](context.Background(), postOnErrorCommand{Fail: true})
+	assert.EqualError(t, err, "command failed")
+	assert.Equal(t, 1, posts, "post-middlewares run on error by default")
+
+	SetRunPostOnError(false)
+	defer SetRunPostOnError(true)
+
+	_, err = SendCommand[string](context.Background(), postOnErrorCommand{Fail: true})
+	assert.EqualError(t, err, "command failed")
+	assert.Equal(t, 1, posts, "post-middlewares should be skipped on error")
+
+	_, err = SendCommand[string](context.Background(), postOnErrorCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, posts, "post-middlewares still run on success")
+}
+
+type stressCommand struct{}
+
+type stressCommandHandler struct{}
+
+func (h *stressCommandHandler) Handle(ctx context.Context, command stressCommand) (string, error) {
+	return "handled", nil
+}
+
+// TestMiddlewareRegistrationDuringDispatch attaches middlewares in a loop while dispatching in parallel.
+// Run with -race to detect unsynchronized access to the middleware chains.
+func TestMiddlewareRegistrationDuringDispatch(t *testing.T) {
+	AddCommandHandler[stressCommand, string](&stressCommandHandler{}).Named("stress.handler")
+
+	const attachments = 200
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < attachments; i++ {
+			name := fmt.Sprintf("stress.middleware.%d", i)
+			RegisterNamedMiddleware(name, MockMiddlewareFunc(true))
+			phase := PrePhase
+			if i%2 == 1 {
+				phase = PostPhase
+			}
+			assert.NoError(t, AttachByName("stress.handler", name, phase))
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				response, err := SendCommand[string](context.Background(), stressCommand{})
+				assert.NoError(t, err)
+				assert.Equal(t, "handled", response)
+			}
+		}()
+	}
+	wg.Wait()
+
+	handlerName, _ := resolveHandlerName("stress.handler")
+	pre, _ := middlewareBuilder.chain(PrePhase, handlerName)
+	post, _ := middlewareBuilder.chain(PostPhase, handlerName)
+	assert.Len(t, pre, attachments/2, "No pre-middleware should be lost")
+	assert.Len(t, post, attachments/2, "No post-middleware should be lost")
+}
+
+type unblockedQuery struct{}
+
+type unblockedQueryHandler struct{}
+
+func (h *unblockedQueryHandler) Handle(ctx context.Context, query unblockedQuery) (string, error) {
+	return "handled", nil
+}
+
+// TestDispatchDuringRegistration_NotBlocked tests that a dispatch reads the middleware chains without waiting for
+// a registration holding the builder's lock.
+func TestDispatchDuringRegistration_NotBlocked(t *testing.T) {
+	AddQueryHandler[unblockedQuery, string](&unblockedQueryHandler{}).
+		PreMiddleware(MockMiddlewareFunc(true)).
+		Behavior(func(ctx context.Context, request any, next NextFunc) (any, error) {
+			return next(ctx, request)
+		})
+
+	middlewareBuilder.mutex.Lock()
+	defer middlewareBuilder.mutex.Unlock()
+
+	dispatched := make(chan error, 1)
+	go func() {
+		_, err := SendQuery[string](context.Background(), unblockedQuery{})
+		dispatched <- err
+	}()
+	select {
+	case err := <-dispatched:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the dispatch waited for the registration")
+	}
+}
+
+type (
+	contendedQuery        struct{}
+	reconfiguredQuery     struct{}
+	contendedQueryHandler struct{}
+	reconfiguredHandler   struct{}
+)
+
+func (h *contendedQueryHandler) Handle(ctx context.Context, query contendedQuery) (string, error) {
+	return "handled", nil
+}
+
+func (h *reconfiguredHandler) Handle(ctx context.Context, query reconfiguredQuery) (string, error) {
+	return "handled", nil
+}
+
+// BenchmarkDispatchDuringRegistration measures the dispatches of one request type, alone and while middlewares are
+// repeatedly attached to and removed from the handler of another type.
+func BenchmarkDispatchDuringRegistration(b *testing.B) {
+	AddQueryHandler[contendedQuery, string](&contendedQueryHandler{}).PreMiddleware(MockMiddlewareFunc(true))
+	reconfigured := AddQueryHandler[reconfiguredQuery, string](&reconfiguredHandler{})
+
+	dispatch := func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := SendQuery[string](context.Background(), contendedQuery{}); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+	}
+
+	b.Run("idle", dispatch)
+	b.Run("registering", func(b *testing.B) {
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				name := fmt.Sprintf("reconfigured.%d", i%8)
+				RegisterSharedMiddleware(name, MockMiddlewareFunc(true))
+				// Attach the middleware to the other handler, as the builder of its registration would.
+				reconfigured.UseShared(name)
+				RemoveSharedMiddleware(name)
+			}
+		}()
+		dispatch(b)
+		close(done)
+		<-stopped
+	})
+}
@@ -0,0 +1,18 @@
+package gocqrs
+
+import (
+	"context"
+)
+
+// PublishNotification publishes a fire-and-forget notification to the event handlers registered for its type.
+// Unlike PublishEvent, a notification without handlers is not an error, and handler failures are reported
+// with the ErrorReporter instead of being returned. Use PublishEvent for domain events that must be handled.
+func PublishNotification(ctx context.Context, notification T) {
+	handlerErrors, _ := publish(ctx, notification, publishConfig{})
+	if isDuplicatePublish(handlerErrors) {
+		return
+	}
+	for _, err := range handlerErrors {
+		reportError(ctx, err)
+	}
+}
@@ -0,0 +1,51 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cacheWarmedNotification struct{}
+
+type failingNotificationHandler struct {
+	calls int
+}
+
+func (h *failingNotificationHandler) Handle(ctx context.Context, notification cacheWarmedNotification) error {
+	h.calls++
+	return errors.New("notification handler failed")
+}
+
+type unhandledNotification struct{}
+
+// TestPublishNotification tests that notifications report handler failures and tolerate missing handlers,
+// while events return failures and require handlers.
+func TestPublishNotification(t *testing.T) {
+	var reported []error
+	SetErrorReporter(func(ctx context.Context, err error) {
+		reported = append(reported, err)
+	})
+	defer SetErrorReporter(nil)
+
+	handler := &failingNotificationHandler{}
+	assert.NoError(t, AddEventHandlers[cacheWarmedNotification](handler))
+
+	PublishNotification(context.Background(), cacheWarmedNotification{})
+	assert.Equal(t, 1, handler.calls)
+	assert.Len(t, reported, 1)
+	assert.EqualError(t, reported[0], "notification handler failed")
+
+	err := PublishEvent(context.Background(), cacheWarmedNotification{})
+	assert.EqualError(t, err, "notification handler failed")
+	assert.Len(t, reported, 1, "event failures are returned, not reported")
+
+	assert.NotPanics(t, func() {
+		PublishNotification(context.Background(), unhandledNotification{})
+	})
+	assert.Panics(t, func() {
+		_ = PublishEvent(context.Background(), unhandledNotification{})
+	})
+}
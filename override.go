@@ -0,0 +1,179 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+)
+
+type (
+	// OverrideOption configures a handler override.
+	OverrideOption func(*overrideConfig)
+
+	overrideConfig struct {
+		topLevelOnly bool
+	}
+
+	// handlerOverrides is the immutable set of overrides carried by a context.
+	handlerOverrides struct {
+		requests map[string]requestOverride
+		events   map[string]eventOverride
+	}
+
+	// requestOverride replaces the handler of a command or query type.
+	requestOverride struct {
+		typeName     string
+		newWrapper   func(handlerName string) any // Wraps the stub under the given handler name.
+		topLevelOnly bool
+	}
+
+	// eventOverride replaces the subscribers of an event type.
+	eventOverride struct {
+		handlers     []eventHandlersType
+		topLevelOnly bool
+	}
+
+	handlerOverridesKey struct{}
+)
+
+// OverrideTopLevelOnly restricts an override to the dispatches using the returned context directly,
+// so dispatches nested inside them use the registered handlers again. Overrides apply to nested dispatches by default.
+func OverrideTopLevelOnly() OverrideOption {
+	return func(config *overrideConfig) {
+		config.topLevelOnly = true
+	}
+}
+
+// WithHandlerOverride returns a context in which dispatches of TRequest are handled by stub instead of the
+// registered handler. The stub runs with the middlewares and behaviors of the registered handler, if any.
+// Overrides never modify the registry, so parallel tests using different contexts stay isolated.
+func WithHandlerOverride[TRequest T, TResponse T](ctx context.Context, stub IHandler[TRequest, TResponse], opts ...OverrideOption) context.Context {
+	config := overrideConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	typed := typeKey(reflect.TypeOf(new(TRequest)).Elem())
+	overrides := overridesFrom(ctx).clone()
+	overrides.requests[typed] = requestOverride{
+		typeName: reflect.TypeOf(stub).String(),
+		newWrapper: func(handlerName string) any {
+			return newHandlerWrapper[TRequest, TResponse](stub, handlerName)
+		},
+		topLevelOnly: config.topLevelOnly,
+	}
+	return context.WithValue(ctx, handlerOverridesKey{}, overrides)
+}
+
+// SendWithHandler dispatches request to handler instead of the handler registered for its type, if any,
+// with the middlewares and behaviors of the registered handler or, without one, those of the request type.
+// The registry is left untouched and dispatches nested in the handler use the registered handlers,
+// which makes it convenient to test middlewares against a stub handler.
+func SendWithHandler[Response T](ctx context.Context, request any, handler IHandler[any, Response]) (Response, error) {
+	typed := rewriteRequestKey(typeKey(reflect.TypeOf(request)))
+	overrides := overridesFrom(ctx).clone()
+	overrides.requests[typed] = requestOverride{
+		typeName: reflect.TypeOf(handler).String(),
+		newWrapper: func(handlerName string) any {
+			return newHandlerWrapper[any, Response](handler, handlerName)
+		},
+		topLevelOnly: true,
+	}
+	return send[Response](context.WithValue(ctx, handlerOverridesKey{}, overrides), request)
+}
+
+// WithEventHandlersOverride returns a context in which PublishEvent delivers TEvent events to handlers only,
+// instead of the registered handlers and consumption groups.
+func WithEventHandlersOverride[TEvent T](ctx context.Context, handlers []IEventHandler[TEvent], opts ...OverrideOption) context.Context {
+	config := overrideConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	typedEvent := eventTypeKey(reflect.TypeOf(new(TEvent)).Elem())
+	subscribers := make([]eventHandlersType, 0, len(handlers))
+	for _, handler := range handlers {
+		typedHandlerName := reflect.TypeOf(handler).String()
+		subscribers = append(subscribers, eventHandlersType{
+			typeName:     typedHandlerName,
+			eventHandler: newEventHandlerWrapper[TEvent](handler, typedHandlerName),
+		})
+	}
+
+	overrides := overridesFrom(ctx).clone()
+	overrides.events[typedEvent] = eventOverride{handlers: subscribers, topLevelOnly: config.topLevelOnly}
+	return context.WithValue(ctx, handlerOverridesKey{}, overrides)
+}
+
+// handlerOverrideFor returns the wrapper overriding the handler of a request type in ctx, if any.
+// registered is the wrapper of the registered handler, whose name the override reuses.
+func handlerOverrideFor(ctx context.Context, typedIn string, registered any) (any, bool) {
+	override, ok := overridesFrom(ctx).requests[typedIn]
+	if !ok {
+		return nil, false
+	}
+
+	handlerName := override.typeName
+	if nameField, ok := getField(registered, "Name"); ok {
+		handlerName = nameField.Interface().(string)
+	}
+	return override.newWrapper(handlerName), true
+}
+
+// eventHandlersOverrideFor returns the subscribers overriding those of an event type in ctx, if any.
+func eventHandlersOverrideFor(ctx context.Context, typedEvent string) ([]eventHandlersType, bool) {
+	override, ok := overridesFrom(ctx).events[typedEvent]
+	return override.handlers, ok
+}
+
+// withNestedOverrides returns the context passed to the handlers of a dispatch, without the top-level only overrides.
+func withNestedOverrides(ctx context.Context) context.Context {
+	overrides, ok := ctx.Value(handlerOverridesKey{}).(*handlerOverrides)
+	if !ok {
+		return ctx
+	}
+
+	nested := &handlerOverrides{requests: make(map[string]requestOverride), events: make(map[string]eventOverride)}
+	stripped := false
+	for typeName, override := range overrides.requests {
+		if override.topLevelOnly {
+			stripped = true
+			continue
+		}
+		nested.requests[typeName] = override
+	}
+	for typeName, override := range overrides.events {
+		if override.topLevelOnly {
+			stripped = true
+			continue
+		}
+		nested.events[typeName] = override
+	}
+
+	if !stripped {
+		return ctx
+	}
+	return context.WithValue(ctx, handlerOverridesKey{}, nested)
+}
+
+// overridesFrom returns the overrides carried by ctx.
+func overridesFrom(ctx context.Context) *handlerOverrides {
+	if overrides, ok := ctx.Value(handlerOverridesKey{}).(*handlerOverrides); ok {
+		return overrides
+	}
+	return &handlerOverrides{}
+}
+
+// clone returns a copy of the overrides that can be modified.
+func (overrides *handlerOverrides) clone() *handlerOverrides {
+	cloned := &handlerOverrides{
+		requests: make(map[string]requestOverride, len(overrides.requests)+1),
+		events:   make(map[string]eventOverride, len(overrides.events)+1),
+	}
+	for typeName, override := range overrides.requests {
+		cloned.requests[typeName] = override
+	}
+	for typeName, override := range overrides.events {
+		cloned.events[typeName] = override
+	}
+	return cloned
+}
@@ -0,0 +1,170 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type priceQuery struct {
+	SKU string
+}
+
+type priceQueryHandler struct{}
+
+func (h *priceQueryHandler) Handle(ctx context.Context, query priceQuery) (int, error) {
+	return 100, nil
+}
+
+// priceStub answers every price query with a fixed price.
+type priceStub struct {
+	price int
+}
+
+func (h *priceStub) Handle(ctx context.Context, query priceQuery) (int, error) {
+	return h.price, nil
+}
+
+type quoteQuery struct{}
+
+type quoteQueryHandler struct{}
+
+func (h *quoteQueryHandler) Handle(ctx context.Context, query quoteQuery) (int, error) {
+	return SendQuery[int](ctx, priceQuery{SKU: "nested"})
+}
+
+type overriddenEvent struct{}
+
+type registeredOverriddenEventHandler struct {
+	calls int
+}
+
+func (h *registeredOverriddenEventHandler) Handle(ctx context.Context, event overriddenEvent) error {
+	h.calls++
+	return nil
+}
+
+type stubOverriddenEventHandler struct {
+	calls int
+}
+
+func (h *stubOverriddenEventHandler) Handle(ctx context.Context, event overriddenEvent) error {
+	h.calls++
+	return nil
+}
+
+// TestWithHandlerOverride_Parallel tests that overrides carried by different contexts do not cross-talk.
+func TestWithHandlerOverride_Parallel(t *testing.T) {
+	var middlewareCalls int
+	var mutex sync.Mutex
+	AddQueryHandler[priceQuery, int](&priceQueryHandler{}).
+		PreMiddleware(func(ctx context.Context, request any) (context.Context, any, bool) {
+			mutex.Lock()
+			middlewareCalls++
+			mutex.Unlock()
+			return ctx, request, true
+		})
+
+	var wg sync.WaitGroup
+	for _, price := range []int{1, 2} {
+		wg.Add(1)
+		go func(price int) {
+			defer wg.Done()
+			ctx := WithHandlerOverride[priceQuery, int](context.Background(), &priceStub{price: price})
+			for i := 0; i < 50; i++ {
+				response, err := SendQuery[int](ctx, priceQuery{SKU: fmt.Sprint(i)})
+				assert.NoError(t, err)
+				assert.Equal(t, price, response)
+			}
+		}(price)
+	}
+	wg.Wait()
+
+	response, err := SendQuery[int](context.Background(), priceQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 100, response, "the registry should not be modified")
+	assert.Equal(t, 101, middlewareCalls, "stubs should run with the registered handler's middlewares")
+}
+
+// TestWithHandlerOverride_Nested tests that overrides apply to nested dispatches unless top-level only.
+func TestWithHandlerOverride_Nested(t *testing.T) {
+	AddQueryHandler[priceQuery, int](&priceQueryHandler{})
+	AddQueryHandler[quoteQuery, int](&quoteQueryHandler{})
+
+	ctx := WithHandlerOverride[priceQuery, int](context.Background(), &priceStub{price: 5})
+	response, err := SendQuery[int](ctx, quoteQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, response)
+
+	ctx = WithHandlerOverride[priceQuery, int](context.Background(), &priceStub{price: 5}, OverrideTopLevelOnly())
+	response, err = SendQuery[int](ctx, quoteQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 100, response)
+	response, err = SendQuery[int](ctx, priceQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, response)
+}
+
+// TestWithEventHandlersOverride tests that overridden subscribers replace the registered ones within the context.
+func TestWithEventHandlersOverride(t *testing.T) {
+	registered := &registeredOverriddenEventHandler{}
+	stub := &stubOverriddenEventHandler{}
+	assert.NoError(t, AddEventHandlers[overriddenEvent](registered))
+
+	ctx := WithEventHandlersOverride[overriddenEvent](context.Background(), []IEventHandler[overriddenEvent]{stub})
+	assert.NoError(t, PublishEvent(ctx, overriddenEvent{}))
+	assert.Equal(t, 0, registered.calls)
+	assert.Equal(t, 1, stub.calls)
+
+	assert.NoError(t, PublishEvent(context.Background(), overriddenEvent{}))
+	assert.Equal(t, 1, registered.calls)
+}
+
+type discountQuery struct {
+	Code string
+}
+
+type discountQueryHandler struct{}
+
+func (h *discountQueryHandler) Handle(ctx context.Context, query discountQuery) (int, error) {
+	return 10, nil
+}
+
+// discountStub is supplied to SendWithHandler, bypassing the registered handler.
+type discountStub struct {
+	queries []any
+}
+
+func (h *discountStub) Handle(ctx context.Context, query any) (int, error) {
+	h.queries = append(h.queries, query)
+	return 50, nil
+}
+
+// TestSendWithHandler tests that the middlewares of the request type run around the supplied handler.
+func TestSendWithHandler(t *testing.T) {
+	var calls []string
+	AddQueryHandler[discountQuery, int](&discountQueryHandler{}).
+		PreMiddleware(func(ctx context.Context, request any) (context.Context, any, bool) {
+			calls = append(calls, "pre")
+			return ctx, discountQuery{Code: "UPPER"}, true
+		}).
+		PostMiddleware(func(ctx context.Context, request any) (context.Context, any, bool) {
+			calls = append(calls, "post")
+			return ctx, request, true
+		})
+
+	stub := &discountStub{}
+	discount, err := SendWithHandler[int](context.Background(), discountQuery{Code: "lower"}, stub)
+	assert.NoError(t, err)
+	assert.Equal(t, 50, discount)
+	assert.Equal(t, []string{"pre", "post"}, calls)
+	assert.Equal(t, []any{discountQuery{Code: "UPPER"}}, stub.queries)
+
+	// The registry is untouched.
+	discount, err = SendQuery[int](context.Background(), discountQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, discount)
+}
@@ -0,0 +1,43 @@
+package gocqrs
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// InternalError is returned in place of a panic when a broken invariant, such as a dispatch of a request
+// type without a handler, is detected while SetNoPanics is enabled.
+type InternalError struct {
+	Invariant string // Name of the broken invariant, e.g. "handler_registered".
+	Err       error  // Description of the violation.
+}
+
+// noPanics is set by SetNoPanics.
+var noPanics atomic.Bool
+
+// SetNoPanics makes the package return or report an *InternalError where it panics by default on programmer
+// errors, e.g. in production where a failed request is preferable to a crash. Dispatches return the error;
+// registrations, which cannot, report it with the ErrorReporter and are ignored. Tests usually keep the default
+// so such errors fail loudly.
+func SetNoPanics(enabled bool) {
+	noPanics.Store(enabled)
+}
+
+// Error describes the violation and names the invariant.
+func (e *InternalError) Error() string {
+	return fmt.Sprintf("internal error (%v): %v", e.Invariant, e.Err)
+}
+
+// Unwrap returns the description of the violation.
+func (e *InternalError) Unwrap() error {
+	return e.Err
+}
+
+// brokenInvariant panics with the message of err, or returns it as an *InternalError if SetNoPanics is enabled.
+// It is the only place the package panics from.
+func brokenInvariant(invariant string, err error) error {
+	if !noPanics.Load() {
+		panic(err.Error())
+	}
+	return &InternalError{Invariant: invariant, Err: err}
+}
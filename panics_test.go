@@ -0,0 +1,81 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type unhandledPanicsQuery struct{}
+
+type unhandledPanicsEvent struct{}
+
+type fieldlessPanicsQuery struct{}
+
+type methodlessPanicsQuery struct{}
+
+// TestSetNoPanics tests that broken invariants panic by default and return an InternalError with SetNoPanics.
+func TestSetNoPanics(t *testing.T) {
+	storeMapValue(handlers, "gocqrs.fieldlessPanicsQuery", struct{ Name string }{Name: "fieldless"}, &handlerMutex)
+	storeMapValue(handlers, "gocqrs.methodlessPanicsQuery", &handlerWrapper[any, any]{Name: "methodless"}, &handlerMutex)
+
+	tests := []struct {
+		name      string
+		invariant string
+		call      func() error
+	}{
+		{"not found", "handler_registered", func() error {
+			_, err := SendQuery[string](context.Background(), unhandledPanicsQuery{})
+			return err
+		}},
+		{"missing field", "handler_field", func() error {
+			_, err := SendQuery[string](context.Background(), fieldlessPanicsQuery{})
+			return err
+		}},
+		{"uninitialized method", "handle_method", func() error {
+			_, err := SendQuery[string](context.Background(), methodlessPanicsQuery{})
+			return err
+		}},
+		{"event not found", "event_handler_registered", func() error {
+			return PublishEvent(context.Background(), unhandledPanicsEvent{})
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Panics(t, func() { _ = test.call() })
+
+			SetNoPanics(true)
+			defer SetNoPanics(false)
+			var internalErr *InternalError
+			if assert.ErrorAs(t, test.call(), &internalErr) {
+				assert.Equal(t, test.invariant, internalErr.Invariant)
+			}
+		})
+	}
+}
+
+// TestSetNoPanics_Registration tests that a rejected middleware is a caller error, reported as is rather than
+// as a broken invariant.
+func TestSetNoPanics_Registration(t *testing.T) {
+	var reported []error
+	SetErrorReporter(func(ctx context.Context, err error) {
+		reported = append(reported, err)
+	})
+	defer SetErrorReporter(nil)
+	SetDuplicateMiddlewarePolicy(DuplicateMiddlewareError)
+	defer SetDuplicateMiddlewarePolicy(DuplicateMiddlewareIgnore)
+	SetNoPanics(true)
+	defer SetNoPanics(false)
+
+	builder := &AddMiddlewareBuilder{
+		currentHandlerName: "noPanicsHandler",
+	}
+	middlewareFunc := MockMiddlewareFunc(true)
+	assert.NotPanics(t, func() { builder.PreMiddleware(middlewareFunc).PreMiddleware(middlewareFunc) })
+	assert.Len(t, builder.chainOf(PrePhase, "noPanicsHandler"), 1)
+	if assert.Len(t, reported, 1) {
+		assert.ErrorIs(t, reported[0], ErrDuplicateMiddleware)
+		assert.NotContains(t, reported[0].Error(), "internal error")
+	}
+}
@@ -0,0 +1,180 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// PipelineStage is a stage of a command or query dispatch. Stages run in the order of their values.
+type PipelineStage int
+
+const (
+	// StagePreMiddlewares runs the pre-middlewares, in registration order.
+	StagePreMiddlewares PipelineStage = iota
+	// StageBehaviors runs the pipeline behaviors around the handler, the behaviors attached to tags first.
+	// The built-in behaviors run there: the cache lookup and, with CacheStoresHandlerOutput, the cache store,
+	// as well as debouncing.
+	StageBehaviors
+	// StageHandler runs the handler.
+	StageHandler
+	// StageResponseProcessor runs the global response processor, skipped by cache hits with CacheStoresFinalResponse.
+	StageResponseProcessor
+	// StageCacheStore stores the final response of caches with CacheStoresFinalResponse.
+	StageCacheStore
+	// StagePostMiddlewares runs the post-middlewares, in registration order, including after cache hits.
+	StagePostMiddlewares
+)
+
+type (
+	// PipelineStep is a step of the pipeline of a handler, as listed by PipelinePlan.
+	PipelineStep struct {
+		Stage PipelineStage
+		Name  string // Middleware name, behavior symbol name, built-in behavior name or handler name.
+	}
+
+	// pipelineStateKey is the context key of the pipelineState of a dispatch.
+	pipelineStateKey struct{}
+
+	// pipelineState lets the behaviors of a dispatch act on its later stages.
+	pipelineState struct {
+		mutex         sync.Mutex
+		skipProcessor bool                            // The response is final, e.g. served from a cache.
+		onFinal       []func(response any, err error) // Called with the final response, before the post-middlewares.
+	}
+)
+
+var (
+	pipelineStepMutex sync.RWMutex
+	pipelineSteps     = make(map[string][]PipelineStep) // Handler name to the steps of its own behaviors.
+)
+
+// String returns the name of the stage.
+func (stage PipelineStage) String() string {
+	switch stage {
+	case StagePreMiddlewares:
+		return "pre-middlewares"
+	case StageBehaviors:
+		return "behaviors"
+	case StageHandler:
+		return "handler"
+	case StageResponseProcessor:
+		return "response processor"
+	case StageCacheStore:
+		return "cache store"
+	case StagePostMiddlewares:
+		return "post-middlewares"
+	default:
+		return "unknown"
+	}
+}
+
+// PipelinePlan returns the steps a dispatch of requestType goes through, in execution order, e.g. to assert in
+// a test whether the cache stores the processed response. It returns false if the request type has no handler.
+func PipelinePlan(requestType any) ([]PipelineStep, bool) {
+	value, ok := getMapValue(handlers, typeKey(reflect.TypeOf(requestType)), &handlerMutex)
+	if !ok {
+		return nil, false
+	}
+	nameField, ok := getField(value, "Name")
+	if !ok {
+		return nil, false
+	}
+	handlerName := nameField.String()
+	typed := typeKey(reflect.TypeOf(requestType))
+	internal := isInternalRequest(typed)
+
+	var steps []PipelineStep
+	pre, _ := middlewareBuilder.registeredChain(PrePhase, handlerName, typed)
+	if internal {
+		pre = nil
+	}
+	for _, name := range middlewareChainNames(pre) {
+		steps = append(steps, PipelineStep{Stage: StagePreMiddlewares, Name: name})
+	}
+	for _, behavior := range tagBehaviorsFor(handlerName) {
+		steps = append(steps, PipelineStep{Stage: StageBehaviors, Name: funcName(behavior)})
+	}
+	pipelineStepMutex.RLock()
+	steps = append(steps, pipelineSteps[handlerName]...)
+	pipelineStepMutex.RUnlock()
+	steps = append(steps, PipelineStep{Stage: StageHandler, Name: handlerName})
+
+	responseProcessorMutex.RLock()
+	if responseProcessor != nil {
+		steps = append(steps, PipelineStep{Stage: StageResponseProcessor, Name: "response processor"})
+	}
+	responseProcessorMutex.RUnlock()
+
+	post, _ := middlewareBuilder.registeredChain(PostPhase, handlerName, typed)
+	if internal {
+		post = nil
+	}
+	for _, name := range middlewareChainNames(post) {
+		steps = append(steps, PipelineStep{Stage: StagePostMiddlewares, Name: name})
+	}
+
+	// Steps declared by built-in behaviors for later stages move to their stage.
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].Stage < steps[j].Stage
+	})
+	return steps, true
+}
+
+// stagedBehavior adds a pipeline behavior to the current handler, described in its PipelinePlan by steps.
+func (middlewareBuilder *AddMiddlewareBuilder) stagedBehavior(behavior PipelineBehavior, steps ...PipelineStep) *AddMiddlewareBuilder {
+	middlewareBuilder.mutex.Lock()
+	handlerName := middlewareBuilder.currentHandlerName
+	behaviors := middlewareBuilder.behaviorsFor(handlerName)
+	// Copy the chain so dispatches holding the previous slice never observe the new element.
+	middlewareBuilder.behaviors.Store(handlerName, append(behaviors[:len(behaviors):len(behaviors)], behavior))
+	middlewareBuilder.mutex.Unlock()
+
+	pipelineStepMutex.Lock()
+	defer pipelineStepMutex.Unlock()
+	current := pipelineSteps[handlerName]
+	pipelineSteps[handlerName] = append(current[:len(current):len(current)], steps...)
+	return middlewareBuilder
+}
+
+// withPipelineState returns a context carrying the pipeline state of a dispatch.
+func withPipelineState(ctx context.Context) (context.Context, *pipelineState) {
+	state := &pipelineState{}
+	return context.WithValue(ctx, pipelineStateKey{}, state), state
+}
+
+// pipelineStateFrom returns the pipeline state of the dispatch of ctx.
+func pipelineStateFrom(ctx context.Context) (*pipelineState, bool) {
+	state, ok := ctx.Value(pipelineStateKey{}).(*pipelineState)
+	return state, ok
+}
+
+// finalResponse marks the response of the dispatch as final, skipping the response processor.
+func (state *pipelineState) finalResponse() {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.skipProcessor = true
+}
+
+// onFinalResponse registers fn to be called with the final response of the dispatch.
+func (state *pipelineState) onFinalResponse(fn func(response any, err error)) {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.onFinal = append(state.onFinal, fn)
+}
+
+// processPipelineResponse runs the response processor unless the response is final, then the final response callbacks.
+func processPipelineResponse[Response T](ctx context.Context, state *pipelineState, response Response, err error) (Response, error) {
+	state.mutex.Lock()
+	skip, onFinal := state.skipProcessor, state.onFinal
+	state.mutex.Unlock()
+
+	if !skip {
+		response, err = processResponse(ctx, response, err)
+	}
+	for _, fn := range onFinal {
+		fn(response, err)
+	}
+	return response, err
+}
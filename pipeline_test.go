@@ -0,0 +1,94 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type rawGreetingQuery struct{ Name string }
+
+type finalGreetingQuery struct{ Name string }
+
+type rawGreetingHandler struct{}
+
+func (h *rawGreetingHandler) Handle(ctx context.Context, query rawGreetingQuery) (string, error) {
+	return "hello " + query.Name, nil
+}
+
+type finalGreetingHandler struct{}
+
+func (h *finalGreetingHandler) Handle(ctx context.Context, query finalGreetingQuery) (string, error) {
+	return "hello " + query.Name, nil
+}
+
+func passThroughBehavior(ctx context.Context, request any, next NextFunc) (any, error) {
+	return next(ctx, request)
+}
+
+// TestPipelinePlan tests the order of the steps of both cache composition modes.
+func TestPipelinePlan(t *testing.T) {
+	processed := 0
+	SetGlobalResponseProcessor(func(ctx context.Context, response any, err error) (any, error) {
+		processed++
+		if greeting, ok := response.(string); ok {
+			return greeting + "!", err
+		}
+		return response, err
+	})
+	defer SetGlobalResponseProcessor(nil)
+
+	postMiddleware := func(ctx context.Context, request any) (context.Context, any, bool) {
+		return ctx, request, true
+	}
+	AddQueryHandler[rawGreetingQuery, string](&rawGreetingHandler{}).
+		WithCache(time.Minute).
+		Behavior(passThroughBehavior).
+		PostMiddleware(postMiddleware)
+	AddQueryHandler[finalGreetingQuery, string](&finalGreetingHandler{}).
+		WithCache(time.Minute, CacheStoresFinalResponse()).
+		PostMiddleware(postMiddleware)
+
+	plan, ok := PipelinePlan(rawGreetingQuery{})
+	assert.True(t, ok)
+	assert.Equal(t, []PipelineStep{
+		{Stage: StageBehaviors, Name: "cache"},
+		{Stage: StageBehaviors, Name: "github.com/victoragudo/go-cqrs.passThroughBehavior"},
+		{Stage: StageHandler, Name: "*gocqrs.rawGreetingHandler"},
+		{Stage: StageResponseProcessor, Name: "response processor"},
+		{Stage: StagePostMiddlewares, Name: "github.com/victoragudo/go-cqrs.TestPipelinePlan.func2"},
+	}, plan)
+
+	plan, ok = PipelinePlan(finalGreetingQuery{})
+	assert.True(t, ok)
+	assert.Equal(t, []PipelineStep{
+		{Stage: StageBehaviors, Name: "cache lookup"},
+		{Stage: StageHandler, Name: "*gocqrs.finalGreetingHandler"},
+		{Stage: StageResponseProcessor, Name: "response processor"},
+		{Stage: StageCacheStore, Name: "cache"},
+		{Stage: StagePostMiddlewares, Name: "github.com/victoragudo/go-cqrs.TestPipelinePlan.func2"},
+	}, plan)
+
+	// The handler output is cached, so hits still go through the response processor.
+	for i := 0; i < 2; i++ {
+		greeting, err := SendQuery[string](context.Background(), rawGreetingQuery{Name: "ada"})
+		assert.NoError(t, err)
+		assert.Equal(t, "hello ada!", greeting)
+	}
+	assert.Equal(t, 2, processed)
+
+	// The final response is cached, so hits skip the response processor.
+	processed = 0
+	for i := 0; i < 2; i++ {
+		greeting, err := SendQuery[string](context.Background(), finalGreetingQuery{Name: "ada"})
+		assert.NoError(t, err)
+		assert.Equal(t, "hello ada!", greeting)
+	}
+	assert.Equal(t, 1, processed)
+
+	_, ok = PipelinePlan(struct{}{})
+	assert.False(t, ok)
+	assert.Equal(t, "cache store", StageCacheStore.String())
+}
@@ -0,0 +1,60 @@
+package gocqrs
+
+// PublishOption changes the error semantics of a single PublishEvent call, or observes its outcome.
+type PublishOption func(*publishConfig)
+
+// FailFast stops delivering the event at the first handler error, which PublishEvent returns.
+// The remaining handlers and consumption groups do not receive the event.
+func FailFast() PublishOption {
+	return func(config *publishConfig) {
+		config.failFast = true
+		config.bestEffort = false
+	}
+}
+
+// BestEffort delivers the event to every handler and reports their errors with the ErrorReporter
+// instead of returning them, so PublishEvent only fails when the event cannot be published at all.
+func BestEffort() PublishOption {
+	return func(config *publishConfig) {
+		config.bestEffort = true
+		config.failFast = false
+	}
+}
+
+// InParallel delivers the event to its handlers concurrently, running at most maxConcurrency of them at a time,
+// or all of them at once if maxConcurrency is zero. Consumption groups are delivered to once the handlers returned.
+// Combined with FailFast, the first handler error cancels the context of the handlers still running
+// and the handlers not started yet do not receive the event. A handler panicking on its delivery goroutine
+// fails with the panic as its error.
+func InParallel(maxConcurrency int) PublishOption {
+	return func(config *publishConfig) {
+		config.parallel = true
+		config.maxConcurrency = maxConcurrency
+	}
+}
+
+// WithMaxCollectedErrors keeps at most n handler errors, so publishing to many failing handlers does not
+// collect an unbounded number of errors. The errors beyond n are summarized by an *OmittedErrors,
+// which counts them per handler.
+func WithMaxCollectedErrors(n int) PublishOption {
+	return func(config *publishConfig) {
+		config.maxErrors = n
+	}
+}
+
+// OnDuplicate calls fn with the event if it is skipped by SetEventDeduplication, for which PublishEvent returns nil
+// like for a handled event. fn runs synchronously, before PublishEvent returns.
+func OnDuplicate(fn func(event any)) PublishOption {
+	return func(config *publishConfig) {
+		config.duplicate = fn
+	}
+}
+
+// newPublishConfig returns the configuration of a PublishEvent call.
+func newPublishConfig(opts []PublishOption) publishConfig {
+	config := publishConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
@@ -0,0 +1,61 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type invoiceIssuedEvent struct{}
+
+type invoiceFailingHandler struct {
+	calls int
+}
+
+func (h *invoiceFailingHandler) Handle(ctx context.Context, event invoiceIssuedEvent) error {
+	h.calls++
+	return errors.New("invoice handler failed")
+}
+
+type invoiceCountingHandler struct {
+	calls int
+}
+
+func (h *invoiceCountingHandler) Handle(ctx context.Context, event invoiceIssuedEvent) error {
+	h.calls++
+	return nil
+}
+
+// TestPublishEventOptions tests that FailFast and BestEffort override the error semantics of a single call.
+func TestPublishEventOptions(t *testing.T) {
+	failing, counting := &invoiceFailingHandler{}, &invoiceCountingHandler{}
+	assert.NoError(t, AddEventHandlers[invoiceIssuedEvent](failing, counting))
+
+	var reported []error
+	SetErrorReporter(func(ctx context.Context, err error) {
+		reported = append(reported, err)
+	})
+	defer SetErrorReporter(nil)
+
+	// Without options every handler runs and the errors are returned.
+	assert.EqualError(t, PublishEvent(context.Background(), invoiceIssuedEvent{}), "invoice handler failed")
+	assert.Equal(t, 1, failing.calls)
+	assert.Equal(t, 1, counting.calls)
+
+	assert.EqualError(t, PublishEvent(context.Background(), invoiceIssuedEvent{}, FailFast()), "invoice handler failed")
+	assert.Equal(t, 2, failing.calls)
+	assert.Equal(t, 1, counting.calls)
+
+	assert.NoError(t, PublishEvent(context.Background(), invoiceIssuedEvent{}, BestEffort()))
+	assert.Equal(t, 3, failing.calls)
+	assert.Equal(t, 2, counting.calls)
+	if assert.Len(t, reported, 1) {
+		assert.EqualError(t, reported[0], "invoice handler failed")
+	}
+
+	// The last option wins.
+	assert.Error(t, PublishEvent(context.Background(), invoiceIssuedEvent{}, BestEffort(), FailFast()))
+	assert.Equal(t, 2, counting.calls)
+}
@@ -0,0 +1,158 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type (
+	// OmittedErrors summarizes the handler errors of a publish beyond the limit set with WithMaxCollectedErrors.
+	OmittedErrors struct {
+		Counts map[string]int // Handler type name to its number of omitted errors.
+	}
+
+	// errorCollector collects the handler errors of a publish, up to a limit, counting the others per handler.
+	errorCollector struct {
+		mutex   sync.Mutex
+		limit   int // Zero for no limit.
+		errs    []error
+		omitted map[string]int
+	}
+
+	// handlerGroup runs the deliveries of a parallel publish with bounded concurrency.
+	// When it aborts on the first error, it cancels the context of the deliveries still running.
+	handlerGroup struct {
+		ctx      context.Context
+		cancel   context.CancelFunc
+		slots    chan struct{} // Nil for unbounded concurrency.
+		failFast bool
+		wg       sync.WaitGroup
+		errs     *errorCollector
+		skipped  int // Deliveries not started because the group was canceled.
+	}
+)
+
+func (e *OmittedErrors) Error() string {
+	names := make([]string, 0, len(e.Counts))
+	total := 0
+	for name, count := range e.Counts {
+		names = append(names, fmt.Sprintf("%v: %d", name, count))
+		total += count
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("%d more handler errors omitted (%v)", total, strings.Join(names, ", "))
+}
+
+// newErrorCollector returns a collector keeping at most limit errors, or all of them if limit is zero.
+func newErrorCollector(limit int) *errorCollector {
+	return &errorCollector{limit: limit}
+}
+
+// add collects the error returned by a handler.
+func (c *errorCollector) add(handlerName string, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.limit > 0 && len(c.errs) >= c.limit {
+		if c.omitted == nil {
+			c.omitted = make(map[string]int)
+		}
+		c.omitted[handlerName]++
+		return
+	}
+	c.errs = append(c.errs, err)
+}
+
+// len returns the number of errors added, including the omitted ones.
+func (c *errorCollector) len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	n := len(c.errs)
+	for _, count := range c.omitted {
+		n += count
+	}
+	return n
+}
+
+// result returns the collected errors followed by the summary of the omitted ones, if any.
+func (c *errorCollector) result() []error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.omitted) == 0 {
+		return c.errs
+	}
+	return append(c.errs, &OmittedErrors{Counts: c.omitted})
+}
+
+// newHandlerGroup returns a group running at most maxConcurrency deliveries at a time, or any number if it is zero.
+func newHandlerGroup(ctx context.Context, maxConcurrency int, failFast bool, errs *errorCollector) *handlerGroup {
+	ctx, cancel := context.WithCancel(ctx)
+	group := &handlerGroup{ctx: ctx, cancel: cancel, failFast: failFast, errs: errs}
+	if maxConcurrency > 0 {
+		group.slots = make(chan struct{}, maxConcurrency)
+	}
+	return group
+}
+
+// goDeliver runs deliver on its own goroutine once a slot is free, unless the group was canceled meanwhile.
+func (group *handlerGroup) goDeliver(handlerName string, deliver func(ctx context.Context) error) {
+	if group.slots != nil {
+		select {
+		case group.slots <- struct{}{}:
+		case <-group.ctx.Done():
+			group.skipped++
+			return
+		}
+	}
+	if group.ctx.Err() != nil {
+		group.skipped++
+		group.release()
+		return
+	}
+
+	group.wg.Add(1)
+	go func() {
+		defer group.wg.Done()
+		defer group.release()
+		if err := deliverRecovered(group.ctx, handlerName, deliver); err != nil {
+			group.errs.add(handlerName, err)
+			if group.failFast {
+				group.cancel()
+			}
+		}
+	}()
+}
+
+// deliverRecovered calls deliver, converting a panic into the error of the handler: on its own goroutine,
+// a panicking handler would crash the process instead of failing the publish.
+func deliverRecovered(ctx context.Context, handlerName string, deliver func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event handler %v panicked: %v", handlerName, r)
+		}
+	}()
+	return deliver(ctx)
+}
+
+// release frees the slot of a delivery.
+func (group *handlerGroup) release() {
+	if group.slots != nil {
+		<-group.slots
+	}
+}
+
+// wait waits for the deliveries started and reports whether the group aborted on an error.
+// The deliveries skipped because the publish context was canceled are reported with its error.
+func (group *handlerGroup) wait(ctx context.Context) bool {
+	group.wg.Wait()
+	group.cancel()
+	if group.failFast && group.errs.len() > 0 {
+		return true
+	}
+	if group.skipped > 0 && ctx.Err() != nil {
+		group.errs.add("", ctx.Err())
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	stockReservedEvent  struct{}
+	ledgerPostedEvent   struct{}
+	parallelProbedEvent struct{}
+	parallelPanicEvent  struct{}
+)
+
+// TestPublishInParallelFailFast tests that the first error of a parallel fail-fast publish cancels a running sibling.
+func TestPublishInParallelFailFast(t *testing.T) {
+	started := make(chan struct{})
+	canceled := make(chan error, 1)
+	assert.NoError(t, AddEventHandlerFunc(func(ctx context.Context, event stockReservedEvent) error {
+		close(started)
+		select {
+		case <-ctx.Done():
+			canceled <- ctx.Err()
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			canceled <- nil
+			return nil
+		}
+	}, WithEventHandlerName("slow")))
+	assert.NoError(t, AddEventHandlerFunc(func(ctx context.Context, event stockReservedEvent) error {
+		<-started
+		return errors.New("out of stock")
+	}, WithEventHandlerName("failing")))
+
+	err := PublishEvent(context.Background(), stockReservedEvent{}, InParallel(0), FailFast())
+	assert.ErrorContains(t, err, "out of stock")
+	assert.ErrorIs(t, <-canceled, context.Canceled)
+}
+
+// TestPublishInParallelBounded tests that a parallel publish runs at most maxConcurrency handlers at a time.
+func TestPublishInParallelBounded(t *testing.T) {
+	var mutex sync.Mutex
+	var running, peak int
+	for i := 0; i < 6; i++ {
+		assert.NoError(t, AddEventHandlerFunc(func(ctx context.Context, event parallelProbedEvent) error {
+			mutex.Lock()
+			running++
+			peak = max(peak, running)
+			mutex.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+			return nil
+		}))
+	}
+
+	assert.NoError(t, PublishEvent(context.Background(), parallelProbedEvent{}, InParallel(2)))
+	assert.LessOrEqual(t, peak, 2)
+}
+
+// TestPublishWithMaxCollectedErrors tests that the errors beyond the limit are counted per handler.
+func TestPublishWithMaxCollectedErrors(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, AddEventHandlerFunc(func(ctx context.Context, event ledgerPostedEvent) error {
+			return errors.New("ledger unavailable")
+		}, WithEventHandlerName(fmt.Sprintf("ledger-%d", i))))
+	}
+
+	for _, opts := range [][]PublishOption{{WithMaxCollectedErrors(3)}, {WithMaxCollectedErrors(3), InParallel(4)}} {
+		err := PublishEvent(context.Background(), ledgerPostedEvent{}, opts...)
+		assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 4)
+
+		var omitted *OmittedErrors
+		if assert.ErrorAs(t, err, &omitted) {
+			total := 0
+			for _, count := range omitted.Counts {
+				total += count
+			}
+			assert.Equal(t, 7, total)
+			assert.Len(t, omitted.Counts, 7)
+			if len(opts) == 1 {
+				// Delivered in registration order, the first three errors are kept.
+				assert.Equal(t, 1, omitted.Counts["ledger-9"])
+				assert.NotContains(t, omitted.Counts, "ledger-0")
+			}
+			assert.Contains(t, omitted.Error(), "7 more handler errors omitted")
+		}
+	}
+}
+
+// TestPublishInParallelPanic tests that a handler panicking on its delivery goroutine fails the publish
+// without preventing its siblings from handling the event.
+func TestPublishInParallelPanic(t *testing.T) {
+	var handled sync.WaitGroup
+	handled.Add(1)
+	assert.NoError(t, AddEventHandlerFunc(func(ctx context.Context, event parallelPanicEvent) error {
+		panic("projection corrupted")
+	}, WithEventHandlerName("panicking")))
+	assert.NoError(t, AddEventHandlerFunc(func(ctx context.Context, event parallelPanicEvent) error {
+		handled.Done()
+		return nil
+	}, WithEventHandlerName("sibling")))
+
+	var err error
+	assert.NotPanics(t, func() {
+		err = PublishEvent(context.Background(), parallelPanicEvent{}, InParallel(0))
+	})
+	assert.EqualError(t, err, "event handler panicking panicked: projection corrupted")
+	handled.Wait()
+}
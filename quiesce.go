@@ -0,0 +1,159 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type (
+	// ResumeFunc lifts the quiescing of a request type. Calling it more than once has no effect.
+	ResumeFunc func()
+
+	// QuiesceOption configures how a quiesced request type treats its dispatches.
+	QuiesceOption func(*quiesceState)
+
+	// QuiesceStatus describes a quiesced request type.
+	QuiesceStatus struct {
+		Reason   string // Reason given to Quiesce.
+		Queued   int    // Number of dispatches waiting for the resume.
+		Capacity int    // Maximum number of waiting dispatches, 0 when dispatches are rejected.
+	}
+
+	// quiesceState holds the quiescing of a request type.
+	quiesceState struct {
+		reason   string
+		capacity int
+		queue    []*quiescedDispatch
+	}
+
+	// quiescedDispatch is a dispatch waiting for its request type to resume.
+	quiescedDispatch struct {
+		release chan struct{} // Closed by the resume when the dispatch may run.
+		done    chan struct{} // Closed by the dispatch once it completed.
+	}
+)
+
+var (
+	quiesceMutex sync.Mutex
+	quiesced     = make(map[string]*quiesceState) // Request type name to its quiescing.
+)
+
+// WithQueue makes a quiesced request type queue up to capacity dispatches instead of rejecting them.
+// Queued dispatches block their caller and run in arrival order when the request type resumes;
+// dispatches beyond capacity fail with ErrQuiesced.
+func WithQueue(capacity int) QuiesceOption {
+	return func(state *quiesceState) {
+		state.capacity = capacity
+	}
+}
+
+// Quiesce makes dispatches of TRequest fail fast with ErrQuiesced carrying reason, e.g. while its storage is migrated,
+// until the returned ResumeFunc is called. Other request types are unaffected.
+// Quiescing an already quiesced request type updates its reason and options; any of the returned ResumeFuncs lifts it.
+func Quiesce[TRequest T](reason string, opts ...QuiesceOption) ResumeFunc {
+	return quiesce(typeKey(reflect.TypeOf(new(TRequest)).Elem()), reason, opts)
+}
+
+// QuiesceByName is Quiesce for a request type identified by its type name, as reported by reflect,
+// for operational tooling. It returns ErrUnknownRequestType if no handler is registered for typeName.
+func QuiesceByName(typeName string, reason string, opts ...QuiesceOption) (ResumeFunc, error) {
+	if _, ok := getRequestType(typeName); !ok {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownRequestType, typeName)
+	}
+	return quiesce(typeName, reason, opts), nil
+}
+
+// Quiesced returns the quiesced request types, keyed by type name.
+func Quiesced() map[string]QuiesceStatus {
+	quiesceMutex.Lock()
+	defer quiesceMutex.Unlock()
+
+	snapshot := make(map[string]QuiesceStatus, len(quiesced))
+	for typeName, state := range quiesced {
+		snapshot[typeName] = QuiesceStatus{
+			Reason:   state.reason,
+			Queued:   len(state.queue),
+			Capacity: state.capacity,
+		}
+	}
+	return snapshot
+}
+
+// quiesce quiesces a request type and returns the function resuming it.
+func quiesce(typeName string, reason string, opts []QuiesceOption) ResumeFunc {
+	quiesceMutex.Lock()
+	defer quiesceMutex.Unlock()
+
+	state, ok := quiesced[typeName]
+	if !ok {
+		state = &quiesceState{}
+		quiesced[typeName] = state
+	}
+	state.reason, state.capacity = reason, 0
+	for _, opt := range opts {
+		opt(state)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { resume(typeName, state) })
+	}
+}
+
+// resume lifts the quiescing of a request type and runs its queued dispatches one at a time, in arrival order.
+// It returns once they all completed.
+func resume(typeName string, state *quiesceState) {
+	quiesceMutex.Lock()
+	if quiesced[typeName] != state {
+		// Already resumed through another ResumeFunc.
+		quiesceMutex.Unlock()
+		return
+	}
+	delete(quiesced, typeName)
+	queue := state.queue
+	state.queue = nil
+	quiesceMutex.Unlock()
+
+	for _, dispatch := range queue {
+		close(dispatch.release)
+		<-dispatch.done
+	}
+}
+
+// enterQuiesce fails if the request type is quiesced, or waits for its resume if it queues dispatches.
+// The returned function must be called once the dispatch completed.
+func enterQuiesce(ctx context.Context, typeName string) (func(), error) {
+	quiesceMutex.Lock()
+	state, ok := quiesced[typeName]
+	if !ok {
+		quiesceMutex.Unlock()
+		return func() {}, nil
+	}
+	if len(state.queue) >= state.capacity {
+		quiesceMutex.Unlock()
+		return nil, fmt.Errorf("%w: %v: %v", ErrQuiesced, typeName, state.reason)
+	}
+	dispatch := &quiescedDispatch{release: make(chan struct{}), done: make(chan struct{})}
+	state.queue = append(state.queue, dispatch)
+	quiesceMutex.Unlock()
+
+	select {
+	case <-dispatch.release:
+		return func() { close(dispatch.done) }, nil
+	case <-ctx.Done():
+	}
+
+	quiesceMutex.Lock()
+	defer quiesceMutex.Unlock()
+	for i, queued := range state.queue {
+		if queued == dispatch {
+			state.queue = append(state.queue[:i:i], state.queue[i+1:]...)
+			return nil, ctx.Err()
+		}
+	}
+	// Released concurrently with the cancellation, let the resume move on.
+	close(dispatch.done)
+	return nil, ctx.Err()
+}
@@ -0,0 +1,120 @@
+package gocqrs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	migrateCommand   struct{ ID int }
+	unaffectedQuery  struct{}
+	queuedCommand    struct{ ID int }
+	cancelledCommand struct{}
+)
+
+type migrateCommandHandler struct{}
+
+func (h *migrateCommandHandler) Handle(ctx context.Context, command migrateCommand) (int, error) {
+	return command.ID, nil
+}
+
+type unaffectedQueryHandler struct{}
+
+func (h *unaffectedQueryHandler) Handle(ctx context.Context, query unaffectedQuery) (string, error) {
+	return "ok", nil
+}
+
+type queuedCommandHandler struct {
+	mutex   sync.Mutex
+	handled []int
+}
+
+func (h *queuedCommandHandler) Handle(ctx context.Context, command queuedCommand) (int, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.handled = append(h.handled, command.ID)
+	return command.ID, nil
+}
+
+type cancelledCommandHandler struct{}
+
+func (h *cancelledCommandHandler) Handle(ctx context.Context, command cancelledCommand) (string, error) {
+	return "ok", nil
+}
+
+// TestQuiesce_Reject tests that a quiesced request type fails fast while other request types are unaffected.
+func TestQuiesce_Reject(t *testing.T) {
+	AddCommandHandler[migrateCommand, int](&migrateCommandHandler{})
+	AddQueryHandler[unaffectedQuery, string](&unaffectedQueryHandler{})
+
+	resume := Quiesce[migrateCommand]("orders table migration")
+	assert.Equal(t, QuiesceStatus{Reason: "orders table migration"}, Quiesced()["gocqrs.migrateCommand"])
+
+	_, err := SendCommand[int](context.Background(), migrateCommand{ID: 1})
+	assert.ErrorIs(t, err, ErrQuiesced)
+	assert.EqualError(t, err, "request type is quiesced: gocqrs.migrateCommand: orders table migration")
+
+	response, err := SendQuery[string](context.Background(), unaffectedQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", response)
+
+	resume()
+	resume()
+	assert.NotContains(t, Quiesced(), "gocqrs.migrateCommand")
+	id, err := SendCommand[int](context.Background(), migrateCommand{ID: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, id)
+}
+
+// TestQuiesce_Queue tests that queued dispatches run in arrival order on resume and that the queue is bounded.
+func TestQuiesce_Queue(t *testing.T) {
+	handler := &queuedCommandHandler{}
+	AddCommandHandler[queuedCommand, int](handler)
+
+	resume, err := QuiesceByName("gocqrs.queuedCommand", "rebalancing", WithQueue(2))
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for id := 1; id <= 2; id++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			response, err := SendCommand[int](context.Background(), queuedCommand{ID: id})
+			assert.NoError(t, err)
+			assert.Equal(t, id, response)
+		}(id)
+		// Wait for the dispatch to be queued so the arrival order is deterministic.
+		assert.Eventually(t, func() bool {
+			return Quiesced()["gocqrs.queuedCommand"].Queued == id
+		}, time.Second, time.Millisecond)
+	}
+
+	_, err = SendCommand[int](context.Background(), queuedCommand{ID: 3})
+	assert.ErrorIs(t, err, ErrQuiesced)
+	assert.Equal(t, QuiesceStatus{Reason: "rebalancing", Queued: 2, Capacity: 2}, Quiesced()["gocqrs.queuedCommand"])
+	assert.Empty(t, handler.handled)
+
+	resume()
+	assert.Equal(t, []int{1, 2}, handler.handled, "resume returns once the queue is flushed")
+	wg.Wait()
+}
+
+// TestQuiesce_QueueCanceled tests that a queued dispatch gives up its place when its context is done.
+func TestQuiesce_QueueCanceled(t *testing.T) {
+	AddCommandHandler[cancelledCommand, string](&cancelledCommandHandler{})
+	resume := Quiesce[cancelledCommand]("maintenance", WithQueue(1))
+	defer resume()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := SendCommand[string](ctx, cancelledCommand{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, Quiesced()["gocqrs.cancelledCommand"].Queued)
+
+	_, err = QuiesceByName("gocqrs.unregisteredCommand", "maintenance")
+	assert.ErrorIs(t, err, ErrUnknownRequestType)
+}
@@ -2,6 +2,7 @@ package gocqrs
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 )
 
@@ -18,7 +19,7 @@ func (r reflectiveHandler[T1, T2]) Handle(ctx context.Context, in T1) (out T2, e
 
 	// Check if the method is properly initialized
 	if !r.method.IsValid() {
-		panic("uninitialized Handle method")
+		return out, ErrHandlerNotInitialized
 	}
 
 	// Check if the context and input are properly initialized
@@ -34,9 +35,10 @@ func (r reflectiveHandler[T1, T2]) Handle(ctx context.Context, in T1) (out T2, e
 
 	// Handle the results of the reflective call
 	if len(reflectResults) > 0 {
-		result, ok := reflectResults[0].Interface().(T2)
-		if ok {
-			out = result
+		var convErr error
+		if out, convErr = As[T2](reflectResults[0].Interface()); convErr != nil {
+			// Reported unless the handler returned its own error.
+			err = fmt.Errorf("incorrect response type: %w", convErr)
 		}
 	}
 
@@ -56,6 +58,17 @@ func (r reflectiveHandler[T1, T2]) Handle(ctx context.Context, in T1) (out T2, e
 }
 
 // createReflectiveHandler creates a new reflectiveHandler with the provided method.
+// The method is always valid when obtained from a registered handler, whose type guarantees its Handle method,
+// or from a resolved handler, checked by resolveHandler.
 func createReflectiveHandler[TResponse T](method reflect.Value) IHandler[T, TResponse] {
 	return reflectiveHandler[T, TResponse]{method: method}
 }
+
+// isNilValue reports whether v holds a nil interface, pointer, map, slice, channel or function.
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		return v.IsNil()
+	}
+	return false
+}
@@ -35,10 +35,9 @@ func TestHandleValidInput(t *testing.T) {
 
 // TestHandleInvalidMethod tests the Handle method with an uninitialized method.
 func TestHandleInvalidMethod(t *testing.T) {
-	assert.Panics(t, func() {
-		var handler reflectiveHandler[string, string]
-		_, _ = handler.Handle(context.Background(), "test")
-	})
+	var handler reflectiveHandler[string, string]
+	_, err := handler.Handle(context.Background(), "test")
+	assert.ErrorIs(t, err, ErrHandlerNotInitialized)
 }
 
 // TestHandleValidContext tests the Handle method with and valid context.
@@ -59,3 +58,56 @@ func TestHandleInvalidInput(t *testing.T) {
 	_, err := handler.Handle(context.Background(), "test")
 	assert.Error(t, err, "Handle should return an error")
 }
+
+type celsius float64
+
+type temperatureReading interface {
+	Degrees() float64
+}
+
+type sensorReading struct {
+	value float64
+}
+
+func (s sensorReading) Degrees() float64 {
+	return s.value
+}
+
+// TestHandleConvertibleResult tests that results assignable or convertible to the requested response are accepted.
+func TestHandleConvertibleResult(t *testing.T) {
+	named := reflect.ValueOf(func(ctx context.Context, input string) (celsius, error) {
+		return 21.5, nil
+	})
+	degrees, err := createReflectiveHandler[float64](named).Handle(context.Background(), "test")
+	assert.NoError(t, err)
+	assert.Equal(t, 21.5, degrees)
+
+	concrete := reflect.ValueOf(func(ctx context.Context, input string) (sensorReading, error) {
+		return sensorReading{value: 3}, nil
+	})
+	reading, err := createReflectiveHandler[temperatureReading](concrete).Handle(context.Background(), "test")
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, reading.Degrees())
+
+	nilPointer := reflect.ValueOf(func(ctx context.Context, input string) (*sensorReading, error) {
+		return nil, nil
+	})
+	_, err = createReflectiveHandler[temperatureReading](nilPointer).Handle(context.Background(), "test")
+	assert.NoError(t, err)
+}
+
+// TestHandleIncompatibleResult tests that a result that cannot represent the requested response is an error,
+// unless the handler returned its own error.
+func TestHandleIncompatibleResult(t *testing.T) {
+	number := reflect.ValueOf(func(ctx context.Context, input string) (int, error) {
+		return 65, nil
+	})
+	_, err := createReflectiveHandler[string](number).Handle(context.Background(), "test")
+	assert.EqualError(t, err, "incorrect response type: cannot convert int to string")
+
+	failing := reflect.ValueOf(func(ctx context.Context, input string) (int, error) {
+		return 0, fmt.Errorf("handler failed")
+	})
+	_, err = createReflectiveHandler[string](failing).Handle(context.Background(), "test")
+	assert.EqualError(t, err, "handler failed")
+}
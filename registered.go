@@ -0,0 +1,42 @@
+package gocqrs
+
+import "sort"
+
+// RegisteredHandlerNames returns the names of every registered handler: command and query handlers,
+// event handlers and members of event handler groups, sorted and without duplicates.
+// Handlers are named after their type, e.g. "*orders.CreateOrderHandler", unless registered under another name,
+// so the result can be compared with an approved list in a test.
+func RegisteredHandlerNames() []string {
+	seen := make(map[string]bool)
+
+	handlerMutex.RLock()
+	for _, wrapper := range handlers {
+		if nameField, ok := getField(wrapper, "Name"); ok {
+			seen[nameField.String()] = true
+		}
+	}
+	handlerMutex.RUnlock()
+
+	eventHandlerMutex.RLock()
+	for _, registered := range eventHandlers {
+		for _, eventHandler := range registered {
+			seen[eventHandler.typeName] = true
+		}
+	}
+	eventHandlerMutex.RUnlock()
+
+	eventGroupMutex.RLock()
+	for _, group := range eventGroups {
+		for _, member := range group.members {
+			seen[member.typeName] = true
+		}
+	}
+	eventGroupMutex.RUnlock()
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,73 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	approvedCommand        struct{}
+	approvedQuery          struct{}
+	approvedEvent          struct{}
+	approvedCommandHandler struct{}
+	approvedQueryHandler   struct{}
+	approvedEventHandler   struct{}
+	approvedGroupHandler   struct{}
+)
+
+func (h *approvedCommandHandler) Handle(ctx context.Context, command approvedCommand) (bool, error) {
+	return true, nil
+}
+
+func (h *approvedQueryHandler) Handle(ctx context.Context, query approvedQuery) (int, error) {
+	return 0, nil
+}
+
+func (h *approvedEventHandler) Handle(ctx context.Context, event approvedEvent) error {
+	return nil
+}
+
+func (h *approvedGroupHandler) Handle(ctx context.Context, event approvedEvent) error {
+	return nil
+}
+
+// TestRegisteredHandlerNames tests that every kind of handler is listed, sorted, against an isolated registry.
+func TestRegisteredHandlerNames(t *testing.T) {
+	handlerMutex.Lock()
+	savedHandlers := handlers
+	handlers = make(handlerMap)
+	handlerMutex.Unlock()
+	eventHandlerMutex.Lock()
+	savedEventHandlers := eventHandlers
+	eventHandlers = make(map[string][]eventHandlersType)
+	eventHandlerMutex.Unlock()
+	eventGroupMutex.Lock()
+	savedGroups, savedTypeGroups := eventGroups, eventTypeGroups
+	eventGroups, eventTypeGroups = make(map[string]*eventHandlerGroup), make(map[string][]*eventHandlerGroup)
+	eventGroupMutex.Unlock()
+	defer func() {
+		handlerMutex.Lock()
+		handlers = savedHandlers
+		handlerMutex.Unlock()
+		eventHandlerMutex.Lock()
+		eventHandlers = savedEventHandlers
+		eventHandlerMutex.Unlock()
+		eventGroupMutex.Lock()
+		eventGroups, eventTypeGroups = savedGroups, savedTypeGroups
+		eventGroupMutex.Unlock()
+	}()
+
+	AddCommandHandler[approvedCommand, bool](&approvedCommandHandler{})
+	AddQueryHandler[approvedQuery, int](&approvedQueryHandler{}).Named("approved query")
+	assert.NoError(t, AddEventHandlers[approvedEvent](&approvedEventHandler{}))
+	assert.NoError(t, AddEventHandlerGroup[approvedEvent]("approved", []IEventHandler[approvedEvent]{&approvedGroupHandler{}}))
+
+	assert.Equal(t, []string{
+		"*gocqrs.approvedCommandHandler",
+		"*gocqrs.approvedEventHandler",
+		"*gocqrs.approvedGroupHandler",
+		"*gocqrs.approvedQueryHandler",
+	}, RegisteredHandlerNames())
+}
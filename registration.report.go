@@ -0,0 +1,93 @@
+package gocqrs
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Kinds of registrations reported by RegistrationReport.
+const (
+	RegistrationHandler      = "handler"       // A command or query handler.
+	RegistrationEventHandler = "event handler" // An event handler or a member of an event handler group.
+	RegistrationMiddleware   = "middleware"    // A pre- or post-middleware, added to any handler.
+)
+
+// RegistrationTiming is the time spent registering a handler or middleware, as measured by the configured Clock.
+type RegistrationTiming struct {
+	Kind     string        // Kind of registration, such as RegistrationHandler.
+	Name     string        // Name of the handler or middleware.
+	Count    int           // Number of registrations under the name.
+	Duration time.Duration // Total time spent in these registrations.
+}
+
+// registrationTimingKey identifies the registrations aggregated into a RegistrationTiming.
+type registrationTimingKey struct {
+	kind string
+	name string
+}
+
+var (
+	registrationProfiling   atomic.Bool
+	registrationTimingMutex sync.Mutex
+	registrationTimings     = make(map[registrationTimingKey]*RegistrationTiming)
+)
+
+// EnableRegistrationProfiling makes the registrations measure the time they take, for RegistrationReport.
+// It is disabled by default: reading the clock twice costs more than most registrations.
+func EnableRegistrationProfiling(enabled bool) {
+	registrationProfiling.Store(enabled)
+}
+
+// RegistrationReport returns the time spent registering every handler and middleware, the costliest first,
+// e.g. to find what slows down the startup of a service registering hundreds of handlers.
+// The registrations sharing a kind and a name, such as a middleware added to every handler, are aggregated.
+// Only the registrations made while EnableRegistrationProfiling is enabled are reported.
+func RegistrationReport() []RegistrationTiming {
+	registrationTimingMutex.Lock()
+	report := make([]RegistrationTiming, 0, len(registrationTimings))
+	for _, timing := range registrationTimings {
+		report = append(report, *timing)
+	}
+	registrationTimingMutex.Unlock()
+
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Duration != report[j].Duration {
+			return report[i].Duration > report[j].Duration
+		}
+		if report[i].Kind != report[j].Kind {
+			return report[i].Kind < report[j].Kind
+		}
+		return report[i].Name < report[j].Name
+	})
+	return report
+}
+
+// registrationStart returns the time a registration starts at, or the zero time if profiling is disabled.
+func registrationStart() time.Time {
+	if !registrationProfiling.Load() {
+		return time.Time{}
+	}
+	return now()
+}
+
+// recordRegistrationTiming adds the time elapsed since start, returned by registrationStart, to the registrations
+// of a kind and name. Registrations started while profiling was disabled are not recorded.
+func recordRegistrationTiming(kind, name string, start time.Time) {
+	if start.IsZero() {
+		return
+	}
+	elapsed := now().Sub(start)
+	key := registrationTimingKey{kind: kind, name: name}
+
+	registrationTimingMutex.Lock()
+	defer registrationTimingMutex.Unlock()
+	timing, ok := registrationTimings[key]
+	if !ok {
+		timing = &RegistrationTiming{Kind: kind, Name: name}
+		registrationTimings[key] = timing
+	}
+	timing.Count++
+	timing.Duration += elapsed
+}
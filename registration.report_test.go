@@ -0,0 +1,123 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type registrationBenchCommand struct{}
+
+type registrationBenchCommandHandler struct{}
+
+func (h *registrationBenchCommandHandler) Handle(ctx context.Context, command registrationBenchCommand) (string, error) {
+	return "registered", nil
+}
+
+func registrationBenchAudit(ctx context.Context, request any) (context.Context, any, bool) {
+	return ctx, request, true
+}
+
+func registrationBenchTrace(ctx context.Context, request any) (context.Context, any, bool) {
+	return ctx, request, true
+}
+
+func registrationBenchMetrics(ctx context.Context, request any) (context.Context, any, bool) {
+	return ctx, request, true
+}
+
+type (
+	reportedCommand        struct{}
+	reportedCommandHandler struct{}
+	reportedEvent          struct{}
+	reportedEventHandler   struct{}
+)
+
+func (h *reportedCommandHandler) Handle(ctx context.Context, command reportedCommand) (string, error) {
+	return "reported", nil
+}
+
+func (h *reportedEventHandler) Handle(ctx context.Context, event reportedEvent) error {
+	return nil
+}
+
+func reportedAudit(ctx context.Context, request any) (context.Context, any, bool) {
+	return ctx, request, true
+}
+
+// TestRegistrationReport tests that the registrations are reported by kind and name, the costliest first.
+func TestRegistrationReport(t *testing.T) {
+	EnableRegistrationProfiling(true)
+	defer EnableRegistrationProfiling(false)
+
+	AddCommandHandler[reportedCommand, string](&reportedCommandHandler{}).
+		PreMiddleware(reportedAudit).
+		PostMiddleware(reportedAudit)
+	assert.NoError(t, AddEventHandlers[reportedEvent](&reportedEventHandler{}))
+
+	report := RegistrationReport()
+	assert.True(t, sort.SliceIsSorted(report, func(i, j int) bool { return report[i].Duration > report[j].Duration }))
+
+	timings := make(map[registrationTimingKey]RegistrationTiming)
+	for _, timing := range report {
+		assert.Positive(t, timing.Count)
+		assert.GreaterOrEqual(t, timing.Duration, time.Duration(0))
+		timings[registrationTimingKey{kind: timing.Kind, name: timing.Name}] = timing
+	}
+	assert.Equal(t, 1, timings[registrationTimingKey{kind: RegistrationHandler, name: "*gocqrs.reportedCommandHandler"}].Count)
+	assert.Equal(t, 1, timings[registrationTimingKey{kind: RegistrationEventHandler, name: "*gocqrs.reportedEventHandler"}].Count)
+	assert.Equal(t, 2, timings[registrationTimingKey{kind: RegistrationMiddleware, name: "github.com/victoragudo/go-cqrs.reportedAudit"}].Count,
+		"a middleware added in both phases is aggregated")
+}
+
+// BenchmarkRegistration registers 1000 handlers with 3 middlewares each.
+func BenchmarkRegistration(b *testing.B) {
+	register := func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 1000; j++ {
+				AddCommandHandler[registrationBenchCommand, string](&registrationBenchCommandHandler{}).
+					PreMiddleware(registrationBenchAudit).
+					PreMiddleware(registrationBenchTrace).
+					PostMiddleware(registrationBenchMetrics)
+			}
+		}
+	}
+
+	b.Run("default", register)
+	b.Run("profiling", func(b *testing.B) {
+		EnableRegistrationProfiling(true)
+		defer EnableRegistrationProfiling(false)
+		register(b)
+	})
+}
+
+// BenchmarkFuncName compares the cached lookup of function names with runtime.FuncForPC.
+func BenchmarkFuncName(b *testing.B) {
+	pc := reflect.ValueOf(registrationBenchAudit).Pointer()
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = runtime.FuncForPC(pc).Name()
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = funcName(registrationBenchAudit)
+		}
+	})
+}
+
+// TestRegistrationReport_Disabled tests that registrations are not measured unless profiling is enabled.
+func TestRegistrationReport_Disabled(t *testing.T) {
+	type unprofiledCommand struct{}
+	AddCommandFunc(func(ctx context.Context, command unprofiledCommand) (string, error) { return "", nil })
+
+	for _, timing := range RegistrationReport() {
+		assert.NotContains(t, timing.Name, "TestRegistrationReport_Disabled")
+	}
+}
@@ -0,0 +1,40 @@
+package gocqrs
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var (
+	internalRequestMutex sync.RWMutex
+	internalRequestTypes = make(map[string]bool) // Request type names marked with MarkInternal.
+)
+
+// MarkInternal marks TRequest as an internal request, such as a bootstrap command, whose dispatches skip
+// every pre- and post-middleware, including those registered for the request type. Behaviors still run.
+// The marked request types are listed by InternalRequestTypes and flagged in the RegistryView.
+func MarkInternal[TRequest T]() {
+	internalRequestMutex.Lock()
+	defer internalRequestMutex.Unlock()
+	internalRequestTypes[typeKey(reflect.TypeOf(new(TRequest)).Elem())] = true
+}
+
+// InternalRequestTypes returns the names of the request types marked with MarkInternal, sorted.
+func InternalRequestTypes() []string {
+	internalRequestMutex.RLock()
+	defer internalRequestMutex.RUnlock()
+	names := make([]string, 0, len(internalRequestTypes))
+	for name := range internalRequestTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isInternalRequest reports whether the request type typed was marked with MarkInternal.
+func isInternalRequest(typed string) bool {
+	internalRequestMutex.RLock()
+	defer internalRequestMutex.RUnlock()
+	return internalRequestTypes[typed]
+}
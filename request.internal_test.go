@@ -0,0 +1,44 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bootstrapCommand struct{}
+
+type bootstrapCommandHandler struct{}
+
+func (h *bootstrapCommandHandler) Handle(ctx context.Context, command bootstrapCommand) (bool, error) {
+	return true, nil
+}
+
+// TestMarkInternal tests that no middleware runs for an internal command and that it is flagged as such.
+func TestMarkInternal(t *testing.T) {
+	var calls []string
+	middleware := func(name string) MiddlewareFunc {
+		return func(ctx context.Context, request any) (context.Context, any, bool) {
+			calls = append(calls, name)
+			return ctx, nil, false // Blocks the dispatch, like a failing auth middleware.
+		}
+	}
+	AddCommandHandler[bootstrapCommand, bool](&bootstrapCommandHandler{}).
+		PreMiddleware(middleware("auth")).
+		PostMiddleware(middleware("logging"))
+	PreMiddlewareFor[bootstrapCommand](middleware("typed"))
+	MarkInternal[bootstrapCommand]()
+
+	ok, err := SendCommand[bool](context.Background(), bootstrapCommand{})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Empty(t, calls)
+
+	assert.Contains(t, InternalRequestTypes(), "gocqrs.bootstrapCommand")
+	view, _ := newRegistryView().Handler("gocqrs.bootstrapCommand")
+	assert.True(t, view.Internal)
+
+	steps, _ := PipelinePlan(bootstrapCommand{})
+	assert.Equal(t, []PipelineStep{{Stage: StageHandler, Name: "*gocqrs.bootstrapCommandHandler"}}, steps)
+}
@@ -0,0 +1,78 @@
+package gocqrs
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	requestKeyRewriterMutex sync.RWMutex
+	requestKeyRewriter      func(typeName string) string
+
+	typeKeyNormalizerMutex sync.RWMutex
+	typeKeyNormalizer      func(typeName string) string
+)
+
+// SetTypeKeyNormalizer sets a function normalizing every type name handlers, event handlers and per-type settings are
+// registered and looked up under, e.g. to strip package paths or ignore case so a request built from a type of
+// another module sharing its name still reaches its handler. It is applied to registrations and dispatches alike,
+// before the request key rewriter, so it must be set before registering handlers. A request routed to the handler of
+// another type is converted to it, as with SetRequestKeyRewriter. Passing nil removes the normalizer.
+func SetTypeKeyNormalizer(normalizer func(typeName string) string) {
+	typeKeyNormalizerMutex.Lock()
+	defer typeKeyNormalizerMutex.Unlock()
+	typeKeyNormalizer = normalizer
+}
+
+// typeKey returns the name typ is registered and looked up under.
+func typeKey(typ reflect.Type) string {
+	return normalizeTypeKey(typ.String())
+}
+
+// normalizeTypeKey applies the type key normalizer, if any, to a type name.
+func normalizeTypeKey(typeName string) string {
+	typeKeyNormalizerMutex.RLock()
+	normalizer := typeKeyNormalizer
+	typeKeyNormalizerMutex.RUnlock()
+
+	if normalizer == nil {
+		return typeName
+	}
+	return normalizer(typeName)
+}
+
+// SetRequestKeyRewriter sets a function mapping the type name of a dispatched request to the type name its
+// handler is looked up under, e.g. to route a deprecated "users.CreateUserV1" to the handler of "users.CreateUserV2".
+// It is consulted by SendCommand, SendQuery and SendByName. A request rewritten to another type is converted to it,
+// which requires the two types to be convertible, such as structs with identical fields; otherwise route it to an
+// upcaster, a handler registered for the old type sending the current one. Passing nil removes the rewriter.
+func SetRequestKeyRewriter(rewriter func(typeName string) string) {
+	requestKeyRewriterMutex.Lock()
+	defer requestKeyRewriterMutex.Unlock()
+	requestKeyRewriter = rewriter
+}
+
+// rewriteRequestKey applies the request key rewriter, if any, to a request type name.
+func rewriteRequestKey(typeName string) string {
+	requestKeyRewriterMutex.RLock()
+	rewriter := requestKeyRewriter
+	requestKeyRewriterMutex.RUnlock()
+
+	if rewriter == nil {
+		return typeName
+	}
+	return rewriter(typeName)
+}
+
+// convertRewrittenRequest converts a request whose type name was rewritten to the request type registered under typeName.
+func convertRewrittenRequest(in any, typeName string) (any, error) {
+	requestType, ok := getRequestType(typeName)
+	if !ok || reflect.TypeOf(in) == requestType {
+		return in, nil
+	}
+	if !reflect.TypeOf(in).ConvertibleTo(requestType) {
+		return nil, fmt.Errorf("request %T rewritten to %v is not convertible to it", in, typeName)
+	}
+	return reflect.ValueOf(in).Convert(requestType).Interface(), nil
+}
@@ -0,0 +1,85 @@
+package gocqrs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	createUserV1 struct{ Name string }
+	createUserV2 struct{ Name string }
+	createUserV0 struct{ Login int }
+)
+
+type createUserV2Handler struct{}
+
+func (h *createUserV2Handler) Handle(ctx context.Context, command createUserV2) (string, error) {
+	return "created " + command.Name, nil
+}
+
+// TestSetRequestKeyRewriter tests that a deprecated request type is routed to the handler of the current one.
+func TestSetRequestKeyRewriter(t *testing.T) {
+	AddCommandHandler[createUserV2, string](&createUserV2Handler{})
+	SetRequestKeyRewriter(func(typeName string) string {
+		switch typeName {
+		case "gocqrs.createUserV1", "gocqrs.createUserV0":
+			return "gocqrs.createUserV2"
+		}
+		return typeName
+	})
+	defer SetRequestKeyRewriter(nil)
+
+	response, err := SendCommand[string](context.Background(), createUserV1{Name: "ada"})
+	assert.NoError(t, err)
+	assert.Equal(t, "created ada", response)
+
+	body, err := SendByName(context.Background(), "gocqrs.createUserV1", []byte(`{"Name":"grace"}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `"created grace"`, string(body))
+
+	// Requests of an incompatible shape need an upcaster.
+	_, err = SendCommand[string](context.Background(), createUserV0{Login: 1})
+	assert.EqualError(t, err, "request gocqrs.createUserV0 rewritten to gocqrs.createUserV2 is not convertible to it")
+
+	// Current requests are unaffected.
+	response, err = SendCommand[string](context.Background(), createUserV2{Name: "linus"})
+	assert.NoError(t, err)
+	assert.Equal(t, "created linus", response)
+}
+
+type (
+	renameAccount struct{ Name string }
+	// RenameAccount stands for the same request declared by another module.
+	RenameAccount      struct{ Name string }
+	renameAccountAlias = renameAccount
+)
+
+type renameAccountHandler struct{}
+
+func (h *renameAccountHandler) Handle(ctx context.Context, command renameAccount) (string, error) {
+	return "renamed " + command.Name, nil
+}
+
+// TestSetTypeKeyNormalizer tests that requests whose type names normalize to the same key reach the same handler.
+func TestSetTypeKeyNormalizer(t *testing.T) {
+	SetTypeKeyNormalizer(func(typeName string) string {
+		return strings.ToLower(typeName[strings.LastIndex(typeName, ".")+1:])
+	})
+	defer SetTypeKeyNormalizer(nil)
+	AddCommandHandler[renameAccount, string](&renameAccountHandler{})
+
+	response, err := SendCommand[string](context.Background(), renameAccountAlias{Name: "ada"})
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed ada", response)
+
+	response, err = SendCommand[string](context.Background(), RenameAccount{Name: "grace"})
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed grace", response)
+
+	body, err := SendByName(context.Background(), "accounts.RenameAccount", []byte(`{"Name":"linus"}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `"renamed linus"`, string(body))
+}
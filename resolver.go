@@ -0,0 +1,86 @@
+package gocqrs
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type (
+	// HandlerResolver obtains the handler of a request type, such as an instance built by a dependency injection
+	// container. The handler must have a Handle(ctx context.Context, request TRequest) (TResponse, error) method.
+	// It returns false if it cannot provide a handler for requestType.
+	HandlerResolver func(requestType reflect.Type) (any, bool)
+
+	// HandlerShapeError describes a handler whose Handle method does not have the
+	// Handle(ctx context.Context, request TRequest) (TResponse, error) shape required for its request type.
+	// It wraps ErrHandlerNotInitialized.
+	HandlerShapeError struct {
+		Handler     string       // Name of the handler type.
+		RequestType reflect.Type // Request type the handler was obtained for.
+		Method      reflect.Type // Type of the Handle method.
+	}
+
+	// resolvedHandler holds a handler obtained from the HandlerResolver, in the shape of the registered wrappers.
+	resolvedHandler struct {
+		Handler any
+		Name    string
+	}
+)
+
+var (
+	handlerResolverMutex sync.RWMutex
+	handlerResolver      HandlerResolver
+)
+
+// SetHandlerResolver sets the resolver asked for a handler when a request type has no registered handler,
+// so handlers can be obtained from a container at dispatch time. Passing nil removes the resolver.
+//
+// A dispatch uses, in order of precedence: the handler override carried by its context, the handler registered
+// with AddCommandHandler, AddQueryHandler or their variants, and finally the handler returned by the resolver.
+// The resolver is called on every dispatch of an unregistered request type, so it decides the lifetime of the
+// handlers it returns. Resolved handlers are named after their type, e.g. "*orders.CreateOrderHandler".
+func SetHandlerResolver(resolver HandlerResolver) {
+	handlerResolverMutex.Lock()
+	defer handlerResolverMutex.Unlock()
+	handlerResolver = resolver
+}
+
+// Error describes the expected and actual shapes of the Handle method.
+func (e *HandlerShapeError) Error() string {
+	return fmt.Sprintf("%v: %v has Handle method %v, want func(context.Context, %v) (TResponse, error)",
+		ErrHandlerNotInitialized, e.Handler, e.Method, e.RequestType)
+}
+
+// Unwrap returns ErrHandlerNotInitialized.
+func (e *HandlerShapeError) Unwrap() error {
+	return ErrHandlerNotInitialized
+}
+
+// resolveHandler asks the HandlerResolver, if any, for the handler of requestType.
+// A resolved handler without a Handle method, or whose Handle method cannot handle requestType, is a broken invariant.
+func resolveHandler(requestType reflect.Type) (any, bool, error) {
+	handlerResolverMutex.RLock()
+	resolver := handlerResolver
+	handlerResolverMutex.RUnlock()
+
+	if resolver == nil {
+		return nil, false, nil
+	}
+	handler, ok := resolver(requestType)
+	if !ok || handler == nil {
+		return nil, false, nil
+	}
+	method := reflect.ValueOf(handler).MethodByName("Handle")
+	if !method.IsValid() {
+		return nil, false, brokenInvariant("handle_method", fmt.Errorf("resolved handler %T for %v has no Handle method", handler, requestType))
+	}
+	if methodType := method.Type(); !isHandlerShaped(methodType) || !requestType.AssignableTo(methodType.In(1)) {
+		return nil, false, brokenInvariant("handle_method", &HandlerShapeError{
+			Handler:     reflect.TypeOf(handler).String(),
+			RequestType: requestType,
+			Method:      methodType,
+		})
+	}
+	return &resolvedHandler{Handler: handler, Name: reflect.TypeOf(handler).String()}, true, nil
+}
@@ -0,0 +1,113 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type resolvedGreetingQuery struct {
+	Name string
+}
+
+type registeredGreetingQuery struct{}
+
+// greetingQueryHandler is provided by the fake container, with its greeting injected.
+type greetingQueryHandler struct {
+	greeting string
+}
+
+func (h *greetingQueryHandler) Handle(ctx context.Context, query resolvedGreetingQuery) (string, error) {
+	return h.greeting + " " + query.Name, nil
+}
+
+type registeredGreetingQueryHandler struct{}
+
+func (h *registeredGreetingQueryHandler) Handle(ctx context.Context, query registeredGreetingQuery) (string, error) {
+	return "registered", nil
+}
+
+// TestSetHandlerResolver tests that unregistered request types are dispatched to the handlers of the resolver.
+func TestSetHandlerResolver(t *testing.T) {
+	var resolved []reflect.Type
+	SetHandlerResolver(func(requestType reflect.Type) (any, bool) {
+		resolved = append(resolved, requestType)
+		if requestType == reflect.TypeOf(resolvedGreetingQuery{}) {
+			return &greetingQueryHandler{greeting: "hello"}, true
+		}
+		return nil, false
+	})
+	defer SetHandlerResolver(nil)
+	AddQueryHandler[registeredGreetingQuery, string](&registeredGreetingQueryHandler{})
+
+	response, err := SendQuery[string](context.Background(), resolvedGreetingQuery{Name: "ada"})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello ada", response)
+
+	// Registered handlers take precedence over the resolver.
+	response, err = SendQuery[string](context.Background(), registeredGreetingQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, "registered", response)
+	assert.Equal(t, []reflect.Type{reflect.TypeOf(resolvedGreetingQuery{})}, resolved)
+
+	type unresolvedQuery struct{}
+	assert.Panics(t, func() {
+		_, _ = SendQuery[string](context.Background(), unresolvedQuery{})
+	})
+}
+
+// TestSetHandlerResolver_InvalidHandler tests that a resolved handler without a Handle method is rejected.
+func TestSetHandlerResolver_InvalidHandler(t *testing.T) {
+	type invalidResolvedQuery struct{}
+	SetHandlerResolver(func(requestType reflect.Type) (any, bool) {
+		return struct{}{}, true
+	})
+	defer SetHandlerResolver(nil)
+
+	assert.PanicsWithValue(t, "resolved handler struct {} for gocqrs.invalidResolvedQuery has no Handle method", func() {
+		_, _ = SendQuery[string](context.Background(), invalidResolvedQuery{})
+	})
+}
+
+type misshapedResolvedQuery struct{}
+
+// misshapedQueryHandler handles another request type than the one it is resolved for.
+type misshapedQueryHandler struct{}
+
+func (h *misshapedQueryHandler) Handle(ctx context.Context, query resolvedGreetingQuery) (string, error) {
+	return "", nil
+}
+
+// argumentlessQueryHandler has a Handle method without request argument.
+type argumentlessQueryHandler struct{}
+
+func (h *argumentlessQueryHandler) Handle(ctx context.Context) error {
+	return nil
+}
+
+// TestSetHandlerResolver_MisshapedHandler tests that a resolved handler whose Handle method cannot handle the
+// request type is rejected before being called.
+func TestSetHandlerResolver_MisshapedHandler(t *testing.T) {
+	var handler any = &misshapedQueryHandler{}
+	SetHandlerResolver(func(requestType reflect.Type) (any, bool) {
+		return handler, true
+	})
+	defer SetHandlerResolver(nil)
+
+	assert.PanicsWithValue(t, "handler Handle method is not initialized: *gocqrs.misshapedQueryHandler has Handle method "+
+		"func(context.Context, gocqrs.resolvedGreetingQuery) (string, error), want func(context.Context, gocqrs.misshapedResolvedQuery) (TResponse, error)", func() {
+		_, _ = SendQuery[string](context.Background(), misshapedResolvedQuery{})
+	})
+
+	SetNoPanics(true)
+	defer SetNoPanics(false)
+	for _, handler = range []any{&misshapedQueryHandler{}, &argumentlessQueryHandler{}} {
+		_, err := SendQuery[string](context.Background(), misshapedResolvedQuery{})
+		var shapeErr *HandlerShapeError
+		assert.ErrorAs(t, err, &shapeErr)
+		assert.ErrorIs(t, err, ErrHandlerNotInitialized)
+		assert.Equal(t, reflect.TypeOf(misshapedResolvedQuery{}), shapeErr.RequestType)
+	}
+}
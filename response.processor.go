@@ -0,0 +1,43 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ResponseProcessor post-processes the response and error of every command and query dispatch.
+// It may return a different response, e.g. an envelope, as long as the caller's response type can hold it.
+type ResponseProcessor func(ctx context.Context, response any, err error) (any, error)
+
+var (
+	responseProcessorMutex sync.RWMutex
+	responseProcessor      ResponseProcessor
+)
+
+// SetGlobalResponseProcessor sets the processor applied by SendCommand and SendQuery after the handler
+// and its pipeline behaviors, before the post-middlewares. Passing nil removes it.
+func SetGlobalResponseProcessor(processor ResponseProcessor) {
+	responseProcessorMutex.Lock()
+	defer responseProcessorMutex.Unlock()
+	responseProcessor = processor
+}
+
+// processResponse applies the global response processor, if any.
+func processResponse[Response T](ctx context.Context, response Response, err error) (Response, error) {
+	responseProcessorMutex.RLock()
+	processor := responseProcessor
+	responseProcessorMutex.RUnlock()
+
+	if processor == nil {
+		return response, err
+	}
+
+	out, err := processor(ctx, response, err)
+	processed, convErr := As[Response](out)
+	if convErr != nil {
+		// The processor replaced the response with a value of another type.
+		return processed, fmt.Errorf("incorrect response type: %w", convErr)
+	}
+	return processed, err
+}
@@ -0,0 +1,72 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stampedResponse struct {
+	Value     string
+	StampedAt time.Time
+}
+
+type stampCommand struct{}
+
+type stampCommandHandler struct{}
+
+func (h *stampCommandHandler) Handle(ctx context.Context, command stampCommand) (*stampedResponse, error) {
+	return &stampedResponse{Value: "command"}, nil
+}
+
+type stampQuery struct{}
+
+type stampQueryHandler struct{}
+
+func (h *stampQueryHandler) Handle(ctx context.Context, query stampQuery) (*stampedResponse, error) {
+	return nil, errors.New("query failed")
+}
+
+// TestSetGlobalResponseProcessor tests that every dispatched response passes through the processor.
+func TestSetGlobalResponseProcessor(t *testing.T) {
+	stamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var processed []error
+	SetGlobalResponseProcessor(func(ctx context.Context, response any, err error) (any, error) {
+		processed = append(processed, err)
+		if stamped, ok := response.(*stampedResponse); ok && stamped != nil {
+			stamped.StampedAt = stamp
+		}
+		return response, err
+	})
+	defer SetGlobalResponseProcessor(nil)
+
+	AddCommandHandler[stampCommand, *stampedResponse](&stampCommandHandler{})
+	AddQueryHandler[stampQuery, *stampedResponse](&stampQueryHandler{})
+
+	response, err := SendCommand[*stampedResponse](context.Background(), stampCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, stamp, response.StampedAt)
+
+	_, err = SendQuery[*stampedResponse](context.Background(), stampQuery{})
+	assert.EqualError(t, err, "query failed")
+
+	assert.Len(t, processed, 2)
+	assert.Nil(t, processed[0])
+	assert.EqualError(t, processed[1], "query failed")
+}
+
+// TestSetGlobalResponseProcessor_IncorrectType tests that a processor returning a mistyped response fails the dispatch.
+func TestSetGlobalResponseProcessor_IncorrectType(t *testing.T) {
+	SetGlobalResponseProcessor(func(ctx context.Context, response any, err error) (any, error) {
+		return map[string]any{"data": response}, err
+	})
+	defer SetGlobalResponseProcessor(nil)
+
+	AddCommandHandler[stampCommand, *stampedResponse](&stampCommandHandler{})
+
+	_, err := SendCommand[*stampedResponse](context.Background(), stampCommand{})
+	assert.EqualError(t, err, "incorrect response type: cannot convert map[string]interface {} to *gocqrs.stampedResponse")
+}
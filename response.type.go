@@ -0,0 +1,22 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+)
+
+// responseTypeKey is the context key of the response type a dispatch was sent with.
+type responseTypeKey struct{}
+
+// ResponseTypeFromContext returns the response type the current command or query was sent with,
+// i.e. the type parameter of SendCommand or SendQuery. Middlewares and behaviors can use it to
+// build type-appropriate default or empty responses.
+func ResponseTypeFromContext(ctx context.Context) (reflect.Type, bool) {
+	responseType, ok := ctx.Value(responseTypeKey{}).(reflect.Type)
+	return responseType, ok
+}
+
+// withResponseType returns a context carrying the response type of a dispatch.
+func withResponseType[Response T](ctx context.Context) context.Context {
+	return context.WithValue(ctx, responseTypeKey{}, reflect.TypeOf(new(Response)).Elem())
+}
@@ -0,0 +1,46 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type defaultedQuery struct{}
+
+type defaultedResponse struct {
+	Items []string
+}
+
+type defaultedQueryHandler struct{}
+
+func (h *defaultedQueryHandler) Handle(ctx context.Context, query defaultedQuery) (defaultedResponse, error) {
+	return defaultedResponse{Items: []string{"live"}}, nil
+}
+
+// TestResponseTypeFromContext tests that a behavior can build a zero value of the response type from the context.
+func TestResponseTypeFromContext(t *testing.T) {
+	var middlewareType reflect.Type
+	AddQueryHandler[defaultedQuery, defaultedResponse](&defaultedQueryHandler{}).
+		PreMiddleware(func(ctx context.Context, request any) (context.Context, any, bool) {
+			middlewareType, _ = ResponseTypeFromContext(ctx)
+			return ctx, request, true
+		}).
+		Behavior(func(ctx context.Context, request any, next NextFunc) (any, error) {
+			responseType, ok := ResponseTypeFromContext(ctx)
+			if !ok {
+				return next(ctx, request)
+			}
+			return reflect.New(responseType).Elem().Interface(), nil
+		})
+
+	response, err := SendQuery[defaultedResponse](context.Background(), defaultedQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultedResponse{}, response)
+	assert.Equal(t, reflect.TypeOf(defaultedResponse{}), middlewareType)
+
+	_, ok := ResponseTypeFromContext(context.Background())
+	assert.False(t, ok)
+}
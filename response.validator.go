@@ -0,0 +1,26 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+)
+
+// ResponseValidator returns a pipeline behavior validating the responses of the handler it is added to,
+// the output-side counterpart of validating requests. A response rejected by validate is discarded
+// and the dispatch fails with the validation error, wrapped with ErrInvalidResponse.
+// Responses of failed dispatches are not validated.
+//
+// Like other behaviors, it sees the response as returned by the behaviors registered after it,
+// so register it last to validate exactly what the handler returned.
+func ResponseValidator(validate func(ctx context.Context, response any) error) PipelineBehavior {
+	return func(ctx context.Context, request any, next NextFunc) (any, error) {
+		response, err := next(ctx, request)
+		if err != nil {
+			return response, err
+		}
+		if err := validate(ctx, response); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidResponse, err)
+		}
+		return response, nil
+	}
+}
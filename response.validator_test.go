@@ -0,0 +1,46 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type priceQuoteQuery struct {
+	Amount int
+}
+
+type priceQuote struct {
+	Currency string
+	Amount   int
+}
+
+type priceQuoteQueryHandler struct{}
+
+func (h *priceQuoteQueryHandler) Handle(ctx context.Context, query priceQuoteQuery) (priceQuote, error) {
+	if query.Amount < 0 {
+		return priceQuote{Amount: query.Amount}, nil // Missing its currency.
+	}
+	return priceQuote{Currency: "EUR", Amount: query.Amount}, nil
+}
+
+// TestResponseValidator tests that a response rejected by the validator turns the dispatch into an error.
+func TestResponseValidator(t *testing.T) {
+	AddQueryHandler[priceQuoteQuery, priceQuote](&priceQuoteQueryHandler{}).Behavior(ResponseValidator(func(ctx context.Context, response any) error {
+		if response.(priceQuote).Currency == "" {
+			return errors.New("missing currency")
+		}
+		return nil
+	}))
+
+	response, err := SendQuery[priceQuote](context.Background(), priceQuoteQuery{Amount: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, priceQuote{Currency: "EUR", Amount: 10}, response)
+
+	response, err = SendQuery[priceQuote](context.Background(), priceQuoteQuery{Amount: -1})
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+	assert.EqualError(t, err, "invalid response: missing currency")
+	assert.Zero(t, response)
+}
@@ -0,0 +1,209 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ScopeMode defines what a dispatch does with the goroutines its handler spawned with Go.
+type ScopeMode int
+
+const (
+	// ScopeWait makes the dispatch wait for its goroutines and join their errors into the dispatch error. This is the default.
+	ScopeWait ScopeMode = iota
+	// ScopeDetach lets the dispatch return while its goroutines keep running. Their errors are reported with the
+	// ErrorReporter and Shutdown waits for them. Inside an outer dispatch scope they are awaited by that scope instead.
+	ScopeDetach
+)
+
+type (
+	// dispatchScope tracks the goroutines spawned with Go during a dispatch.
+	dispatchScope struct {
+		ctx     context.Context
+		cancel  context.CancelFunc
+		mode    ScopeMode
+		parent  *dispatchScope    // Scope of the outer dispatch, if any.
+		tracker *goroutineTracker // Goroutines awaited by a ScopeWait scope.
+		mutex   sync.Mutex
+		errs    []error
+		closed  bool
+	}
+
+	// goroutineTracker counts running goroutines and lets callers wait until none is left.
+	goroutineTracker struct {
+		mutex sync.Mutex
+		count int
+		idle  chan struct{}
+	}
+
+	dispatchScopeKey struct{}
+)
+
+var (
+	scopeModeMutex sync.RWMutex
+	scopeModes     = make(map[string]ScopeMode)
+
+	// detachedGoroutines tracks goroutines that outlive their dispatch, awaited by Shutdown.
+	detachedGoroutines = &goroutineTracker{}
+)
+
+// WithScopeMode configures whether dispatches of the current handler wait for the goroutines spawned with Go.
+func (middlewareBuilder *AddMiddlewareBuilder) WithScopeMode(mode ScopeMode) *AddMiddlewareBuilder {
+	handlerName := middlewareBuilder.currentHandler()
+
+	scopeModeMutex.Lock()
+	defer scopeModeMutex.Unlock()
+	scopeModes[handlerName] = mode
+	return middlewareBuilder
+}
+
+// Go runs fn in a goroutine tied to the scope of the dispatch ctx belongs to.
+// Depending on the handler's ScopeMode the dispatch waits for fn and joins its error into the dispatch error,
+// or returns while fn keeps running. fn's context is canceled when the handler returns an error.
+// Panics in fn are recovered and treated as errors.
+// Outside a dispatch fn runs detached: its error is reported with the ErrorReporter and Shutdown waits for it.
+func Go(ctx context.Context, fn func(ctx context.Context) error) {
+	if scope, ok := ctx.Value(dispatchScopeKey{}).(*dispatchScope); ok {
+		if tracker, ok := scope.add(); ok {
+			go func() {
+				defer tracker.done()
+				if err := runScoped(scope.ctx, fn); err != nil {
+					scope.fail(err)
+				}
+			}()
+			return
+		}
+	}
+
+	detachedGoroutines.add()
+	go func() {
+		defer detachedGoroutines.done()
+		if err := runScoped(ctx, fn); err != nil {
+			reportError(ctx, err)
+		}
+	}()
+}
+
+// runScoped calls fn, converting a panic into an error.
+func runScoped(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("scoped goroutine panicked: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// newDispatchScope creates the scope of a dispatch of the given handler and returns the context carrying it.
+func newDispatchScope(ctx context.Context, handlerName string) *dispatchScope {
+	scopeModeMutex.RLock()
+	mode := scopeModes[handlerName]
+	scopeModeMutex.RUnlock()
+
+	scope := &dispatchScope{mode: mode, tracker: &goroutineTracker{}}
+	scope.parent, _ = ctx.Value(dispatchScopeKey{}).(*dispatchScope)
+	if mode == ScopeDetach {
+		// Detached goroutines outlive the dispatch, so they must not be canceled when it completes.
+		ctx = context.WithoutCancel(ctx)
+	}
+	scope.ctx, scope.cancel = context.WithCancel(context.WithValue(ctx, dispatchScopeKey{}, scope))
+	return scope
+}
+
+// close ends the scope once the handler returned err. It cancels the goroutines if err is not nil.
+// In ScopeWait mode it waits for them and returns err joined with their errors.
+func (scope *dispatchScope) close(err error) error {
+	if err != nil {
+		scope.cancel()
+	}
+
+	if scope.mode == ScopeDetach {
+		return err
+	}
+
+	_ = scope.tracker.wait(context.Background())
+	scope.cancel()
+
+	scope.mutex.Lock()
+	defer scope.mutex.Unlock()
+	scope.closed = true
+	if len(scope.errs) == 0 {
+		return err
+	}
+	return errors.Join(append([]error{err}, scope.errs...)...)
+}
+
+// add registers a goroutine in the scope and returns the tracker it must be unregistered from.
+// A ScopeDetach scope hands its goroutines to the outer scope, or to Shutdown when there is none.
+// It returns false once a ScopeWait scope has been closed.
+func (scope *dispatchScope) add() (*goroutineTracker, bool) {
+	scope.mutex.Lock()
+	defer scope.mutex.Unlock()
+
+	if scope.mode == ScopeWait {
+		if scope.closed {
+			return nil, false
+		}
+		scope.tracker.add()
+		return scope.tracker, true
+	}
+
+	if scope.parent != nil {
+		if tracker, ok := scope.parent.add(); ok {
+			return tracker, true
+		}
+	}
+	detachedGoroutines.add()
+	return detachedGoroutines, true
+}
+
+// fail records the error of a goroutine of the scope.
+func (scope *dispatchScope) fail(err error) {
+	scope.mutex.Lock()
+	defer scope.mutex.Unlock()
+	if scope.mode == ScopeDetach {
+		reportError(scope.ctx, err)
+		return
+	}
+	scope.errs = append(scope.errs, err)
+}
+
+// add registers a running goroutine.
+func (tracker *goroutineTracker) add() {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	if tracker.count == 0 {
+		tracker.idle = make(chan struct{})
+	}
+	tracker.count++
+}
+
+// done unregisters a goroutine.
+func (tracker *goroutineTracker) done() {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	tracker.count--
+	if tracker.count == 0 {
+		close(tracker.idle)
+	}
+}
+
+// wait blocks until no goroutine is running or ctx is done.
+func (tracker *goroutineTracker) wait(ctx context.Context) error {
+	tracker.mutex.Lock()
+	if tracker.count == 0 {
+		tracker.mutex.Unlock()
+		return nil
+	}
+	idle := tracker.idle
+	tracker.mutex.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
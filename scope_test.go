@@ -0,0 +1,151 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type scopeCommand struct {
+	Fail bool
+}
+
+// scopeCommandHandler spawns the goroutines returned by spawn and fails if the command asks to.
+type scopeCommandHandler struct {
+	spawn func(ctx context.Context)
+}
+
+func (h *scopeCommandHandler) Handle(ctx context.Context, command scopeCommand) (string, error) {
+	h.spawn(ctx)
+	if command.Fail {
+		return "", errors.New("handler failed")
+	}
+	return "done", nil
+}
+
+type scopeOuterCommand struct{}
+
+type scopeOuterCommandHandler struct{}
+
+func (h *scopeOuterCommandHandler) Handle(ctx context.Context, command scopeOuterCommand) (string, error) {
+	return SendCommand[string](ctx, scopeInnerCommand{})
+}
+
+type scopeInnerCommand struct{}
+
+type scopeInnerCommandHandler struct {
+	finished *atomic.Bool
+}
+
+func (h *scopeInnerCommandHandler) Handle(ctx context.Context, command scopeInnerCommand) (string, error) {
+	Go(ctx, func(ctx context.Context) error {
+		time.Sleep(10 * time.Millisecond)
+		h.finished.Store(true)
+		return nil
+	})
+	return "inner", nil
+}
+
+// TestGo_Wait tests that a dispatch waits for its goroutines and joins their errors and panics.
+func TestGo_Wait(t *testing.T) {
+	var finished atomic.Int32
+	handler := &scopeCommandHandler{}
+	AddCommandHandler[scopeCommand, string](handler)
+
+	handler.spawn = func(ctx context.Context) {
+		for i := 0; i < 3; i++ {
+			Go(ctx, func(ctx context.Context) error {
+				time.Sleep(5 * time.Millisecond)
+				finished.Add(1)
+				return nil
+			})
+		}
+	}
+	response, err := SendCommand[string](context.Background(), scopeCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, "done", response)
+	assert.Equal(t, int32(3), finished.Load(), "the dispatch should wait for its goroutines")
+
+	goroutineErr := errors.New("goroutine failed")
+	handler.spawn = func(ctx context.Context) {
+		Go(ctx, func(ctx context.Context) error {
+			return goroutineErr
+		})
+		Go(ctx, func(ctx context.Context) error {
+			panic("boom")
+		})
+	}
+	_, err = SendCommand[string](context.Background(), scopeCommand{})
+	assert.ErrorIs(t, err, goroutineErr)
+	assert.ErrorContains(t, err, "boom")
+}
+
+// TestGo_CancelOnHandlerError tests that the goroutines are canceled when the handler returns an error.
+func TestGo_CancelOnHandlerError(t *testing.T) {
+	handler := &scopeCommandHandler{}
+	AddCommandHandler[scopeCommand, string](handler)
+
+	handler.spawn = func(ctx context.Context) {
+		Go(ctx, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}
+	_, err := SendCommand[string](context.Background(), scopeCommand{Fail: true})
+	assert.ErrorContains(t, err, "handler failed")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// TestGo_Detach tests that a detached dispatch returns immediately and Shutdown waits for its goroutines.
+func TestGo_Detach(t *testing.T) {
+	var reported atomic.Int32
+	SetErrorReporter(func(ctx context.Context, err error) {
+		reported.Add(1)
+	})
+	defer SetErrorReporter(nil)
+
+	release := make(chan struct{})
+	var finished atomic.Bool
+	handler := &scopeCommandHandler{spawn: func(ctx context.Context) {
+		Go(ctx, func(ctx context.Context) error {
+			<-release
+			finished.Store(true)
+			return errors.New("detached failure")
+		})
+	}}
+	AddCommandHandler[scopeCommand, string](handler).WithScopeMode(ScopeDetach)
+	defer func() {
+		scopeModeMutex.Lock()
+		delete(scopeModes, "*gocqrs.scopeCommandHandler")
+		scopeModeMutex.Unlock()
+	}()
+
+	_, err := SendCommand[string](context.Background(), scopeCommand{})
+	assert.NoError(t, err)
+	assert.False(t, finished.Load())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, Shutdown(ctx), context.DeadlineExceeded, "Shutdown should wait for detached goroutines")
+
+	close(release)
+	assert.NoError(t, Shutdown(context.Background()))
+	assert.True(t, finished.Load())
+	assert.Equal(t, int32(1), reported.Load())
+}
+
+// TestGo_NestedScopes tests that an outer dispatch waits for the goroutines of a detached inner dispatch.
+func TestGo_NestedScopes(t *testing.T) {
+	var finished atomic.Bool
+	AddCommandHandler[scopeOuterCommand, string](&scopeOuterCommandHandler{})
+	AddCommandHandler[scopeInnerCommand, string](&scopeInnerCommandHandler{finished: &finished}).WithScopeMode(ScopeDetach)
+
+	response, err := SendCommand[string](context.Background(), scopeOuterCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, "inner", response)
+	assert.True(t, finished.Load(), "the outer dispatch should wait for goroutines detached by the inner dispatch")
+}
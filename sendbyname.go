@@ -0,0 +1,57 @@
+package gocqrs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	requestTypeMutex sync.RWMutex
+	requestTypes     = make(map[string]reflect.Type)
+)
+
+// storeRequestType records the Go type registered for a request type name.
+func storeRequestType(typeName string, requestType reflect.Type) {
+	requestTypeMutex.Lock()
+	defer requestTypeMutex.Unlock()
+	requestTypes[typeName] = requestType
+}
+
+// getRequestType returns the Go type registered for a request type name.
+func getRequestType(typeName string) (reflect.Type, bool) {
+	requestTypeMutex.RLock()
+	defer requestTypeMutex.RUnlock()
+	requestType, ok := requestTypes[typeName]
+	return requestType, ok
+}
+
+// SendByName dispatches a command or query identified by its type name, as reported by
+// reflect (e.g. "orders.CreateOrder"), instead of a Go type.
+// The JSON body is unmarshalled into a new value of the registered request type, the request is
+// sent through the regular pipeline and the response is returned marshalled as JSON.
+// It returns ErrUnknownRequestType if no handler is registered for typeName.
+// Type names are rewritten by the request key rewriter first, so old serialized requests decode into the current type.
+func SendByName(ctx context.Context, typeName string, jsonBody []byte) ([]byte, error) {
+	typeName = rewriteRequestKey(normalizeTypeKey(typeName))
+	requestType, ok := getRequestType(typeName)
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownRequestType, typeName)
+	}
+
+	// Unmarshal the body into a new instance of the registered request type.
+	request := reflect.New(requestType)
+	if len(jsonBody) > 0 {
+		if err := json.Unmarshal(jsonBody, request.Interface()); err != nil {
+			return nil, fmt.Errorf("decoding %v: %w", typeName, err)
+		}
+	}
+
+	response, err := send[any](ctx, request.Elem().Interface())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(response)
+}
@@ -0,0 +1,46 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type createAccountCommand struct {
+	Owner   string `json:"owner"`
+	Balance int    `json:"balance"`
+}
+
+type createAccountResponse struct {
+	ID      string `json:"id"`
+	Balance int    `json:"balance"`
+}
+
+type createAccountCommandHandler struct{}
+
+func (h *createAccountCommandHandler) Handle(ctx context.Context, command createAccountCommand) (createAccountResponse, error) {
+	if command.Balance < 0 {
+		return createAccountResponse{}, errors.New("negative balance")
+	}
+	return createAccountResponse{ID: "acc-" + command.Owner, Balance: command.Balance}, nil
+}
+
+// TestSendByName tests dispatching a command purely by its type name and JSON body.
+func TestSendByName(t *testing.T) {
+	AddCommandHandler[createAccountCommand, createAccountResponse](&createAccountCommandHandler{})
+
+	response, err := SendByName(context.Background(), "gocqrs.createAccountCommand", []byte(`{"owner":"ada","balance":10}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":"acc-ada","balance":10}`, string(response))
+
+	_, err = SendByName(context.Background(), "gocqrs.createAccountCommand", []byte(`{"owner":"ada","balance":-1}`))
+	assert.EqualError(t, err, "negative balance")
+
+	_, err = SendByName(context.Background(), "gocqrs.createAccountCommand", []byte(`{"owner":`))
+	assert.Error(t, err, "Malformed body should be rejected")
+
+	_, err = SendByName(context.Background(), "gocqrs.unknownCommand", nil)
+	assert.True(t, errors.Is(err, ErrUnknownRequestType))
+}
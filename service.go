@@ -0,0 +1,66 @@
+package gocqrs
+
+import (
+	"context"
+	"reflect"
+)
+
+// serviceMethodHandler handles a request type with a method of a service registered with RegisterService.
+type serviceMethodHandler struct {
+	method  reflect.Value
+	handler any // Handler built by AutoRegister the method belongs to, nil for the methods of a service.
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// RegisterService registers every exported method of svc shaped like a handler,
+// func(ctx context.Context, request In) (Out, error), as the command or query handler of In.
+// Methods of another shape are skipped. Each method is registered under the handler name
+// "<service type>.<method>", e.g. "*orders.OrderService.CreateOrder", to attach middlewares by name.
+// It returns the type names of the registered request types, in method name order.
+func RegisterService(svc any) []string {
+	service := reflect.ValueOf(svc)
+	serviceName := service.Type().String()
+
+	registered := make([]string, 0)
+	for i := 0; i < service.NumMethod(); i++ {
+		method := service.Method(i)
+		if !isHandlerShaped(method.Type()) {
+			continue
+		}
+		requestType := method.Type().In(1)
+		handlerName := serviceName + "." + service.Type().Method(i).Name
+		wrapper := newHandlerWrapper[any, any](&serviceMethodHandler{method: method}, handlerName)
+		registerHandler(requestType, wrapper, handlerName, nil)
+		registered = append(registered, requestType.String())
+	}
+	return registered
+}
+
+// Handle calls the service method with the request.
+func (h *serviceMethodHandler) Handle(ctx context.Context, in any) (any, error) {
+	results := h.method.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(in)})
+	err, _ := results[1].Interface().(error)
+	return results[0].Interface(), err
+}
+
+// handlerInstance returns the value implementing the optional interfaces, such as IRequiredContextKeys or
+// IStartable, of a registered handler: the handler built by AutoRegister rather than its method handler.
+func handlerInstance(handler any) any {
+	if method, ok := handler.(*serviceMethodHandler); ok && method.handler != nil {
+		return method.handler
+	}
+	return handler
+}
+
+// isHandlerShaped reports whether a method type is func(context.Context, In) (Out, error).
+func isHandlerShaped(methodType reflect.Type) bool {
+	return methodType.NumIn() == 2 &&
+		methodType.In(0) == contextType &&
+		methodType.In(1) != contextType &&
+		methodType.NumOut() == 2 &&
+		methodType.Out(1) == errorType
+}
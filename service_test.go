@@ -0,0 +1,63 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	serviceCreateOrder struct{ Item string }
+	serviceCancelOrder struct{ ID int }
+	serviceOrderID     int
+)
+
+type serviceOrderService struct {
+	nextID int
+}
+
+func (s *serviceOrderService) CreateOrder(ctx context.Context, command serviceCreateOrder) (serviceOrderID, error) {
+	s.nextID++
+	return serviceOrderID(s.nextID), nil
+}
+
+func (s *serviceOrderService) CancelOrder(ctx context.Context, command serviceCancelOrder) (bool, error) {
+	if command.ID == 0 {
+		return false, errors.New("unknown order")
+	}
+	return true, nil
+}
+
+// Describe does not take a context and is skipped.
+func (s *serviceOrderService) Describe(command serviceCreateOrder) (string, error) {
+	return command.Item, nil
+}
+
+// Count does not return an error and is skipped.
+func (s *serviceOrderService) Count(ctx context.Context, command serviceCancelOrder) int {
+	return s.nextID
+}
+
+// TestRegisterService tests that the handler shaped methods of a service are registered by request type.
+func TestRegisterService(t *testing.T) {
+	registered := RegisterService(&serviceOrderService{})
+	assert.Equal(t, []string{"gocqrs.serviceCancelOrder", "gocqrs.serviceCreateOrder"}, registered)
+
+	// Methods are named after the service, the last registered one is the builder's current handler.
+	assert.Equal(t, "*gocqrs.serviceOrderService.CreateOrder", middlewareBuilder.currentHandler())
+
+	id, err := SendCommand[serviceOrderID](context.Background(), serviceCreateOrder{Item: "book"})
+	assert.NoError(t, err)
+	assert.Equal(t, serviceOrderID(1), id)
+	id, err = SendCommand[serviceOrderID](context.Background(), serviceCreateOrder{Item: "pen"})
+	assert.NoError(t, err)
+	assert.Equal(t, serviceOrderID(2), id)
+
+	canceled, err := SendCommand[bool](context.Background(), serviceCancelOrder{ID: 1})
+	assert.NoError(t, err)
+	assert.True(t, canceled)
+	_, err = SendCommand[bool](context.Background(), serviceCancelOrder{})
+	assert.EqualError(t, err, "unknown order")
+}
@@ -0,0 +1,27 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+)
+
+// Shutdown releases the resources held by registered handlers.
+// It first waits for the goroutines spawned with Go that outlived their dispatch, then drains event handler mailboxes.
+// During the handler-close phase the handlers started by StartAll are stopped in reverse dependency order and
+// every dedicated executor is stopped; Shutdown waits for their goroutines to exit until ctx is done,
+// in which case ctx's error is returned.
+func Shutdown(ctx context.Context) error {
+	// Detached goroutines may still dispatch, so they are awaited before closing handlers.
+	if err := detachedGoroutines.wait(ctx); err != nil {
+		return err
+	}
+
+	// Pending mailbox deliveries are handled before the handlers are closed.
+	if err := closeMailboxes(ctx); err != nil {
+		return err
+	}
+
+	// Handler-close phase: handlers started by StartAll are stopped in reverse dependency order.
+	stopErr := stopHandlers(ctx)
+	return errors.Join(stopErr, closeExecutors(ctx))
+}
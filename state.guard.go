@@ -0,0 +1,58 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type (
+	// StateLoader loads the current state of the aggregate targeted by a command, e.g. the status of an order.
+	StateLoader func(ctx context.Context, command any) (string, error)
+
+	// InvalidStateError is returned when WithStateGuard rejects a command because of the state of its aggregate.
+	// It wraps ErrInvalidState.
+	InvalidStateError struct {
+		State   string   // The state loaded for the command.
+		Allowed []string // The states the command is valid in.
+	}
+
+	// stateKey is the context key of the state loaded by a state guard.
+	stateKey struct{}
+)
+
+// Error describes the current and allowed states.
+func (e *InvalidStateError) Error() string {
+	return fmt.Sprintf("%v: %q is not one of %v", ErrInvalidState, e.State, strings.Join(e.Allowed, ", "))
+}
+
+// Unwrap returns ErrInvalidState.
+func (e *InvalidStateError) Unwrap() error {
+	return ErrInvalidState
+}
+
+// WithStateGuard only lets the current handler handle commands whose aggregate is in one of the allowed states,
+// as loaded by loader. Other commands are rejected with an *InvalidStateError, and loader errors are returned
+// wrapped with ErrStateLoad. The loaded state is passed to the handler, see StateFromContext.
+func (middlewareBuilder *AddMiddlewareBuilder) WithStateGuard(loader StateLoader, allowed ...string) *AddMiddlewareBuilder {
+	allowed = slices.Clone(allowed)
+	guard := func(ctx context.Context, request any, next NextFunc) (any, error) {
+		state, err := loader(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrStateLoad, err)
+		}
+		if !slices.Contains(allowed, state) {
+			return nil, &InvalidStateError{State: state, Allowed: slices.Clone(allowed)}
+		}
+		return next(context.WithValue(ctx, stateKey{}, state), request)
+	}
+	return middlewareBuilder.stagedBehavior(guard, PipelineStep{Stage: StageBehaviors, Name: "state guard"})
+}
+
+// StateFromContext returns the state loaded by the state guard of the current dispatch,
+// so the handler does not need to load it again. It returns false without a state guard.
+func StateFromContext(ctx context.Context) (string, bool) {
+	state, ok := ctx.Value(stateKey{}).(string)
+	return state, ok
+}
@@ -0,0 +1,61 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type shipPaidOrderCommand struct {
+	OrderID string
+}
+
+type shipPaidOrderCommandHandler struct{}
+
+func (h *shipPaidOrderCommandHandler) Handle(ctx context.Context, command shipPaidOrderCommand) (string, error) {
+	state, _ := StateFromContext(ctx)
+	return "shipped from " + state, nil
+}
+
+// TestWithStateGuard tests that commands are only handled in the allowed states, with the loaded state in ctx.
+func TestWithStateGuard(t *testing.T) {
+	states := map[string]string{"1": "paid", "2": "packed", "3": "unpaid"}
+	loads := 0
+	loader := func(ctx context.Context, command any) (string, error) {
+		loads++
+		state, ok := states[command.(shipPaidOrderCommand).OrderID]
+		if !ok {
+			return "", errors.New("order not found")
+		}
+		return state, nil
+	}
+	AddCommandHandler[shipPaidOrderCommand, string](&shipPaidOrderCommandHandler{}).WithStateGuard(loader, "paid", "packed")
+
+	response, err := SendCommand[string](context.Background(), shipPaidOrderCommand{OrderID: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "shipped from paid", response)
+
+	response, err = SendCommand[string](context.Background(), shipPaidOrderCommand{OrderID: "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "shipped from packed", response)
+	assert.Equal(t, 2, loads)
+
+	_, err = SendCommand[string](context.Background(), shipPaidOrderCommand{OrderID: "3"})
+	assert.ErrorIs(t, err, ErrInvalidState)
+	var invalid *InvalidStateError
+	if assert.ErrorAs(t, err, &invalid) {
+		assert.Equal(t, "unpaid", invalid.State)
+		assert.Equal(t, []string{"paid", "packed"}, invalid.Allowed)
+	}
+	assert.EqualError(t, err, `invalid state: "unpaid" is not one of paid, packed`)
+
+	_, err = SendCommand[string](context.Background(), shipPaidOrderCommand{OrderID: "4"})
+	assert.ErrorIs(t, err, ErrStateLoad)
+	assert.NotErrorIs(t, err, ErrInvalidState)
+	assert.EqualError(t, err, "loading state: order not found")
+
+	steps, _ := PipelinePlan(shipPaidOrderCommand{})
+	assert.Contains(t, steps, PipelineStep{Stage: StageBehaviors, Name: "state guard"})
+}
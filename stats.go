@@ -0,0 +1,165 @@
+package gocqrs
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// TypeStats holds the dispatch statistics of a request type.
+	TypeStats struct {
+		Count      uint64            // Number of dispatches.
+		Errors     uint64            // Number of dispatches that returned an error.
+		AvgLatency time.Duration     // Average duration of a dispatch, middlewares included.
+		Outcomes   map[string]uint64 // Number of dispatches per outcome label, as returned by DispatchOutcome.
+	}
+
+	// typeStatsCounters accumulates the statistics of a request type.
+	typeStatsCounters struct {
+		count        atomic.Uint64
+		errors       atomic.Uint64
+		totalLatency atomic.Int64
+		outcomes     sync.Map // Outcome label to its *atomic.Uint64 count.
+	}
+
+	// HandlerUsage holds when a handler was registered and last dispatched.
+	HandlerUsage struct {
+		RegisteredAt   time.Time // First registration of the handler name.
+		LastDispatched time.Time // Last dispatch of a request or delivery of an event, zero if never.
+	}
+
+	// handlerUsageTimes records the usage of a handler name; lastDispatched is in Unix nanoseconds.
+	handlerUsageTimes struct {
+		registeredAt   time.Time
+		lastDispatched atomic.Int64
+	}
+)
+
+var (
+	statsMutex sync.RWMutex
+	stats      = make(map[string]*typeStatsCounters)
+
+	usageMutex sync.RWMutex
+	usage      = make(map[string]*handlerUsageTimes) // Handler name to its usage, kept by ResetStats.
+)
+
+// Stats returns a snapshot of the dispatch statistics of every dispatched request type, keyed by type name.
+func Stats() map[string]TypeStats {
+	statsMutex.RLock()
+	defer statsMutex.RUnlock()
+
+	snapshot := make(map[string]TypeStats, len(stats))
+	for typeName, counters := range stats {
+		snapshot[typeName] = counters.snapshot()
+	}
+	return snapshot
+}
+
+// ResetStats clears the dispatch statistics of every request type.
+func ResetStats() {
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+	stats = make(map[string]*typeStatsCounters)
+}
+
+// UnusedRegistrations returns the names of the registered handlers, event handlers and group members included,
+// that were not dispatched to within the last since, sorted, e.g. to find dead handlers worth removing.
+// Handlers registered within the window are reported as well if they were not dispatched to yet;
+// compare with HandlerUsage.RegisteredAt, from RegistryView.Usage, to tell them apart.
+func UnusedRegistrations(since time.Duration) []string {
+	cutoff := now().Add(-since)
+
+	var unused []string
+	for _, name := range RegisteredHandlerNames() {
+		handlerUsage, ok := usageOf(name)
+		if !ok || handlerUsage.LastDispatched.Before(cutoff) {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// recordRegistration remembers when a handler name was first registered.
+func recordRegistration(handlerName string) {
+	usageMutex.Lock()
+	defer usageMutex.Unlock()
+	if _, ok := usage[handlerName]; !ok {
+		usage[handlerName] = &handlerUsageTimes{registeredAt: now()}
+	}
+}
+
+// recordUsage remembers the dispatch to a registered handler name.
+func recordUsage(handlerName string) {
+	usageMutex.RLock()
+	times, ok := usage[handlerName]
+	usageMutex.RUnlock()
+	if ok {
+		times.lastDispatched.Store(now().UnixNano())
+	}
+}
+
+// usageOf returns the usage of a handler name. It returns false if the name was never registered.
+func usageOf(handlerName string) (HandlerUsage, bool) {
+	usageMutex.RLock()
+	times, ok := usage[handlerName]
+	usageMutex.RUnlock()
+	if !ok {
+		return HandlerUsage{}, false
+	}
+
+	handlerUsage := HandlerUsage{RegisteredAt: times.registeredAt}
+	if lastDispatched := times.lastDispatched.Load(); lastDispatched != 0 {
+		handlerUsage.LastDispatched = time.Unix(0, lastDispatched)
+	}
+	return handlerUsage, true
+}
+
+// recordDispatch adds a dispatch of a request type to the statistics.
+func recordDispatch(typeName string, latency time.Duration, err error) {
+	counters := loadOrStoreStatsCounters(typeName)
+	counters.count.Add(1)
+	counters.totalLatency.Add(int64(latency))
+	if err != nil {
+		counters.errors.Add(1)
+	}
+	outcome, _ := counters.outcomes.LoadOrStore(DispatchOutcome(err), &atomic.Uint64{})
+	outcome.(*atomic.Uint64).Add(1)
+}
+
+// loadOrStoreStatsCounters returns the counters of a request type, creating them if needed.
+func loadOrStoreStatsCounters(typeName string) *typeStatsCounters {
+	statsMutex.RLock()
+	counters, ok := stats[typeName]
+	statsMutex.RUnlock()
+	if ok {
+		return counters
+	}
+
+	statsMutex.Lock()
+	defer statsMutex.Unlock()
+	if counters, ok = stats[typeName]; !ok {
+		counters = &typeStatsCounters{}
+		stats[typeName] = counters
+	}
+	return counters
+}
+
+// snapshot returns the current values of the counters.
+func (counters *typeStatsCounters) snapshot() TypeStats {
+	typeStats := TypeStats{
+		Count:    counters.count.Load(),
+		Errors:   counters.errors.Load(),
+		Outcomes: make(map[string]uint64),
+	}
+	counters.outcomes.Range(func(outcome, count any) bool {
+		typeStats.Outcomes[outcome.(string)] = count.(*atomic.Uint64).Load()
+		return true
+	})
+	if typeStats.Count > 0 {
+		typeStats.AvgLatency = time.Duration(counters.totalLatency.Load() / int64(typeStats.Count))
+	}
+	return typeStats
+}
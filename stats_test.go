@@ -0,0 +1,127 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type statsCommand struct {
+	Fail bool
+}
+
+type statsCommandHandler struct{}
+
+func (h *statsCommandHandler) Handle(ctx context.Context, command statsCommand) (string, error) {
+	if command.Fail {
+		return "", errors.New("failed")
+	}
+	return "done", nil
+}
+
+// TestStats tests that dispatches and errors are counted per request type.
+func TestStats(t *testing.T) {
+	AddCommandHandler[statsCommand, string](&statsCommandHandler{})
+
+	for i := 0; i < 5; i++ {
+		_, _ = SendCommand[string](context.Background(), statsCommand{Fail: i%2 == 0})
+	}
+
+	typeStats, ok := Stats()["gocqrs.statsCommand"]
+	assert.True(t, ok)
+	assert.Equal(t, uint64(5), typeStats.Count)
+	assert.Equal(t, uint64(3), typeStats.Errors)
+	assert.Greater(t, typeStats.AvgLatency, time.Duration(0))
+
+	ResetStats()
+	_, ok = Stats()["gocqrs.statsCommand"]
+	assert.False(t, ok, "Stats should be empty after a reset")
+}
+
+type (
+	usageReportCommand  struct{}
+	usageAuditQuery     struct{}
+	usageLegacyCommand  struct{}
+	usageReportedEvent  struct{}
+	usageForgottenEvent struct{}
+)
+
+type usageReportCommandHandler struct{}
+
+func (h *usageReportCommandHandler) Handle(ctx context.Context, command usageReportCommand) (string, error) {
+	return "reported", nil
+}
+
+type usageAuditQueryHandler struct{}
+
+func (h *usageAuditQueryHandler) Handle(ctx context.Context, query usageAuditQuery) (string, error) {
+	return "audited", nil
+}
+
+type usageLegacyCommandHandler struct{}
+
+func (h *usageLegacyCommandHandler) Handle(ctx context.Context, command usageLegacyCommand) (string, error) {
+	return "legacy", nil
+}
+
+type usageReportedEventHandler struct{}
+
+func (h *usageReportedEventHandler) Handle(ctx context.Context, event usageReportedEvent) error {
+	return nil
+}
+
+type usageForgottenEventHandler struct{}
+
+func (h *usageForgottenEventHandler) Handle(ctx context.Context, event usageForgottenEvent) error {
+	return nil
+}
+
+// TestUnusedRegistrations tests that the handlers not dispatched to within the window are reported.
+func TestUnusedRegistrations(t *testing.T) {
+	clock := newFakeClock()
+	SetClock(clock)
+	defer SetClock(nil)
+
+	registeredAt := clock.Now()
+	AddCommandHandler[usageReportCommand, string](&usageReportCommandHandler{})
+	AddQueryHandler[usageAuditQuery, string](&usageAuditQueryHandler{})
+	AddCommandHandler[usageLegacyCommand, string](&usageLegacyCommandHandler{})
+	assert.NoError(t, AddEventHandlers[usageReportedEvent](&usageReportedEventHandler{}))
+	assert.NoError(t, AddEventHandlers[usageForgottenEvent](&usageForgottenEventHandler{}))
+
+	clock.Advance(time.Hour)
+	_, err := SendCommand[string](context.Background(), usageReportCommand{})
+	assert.NoError(t, err)
+	_, err = SendQuery[string](context.Background(), usageAuditQuery{})
+	assert.NoError(t, err)
+	assert.NoError(t, PublishEvent(context.Background(), usageReportedEvent{}))
+
+	clock.Advance(time.Minute)
+	unused := UnusedRegistrations(10 * time.Minute)
+	assert.Contains(t, unused, "*gocqrs.usageLegacyCommandHandler")
+	assert.Contains(t, unused, "*gocqrs.usageForgottenEventHandler")
+	assert.NotContains(t, unused, "*gocqrs.usageReportCommandHandler")
+	assert.NotContains(t, unused, "*gocqrs.usageAuditQueryHandler")
+	assert.NotContains(t, unused, "*gocqrs.usageReportedEventHandler")
+
+	view := newRegistryView()
+	legacy, ok := view.Handler("gocqrs.usageLegacyCommand")
+	assert.True(t, ok)
+	assert.True(t, legacy.Usage.RegisteredAt.Equal(registeredAt))
+	assert.True(t, legacy.Usage.LastDispatched.IsZero())
+
+	report, _ := view.Handler("gocqrs.usageReportCommand")
+	assert.True(t, report.Usage.LastDispatched.Equal(registeredAt.Add(time.Hour)))
+
+	forgotten, ok := view.Usage("*gocqrs.usageForgottenEventHandler")
+	assert.True(t, ok)
+	assert.True(t, forgotten.RegisteredAt.Equal(registeredAt))
+	assert.True(t, forgotten.LastDispatched.IsZero())
+
+	// Past the window, every handler is unused again.
+	clock.Advance(time.Hour)
+	assert.Contains(t, UnusedRegistrations(10*time.Minute), "*gocqrs.usageReportCommandHandler")
+}
@@ -0,0 +1,133 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// subscription tracks the deliveries in progress to an event handler, so removing the handler can wait for them
+// and prevent new ones. A nil subscription never ends.
+type subscription struct {
+	mutex   sync.Mutex
+	removed bool
+	active  int           // Deliveries in progress.
+	idle    chan struct{} // Closed once removed and no delivery is in progress.
+}
+
+// enter starts a delivery. It returns false if the subscription was removed.
+func (sub *subscription) enter() bool {
+	if sub == nil {
+		return true
+	}
+	sub.mutex.Lock()
+	defer sub.mutex.Unlock()
+	if sub.removed {
+		return false
+	}
+	sub.active++
+	return true
+}
+
+// leave ends a delivery started with enter.
+func (sub *subscription) leave() {
+	if sub == nil {
+		return
+	}
+	sub.mutex.Lock()
+	defer sub.mutex.Unlock()
+	sub.active--
+	if sub.removed && sub.active == 0 {
+		close(sub.idle)
+	}
+}
+
+// end prevents new deliveries and returns a channel closed once the deliveries in progress end.
+func (sub *subscription) end() <-chan struct{} {
+	sub.mutex.Lock()
+	defer sub.mutex.Unlock()
+	if !sub.removed {
+		sub.removed = true
+		sub.idle = make(chan struct{})
+		if sub.active == 0 {
+			close(sub.idle)
+		}
+	}
+	return sub.idle
+}
+
+// deliver hands event to the handler unless its subscription was removed or its filter rejects the event,
+// in which case it returns false.
+func (registered eventHandlersType) deliver(ctx context.Context, event T) (bool, error) {
+	if registered.filter != nil && !registered.filter(event) {
+		return false, nil
+	}
+	if !registered.sub.enter() {
+		return false, nil
+	}
+	defer registered.sub.leave()
+	_, err := registered.eventHandler.Handle(ctx, event)
+	return true, err
+}
+
+// RemoveEventHandler unregisters a handler registered for TEvent with AddEventHandler or AddEventHandlers.
+// The handler is identified by its value, whatever name it was registered under, so it must be comparable,
+// typically a pointer; a handler registered with AddEventHandlerFunc is removed with RemoveEventHandlerNamed.
+// Once it returns, no delivery to the handler starts anymore, even for a PublishEvent already in progress.
+// It waits, until ctx is done, for the deliveries in progress to finish. Events still queued in the mailbox of
+// the handler are not handled: each of them is reported with the ErrorReporter as ErrDeliveryDropped.
+// It returns false if the handler is not registered, and the error of ctx if the deliveries did not finish in time.
+func RemoveEventHandler[TEvent T](ctx context.Context, handler IEventHandler[TEvent]) (bool, error) {
+	return removeEventHandler[TEvent](ctx, func(registered eventHandlersType) bool {
+		return sameHandler(registered.handler, handler)
+	})
+}
+
+// RemoveEventHandlerNamed unregisters the handler registered for TEvent under name, the one set with
+// WithEventHandlerName or else its type name, like RemoveEventHandler.
+// It is the way to remove a handler registered with AddEventHandlerFunc, whose functions are not comparable.
+func RemoveEventHandlerNamed[TEvent T](ctx context.Context, name string) (bool, error) {
+	return removeEventHandler[TEvent](ctx, func(registered eventHandlersType) bool {
+		return registered.typeName == name
+	})
+}
+
+// removeEventHandler unregisters the first handler registered for TEvent matching match.
+func removeEventHandler[TEvent T](ctx context.Context, match func(registered eventHandlersType) bool) (bool, error) {
+	typedEvent := eventTypeKey(reflect.TypeOf(new(TEvent)).Elem())
+
+	eventHandlerMutex.Lock()
+	registeredHandlers := eventHandlers[typedEvent]
+	index := -1
+	for i, registered := range registeredHandlers {
+		if match(registered) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		eventHandlerMutex.Unlock()
+		return false, nil
+	}
+	removed := registeredHandlers[index]
+	remaining := append([]eventHandlersType(nil), registeredHandlers[:index]...)
+	eventHandlers[typedEvent] = append(remaining, registeredHandlers[index+1:]...)
+	eventHandlerMutex.Unlock()
+
+	// Ending the subscription first makes the mailbox drop its queued events rather than handle them.
+	idle := removed.sub.end()
+	drained := idle
+	if box, ok := removed.eventHandler.(*mailbox); ok {
+		box.remove()
+		drained = box.done
+	}
+	for _, done := range []<-chan struct{}{idle, drained} {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return true, fmt.Errorf("event handler %v removed with deliveries in progress: %w", removed.typeName, ctx.Err())
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,163 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type removalEvent struct {
+	Value int
+}
+
+type removalMailboxHandler struct {
+	slowMailboxHandler
+}
+
+func (h *removalMailboxHandler) Handle(ctx context.Context, event removalEvent) error {
+	return h.slowMailboxHandler.Handle(ctx, mailboxEvent(event))
+}
+
+type syncRemovalEvent struct{}
+
+type syncRemovalHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *syncRemovalHandler) Handle(ctx context.Context, event syncRemovalEvent) error {
+	h.started <- struct{}{}
+	<-h.release
+	return nil
+}
+
+// TestRemoveEventHandler_Mailbox tests that removal waits for the delivery in progress and drops the queued ones.
+func TestRemoveEventHandler_Mailbox(t *testing.T) {
+	var mutex sync.Mutex
+	var dropped []error
+	SetErrorReporter(func(ctx context.Context, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if errors.Is(err, ErrDeliveryDropped) {
+			dropped = append(dropped, err)
+		}
+	})
+	defer SetErrorReporter(nil)
+
+	handler := &removalMailboxHandler{slowMailboxHandler{started: make(chan struct{}, 10), release: make(chan struct{})}}
+	assert.NoError(t, AddEventHandler[removalEvent](handler, WithMailbox(10, OverflowBlock)))
+	for i := 1; i <= 4; i++ {
+		assert.NoError(t, PublishEvent(context.Background(), removalEvent{Value: i}))
+	}
+	<-handler.started
+
+	removed := make(chan error)
+	go func() {
+		ok, err := RemoveEventHandler[removalEvent](context.Background(), handler)
+		assert.True(t, ok)
+		removed <- err
+	}()
+
+	select {
+	case <-removed:
+		t.Fatal("removal returned while a delivery was in progress")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(handler.release)
+	assert.NoError(t, <-removed)
+
+	// No delivery starts after the removal, including for later publications.
+	assert.NoError(t, PublishEvent(context.Background(), removalEvent{Value: 5}))
+	handler.mutex.Lock()
+	assert.Equal(t, []int{1}, handler.values)
+	handler.mutex.Unlock()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Len(t, dropped, 3)
+	assert.NotContains(t, MailboxStats(), "*gocqrs.removalMailboxHandler")
+}
+
+// TestRemoveEventHandler_Timeout tests that removal returns the error of ctx when a delivery does not finish in time.
+func TestRemoveEventHandler_Timeout(t *testing.T) {
+	handler := &syncRemovalHandler{started: make(chan struct{}, 1), release: make(chan struct{})}
+	assert.NoError(t, AddEventHandlers[syncRemovalEvent](handler))
+
+	published := make(chan error)
+	go func() { published <- PublishEvent(context.Background(), syncRemovalEvent{}) }()
+	<-handler.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	ok, err := RemoveEventHandler[syncRemovalEvent](ctx, handler)
+	assert.True(t, ok)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(handler.release)
+	assert.NoError(t, <-published)
+	assert.NoError(t, PublishEvent(context.Background(), syncRemovalEvent{}))
+	assert.Empty(t, handler.started)
+}
+
+// TestRemoveEventHandler_NotRegistered tests that removing an unknown handler returns false.
+func TestRemoveEventHandler_NotRegistered(t *testing.T) {
+	ok, err := RemoveEventHandler[syncRemovalEvent](context.Background(), &syncRemovalHandler{})
+	assert.False(t, ok)
+	assert.NoError(t, err)
+}
+
+type renamedRemovalEvent struct{}
+
+type renamedRemovalHandler struct {
+	calls int
+}
+
+func (h *renamedRemovalHandler) Handle(ctx context.Context, event renamedRemovalEvent) error {
+	h.calls++
+	return nil
+}
+
+// TestRemoveEventHandler_Renamed tests that a handler renamed with WithEventHandlerName is removed by its value.
+func TestRemoveEventHandler_Renamed(t *testing.T) {
+	handler := &renamedRemovalHandler{}
+	assert.NoError(t, AddEventHandler[renamedRemovalEvent](handler, WithEventHandlerName("renamed-removal")))
+
+	ok, err := RemoveEventHandler[renamedRemovalEvent](context.Background(), &renamedRemovalHandler{})
+	assert.False(t, ok, "another handler of the same type is not the registered one")
+	assert.NoError(t, err)
+
+	ok, err = RemoveEventHandler[renamedRemovalEvent](context.Background(), handler)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.NoError(t, PublishEvent(context.Background(), renamedRemovalEvent{}))
+	assert.Equal(t, 0, handler.calls)
+}
+
+type funcRemovalEvent struct{}
+
+// TestRemoveEventHandlerNamed tests that a handler function is removed by the name it was registered under.
+func TestRemoveEventHandlerNamed(t *testing.T) {
+	var calls []string
+	assert.NoError(t, AddEventHandlerFunc(func(ctx context.Context, event funcRemovalEvent) error {
+		calls = append(calls, "kept")
+		return nil
+	}))
+	assert.NoError(t, AddEventHandlerFunc(func(ctx context.Context, event funcRemovalEvent) error {
+		calls = append(calls, "removed")
+		return nil
+	}, WithEventHandlerName("func-removal")))
+
+	ok, err := RemoveEventHandlerNamed[funcRemovalEvent](context.Background(), "func-removal")
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	ok, err = RemoveEventHandlerNamed[funcRemovalEvent](context.Background(), "func-removal")
+	assert.False(t, ok)
+	assert.NoError(t, err)
+
+	assert.NoError(t, PublishEvent(context.Background(), funcRemovalEvent{}))
+	assert.Equal(t, []string{"kept"}, calls)
+}
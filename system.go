@@ -0,0 +1,149 @@
+package gocqrs
+
+import (
+	"context"
+	"regexp"
+	"sort"
+)
+
+type (
+	// SystemInfoQuery is the built-in query returning a SystemInfo, handled once EnableSystemQueries is called.
+	SystemInfoQuery struct{}
+
+	// SystemInfo is a serializable snapshot of the registry and of the runtime state of the package.
+	SystemInfo struct {
+		Handlers         []SystemHandlerInfo          // Command and query handlers, by request type name.
+		EventSubscribers map[string][]string          // Event type name to the names of its handlers.
+		Stats            map[string]TypeStats         // Dispatch statistics, as returned by Stats.
+		Mailboxes        map[string]MailboxStat       // Mailbox depths, as returned by MailboxStats.
+		AdaptiveLimits   map[string]AdaptiveLimitStat // Adaptive limits, as returned by AdaptiveLimits.
+		Quiesced         map[string]QuiesceStatus     // Quiesced request types, as returned by Quiesced.
+		InFlight         int                          // Number of dispatches running.
+		RecentDispatches []DispatchRecord             // Dispatch history, as returned by RecentDispatches.
+	}
+
+	// SystemHandlerInfo describes a registered command or query handler.
+	SystemHandlerInfo struct {
+		RequestType     string
+		Handler         string
+		Tags            []string
+		PreMiddlewares  []string
+		PostMiddlewares []string
+	}
+
+	// SystemQueryOption configures the built-in system queries.
+	SystemQueryOption func(*systemInfoHandler)
+
+	// systemInfoHandler handles SystemInfoQuery.
+	systemInfoHandler struct {
+		maskTypePaths bool
+	}
+)
+
+// typePathPattern matches the import path prefixes of type and function names, e.g. "github.com/org/".
+var typePathPattern = regexp.MustCompile(`(?:[\w.\-~]+/)+`)
+
+// MaskTypePaths strips the import paths from the handler, middleware and event type names of SystemInfo,
+// e.g. "*github.com/org/orders.Handler" is reported as "*orders.Handler".
+func MaskTypePaths() SystemQueryOption {
+	return func(handler *systemInfoHandler) {
+		handler.maskTypePaths = true
+	}
+}
+
+// EnableSystemQueries registers the handler of SystemInfoQuery, so the registry state can be queried
+// through SendQuery like any other query.
+func EnableSystemQueries(opts ...SystemQueryOption) {
+	handler := &systemInfoHandler{}
+	for _, opt := range opts {
+		opt(handler)
+	}
+	AddQueryHandler[SystemInfoQuery, SystemInfo](handler)
+}
+
+// Handle returns a snapshot of the registry.
+func (h *systemInfoHandler) Handle(ctx context.Context, query SystemInfoQuery) (SystemInfo, error) {
+	info := SystemInfo{
+		Handlers:         h.handlers(),
+		EventSubscribers: make(map[string][]string),
+		Stats:            make(map[string]TypeStats),
+		Mailboxes:        make(map[string]MailboxStat),
+		AdaptiveLimits:   make(map[string]AdaptiveLimitStat),
+		Quiesced:         make(map[string]QuiesceStatus),
+		InFlight:         len(InFlight()),
+	}
+
+	eventHandlerMutex.RLock()
+	for eventType, registered := range eventHandlers {
+		names := make([]string, 0, len(registered))
+		for _, eventHandler := range registered {
+			names = append(names, h.mask(eventHandler.typeName))
+		}
+		info.EventSubscribers[h.mask(eventType)] = names
+	}
+	eventHandlerMutex.RUnlock()
+
+	for requestType, typeStats := range Stats() {
+		info.Stats[h.mask(requestType)] = typeStats
+	}
+	for handlerName, stat := range MailboxStats() {
+		info.Mailboxes[h.mask(handlerName)] = stat
+	}
+	for handlerName, stat := range AdaptiveLimits() {
+		info.AdaptiveLimits[h.mask(handlerName)] = stat
+	}
+	for requestType, status := range Quiesced() {
+		info.Quiesced[h.mask(requestType)] = status
+	}
+	for _, record := range RecentDispatches() {
+		record.RequestType, record.Handler = h.mask(record.RequestType), h.mask(record.Handler)
+		info.RecentDispatches = append(info.RecentDispatches, record)
+	}
+	return info, nil
+}
+
+// handlers describes the registered command and query handlers, sorted by request type.
+func (h *systemInfoHandler) handlers() []SystemHandlerInfo {
+	handlerMutex.RLock()
+	names := make(map[string]string, len(handlers))
+	for requestType, wrapper := range handlers {
+		if nameField, ok := getField(wrapper, "Name"); ok {
+			names[requestType] = nameField.String()
+		}
+	}
+	handlerMutex.RUnlock()
+
+	infos := make([]SystemHandlerInfo, 0, len(names))
+	for requestType, handlerName := range names {
+		pre, _ := middlewareBuilder.registeredChain(PrePhase, handlerName, requestType)
+		post, _ := middlewareBuilder.registeredChain(PostPhase, handlerName, requestType)
+		infos = append(infos, SystemHandlerInfo{
+			RequestType:     h.mask(requestType),
+			Handler:         h.mask(handlerName),
+			Tags:            append([]string(nil), tagsOf(handlerName)...),
+			PreMiddlewares:  h.middlewareNames(pre),
+			PostMiddlewares: h.middlewareNames(post),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].RequestType < infos[j].RequestType
+	})
+	return infos
+}
+
+// middlewareNames returns the masked names of a middleware chain.
+func (h *systemInfoHandler) middlewareNames(chain []middlewareStruct) []string {
+	names := middlewareChainNames(chain)
+	for i, name := range names {
+		names[i] = h.mask(name)
+	}
+	return names
+}
+
+// mask strips the import paths from name if MaskTypePaths is set.
+func (h *systemInfoHandler) mask(name string) string {
+	if !h.maskTypePaths {
+		return name
+	}
+	return typePathPattern.ReplaceAllString(name, "")
+}
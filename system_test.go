@@ -0,0 +1,48 @@
+package gocqrs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type systemProbeQuery struct{}
+
+// TestEnableSystemQueries tests that the registry snapshot is available through SendQuery.
+func TestEnableSystemQueries(t *testing.T) {
+	AddQueryHandler[systemProbeQuery, []string](&taggedHandler[systemProbeQuery]{}).
+		Tags("system-probe").
+		PreMiddlewareNamed("Auth", func(ctx context.Context, request any) (context.Context, any, bool) {
+			return ctx, request, true
+		})
+	_, err := SendQuery[[]string](context.Background(), systemProbeQuery{})
+	assert.NoError(t, err)
+
+	EnableSystemQueries()
+	info, err := SendQuery[SystemInfo](context.Background(), SystemInfoQuery{})
+	assert.NoError(t, err)
+	assert.Contains(t, info.Handlers, SystemHandlerInfo{
+		RequestType:     "gocqrs.systemProbeQuery",
+		Handler:         "*gocqrs.taggedHandler[github.com/victoragudo/go-cqrs.systemProbeQuery]",
+		Tags:            []string{"system-probe"},
+		PreMiddlewares:  []string{"Auth"},
+		PostMiddlewares: []string{},
+	})
+	assert.Equal(t, uint64(1), info.Stats["gocqrs.systemProbeQuery"].Count)
+	assert.Equal(t, 1, info.InFlight, "the system query itself is running")
+
+	_, err = json.Marshal(info)
+	assert.NoError(t, err)
+
+	EnableSystemQueries(MaskTypePaths())
+	info, err = SendQuery[SystemInfo](context.Background(), SystemInfoQuery{})
+	assert.NoError(t, err)
+	for _, handler := range info.Handlers {
+		if handler.RequestType == "gocqrs.systemProbeQuery" {
+			assert.Equal(t, "*gocqrs.taggedHandler[go-cqrs.systemProbeQuery]", handler.Handler)
+		}
+		assert.NotContains(t, handler.Handler, "github.com/")
+	}
+}
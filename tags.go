@@ -0,0 +1,86 @@
+package gocqrs
+
+import (
+	"sort"
+	"sync"
+)
+
+var (
+	tagMutex     sync.RWMutex
+	handlerTags  = make(map[string][]string)           // Handler name to its tags, in declaration order.
+	tagBehaviors = make(map[string][]PipelineBehavior) // Tag to the behaviors attached to it.
+)
+
+// Tags labels the current handler, e.g. "write", "billing" or "pii", to drive cross-cutting configuration
+// with AttachToTag and HandlersWithTag. Tags are also reported in the DispatchInfo of its dispatches.
+// They are the Tags of its HandlerMeta too, along with the tags given with WithMeta.
+func (middlewareBuilder *AddMiddlewareBuilder) Tags(tags ...string) *AddMiddlewareBuilder {
+	tagHandler(middlewareBuilder.currentHandler(), tags)
+	return middlewareBuilder
+}
+
+// tagHandler adds the tags a handler is not labeled with yet.
+func tagHandler(handlerName string, tags []string) {
+	tagMutex.Lock()
+	defer tagMutex.Unlock()
+	current := handlerTags[handlerName]
+	for _, tag := range tags {
+		if !containsTag(current, tag) {
+			current = append(current[:len(current):len(current)], tag)
+		}
+	}
+	handlerTags[handlerName] = current
+}
+
+// HandlersWithTag returns the names of the handlers labeled with tag, sorted.
+func HandlersWithTag(tag string) []string {
+	tagMutex.RLock()
+	defer tagMutex.RUnlock()
+
+	names := make([]string, 0)
+	for handlerName, tags := range handlerTags {
+		if containsTag(tags, tag) {
+			names = append(names, handlerName)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AttachToTag adds a pipeline behavior to every handler labeled with tag, including the handlers tagged later.
+// Tag behaviors wrap the behaviors added to the handler itself, in the order they were attached.
+func AttachToTag(tag string, behavior PipelineBehavior) {
+	tagMutex.Lock()
+	defer tagMutex.Unlock()
+	behaviors := tagBehaviors[tag]
+	tagBehaviors[tag] = append(behaviors[:len(behaviors):len(behaviors)], behavior)
+}
+
+// tagsOf returns the tags of a handler.
+func tagsOf(handlerName string) []string {
+	tagMutex.RLock()
+	defer tagMutex.RUnlock()
+	return handlerTags[handlerName]
+}
+
+// tagBehaviorsFor returns the behaviors attached to the tags of a handler, in tag declaration order.
+func tagBehaviorsFor(handlerName string) []PipelineBehavior {
+	tagMutex.RLock()
+	defer tagMutex.RUnlock()
+
+	var behaviors []PipelineBehavior
+	for _, tag := range handlerTags[handlerName] {
+		behaviors = append(behaviors, tagBehaviors[tag]...)
+	}
+	return behaviors
+}
+
+// containsTag reports whether tags contains tag.
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
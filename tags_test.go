@@ -0,0 +1,86 @@
+package gocqrs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	exportCustomerQuery struct{}
+	deleteCustomerCmd   struct{}
+	listProductsQuery   struct{}
+)
+
+// taggedHandler returns the tags of its dispatch, as seen by InFlight.
+type taggedHandler[TRequest T] struct{}
+
+func (h *taggedHandler[TRequest]) Handle(ctx context.Context, request TRequest) ([]string, error) {
+	for _, dispatch := range InFlight() {
+		if dispatch.RequestType == fmt.Sprintf("%T", request) {
+			return dispatch.Tags, nil
+		}
+	}
+	return nil, nil
+}
+
+// TestTags tests tag queries and that tag behaviors apply to handlers tagged after the attachment.
+func TestTags(t *testing.T) {
+	var audited []string
+	AttachToTag("pii", func(ctx context.Context, request any, next NextFunc) (any, error) {
+		audited = append(audited, fmt.Sprintf("%T", request))
+		return next(ctx, request)
+	})
+
+	AddQueryHandler[exportCustomerQuery, []string](&taggedHandler[exportCustomerQuery]{}).
+		Tags("pii", "read", "pii")
+	AddQueryHandler[listProductsQuery, []string](&taggedHandler[listProductsQuery]{}).
+		Tags("read", "public")
+
+	tags, err := SendQuery[[]string](context.Background(), exportCustomerQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pii", "read"}, tags)
+
+	// A handler registered after the attachment gets the behavior too.
+	AddCommandHandler[deleteCustomerCmd, []string](&taggedHandler[deleteCustomerCmd]{}).
+		Tags("write", "pii")
+	_, err = SendCommand[[]string](context.Background(), deleteCustomerCmd{})
+	assert.NoError(t, err)
+	_, err = SendQuery[[]string](context.Background(), listProductsQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gocqrs.exportCustomerQuery", "gocqrs.deleteCustomerCmd"}, audited)
+
+	assert.Equal(t, []string{
+		"*gocqrs.taggedHandler[github.com/victoragudo/go-cqrs.deleteCustomerCmd]",
+		"*gocqrs.taggedHandler[github.com/victoragudo/go-cqrs.exportCustomerQuery]",
+	}, HandlersWithTag("pii"))
+	assert.Equal(t, []string{"*gocqrs.taggedHandler[github.com/victoragudo/go-cqrs.listProductsQuery]"}, HandlersWithTag("public"))
+	assert.Empty(t, HandlersWithTag("internal"))
+}
+
+type archiveCustomerCmd struct{}
+
+// TestTags_Meta tests that the tags given with WithMeta and with Tags are the same tags.
+func TestTags_Meta(t *testing.T) {
+	var audited int
+	AttachToTag("meta-tagged", func(ctx context.Context, request any, next NextFunc) (any, error) {
+		audited++
+		return next(ctx, request)
+	})
+
+	AddCommandHandler[archiveCustomerCmd, []string](&taggedHandler[archiveCustomerCmd]{},
+		WithMeta(HandlerMeta{Summary: "Archives a customer", Tags: []string{"meta-tagged"}})).
+		Tags("builder-tagged")
+
+	assert.Equal(t, []string{"*gocqrs.taggedHandler[github.com/victoragudo/go-cqrs.archiveCustomerCmd]"}, HandlersWithTag("meta-tagged"))
+	meta, ok := HandlerMetadata(archiveCustomerCmd{})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"meta-tagged", "builder-tagged"}, meta.Tags)
+
+	tags, err := SendCommand[[]string](context.Background(), archiveCustomerCmd{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"meta-tagged", "builder-tagged"}, tags)
+	assert.Equal(t, 1, audited)
+}
@@ -2,6 +2,7 @@ package gocqrs
 
 import (
 	"context"
+	"io"
 )
 
 type (
@@ -12,6 +13,9 @@ type (
 	eventHandlersType struct {
 		typeName     string
 		eventHandler IHandler[T, T]
+		handler      any                  // The registered handler, identifying it for RemoveEventHandler.
+		sub          *subscription        // Deliveries in progress, for RemoveEventHandler.
+		filter       func(event any) bool // Set by WithEventFilter.
 	}
 	// IHandler is an interface representing a generic handler
 	// with input and output of generic types T1 and T2.
@@ -25,4 +29,14 @@ type (
 	IEventHandler[TEvent T] interface {
 		Handle(ctx context.Context, event TEvent) error
 	}
+	// IWriterHandler is an interface for command handlers
+	// that stream their output to a writer instead of returning a response.
+	IWriterHandler[TCommand T] interface {
+		Handle(ctx context.Context, command TCommand, w io.Writer) error
+	}
+	// IEventProducingHandler is an interface for command handlers
+	// that return the domain events they produced along with their response.
+	IEventProducingHandler[TCommand T, TResponse T] interface {
+		Handle(ctx context.Context, command TCommand) (TResponse, []any, error)
+	}
 )
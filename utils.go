@@ -29,6 +29,10 @@ func getField(object T, fieldName string) (reflect.Value, bool) {
 // getMethodByName retrieves a method by its name with reflection.
 // It returns the method as reflect.Value and a boolean indicating if the method was found.
 func getMethodByName(value reflect.Value, methodName string) (reflect.Value, bool) {
+	// A nil interface, such as an uninitialized handler, has no method to look up.
+	if !value.IsValid() || (value.Kind() == reflect.Interface && value.IsNil()) {
+		return reflect.Value{}, false
+	}
 	method := value.MethodByName(methodName)
 	if !method.IsValid() {
 		return reflect.Value{}, false // Return a zero reflect.Value and false if the method is not found.
@@ -0,0 +1,276 @@
+package gocqrs
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// VerifyRule checks the registrations described by view and returns the problems it found.
+	VerifyRule func(view RegistryView) []error
+
+	// VerifyError is a problem found by a verification rule.
+	VerifyError struct {
+		Rule string // Name of the rule that found the problem.
+		Err  error
+	}
+
+	// RegistryView is a read-only snapshot of the registrations, passed to the verification rules.
+	// Its accessors return copies, so rules cannot change the registrations.
+	RegistryView struct {
+		handlers         []HandlerView
+		eventSubscribers map[string][]string
+		usage            map[string]HandlerUsage // Handler name to its usage, event handlers included.
+	}
+
+	// HandlerView describes a registered command or query handler.
+	HandlerView struct {
+		RequestType       string
+		Handler           string
+		Tags              []string
+		Meta              HandlerMeta
+		PreMiddlewares    []string      // Middleware names, in execution order.
+		PostMiddlewares   []string      // Middleware names, in execution order.
+		SharedMiddlewares []string      // Names of the pre- and post-middlewares referencing a shared middleware.
+		Behaviors         []string      // Symbol names of the behaviors, tag behaviors first, outermost first.
+		Timeout           time.Duration // Set with WithTimeout, zero if none.
+		Internal          bool          // Marked with MarkInternal, so its middlewares are skipped.
+		Usage             HandlerUsage  // When the handler was registered and last dispatched.
+	}
+
+	// namedVerifyRule is a rule registered with AddVerifyRule.
+	namedVerifyRule struct {
+		name string
+		rule VerifyRule
+	}
+)
+
+var (
+	verifyRuleMutex sync.RWMutex
+	verifyRules     = []namedVerifyRule{
+		{name: "compensations", rule: func(RegistryView) []error { return verifyCompensations() }},
+		{name: "delivery", rule: func(RegistryView) []error { return verifyDeliveryExpectations() }},
+	}
+)
+
+// Error prefixes the problem with the name of the rule.
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Rule, e.Err)
+}
+
+// Unwrap returns the problem.
+func (e *VerifyError) Unwrap() error {
+	return e.Err
+}
+
+// Verify checks the registrations for inconsistencies that would only surface when a request is dispatched,
+// such as a command declared Compensable without a registered compensation, then runs the rules added with
+// AddVerifyRule. It is meant to run once at startup, after every handler is registered, and returns all
+// problems joined, each as a *VerifyError naming its rule.
+func Verify() error {
+	verifyRuleMutex.RLock()
+	rules := verifyRules
+	verifyRuleMutex.RUnlock()
+
+	view := newRegistryView()
+	var problems []error
+	for _, rule := range rules {
+		for _, err := range rule.rule(view) {
+			problems = append(problems, &VerifyError{Rule: rule.name, Err: err})
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// AddVerifyRule adds a rule run by Verify, e.g. a naming convention or a behavior required by a tag.
+// Adding a rule under the name of a registered one replaces it.
+func AddVerifyRule(name string, rule VerifyRule) {
+	verifyRuleMutex.Lock()
+	defer verifyRuleMutex.Unlock()
+
+	rules := make([]namedVerifyRule, 0, len(verifyRules)+1)
+	for _, r := range verifyRules {
+		if r.name != name {
+			rules = append(rules, r)
+		}
+	}
+	verifyRules = append(rules, namedVerifyRule{name: name, rule: rule})
+}
+
+// RemoveVerifyRule removes a rule added with AddVerifyRule. It returns false if no rule has that name.
+func RemoveVerifyRule(name string) bool {
+	verifyRuleMutex.Lock()
+	defer verifyRuleMutex.Unlock()
+
+	rules := make([]namedVerifyRule, 0, len(verifyRules))
+	for _, r := range verifyRules {
+		if r.name != name {
+			rules = append(rules, r)
+		}
+	}
+	removed := len(rules) < len(verifyRules)
+	verifyRules = rules
+	return removed
+}
+
+// RequireBehaviorWithTag returns a rule requiring every handler labeled with tag to run behavior, either added
+// to the handler or attached to one of its tags. Behaviors are identified by their function symbol name.
+func RequireBehaviorWithTag(tag string, behavior PipelineBehavior) VerifyRule {
+	name := funcName(behavior)
+	return func(view RegistryView) []error {
+		var problems []error
+		for _, handler := range view.HandlersWithTag(tag) {
+			if !slices.Contains(handler.Behaviors, name) {
+				problems = append(problems, fmt.Errorf("%v is tagged %v but does not run %v", handler.Handler, tag, name))
+			}
+		}
+		return problems
+	}
+}
+
+// ForbidBehaviorWithTag returns a rule rejecting the handlers labeled with tag that run behavior, e.g. a
+// transaction behavior on read-only handlers. Behaviors are identified by their function symbol name.
+func ForbidBehaviorWithTag(tag string, behavior PipelineBehavior) VerifyRule {
+	name := funcName(behavior)
+	return func(view RegistryView) []error {
+		var problems []error
+		for _, handler := range view.HandlersWithTag(tag) {
+			if slices.Contains(handler.Behaviors, name) {
+				problems = append(problems, fmt.Errorf("%v is tagged %v and must not run %v", handler.Handler, tag, name))
+			}
+		}
+		return problems
+	}
+}
+
+// newRegistryView takes a snapshot of the registrations.
+func newRegistryView() RegistryView {
+	handlerMutex.RLock()
+	names := make(map[string]string, len(handlers))
+	for requestType, wrapper := range handlers {
+		if nameField, ok := getField(wrapper, "Name"); ok {
+			names[requestType] = nameField.String()
+		}
+	}
+	handlerMutex.RUnlock()
+
+	view := RegistryView{
+		handlers:         make([]HandlerView, 0, len(names)),
+		eventSubscribers: make(map[string][]string),
+		usage:            make(map[string]HandlerUsage),
+	}
+	for requestType, handlerName := range names {
+		pre, _ := middlewareBuilder.registeredChain(PrePhase, handlerName, requestType)
+		post, _ := middlewareBuilder.registeredChain(PostPhase, handlerName, requestType)
+		timeout, _ := handlerTimeout(handlerName)
+
+		meta, _ := handlerMeta(requestType, handlerName)
+
+		var behaviors []string
+		for _, behavior := range append(tagBehaviorsFor(handlerName), middlewareBuilder.behaviorsFor(handlerName)...) {
+			behaviors = append(behaviors, funcName(behavior))
+		}
+		view.handlers = append(view.handlers, HandlerView{
+			RequestType:       requestType,
+			Handler:           handlerName,
+			Tags:              tagsOf(handlerName),
+			Meta:              meta,
+			PreMiddlewares:    middlewareChainNames(pre),
+			PostMiddlewares:   middlewareChainNames(post),
+			SharedMiddlewares: append(sharedMiddlewareNames(pre), sharedMiddlewareNames(post)...),
+			Behaviors:         behaviors,
+			Timeout:           timeout,
+			Internal:          isInternalRequest(requestType),
+		})
+	}
+	for _, name := range RegisteredHandlerNames() {
+		if handlerUsage, ok := usageOf(name); ok {
+			view.usage[name] = handlerUsage
+		}
+	}
+	for i := range view.handlers {
+		view.handlers[i].Usage = view.usage[view.handlers[i].Handler]
+	}
+	sort.Slice(view.handlers, func(i, j int) bool {
+		return view.handlers[i].RequestType < view.handlers[j].RequestType
+	})
+
+	eventHandlerMutex.RLock()
+	for eventType, registered := range eventHandlers {
+		for _, eventHandler := range registered {
+			view.eventSubscribers[eventType] = append(view.eventSubscribers[eventType], eventHandler.typeName)
+		}
+	}
+	eventHandlerMutex.RUnlock()
+	return view
+}
+
+// Handlers returns the command and query handlers, sorted by request type name.
+func (view RegistryView) Handlers() []HandlerView {
+	handlers := make([]HandlerView, 0, len(view.handlers))
+	for _, handler := range view.handlers {
+		handlers = append(handlers, handler.clone())
+	}
+	return handlers
+}
+
+// Handler returns the handler of a request type name. It returns false if the request type has no handler.
+func (view RegistryView) Handler(requestType string) (HandlerView, bool) {
+	for _, handler := range view.handlers {
+		if handler.RequestType == requestType {
+			return handler.clone(), true
+		}
+	}
+	return HandlerView{}, false
+}
+
+// HandlersWithTag returns the handlers labeled with tag, sorted by request type name.
+func (view RegistryView) HandlersWithTag(tag string) []HandlerView {
+	var tagged []HandlerView
+	for _, handler := range view.handlers {
+		if containsTag(handler.Tags, tag) {
+			tagged = append(tagged, handler.clone())
+		}
+	}
+	return tagged
+}
+
+// EventSubscribers returns the names of the handlers of every event type name.
+func (view RegistryView) EventSubscribers() map[string][]string {
+	subscribers := make(map[string][]string, len(view.eventSubscribers))
+	for eventType, names := range view.eventSubscribers {
+		subscribers[eventType] = append([]string(nil), names...)
+	}
+	return subscribers
+}
+
+// Usage returns when a handler, event handlers included, was registered and last dispatched.
+// It returns false if no handler is registered under handlerName.
+func (view RegistryView) Usage(handlerName string) (HandlerUsage, bool) {
+	handlerUsage, ok := view.usage[handlerName]
+	return handlerUsage, ok
+}
+
+// clone copies the slices of a handler view, so changing them does not affect the view.
+func (handler HandlerView) clone() HandlerView {
+	handler.Tags = append([]string(nil), handler.Tags...)
+	handler.Meta.Tags = append([]string(nil), handler.Meta.Tags...)
+	handler.PreMiddlewares = append([]string(nil), handler.PreMiddlewares...)
+	handler.PostMiddlewares = append([]string(nil), handler.PostMiddlewares...)
+	handler.SharedMiddlewares = append([]string(nil), handler.SharedMiddlewares...)
+	handler.Behaviors = append([]string(nil), handler.Behaviors...)
+	return handler
+}
+
+// middlewareChainNames returns the names of a middleware chain.
+func middlewareChainNames(chain []middlewareStruct) []string {
+	names := make([]string, 0, len(chain))
+	for _, middleware := range chain {
+		names = append(names, middleware.middlewareName)
+	}
+	return names
+}
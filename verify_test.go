@@ -0,0 +1,92 @@
+package gocqrs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type chargeInvoiceCommand struct{}
+
+type refundInvoiceCommand struct{}
+
+type invoiceTotalQuery struct{}
+
+type chargeInvoiceHandler struct{}
+
+func (h *chargeInvoiceHandler) Handle(ctx context.Context, command chargeInvoiceCommand) (string, error) {
+	return "charged", nil
+}
+
+type refundInvoiceHandler struct{}
+
+func (h *refundInvoiceHandler) Handle(ctx context.Context, command refundInvoiceCommand) (string, error) {
+	return "refunded", nil
+}
+
+type invoiceTotalHandler struct{}
+
+func (h *invoiceTotalHandler) Handle(ctx context.Context, query invoiceTotalQuery) (int, error) {
+	return 0, nil
+}
+
+func auditBehavior(ctx context.Context, request any, next NextFunc) (any, error) {
+	return next(ctx, request)
+}
+
+func transactionBehavior(ctx context.Context, request any, next NextFunc) (any, error) {
+	return next(ctx, request)
+}
+
+// TestAddVerifyRule tests that Verify reports the violations of custom rules, attributed to them.
+func TestAddVerifyRule(t *testing.T) {
+	AddCommandHandler[chargeInvoiceCommand, string](&chargeInvoiceHandler{}).Tags("verify-billing").Behavior(auditBehavior)
+	AddCommandHandler[refundInvoiceCommand, string](&refundInvoiceHandler{}).Tags("verify-billing")
+	AddQueryHandler[invoiceTotalQuery, int](&invoiceTotalHandler{}).Tags("verify-read").Behavior(transactionBehavior)
+
+	AddVerifyRule("billing-audit", RequireBehaviorWithTag("verify-billing", auditBehavior))
+	defer RemoveVerifyRule("billing-audit")
+	AddVerifyRule("read-only", ForbidBehaviorWithTag("verify-read", transactionBehavior))
+	defer RemoveVerifyRule("read-only")
+
+	err := Verify()
+	var verifyErr *VerifyError
+	assert.ErrorAs(t, err, &verifyErr)
+	assert.Equal(t, strings.Join([]string{
+		"billing-audit: *gocqrs.refundInvoiceHandler is tagged verify-billing but does not run github.com/victoragudo/go-cqrs.auditBehavior",
+		"read-only: *gocqrs.invoiceTotalHandler is tagged verify-read and must not run github.com/victoragudo/go-cqrs.transactionBehavior",
+	}, "\n"), err.Error())
+
+	// Attaching the behavior to the tag satisfies the rule for every tagged handler.
+	AttachToTag("verify-billing", auditBehavior)
+	assert.True(t, RemoveVerifyRule("read-only"))
+	assert.NoError(t, Verify())
+}
+
+// TestRegistryView tests that rules see the registrations but cannot change them.
+func TestRegistryView(t *testing.T) {
+	AddQueryHandler[invoiceTotalQuery, int](&invoiceTotalHandler{}).Tags("verify-view").WithTimeout(time.Second)
+
+	var seen HandlerView
+	AddVerifyRule("view", func(view RegistryView) []error {
+		handler, ok := view.Handler("gocqrs.invoiceTotalQuery")
+		if !ok {
+			return []error{errors.New("handler not found")}
+		}
+		seen = handler
+		handler.Tags[0] = "changed"
+		return nil
+	})
+	defer RemoveVerifyRule("view")
+
+	assert.NoError(t, Verify())
+	assert.Equal(t, "*gocqrs.invoiceTotalHandler", seen.Handler)
+	assert.Equal(t, time.Second, seen.Timeout)
+	assert.Contains(t, tagsOf("*gocqrs.invoiceTotalHandler"), "verify-view")
+	assert.NotContains(t, tagsOf("*gocqrs.invoiceTotalHandler"), "changed")
+	assert.False(t, RemoveVerifyRule("unknown"))
+}
@@ -0,0 +1,45 @@
+package gocqrs
+
+import (
+	"context"
+	"sync"
+)
+
+// warningCollector accumulates the non-fatal warnings raised during a dispatch.
+type warningCollector struct {
+	mutex    sync.Mutex
+	warnings []string
+}
+
+// warningsKey is the context key of the warningCollector of a dispatch.
+type warningsKey struct{}
+
+// WithWarnings returns a context collecting the warnings raised by the handlers it is dispatched to,
+// including nested dispatches. Read them with WarningsFromContext once the dispatch returns.
+func WithWarnings(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(warningsKey{}).(*warningCollector); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, warningsKey{}, &warningCollector{})
+}
+
+// AddWarning records a non-fatal warning, such as a deprecation notice or partial data, without failing the dispatch.
+// It is a no-op if ctx does not come from a dispatch or WithWarnings.
+func AddWarning(ctx context.Context, msg string) {
+	if collector, ok := ctx.Value(warningsKey{}).(*warningCollector); ok {
+		collector.mutex.Lock()
+		collector.warnings = append(collector.warnings, msg)
+		collector.mutex.Unlock()
+	}
+}
+
+// WarningsFromContext returns the warnings recorded so far in the collector of ctx, in the order they were added.
+func WarningsFromContext(ctx context.Context) []string {
+	collector, ok := ctx.Value(warningsKey{}).(*warningCollector)
+	if !ok {
+		return nil
+	}
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	return append([]string(nil), collector.warnings...)
+}
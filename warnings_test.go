@@ -0,0 +1,41 @@
+package gocqrs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type legacyExportCommand struct{}
+
+type legacyExportCommandHandler struct{}
+
+func (h *legacyExportCommandHandler) Handle(ctx context.Context, command legacyExportCommand) (string, error) {
+	AddWarning(ctx, "legacyExportCommand is deprecated")
+	AddWarning(ctx, "2 rows skipped")
+	return "exported", nil
+}
+
+// TestAddWarning tests that the caller reads the warnings raised by the handler and seen by post-middlewares.
+func TestAddWarning(t *testing.T) {
+	var seenByMiddleware []string
+	AddCommandHandler[legacyExportCommand, string](&legacyExportCommandHandler{}).
+		PostMiddleware(func(ctx context.Context, request any) (context.Context, any, bool) {
+			seenByMiddleware = WarningsFromContext(ctx)
+			return ctx, request, true
+		})
+
+	ctx := WithWarnings(context.Background())
+	response, err := SendCommand[string](ctx, legacyExportCommand{})
+	assert.NoError(t, err)
+	assert.Equal(t, "exported", response)
+	assert.Equal(t, []string{"legacyExportCommand is deprecated", "2 rows skipped"}, WarningsFromContext(ctx))
+	assert.Equal(t, WarningsFromContext(ctx), seenByMiddleware)
+
+	// Without a collector the warnings only live for the dispatch.
+	_, err = SendCommand[string](context.Background(), legacyExportCommand{})
+	assert.NoError(t, err)
+	assert.Len(t, seenByMiddleware, 2)
+	assert.Nil(t, WarningsFromContext(context.Background()))
+}
@@ -3,6 +3,7 @@ package gocqrs
 import (
 	"context"
 	"fmt"
+	"io"
 )
 
 type (
@@ -15,6 +16,12 @@ type (
 	eventHandlerAdapter[TEvent T] struct {
 		eventHandler IEventHandler[TEvent]
 	}
+	writerHandlerAdapter[TCommand T] struct {
+		writerHandler IWriterHandler[TCommand]
+	}
+	eventProducingHandlerAdapter[TCommand T, TResponse T] struct {
+		eventProducingHandler IEventProducingHandler[TCommand, TResponse]
+	}
 )
 
 // Handle method for commandHandlerWrapper.
@@ -23,10 +30,15 @@ type (
 func (handlerWrapper *handlerWrapper[T1, T2]) Handle(ctx context.Context, in T) (T, error) {
 	// Assert the type of commandRequest to CommandRequest.
 	typedIn, ok := in.(T1)
+	if !ok {
+		// Events published as a value may reach a handler of their pointer type, and vice versa.
+		typedIn, ok = convertEventForm[T1](in)
+	}
 	if !ok {
 		// Return an error if the assertion fails.
 		return nil, fmt.Errorf("incorrect request type: %T", in)
 	}
+	recordUsage(handlerWrapper.Name)
 	// Call the wrapped command handler's Handle method.
 	return handlerWrapper.Handler.Handle(ctx, typedIn)
 }
@@ -50,3 +62,35 @@ func (adapter *eventHandlerAdapter[T1]) Handle(ctx context.Context, in T1) (out
 	err = adapter.eventHandler.Handle(ctx, in)
 	return nil, err
 }
+
+// reversible returns the wrapped event handler if it implements Reversible.
+func (handlerWrapper *handlerWrapper[T1, T2]) reversible() (Reversible, bool) {
+	if adapter, ok := any(handlerWrapper.Handler).(reversibleHandler); ok {
+		return adapter.reversible()
+	}
+	return nil, false
+}
+
+// reversible returns the event handler if it implements Reversible.
+func (adapter *eventHandlerAdapter[T1]) reversible() (Reversible, bool) {
+	reversible, ok := adapter.eventHandler.(Reversible)
+	return reversible, ok
+}
+
+// Handle passes the writer given to SendToWriter to the wrapped writer handler.
+func (adapter *writerHandlerAdapter[T1]) Handle(ctx context.Context, in T1) (out T, err error) {
+	w, ok := ctx.Value(writerKey{}).(io.Writer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrWriterRequired, in)
+	}
+	return nil, adapter.writerHandler.Handle(ctx, in, w)
+}
+
+// Handle hands the events produced by the wrapped handler to the dispatch, which publishes them once it succeeded.
+func (adapter *eventProducingHandlerAdapter[T1, T2]) Handle(ctx context.Context, in T1) (T2, error) {
+	out, events, err := adapter.eventProducingHandler.Handle(ctx, in)
+	if err == nil {
+		addProducedEvents(ctx, events)
+	}
+	return out, err
+}
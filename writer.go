@@ -0,0 +1,25 @@
+package gocqrs
+
+import (
+	"context"
+	"io"
+	"reflect"
+)
+
+// writerKey is the context key of the writer given to SendToWriter.
+type writerKey struct{}
+
+// AddWriterCommandHandler registers a command handler streaming its output to a writer.
+// Such commands are dispatched with SendToWriter and go through the same middlewares and behaviors as
+// any other command; behaviors see a nil response.
+func AddWriterCommandHandler[Command T](handler IWriterHandler[Command]) *AddMiddlewareBuilder {
+	typedHandlerName := reflect.TypeOf(handler).String()
+	return addRequestNamed[Command, T](&writerHandlerAdapter[Command]{writerHandler: handler}, typedHandlerName)
+}
+
+// SendToWriter executes a command registered with AddWriterCommandHandler, letting its handler stream to w.
+// Output already written to w when the handler fails is not rolled back.
+func SendToWriter(ctx context.Context, command any, w io.Writer) error {
+	_, err := send[T](context.WithValue(ctx, writerKey{}, w), command)
+	return err
+}
@@ -0,0 +1,80 @@
+package gocqrs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type exportCommand struct {
+	Rows   int
+	FailAt int // Row at which the export fails, if positive.
+}
+
+type exportCommandHandler struct{}
+
+func (h *exportCommandHandler) Handle(ctx context.Context, command exportCommand, w io.Writer) error {
+	row := bytes.Repeat([]byte("x"), 1023)
+	row = append(row, '\n')
+	for i := 0; i < command.Rows; i++ {
+		if command.FailAt > 0 && i == command.FailAt {
+			return errors.New("export interrupted")
+		}
+		if _, err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TestSendToWriter tests that a writer command streams its output through the normal pipeline.
+func TestSendToWriter(t *testing.T) {
+	var preCalls int
+	var behaviorResponses []any
+	AddWriterCommandHandler[exportCommand](&exportCommandHandler{}).
+		PreMiddleware(func(ctx context.Context, request any) (context.Context, any, bool) {
+			preCalls++
+			return ctx, request, true
+		}).
+		Behavior(func(ctx context.Context, request any, next NextFunc) (any, error) {
+			response, err := next(ctx, request)
+			behaviorResponses = append(behaviorResponses, response)
+			return response, err
+		})
+
+	var buf bytes.Buffer
+	err := SendToWriter(context.Background(), exportCommand{Rows: 4096}, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 4096*1024, buf.Len())
+
+	recorder := httptest.NewRecorder()
+	err = SendToWriter(context.Background(), exportCommand{Rows: 2048}, recorder)
+	assert.NoError(t, err)
+	assert.Equal(t, 2048*1024, recorder.Body.Len())
+
+	assert.Equal(t, 2, preCalls)
+	assert.Equal(t, []any{nil, nil}, behaviorResponses)
+}
+
+// TestSendToWriter_MidStreamError tests that a handler failing mid-stream surfaces its error after partial output.
+func TestSendToWriter_MidStreamError(t *testing.T) {
+	AddWriterCommandHandler[exportCommand](&exportCommandHandler{})
+
+	var buf bytes.Buffer
+	err := SendToWriter(context.Background(), exportCommand{Rows: 4096, FailAt: 1000}, &buf)
+	assert.EqualError(t, err, "export interrupted")
+	assert.Equal(t, 1000*1024, buf.Len())
+}
+
+// TestSendToWriter_WithoutWriter tests that a writer command sent as a regular command fails.
+func TestSendToWriter_WithoutWriter(t *testing.T) {
+	AddWriterCommandHandler[exportCommand](&exportCommandHandler{})
+
+	_, err := SendCommand[any](context.Background(), exportCommand{Rows: 1})
+	assert.ErrorIs(t, err, ErrWriterRequired)
+}